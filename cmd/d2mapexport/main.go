@@ -0,0 +1,165 @@
+// Command d2mapexport renders a generated map to a PNG image without a
+// window, e.g. to review the maps of a level generator or to diff them.
+//
+// A level is generated like in a game of the given seed:
+//
+//	d2mapexport --level 8 --seed 42 --walkmesh -o den_of_evil.png
+//
+// A single preset of a region is loaded like in the map engine test screen:
+//
+//	d2mapexport --region 2 --preset 57 -o cold_plains.png
+package main
+
+import (
+	"image/png"
+	"log"
+	"os"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2config"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapgen"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2maprenderer"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2render/software"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func main() {
+	log.SetFlags(log.Lshortfile)
+
+	levelID := kingpin.Flag("level", "Levels.txt ID of the level to generate").Int()
+	region := kingpin.Flag("region", "region of the preset to load, 0 generates the act 1 overworld").Default("-1").Int()
+	preset := kingpin.Flag("preset", "LvlPrest.txt ID of the preset to load").Int()
+	fileIndex := kingpin.Flag("file-index", "file of the preset to load, -1 picks one at random").Default("-1").Int()
+	seed := kingpin.Flag("seed", "seed of the game the level is generated in").Default("1").Int64()
+	difficulty := kingpin.Flag("difficulty", "difficulty the level is populated for, 0 for normal to 2 for hell").Int()
+	walkMesh := kingpin.Flag("walkmesh", "marks the sub-tiles which cannot be walked on").Bool()
+	entities := kingpin.Flag("entities", "draws the NPCs, monsters and objects").Bool()
+	output := kingpin.Flag("output", "path of the PNG image").Short('o').Default("map.png").String()
+	kingpin.Parse()
+
+	if *levelID == 0 && *region < 0 {
+		kingpin.Fatalf("either a level or a region is required")
+	}
+
+	if err := d2config.Load(); err != nil {
+		log.Fatal(err)
+	}
+
+	renderer, err := software.CreateRenderer()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	term := &logTerminal{}
+
+	if err := d2asset.Initialize(renderer, term); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := loadData(); err != nil {
+		log.Fatal(err)
+	}
+
+	mapEngine := d2mapengine.CreateMapEngine()
+
+	switch {
+	case *levelID != 0:
+		mapEngine.SetSeed(d2mapgen.LevelSeed(*seed, *levelID))
+
+		if err := d2mapgen.GenerateLevel(mapEngine, *levelID, d2enum.DifficultyType(*difficulty)); err != nil {
+			log.Fatal(err)
+		}
+
+		d2mapgen.PopulateMonsters(mapEngine, d2enum.DifficultyType(*difficulty))
+	case *region == 0:
+		mapEngine.SetSeed(*seed)
+		d2mapgen.GenerateAct1Overworld(mapEngine)
+		d2mapgen.PopulateMonsters(mapEngine, d2enum.DifficultyType(*difficulty))
+	default:
+		mapEngine.SetSeed(*seed)
+		mapEngine.GenerateMap(d2enum.RegionIdType(*region), *preset, *fileIndex, false)
+		mapEngine.RegenerateWalkPaths()
+	}
+
+	if err := exportMap(renderer, mapEngine, term, d2maprenderer.MapImageOptions{
+		Entities: *entities,
+		WalkMesh: *walkMesh,
+	}, *output); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("exported the map to %s", *output)
+}
+
+// exportMap renders the whole map and saves it to a PNG image.
+func exportMap(renderer *software.Renderer, mapEngine *d2mapengine.MapEngine, term *logTerminal,
+	options d2maprenderer.MapImageOptions, filePath string) error {
+	mapRenderer := d2maprenderer.CreateMapRenderer(renderer, mapEngine, term)
+	width, height := mapRenderer.MapImageSize()
+
+	target, err := renderer.NewSurface(width, height, d2enum.FilterNearest)
+	if err != nil {
+		return err
+	}
+
+	mapRenderer.RenderMap(target, options)
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(file, target.Screenshot()); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// loadData loads the data tables and the strings the levels are generated
+// and populated from.
+func loadData() error {
+	entries := []struct {
+		path   string
+		loader func(data []byte)
+	}{
+		{d2resource.LevelType, d2datadict.LoadLevelTypes},
+		{d2resource.LevelPreset, d2datadict.LoadLevelPresets},
+		{d2resource.LevelWarp, d2datadict.LoadLevelWarps},
+		{d2resource.ObjectType, d2datadict.LoadObjectTypes},
+		{d2resource.ObjectDetails, d2datadict.LoadObjects},
+		{d2resource.AnimationData, d2data.LoadAnimationData},
+		{d2resource.MonStats, d2datadict.LoadMonStats},
+		{d2resource.MonStats2, d2datadict.LoadMonStats2},
+		{d2resource.MonPreset, d2datadict.LoadMonPresets},
+		{d2resource.SuperUniques, d2datadict.LoadSuperUniques},
+		{d2resource.Hireling, d2datadict.LoadHireling},
+		{d2resource.DifficultyLevels, d2datadict.LoadDifficultyLevels},
+		{d2resource.LevelDetails, d2datadict.LoadLevelDetails},
+		{d2resource.LevelMaze, d2datadict.LoadLevelMazeDetails},
+		{d2resource.LevelSubstitutions, d2datadict.LoadLevelSubstitutions},
+		{d2resource.PatchStringTable, d2common.LoadTextDictionary},
+		{d2resource.ExpansionStringTable, d2common.LoadTextDictionary},
+		{d2resource.StringTable, d2common.LoadTextDictionary},
+	}
+
+	d2datadict.InitObjectRecords()
+
+	for _, entry := range entries {
+		data, err := d2asset.LoadFile(entry.path)
+		if err != nil {
+			return err
+		}
+
+		entry.loader(data)
+	}
+
+	return nil
+}
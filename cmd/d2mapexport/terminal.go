@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+)
+
+// logTerminal is a terminal without a window, the actions bound by the
+// engine are ignored and the output is logged.
+type logTerminal struct{}
+
+func (*logTerminal) BindLogger() {}
+
+func (*logTerminal) Advance(elapsed float64) error {
+	return nil
+}
+
+func (*logTerminal) OnKeyDown(event d2interface.KeyEvent) bool {
+	return false
+}
+
+func (*logTerminal) OnKeyChars(event d2interface.KeyCharsEvent) bool {
+	return false
+}
+
+func (*logTerminal) Render(surface d2interface.Surface) error {
+	return nil
+}
+
+func (*logTerminal) Execute(command string) error {
+	return fmt.Errorf("cannot execute %q without a terminal", command)
+}
+
+func (*logTerminal) OutputRaw(text string, category d2enum.TermCategory) {
+	log.Print(text)
+}
+
+func (*logTerminal) Outputf(format string, params ...interface{}) {
+	log.Printf(format, params...)
+}
+
+func (*logTerminal) OutputInfof(format string, params ...interface{}) {
+	log.Printf(format, params...)
+}
+
+func (*logTerminal) OutputWarningf(format string, params ...interface{}) {
+	log.Printf(format, params...)
+}
+
+func (*logTerminal) OutputErrorf(format string, params ...interface{}) {
+	log.Printf(format, params...)
+}
+
+func (*logTerminal) OutputClear() {}
+
+func (*logTerminal) IsVisible() bool {
+	return false
+}
+
+func (*logTerminal) Hide() {}
+
+func (*logTerminal) Show() {}
+
+func (*logTerminal) BindAction(name, description string, action interface{}) error {
+	return nil
+}
+
+func (*logTerminal) UnbindAction(name string) error {
+	return nil
+}
@@ -223,13 +223,16 @@ func (p *App) loadDataDict() error {
 		{d2resource.ItemStatCost, d2datadict.LoadItemStatCosts},
 		{d2resource.CharStats, d2datadict.LoadCharStats},
 		{d2resource.Hireling, d2datadict.LoadHireling},
+		{d2resource.NPC, d2datadict.LoadNPCs},
 		{d2resource.Experience, d2datadict.LoadExperienceBreakpoints},
 		{d2resource.Gems, d2datadict.LoadGems},
+		{d2resource.Runes, d2datadict.LoadRunewords},
 		{d2resource.DifficultyLevels, d2datadict.LoadDifficultyLevels},
 		{d2resource.AutoMap, d2datadict.LoadAutoMaps},
 		{d2resource.LevelDetails, d2datadict.LoadLevelDetails},
 		{d2resource.LevelMaze, d2datadict.LoadLevelMazeDetails},
 		{d2resource.LevelSubstitutions, d2datadict.LoadLevelSubstitutions},
+		{d2resource.ItemTypes, d2datadict.LoadItemTypes},
 		{d2resource.CubeRecipes, d2datadict.LoadCubeRecipes},
 		{d2resource.SuperUniques, d2datadict.LoadSuperUniques},
 	}
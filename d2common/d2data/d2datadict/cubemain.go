@@ -61,6 +61,8 @@ type CubeRecipeRecord struct {
 	// example. It understands the following syntax,
 	// which may be incorrect:
 	// "ama,bar,dru"
+	//
+	// It is empty for the recipes every class can use.
 	Class []d2enum.Hero
 
 	// NumInputs is the total count of input items
@@ -194,8 +196,8 @@ func LoadCubeRecipes(file []byte) {
 					d.String(outputFields[o])),
 
 				Level:  d.Number(outLabel + "lvl"),
-				ILevel: d.Number(outLabel + "plvl"),
-				PLevel: d.Number(outLabel + "ilvl"),
+				PLevel: d.Number(outLabel + "plvl"),
+				ILevel: d.Number(outLabel + "ilvl"),
 			}
 
 			// Create properties - mod 1-5
@@ -280,28 +282,28 @@ func newCubeRecipeItem(f string) CubeRecipeItem {
 // classFieldToEnum converts class tokens to s2enum.Hero.
 func classFieldToEnum(f string) []d2enum.Hero {
 	split := splitFieldValue(f)
-	enums := make([]d2enum.Hero, len(split))
+	enums := make([]d2enum.Hero, 0, len(split))
 
-	for idx, class := range split {
+	for _, class := range split {
 		if class == "" {
 			continue
 		}
 
 		switch class {
 		case "bar":
-			enums[idx] = d2enum.HeroBarbarian
+			enums = append(enums, d2enum.HeroBarbarian)
 		case "nec":
-			enums[idx] = d2enum.HeroNecromancer
+			enums = append(enums, d2enum.HeroNecromancer)
 		case "pal":
-			enums[idx] = d2enum.HeroPaladin
+			enums = append(enums, d2enum.HeroPaladin)
 		case "ass":
-			enums[idx] = d2enum.HeroAssassin
+			enums = append(enums, d2enum.HeroAssassin)
 		case "sor":
-			enums[idx] = d2enum.HeroSorceress
+			enums = append(enums, d2enum.HeroSorceress)
 		case "ama":
-			enums[idx] = d2enum.HeroAmazon
+			enums = append(enums, d2enum.HeroAmazon)
 		case "dru":
-			enums[idx] = d2enum.HeroDruid
+			enums = append(enums, d2enum.HeroDruid)
 		default:
 			log.Fatalf("Unknown hero token: '%s'", class)
 		}
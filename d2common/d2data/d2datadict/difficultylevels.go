@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 )
 
 // DifficultyLevels contain the difficulty records for each difficulty
@@ -92,6 +93,12 @@ type DifficultyLevelRecord struct {
 
 }
 
+// GetDifficultyLevel returns the record of the given difficulty, or nil if
+// difficultylevels.txt is not loaded
+func GetDifficultyLevel(difficulty d2enum.DifficultyType) *DifficultyLevelRecord {
+	return DifficultyLevels[difficulty.String()]
+}
+
 // LoadDifficultyLevels is a loader for difficultylevels.txt
 func LoadDifficultyLevels(file []byte) {
 	DifficultyLevels = make(map[string]*DifficultyLevelRecord)
@@ -113,3 +113,15 @@ func LoadGems(file []byte) {
 
 	log.Printf("Loaded %d Gems records", len(Gems))
 }
+
+// GetGemByCode returns the record of the gem, rune or jewel of the item
+// code, nil if there is none
+func GetGemByCode(code string) *GemsRecord {
+	for _, gem := range Gems {
+		if gem.Code == code {
+			return gem
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package d2datadict
+
+import (
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+)
+
+// ItemTypeRecord is a representation of a row in ItemTypes.txt, a type of
+// items. Types are part of broader types, e.g. axes are melee weapons and
+// melee weapons are weapons, so that the item types match the items of their
+// narrower types.
+type ItemTypeRecord struct {
+	Name   string
+	Code   string
+	Equiv1 string // the code of a broader type
+	Equiv2 string // the code of another broader type
+
+	// the most sockets the items of the type have from item level 1, 25
+	// and 40 on
+	MaxSock1  int
+	MaxSock25 int
+	MaxSock40 int
+}
+
+// ItemTypes stores the ItemTypeRecords by their code
+var ItemTypes map[string]*ItemTypeRecord //nolint:gochecknoglobals // Currently global by design, only written once
+
+// LoadItemTypes loads ItemTypeRecords into ItemTypes
+func LoadItemTypes(file []byte) {
+	ItemTypes = make(map[string]*ItemTypeRecord)
+
+	d := d2common.LoadDataDictionary(file)
+	for d.Next() {
+		record := &ItemTypeRecord{
+			Name:   d.String("ItemType"),
+			Code:   d.String("Code"),
+			Equiv1: d.String("Equiv1"),
+			Equiv2: d.String("Equiv2"),
+
+			MaxSock1:  d.Number("MaxSock1"),
+			MaxSock25: d.Number("MaxSock25"),
+			MaxSock40: d.Number("MaxSock40"),
+		}
+
+		// the rows which separate the expansion types have no code
+		if record.Code == "" {
+			continue
+		}
+
+		ItemTypes[record.Code] = record
+	}
+
+	if d.Err != nil {
+		panic(d.Err)
+	}
+
+	log.Printf("Loaded %d ItemType records", len(ItemTypes))
+}
+
+// MaxSockets returns the most sockets the items of the type have at the
+// item level.
+func (r *ItemTypeRecord) MaxSockets(level int) int {
+	const (
+		secondSocketLevel = 25
+		thirdSocketLevel  = 40
+	)
+
+	switch {
+	case level >= thirdSocketLevel:
+		return r.MaxSock40
+	case level >= secondSocketLevel:
+		return r.MaxSock25
+	}
+
+	return r.MaxSock1
+}
+
+// IsItemTypeOf returns true if the item type is the given type, or one of
+// its narrower types.
+func IsItemTypeOf(code, broader string) bool {
+	// the types are at most a few levels deep, the limit only guards
+	// against loops in the modded files
+	const maxDepth = 16
+
+	pending := []string{code}
+
+	for depth := 0; depth < maxDepth && len(pending) > 0; depth++ {
+		next := make([]string, 0, len(pending))
+
+		for _, typeCode := range pending {
+			if typeCode == "" {
+				continue
+			}
+
+			if typeCode == broader {
+				return true
+			}
+
+			if record, ok := ItemTypes[typeCode]; ok {
+				next = append(next, record.Equiv1, record.Equiv2)
+			}
+		}
+
+		pending = next
+	}
+
+	return false
+}
@@ -64,13 +64,15 @@ type LevelSubstitutionRecord struct {
 	// Beta
 }
 
-// LevelSubstitutions stores all of the LevelSubstitutionRecords
+// LevelSubstitutions stores all of the LevelSubstitutionRecords by their
+// Type, in the order of lvlsub.txt, so that the index of a record in its
+// group is the SubTheme, SubWaypoint or SubShrine of Levels.txt.
 //nolint:gochecknoglobals // Currently global by design
-var LevelSubstitutions map[int]*LevelSubstitutionRecord
+var LevelSubstitutions map[int][]*LevelSubstitutionRecord
 
 // LoadLevelSubstitutions loads lvlsub.txt and parses into records
 func LoadLevelSubstitutions(file []byte) {
-	LevelSubstitutions = make(map[int]*LevelSubstitutionRecord)
+	LevelSubstitutions = make(map[int][]*LevelSubstitutionRecord)
 
 	d := d2common.LoadDataDictionary(file)
 	for d.Next() {
@@ -98,12 +100,12 @@ func LoadLevelSubstitutions(file []byte) {
 			GridMax3:     d.Number("Max3"),
 			GridMax4:     d.Number("Max4"),
 		}
-		LevelSubstitutions[record.ID] = record
+		LevelSubstitutions[record.ID] = append(LevelSubstitutions[record.ID], record)
 	}
 
 	if d.Err != nil {
 		panic(d.Err)
 	}
 
-	log.Printf("Loaded %d LevelSubstitution records", len(LevelSubstitutions))
+	log.Printf("Loaded %d LevelSubstitution groups", len(LevelSubstitutions))
 }
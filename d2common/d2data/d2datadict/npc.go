@@ -0,0 +1,50 @@
+package d2datadict
+
+import (
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+)
+
+// NPCRecord is a representation of a row in npc.txt, the prices the vendors
+// trade and repair items at. The multipliers are in 1024ths of the cost of
+// the items.
+type NPCRecord struct {
+	Name             string // the Id of the NPC in monstats.txt
+	BuyMultiplier    int    // of the items the players buy from the NPC
+	SellMultiplier   int    // of the items the players sell to the NPC
+	RepairMultiplier int    // of the items the NPC repairs
+
+	// MaxBuy is the most gold the NPC pays for an item, on each difficulty
+	MaxBuy [3]int
+}
+
+// NPCs stores the NPCRecords by the Id of the NPC in monstats.txt
+var NPCs map[string]*NPCRecord //nolint:gochecknoglobals // Currently global by design, only written once
+
+// LoadNPCs loads NPCRecords into NPCs
+func LoadNPCs(file []byte) {
+	NPCs = make(map[string]*NPCRecord)
+
+	d := d2common.LoadDataDictionary(file)
+	for d.Next() {
+		record := &NPCRecord{
+			Name:             d.String("npc"),
+			BuyMultiplier:    d.Number("buy mult"),
+			SellMultiplier:   d.Number("sell mult"),
+			RepairMultiplier: d.Number("rep mult"),
+			MaxBuy: [3]int{
+				d.Number("max buy"),
+				d.Number("max buy (N)"),
+				d.Number("max buy (H)"),
+			},
+		}
+		NPCs[record.Name] = record
+	}
+
+	if d.Err != nil {
+		panic(d.Err)
+	}
+
+	log.Printf("Loaded %d NPC records", len(NPCs))
+}
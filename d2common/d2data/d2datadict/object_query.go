@@ -66,6 +66,28 @@ func lookupObject(act, typ, id int, objects [][][]*ObjectLookupRecord) *ObjectLo
 	return objects[act][typ][id]
 }
 
+// LookupObjects returns the lookup records of all objects of the given type
+// in an act, ordered by their id.
+func LookupObjects(act, typ int) []*ObjectLookupRecord {
+	return lookupObjects(act, typ, indexedObjects)
+}
+
+func lookupObjects(act, typ int, objects [][][]*ObjectLookupRecord) []*ObjectLookupRecord {
+	if act < 0 || act >= len(objects) || typ < 0 || typ >= len(objects[act]) {
+		return nil
+	}
+
+	records := make([]*ObjectLookupRecord, 0)
+
+	for _, record := range objects[act][typ] {
+		if record != nil {
+			records = append(records, record)
+		}
+	}
+
+	return records
+}
+
 // InitObjectRecords loads ObjectRecords
 func InitObjectRecords() {
 	indexedObjects = indexObjects(objectLookups)
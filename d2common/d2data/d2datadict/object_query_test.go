@@ -41,3 +41,23 @@ func TestIndexObjects(t *testing.T) {
 	assert.Equal("Act2CharId3", lookupObject(2, typeCharacter, 3, indexedTestObjects).Description)
 	assert.Equal("Act2ItemId1", lookupObject(2, typeItem, 1, indexedTestObjects).Description)
 }
+
+// Verify all objects of an act and type are returned in id order.
+func TestLookupObjects(t *testing.T) {
+	assert := testify.New(t)
+
+	testObjects := []ObjectLookupRecord{
+		{Act: 1, Type: d2enum.ObjectTypeItem, Id: 3, Description: "Act1ItemId3"},
+		{Act: 1, Type: d2enum.ObjectTypeItem, Id: 1, Description: "Act1ItemId1"},
+		{Act: 1, Type: d2enum.ObjectTypeCharacter, Id: 0, Description: "Act1CharId0"},
+		{Act: 2, Type: d2enum.ObjectTypeItem, Id: 0, Description: "Act2ItemId0"},
+	}
+
+	indexedTestObjects := indexObjects(testObjects)
+	records := lookupObjects(1, int(d2enum.ObjectTypeItem), indexedTestObjects)
+
+	assert.Len(records, 2)
+	assert.Equal("Act1ItemId1", records[0].Description)
+	assert.Equal("Act1ItemId3", records[1].Description)
+	assert.Empty(lookupObjects(4, int(d2enum.ObjectTypeItem), indexedTestObjects))
+}
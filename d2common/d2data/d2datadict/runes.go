@@ -0,0 +1,99 @@
+package d2datadict
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+)
+
+// the repeated columns of runes.txt
+const (
+	numRunewordTypes         = 6
+	numRunewordExcludedTypes = 3
+	numRunewordRunes         = 6
+	numRunewordProperties    = 7
+)
+
+// RunesRecord is a representation of a row in runes.txt, a runeword: the
+// runes which make it when they are socketed in order into an item of one of
+// its types, and the properties the item gets.
+type RunesRecord struct {
+	Name     string // the key of the runeword, e.g. Runeword1
+	RuneName string // the string key of the name of the runeword
+
+	// only the complete runewords can be made, the others are left over
+	// from the development of the game
+	Complete bool
+
+	Types         []string // the item types the runeword can be made in
+	ExcludedTypes []string // the narrower item types it can not be made in
+	Runes         []string // the codes of the runes, in the order they are socketed
+
+	Properties []RunesProperty
+}
+
+// RunesProperty is a property of the items made a runeword.
+type RunesProperty struct {
+	Code  string // the code field from properties.txt
+	Param int    // for properties that use parameters
+	Min   int    // the minimum value of the property stat
+	Max   int    // the maximum value of the property stat
+}
+
+// Runewords stores the RunesRecords by their name
+var Runewords map[string]*RunesRecord //nolint:gochecknoglobals // Currently global by design, only written once
+
+// LoadRunewords loads RunesRecords into Runewords
+func LoadRunewords(file []byte) {
+	Runewords = make(map[string]*RunesRecord)
+
+	d := d2common.LoadDataDictionary(file)
+	for d.Next() {
+		record := &RunesRecord{
+			Name:     d.String("Name"),
+			RuneName: d.String("Rune Name"),
+			Complete: d.Bool("complete"),
+		}
+
+		record.Types = nonEmptyColumns(d, "itype%d", numRunewordTypes)
+		record.ExcludedTypes = nonEmptyColumns(d, "etype%d", numRunewordExcludedTypes)
+		record.Runes = nonEmptyColumns(d, "Rune%d", numRunewordRunes)
+
+		for idx := 1; idx <= numRunewordProperties; idx++ {
+			code := d.String(fmt.Sprintf("T1Code%d", idx))
+			if code == "" {
+				continue
+			}
+
+			record.Properties = append(record.Properties, RunesProperty{
+				Code:  code,
+				Param: d.Number(fmt.Sprintf("T1Param%d", idx)),
+				Min:   d.Number(fmt.Sprintf("T1Min%d", idx)),
+				Max:   d.Number(fmt.Sprintf("T1Max%d", idx)),
+			})
+		}
+
+		Runewords[record.Name] = record
+	}
+
+	if d.Err != nil {
+		panic(d.Err)
+	}
+
+	log.Printf("Loaded %d Runeword records", len(Runewords))
+}
+
+// nonEmptyColumns returns the values of the numbered columns, from 1 to
+// count, which are not empty.
+func nonEmptyColumns(d *d2common.DataDictionary, format string, count int) []string {
+	values := make([]string, 0, count)
+
+	for idx := 1; idx <= count; idx++ {
+		if value := d.String(fmt.Sprintf(format, idx)); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
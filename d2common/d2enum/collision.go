@@ -0,0 +1,21 @@
+package d2enum
+
+// Collision is a set of collision classes, it tells what a sub-tile of the
+// walk mesh blocks, or how an entity moves on it.
+type Collision byte
+
+// Collision classes
+const (
+	CollisionWalk    Collision = 1 << iota // units walking on the ground
+	CollisionFly                           // units flying over the ground, e.g. over water
+	CollisionMissile                       // missiles
+
+	CollisionNone  Collision = 0
+	CollisionUnit            = CollisionWalk | CollisionFly
+	CollisionSolid           = CollisionWalk | CollisionFly | CollisionMissile
+)
+
+// Blocks returns true if any of the given collision classes is blocked.
+func (c Collision) Blocks(classes Collision) bool {
+	return c&classes != 0
+}
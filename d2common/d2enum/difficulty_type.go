@@ -0,0 +1,25 @@
+package d2enum
+
+// DifficultyType represents the difficulty a game is played on
+type DifficultyType int
+
+// Difficulty types
+const (
+	DifficultyNormal DifficultyType = iota
+	DifficultyNightmare
+	DifficultyHell
+)
+
+// String returns the name of the difficulty, as in DifficultyLevels.txt
+func (d DifficultyType) String() string {
+	switch d {
+	case DifficultyNormal:
+		return "Normal"
+	case DifficultyNightmare:
+		return "Nightmare"
+	case DifficultyHell:
+		return "Hell"
+	}
+
+	return ""
+}
@@ -0,0 +1,40 @@
+package d2enum
+
+// ItemQuality represents the quality of an item
+type ItemQuality int
+
+// Item qualities, the items of normal quality are the zero value
+const (
+	ItemQualityNormal ItemQuality = iota
+	ItemQualityLow
+	ItemQualitySuperior
+	ItemQualityMagic
+	ItemQualitySet
+	ItemQualityRare
+	ItemQualityUnique
+	ItemQualityCrafted
+)
+
+// String returns the name of the quality
+func (q ItemQuality) String() string {
+	switch q {
+	case ItemQualityNormal:
+		return "Normal"
+	case ItemQualityLow:
+		return "Low Quality"
+	case ItemQualitySuperior:
+		return "Superior"
+	case ItemQualityMagic:
+		return "Magic"
+	case ItemQualitySet:
+		return "Set"
+	case ItemQualityRare:
+		return "Rare"
+	case ItemQualityUnique:
+		return "Unique"
+	case ItemQualityCrafted:
+		return "Crafted"
+	}
+
+	return ""
+}
@@ -0,0 +1,28 @@
+package d2enum
+
+// NPCService represents what a town NPC does for the players which talk to it
+type NPCService int
+
+// NPC services
+const (
+	NPCServiceTalk NPCService = iota
+	NPCServiceTrade
+	NPCServiceRepair
+	NPCServiceIdentify
+)
+
+// String returns the name of the service, as shown in the menu of the NPC
+func (s NPCService) String() string {
+	switch s {
+	case NPCServiceTalk:
+		return "Talk"
+	case NPCServiceTrade:
+		return "Trade"
+	case NPCServiceRepair:
+		return "Repair"
+	case NPCServiceIdentify:
+		return "Identify Items"
+	}
+
+	return ""
+}
@@ -0,0 +1,35 @@
+package d2enum
+
+// TradeAction represents what a player does in the trade window it shares
+// with another player
+type TradeAction int
+
+// Trade actions
+const (
+	TradeActionOfferItem TradeAction = iota
+	TradeActionWithdrawItem
+	TradeActionOfferGold
+	TradeActionAccept
+	TradeActionConfirm
+	TradeActionCancel
+)
+
+// String returns the name of the trade action
+func (a TradeAction) String() string {
+	switch a {
+	case TradeActionOfferItem:
+		return "offer item"
+	case TradeActionWithdrawItem:
+		return "withdraw item"
+	case TradeActionOfferGold:
+		return "offer gold"
+	case TradeActionAccept:
+		return "accept"
+	case TradeActionConfirm:
+		return "confirm"
+	case TradeActionCancel:
+		return "cancel"
+	}
+
+	return ""
+}
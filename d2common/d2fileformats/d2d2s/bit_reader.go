@@ -0,0 +1,99 @@
+package d2d2s
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+)
+
+const byteLen = 8
+
+// bitReader reads the sections of a d2s file with a BitMuncher. Reading past
+// the end of the file sets ErrTruncated and returns zeros from there on.
+type bitReader struct {
+	bitMuncher d2interface.BitMuncher
+	size       int // in bits
+	stats      statCosts
+	err        error
+}
+
+func createBitReader(data []byte) *bitReader {
+	return &bitReader{
+		bitMuncher: d2common.CreateBitMuncher(data, 0),
+		size:       len(data) * byteLen,
+		stats:      loadStatCosts(),
+	}
+}
+
+func (r *bitReader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *bitReader) bits(bits int) uint32 {
+	if r.err != nil {
+		return 0
+	}
+
+	if r.bitMuncher.Offset()+bits > r.size {
+		r.fail(ErrTruncated)
+		return 0
+	}
+
+	return r.bitMuncher.GetBits(bits)
+}
+
+func (r *bitReader) bit() bool {
+	return r.bits(1) == 1
+}
+
+func (r *bitReader) byte() byte {
+	return byte(r.bits(byteLen))
+}
+
+func (r *bitReader) uint16() uint16 {
+	return uint16(r.bits(2 * byteLen))
+}
+
+func (r *bitReader) uint32() uint32 {
+	return r.bits(4 * byteLen)
+}
+
+func (r *bitReader) bytes(data []byte) {
+	for idx := range data {
+		data[idx] = r.byte()
+	}
+}
+
+// expect reads the magic string a section starts with
+func (r *bitReader) expect(magic string) {
+	for idx := 0; idx < len(magic); idx++ {
+		if r.byte() != magic[idx] {
+			r.fail(ErrSection)
+		}
+	}
+}
+
+// align skips the bits left of the current byte
+func (r *bitReader) align() {
+	if offset := r.bitMuncher.Offset() % byteLen; offset != 0 {
+		r.bits(byteLen - offset)
+	}
+}
+
+// text reads a string of characters of the given number of bits, ending with
+// a zero
+func (r *bitReader) text(bits int) string {
+	var result []byte
+
+	for r.err == nil {
+		c := byte(r.bits(bits))
+		if c == 0 {
+			break
+		}
+
+		result = append(result, c)
+	}
+
+	return string(result)
+}
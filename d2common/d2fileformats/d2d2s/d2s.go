@@ -0,0 +1,460 @@
+package d2d2s
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+)
+
+const (
+	signature = 0xaa55aa55
+
+	// Version is the version of the characters saved by Diablo II 1.10 to
+	// 1.14, the only one supported
+	Version = 96
+)
+
+const (
+	fileSizeOffset   = 8
+	checksumOffset   = 12
+	headerLength     = 335
+	nameLength       = 16
+	hotkeyCount      = 16
+	appearanceLength = 32
+	difficultyCount  = 3
+
+	questsMagic      = "Woo!"
+	questsVersion    = 6
+	questsLength     = 96 // of each difficulty
+	waypointsMagic   = "WS"
+	waypointsVersion = 1
+	waypointsLength  = 24 // of each difficulty
+	npcsMagic        = "w4"
+	npcsLength       = 48
+	statsMagic       = "gf"
+	skillsMagic      = "if"
+	skillCount       = 30
+	mercenaryMagic   = "jf"
+	golemMagic       = "kf"
+
+	// the difficulty the character plays has this bit set, with the act it
+	// is in in the low bits
+	difficultyActive = 0x80
+	actMask          = 0x07
+
+	noSkill = 0xFFFF
+)
+
+// Errors
+var (
+	ErrSignature   = errors.New("not a d2s file")
+	ErrVersion     = errors.New("unsupported d2s version")
+	ErrChecksum    = errors.New("d2s checksum mismatch")
+	ErrTruncated   = errors.New("truncated d2s file")
+	ErrSection     = errors.New("d2s section not found")
+	ErrUnknownStat = errors.New("stat missing from ItemStatCost.txt")
+	ErrUnknownItem = errors.New("item missing from the item tables")
+)
+
+// Mercenary is the hireling of a character
+type Mercenary struct {
+	Dead       uint16
+	ID         uint32 // 0 without a mercenary
+	NameID     uint16
+	Type       uint16
+	Experience uint32
+}
+
+// Corpse is the body a character left with its items when it died
+type Corpse struct {
+	Unknown uint32
+	X       uint32
+	Y       uint32
+	Items   []*Item
+}
+
+// D2S is a character saved by Diablo II. The quests and the introductions of
+// the NPCs are kept as they are saved, as flags of each difficulty.
+type D2S struct {
+	ActiveWeapons  uint32 // the weapon set used, 0 or 1
+	Name           string
+	Status         Status
+	Progression    byte // the acts completed
+	Class          Class
+	Level          byte
+	LastPlayed     uint32 // unix time
+	SkillHotkeys   [hotkeyCount]uint32
+	LeftSkill      uint32
+	RightSkill     uint32
+	LeftSwapSkill  uint32
+	RightSwapSkill uint32
+	Appearance     [appearanceLength]byte // of the character in the character select screen
+	Difficulty     [difficultyCount]byte
+	MapID          uint32 // the seed of the maps
+	Mercenary      Mercenary
+	Quests         [difficultyCount][questsLength]byte
+	Waypoints      [difficultyCount]Waypoints
+	NPCs           [npcsLength]byte
+	Stats          map[StatID]uint32
+	Skills         [skillCount]byte // levels of the skills of the class, in the order of Skills.txt
+	Items          []*Item
+	Corpse         *Corpse
+	MercenaryItems []*Item
+	Golem          *Item // the item an iron golem is made of
+
+	// the unknown bytes of the header, saved back as they are
+	header [headerLength]byte
+}
+
+// CreateD2S creates a level 1 expansion character, in the first act of the
+// normal difficulty
+func CreateD2S(name string, class Class) *D2S {
+	result := &D2S{
+		Name:   name,
+		Status: StatusExpansion,
+		Class:  class,
+		Level:  1,
+		Stats:  make(map[StatID]uint32),
+	}
+
+	result.header[41], result.header[42] = 0x10, 0x1E
+	copy(result.header[52:56], []byte{0xFF, 0xFF, 0xFF, 0xFF})
+
+	for idx := range result.SkillHotkeys {
+		result.SkillHotkeys[idx] = noSkill
+	}
+
+	for idx := range result.Appearance {
+		result.Appearance[idx] = 0xFF
+	}
+
+	for idx := range result.Waypoints {
+		result.Waypoints[idx] = createWaypoints()
+	}
+
+	result.SetAct(0, 0)
+
+	return result
+}
+
+// Load reads a character saved by Diablo II. The stats and the items are
+// decoded with the records of ItemStatCost.txt and of the item tables, which
+// must be loaded.
+func Load(data []byte) (*D2S, error) {
+	r := createBitReader(data)
+
+	if r.uint32() != signature {
+		return nil, ErrSignature
+	}
+
+	if r.uint32() != Version {
+		return nil, ErrVersion
+	}
+
+	if r.uint32() != uint32(len(data)) {
+		return nil, ErrTruncated
+	}
+
+	if r.uint32() != checksum(data) {
+		return nil, ErrChecksum
+	}
+
+	result := &D2S{}
+	result.loadHeader(r)
+	result.loadSections(r)
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return result, nil
+}
+
+func (d *D2S) loadHeader(r *bitReader) {
+	d.ActiveWeapons = r.uint32()
+
+	name := make([]byte, nameLength)
+	r.bytes(name)
+	d.Name = strings.TrimRight(string(name), "\x00")
+
+	d.Status = Status(r.byte())
+	d.Progression = r.byte()
+	r.bytes(d.header[38:40])
+	d.Class = Class(r.byte())
+	r.bytes(d.header[41:43])
+	d.Level = r.byte()
+	r.bytes(d.header[44:48])
+	d.LastPlayed = r.uint32()
+	r.bytes(d.header[52:56])
+
+	for idx := range d.SkillHotkeys {
+		d.SkillHotkeys[idx] = r.uint32()
+	}
+
+	d.LeftSkill = r.uint32()
+	d.RightSkill = r.uint32()
+	d.LeftSwapSkill = r.uint32()
+	d.RightSwapSkill = r.uint32()
+
+	r.bytes(d.Appearance[:])
+	r.bytes(d.Difficulty[:])
+	d.MapID = r.uint32()
+	r.bytes(d.header[175:177])
+
+	d.Mercenary.Dead = r.uint16()
+	d.Mercenary.ID = r.uint32()
+	d.Mercenary.NameID = r.uint16()
+	d.Mercenary.Type = r.uint16()
+	d.Mercenary.Experience = r.uint32()
+
+	r.bytes(d.header[191:headerLength])
+}
+
+func (d *D2S) loadSections(r *bitReader) {
+	r.expect(questsMagic)
+	r.uint32() // version
+	r.uint16() // size
+
+	for idx := range d.Quests {
+		r.bytes(d.Quests[idx][:])
+	}
+
+	r.expect(waypointsMagic)
+	r.uint32() // version
+	r.uint16() // size
+
+	for idx := range d.Waypoints {
+		r.bytes(d.Waypoints[idx][:])
+	}
+
+	r.expect(npcsMagic)
+	r.uint16() // size
+	r.bytes(d.NPCs[:])
+
+	d.Stats = r.readStats()
+
+	r.expect(skillsMagic)
+	r.bytes(d.Skills[:])
+
+	d.Items = r.readItemList()
+
+	r.expect(itemListMagic)
+
+	if r.uint16() > 0 {
+		d.Corpse = &Corpse{Unknown: r.uint32(), X: r.uint32(), Y: r.uint32()}
+		d.Corpse.Items = r.readItemList()
+	}
+
+	if !d.Status.Has(StatusExpansion) {
+		return
+	}
+
+	r.expect(mercenaryMagic)
+
+	if d.Mercenary.ID != 0 {
+		d.MercenaryItems = r.readItemList()
+	}
+
+	r.expect(golemMagic)
+
+	if r.byte() != 0 {
+		d.Golem = r.readItem()
+	}
+}
+
+// Marshal encodes the character as a d2s file. The stats and the items are
+// encoded with the records of ItemStatCost.txt and of the item tables, which
+// must be loaded.
+func (d *D2S) Marshal() ([]byte, error) {
+	w := createBitWriter()
+
+	w.PushUint32(signature)
+	w.PushUint32(Version)
+	w.PushUint32(0) // file size
+	w.PushUint32(0) // checksum
+
+	d.writeHeader(w)
+	d.writeSections(w)
+
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	data := w.GetBytes()
+	binary.LittleEndian.PutUint32(data[fileSizeOffset:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(data[checksumOffset:], checksum(data))
+
+	return data, nil
+}
+
+func (d *D2S) writeHeader(w *bitWriter) {
+	w.PushUint32(d.ActiveWeapons)
+
+	// the name ends with a zero
+	name := make([]byte, nameLength)
+	copy(name[:nameLength-1], d.Name)
+	w.PushBytes(name...)
+
+	w.PushByte(byte(d.Status))
+	w.PushByte(d.Progression)
+	w.PushBytes(d.header[38:40]...)
+	w.PushByte(byte(d.Class))
+	w.PushBytes(d.header[41:43]...)
+	w.PushByte(d.Level)
+	w.PushBytes(d.header[44:48]...)
+	w.PushUint32(d.LastPlayed)
+	w.PushBytes(d.header[52:56]...)
+
+	for _, hotkey := range d.SkillHotkeys {
+		w.PushUint32(hotkey)
+	}
+
+	w.PushUint32(d.LeftSkill)
+	w.PushUint32(d.RightSkill)
+	w.PushUint32(d.LeftSwapSkill)
+	w.PushUint32(d.RightSwapSkill)
+
+	w.PushBytes(d.Appearance[:]...)
+	w.PushBytes(d.Difficulty[:]...)
+	w.PushUint32(d.MapID)
+	w.PushBytes(d.header[175:177]...)
+
+	w.PushUint16(d.Mercenary.Dead)
+	w.PushUint32(d.Mercenary.ID)
+	w.PushUint16(d.Mercenary.NameID)
+	w.PushUint16(d.Mercenary.Type)
+	w.PushUint32(d.Mercenary.Experience)
+
+	w.PushBytes(d.header[191:headerLength]...)
+}
+
+func (d *D2S) writeSections(w *bitWriter) {
+	w.PushBytes([]byte(questsMagic)...)
+	w.PushUint32(questsVersion)
+	w.PushUint16(uint16(len(questsMagic) + 6 + difficultyCount*questsLength))
+
+	for idx := range d.Quests {
+		w.PushBytes(d.Quests[idx][:]...)
+	}
+
+	w.PushBytes([]byte(waypointsMagic)...)
+	w.PushUint32(waypointsVersion)
+	w.PushUint16(uint16(len(waypointsMagic) + 6 + difficultyCount*waypointsLength))
+
+	for idx := range d.Waypoints {
+		w.PushBytes(d.Waypoints[idx][:]...)
+	}
+
+	w.PushBytes([]byte(npcsMagic)...)
+	w.PushUint16(uint16(len(npcsMagic) + 2 + npcsLength))
+	w.PushBytes(d.NPCs[:]...)
+
+	w.writeStats(d.Stats)
+
+	w.PushBytes([]byte(skillsMagic)...)
+	w.PushBytes(d.Skills[:]...)
+
+	w.writeItemList(d.Items)
+
+	w.PushBytes([]byte(itemListMagic)...)
+
+	if d.Corpse == nil {
+		w.PushUint16(0)
+	} else {
+		w.PushUint16(1)
+		w.PushUint32(d.Corpse.Unknown)
+		w.PushUint32(d.Corpse.X)
+		w.PushUint32(d.Corpse.Y)
+		w.writeItemList(d.Corpse.Items)
+	}
+
+	if !d.Status.Has(StatusExpansion) {
+		return
+	}
+
+	w.PushBytes([]byte(mercenaryMagic)...)
+
+	if d.Mercenary.ID != 0 {
+		w.writeItemList(d.MercenaryItems)
+	}
+
+	w.PushBytes([]byte(golemMagic)...)
+
+	if d.Golem == nil {
+		w.PushByte(0)
+	} else {
+		w.PushByte(1)
+		w.writeItem(d.Golem)
+	}
+}
+
+// Act returns the difficulty (0 for normal to 2 for hell) and the act (0 to
+// 4) the character is in
+func (d *D2S) Act() (difficulty, act int) {
+	for idx, value := range d.Difficulty {
+		if value&difficultyActive != 0 {
+			return idx, int(value & actMask)
+		}
+	}
+
+	return 0, 0
+}
+
+// SetAct sets the difficulty (0 for normal to 2 for hell) and the act (0 to
+// 4) the character is in
+func (d *D2S) SetAct(difficulty, act int) {
+	for idx := range d.Difficulty {
+		d.Difficulty[idx] = 0
+	}
+
+	d.Difficulty[difficulty] = difficultyActive | byte(act)&actMask
+}
+
+// checksum sums the bytes of the file, rotating the sum left by a bit before
+// each byte. The checksum itself counts as zeros.
+func checksum(data []byte) uint32 {
+	var sum uint32
+
+	for idx, value := range data {
+		if idx >= checksumOffset && idx < checksumOffset+4 {
+			value = 0
+		}
+
+		sum = (sum<<1 | sum>>31) + uint32(value)
+	}
+
+	return sum
+}
+
+// bitWriter writes the sections of a d2s file. Writing an unknown stat or
+// item sets the error of the writer.
+type bitWriter struct {
+	*d2common.StreamWriter
+	stats statCosts
+	err   error
+}
+
+func createBitWriter() *bitWriter {
+	return &bitWriter{
+		StreamWriter: d2common.CreateStreamWriter(),
+		stats:        loadStatCosts(),
+	}
+}
+
+func (w *bitWriter) fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// text writes a string of characters of the given number of bits, ending
+// with a zero
+func (w *bitWriter) text(text string, bits int) {
+	for idx := 0; idx < len(text); idx++ {
+		w.PushBits(uint32(text[idx]), bits)
+	}
+
+	w.PushBits(0, bits)
+}
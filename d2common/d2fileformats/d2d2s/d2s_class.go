@@ -0,0 +1,65 @@
+package d2d2s
+
+import "github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+
+// Class is the class of a character, in the order of Diablo II, which is not
+// the order of d2enum.Hero
+type Class byte
+
+// Classes
+const (
+	ClassAmazon Class = iota
+	ClassSorceress
+	ClassNecromancer
+	ClassPaladin
+	ClassBarbarian
+	ClassDruid
+	ClassAssassin
+)
+
+//nolint:gochecknoglobals // constant lookup table of the heroes of the classes
+var classHeroes = [...]d2enum.Hero{
+	ClassAmazon:      d2enum.HeroAmazon,
+	ClassSorceress:   d2enum.HeroSorceress,
+	ClassNecromancer: d2enum.HeroNecromancer,
+	ClassPaladin:     d2enum.HeroPaladin,
+	ClassBarbarian:   d2enum.HeroBarbarian,
+	ClassDruid:       d2enum.HeroDruid,
+	ClassAssassin:    d2enum.HeroAssassin,
+}
+
+// Hero returns the hero of the class, d2enum.HeroNone if the class is unknown
+func (c Class) Hero() d2enum.Hero {
+	if int(c) >= len(classHeroes) {
+		return d2enum.HeroNone
+	}
+
+	return classHeroes[c]
+}
+
+// ClassOf returns the class of a hero
+func ClassOf(hero d2enum.Hero) (Class, bool) {
+	for class, classHero := range classHeroes {
+		if classHero == hero {
+			return Class(class), true
+		}
+	}
+
+	return 0, false
+}
+
+// Status is a set of flags of a character
+type Status byte
+
+// Status flags
+const (
+	StatusHardcore  Status = 1 << 2
+	StatusDied      Status = 1 << 3
+	StatusExpansion Status = 1 << 5
+	StatusLadder    Status = 1 << 6
+)
+
+// Has returns true if the given flags are set
+func (s Status) Has(flags Status) bool {
+	return s&flags == flags
+}
@@ -0,0 +1,502 @@
+package d2d2s
+
+import (
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+)
+
+const (
+	itemListMagic = "JM"
+
+	itemVersionBits     = 10
+	itemLocationBits    = 3
+	itemSlotBits        = 4
+	itemPositionBits    = 4
+	itemStorageBits     = 3
+	itemCodeLength      = 4
+	itemSocketedBits    = 3
+	itemIDBits          = 32
+	itemLevelBits       = 7
+	itemQualityBits     = 4
+	itemPictureBits     = 3
+	itemAutoAffixBits   = 11
+	itemQualityIDBits   = 3
+	itemAffixBits       = 11
+	itemSetUniqueBits   = 12
+	itemRareNameBits    = 8
+	itemRunewordBits    = 12
+	itemRunewordPadding = 4
+	itemCharBits        = 7
+	itemTomeBits        = 5
+	itemRealmDataCount  = 3
+	itemDefenseBits     = 11
+	itemDefenseAdd      = 10
+	itemDurabilityBits  = 8
+	itemQuantityBits    = 9
+	itemSocketsBits     = 4
+	itemSetListCount    = 5
+	earClassBits        = 3
+	earLevelBits        = 7
+	rareAffixCount      = 6
+)
+
+// ItemFlags are the flags an item is saved with
+type ItemFlags uint32
+
+// Item flags
+const (
+	ItemIdentified   ItemFlags = 1 << 4
+	ItemSocketed     ItemFlags = 1 << 11
+	ItemNew          ItemFlags = 1 << 13 // picked up since the last save
+	ItemEar          ItemFlags = 1 << 16
+	ItemStarter      ItemFlags = 1 << 17 // given to new characters
+	ItemSimple       ItemFlags = 1 << 21 // saved without the extended data
+	ItemEthereal     ItemFlags = 1 << 22
+	ItemAlwaysSet    ItemFlags = 1 << 23
+	ItemPersonalized ItemFlags = 1 << 24
+	ItemRuneword     ItemFlags = 1 << 26
+)
+
+// Has returns true if the given flags are set
+func (f ItemFlags) Has(flags ItemFlags) bool {
+	return f&flags == flags
+}
+
+// ItemLocation is where an item is
+type ItemLocation byte
+
+// Item locations
+const (
+	LocationStored   ItemLocation = 0 // in the inventory panel, the cube or the stash
+	LocationEquipped ItemLocation = 1
+	LocationBelt     ItemLocation = 2
+	LocationCursor   ItemLocation = 4
+	LocationSocket   ItemLocation = 6
+)
+
+// ItemStorage is where a stored item is
+type ItemStorage byte
+
+// Item storages
+const (
+	StorageNone      ItemStorage = 0
+	StorageInventory ItemStorage = 1
+	StorageCube      ItemStorage = 4
+	StorageStash     ItemStorage = 5
+)
+
+// Body parts of the equipped items
+const (
+	BodyHead = iota + 1
+	BodyNeck
+	BodyTorso
+	BodyRightHand // the weapon
+	BodyLeftHand  // the shield
+	BodyRightRing
+	BodyLeftRing
+	BodyBelt
+	BodyFeet
+	BodyGloves
+	BodySwapRightHand
+	BodySwapLeftHand
+)
+
+// Quality is the quality of an item
+type Quality byte
+
+// Item qualities
+const (
+	QualityLow Quality = iota + 1
+	QualityNormal
+	QualitySuperior
+	QualityMagic
+	QualitySet
+	QualityRare
+	QualityUnique
+	QualityCrafted
+)
+
+// Ear is the ear of a character, the trophy of a duel
+type Ear struct {
+	Class Class
+	Level byte
+	Name  string
+}
+
+// Item is an item saved by Diablo II. The simple items, e.g. the potions,
+// are saved without the fields which follow SocketedItems.
+type Item struct {
+	Flags         ItemFlags
+	Version       uint16
+	Location      ItemLocation
+	BodyPart      byte // of the equipped items
+	X             byte // in the grid, or the slot of the belt
+	Y             byte
+	Storage       ItemStorage
+	Code          string
+	Ear           *Ear    // instead of the code, of the ears
+	SocketedItems []*Item // the gems, runes and jewels in the sockets
+
+	ID                 uint32
+	Level              byte
+	Quality            Quality
+	HasPicture         bool
+	Picture            byte // of the rings, amulets, jewels and charms
+	ClassSpecific      bool
+	AutoAffix          uint16
+	QualityID          uint16                 // of the low and superior qualities, or of the set or unique item
+	RareNames          [2]byte                // of the rare and crafted items
+	Affixes            [rareAffixCount]uint16 // prefixes and suffixes, alternating, 0 when missing
+	RunewordID         uint16
+	PersonalizedName   string
+	TomeData           byte
+	RealmData          []uint32
+	Defense            int
+	MaxDurability      int
+	Durability         int
+	Quantity           int
+	Sockets            byte
+	Properties         []Property
+	SetProperties      [itemSetListCount][]Property // the bonuses of the set items, nil for the missing ones
+	RunewordProperties []Property
+
+	runewordPadding uint32
+}
+
+func isTome(code string) bool {
+	return code == "tbk" || code == "ibk"
+}
+
+func (r *bitReader) readItemList() []*Item {
+	r.expect(itemListMagic)
+
+	count := int(r.uint16())
+	result := make([]*Item, 0, count)
+
+	for idx := 0; idx < count && r.err == nil; idx++ {
+		result = append(result, r.readItem())
+	}
+
+	return result
+}
+
+func (w *bitWriter) writeItemList(items []*Item) {
+	w.PushBytes([]byte(itemListMagic)...)
+	w.PushUint16(uint16(len(items)))
+
+	for _, item := range items {
+		w.writeItem(item)
+	}
+}
+
+func (r *bitReader) readItem() *Item {
+	item := &Item{}
+
+	r.expect(itemListMagic)
+
+	item.Flags = ItemFlags(r.uint32())
+	item.Version = uint16(r.bits(itemVersionBits))
+	item.Location = ItemLocation(r.bits(itemLocationBits))
+	item.BodyPart = byte(r.bits(itemSlotBits))
+	item.X = byte(r.bits(itemPositionBits))
+	item.Y = byte(r.bits(itemPositionBits))
+	item.Storage = ItemStorage(r.bits(itemStorageBits))
+
+	if item.Flags.Has(ItemEar) {
+		item.Ear = &Ear{
+			Class: Class(r.bits(earClassBits)),
+			Level: byte(r.bits(earLevelBits)),
+			Name:  r.text(itemCharBits),
+		}
+
+		r.align()
+
+		return item
+	}
+
+	code := make([]byte, itemCodeLength)
+	r.bytes(code)
+	item.Code = strings.TrimRight(string(code), " ")
+
+	socketed := int(r.bits(itemSocketedBits))
+
+	if !item.Flags.Has(ItemSimple) {
+		r.readExtendedItem(item)
+	}
+
+	r.align()
+
+	for idx := 0; idx < socketed && r.err == nil; idx++ {
+		item.SocketedItems = append(item.SocketedItems, r.readItem())
+	}
+
+	return item
+}
+
+func (w *bitWriter) writeItem(item *Item) {
+	w.PushBytes([]byte(itemListMagic)...)
+
+	w.PushBits(uint32(item.Flags), 4*byteLen)
+	w.PushBits(uint32(item.Version), itemVersionBits)
+	w.PushBits(uint32(item.Location), itemLocationBits)
+	w.PushBits(uint32(item.BodyPart), itemSlotBits)
+	w.PushBits(uint32(item.X), itemPositionBits)
+	w.PushBits(uint32(item.Y), itemPositionBits)
+	w.PushBits(uint32(item.Storage), itemStorageBits)
+
+	if item.Flags.Has(ItemEar) {
+		ear := item.Ear
+		if ear == nil {
+			ear = &Ear{}
+		}
+
+		w.PushBits(uint32(ear.Class), earClassBits)
+		w.PushBits(uint32(ear.Level), earLevelBits)
+		w.text(ear.Name, itemCharBits)
+
+		return
+	}
+
+	code := []byte(item.Code + strings.Repeat(" ", itemCodeLength))
+	for _, c := range code[:itemCodeLength] {
+		w.PushBits(uint32(c), byteLen)
+	}
+
+	w.PushBits(uint32(len(item.SocketedItems)), itemSocketedBits)
+
+	if !item.Flags.Has(ItemSimple) {
+		w.writeExtendedItem(item)
+	}
+
+	for _, socketed := range item.SocketedItems {
+		w.writeItem(socketed)
+	}
+}
+
+func (r *bitReader) readExtendedItem(item *Item) {
+	record := d2datadict.CommonItems[item.Code]
+	if record == nil {
+		r.fail(ErrUnknownItem)
+		return
+	}
+
+	item.ID = r.bits(itemIDBits)
+	item.Level = byte(r.bits(itemLevelBits))
+	item.Quality = Quality(r.bits(itemQualityBits))
+
+	if item.HasPicture = r.bit(); item.HasPicture {
+		item.Picture = byte(r.bits(itemPictureBits))
+	}
+
+	if item.ClassSpecific = r.bit(); item.ClassSpecific {
+		item.AutoAffix = uint16(r.bits(itemAutoAffixBits))
+	}
+
+	r.readQualityData(item)
+
+	if item.Flags.Has(ItemRuneword) {
+		item.RunewordID = uint16(r.bits(itemRunewordBits))
+		item.runewordPadding = r.bits(itemRunewordPadding)
+	}
+
+	if item.Flags.Has(ItemPersonalized) {
+		item.PersonalizedName = r.text(itemCharBits)
+	}
+
+	if isTome(item.Code) {
+		item.TomeData = byte(r.bits(itemTomeBits))
+	}
+
+	if r.bit() {
+		item.RealmData = make([]uint32, itemRealmDataCount)
+		for idx := range item.RealmData {
+			item.RealmData[idx] = r.uint32()
+		}
+	}
+
+	_, isArmor := d2datadict.Armors[item.Code]
+	_, isWeapon := d2datadict.Weapons[item.Code]
+
+	if isArmor {
+		item.Defense = int(r.bits(itemDefenseBits)) - itemDefenseAdd
+	}
+
+	if isArmor || isWeapon {
+		item.MaxDurability = int(r.bits(itemDurabilityBits))
+
+		if item.MaxDurability > 0 {
+			item.Durability = int(r.bits(itemDurabilityBits))
+			r.bit() // unknown
+		}
+	}
+
+	if record.Stackable {
+		item.Quantity = int(r.bits(itemQuantityBits))
+	}
+
+	if item.Flags.Has(ItemSocketed) {
+		item.Sockets = byte(r.bits(itemSocketsBits))
+	}
+
+	var setLists uint32
+	if item.Quality == QualitySet {
+		setLists = r.bits(itemSetListCount)
+	}
+
+	item.Properties = r.readProperties()
+
+	for idx := range item.SetProperties {
+		if setLists&(1<<uint(idx)) != 0 {
+			item.SetProperties[idx] = r.readProperties()
+		}
+	}
+
+	if item.Flags.Has(ItemRuneword) {
+		item.RunewordProperties = r.readProperties()
+	}
+}
+
+func (w *bitWriter) writeExtendedItem(item *Item) {
+	record := d2datadict.CommonItems[item.Code]
+	if record == nil {
+		w.fail(ErrUnknownItem)
+		return
+	}
+
+	w.PushBits(item.ID, itemIDBits)
+	w.PushBits(uint32(item.Level), itemLevelBits)
+	w.PushBits(uint32(item.Quality), itemQualityBits)
+
+	w.PushBit(item.HasPicture)
+
+	if item.HasPicture {
+		w.PushBits(uint32(item.Picture), itemPictureBits)
+	}
+
+	w.PushBit(item.ClassSpecific)
+
+	if item.ClassSpecific {
+		w.PushBits(uint32(item.AutoAffix), itemAutoAffixBits)
+	}
+
+	w.writeQualityData(item)
+
+	if item.Flags.Has(ItemRuneword) {
+		w.PushBits(uint32(item.RunewordID), itemRunewordBits)
+		w.PushBits(item.runewordPadding, itemRunewordPadding)
+	}
+
+	if item.Flags.Has(ItemPersonalized) {
+		w.text(item.PersonalizedName, itemCharBits)
+	}
+
+	if isTome(item.Code) {
+		w.PushBits(uint32(item.TomeData), itemTomeBits)
+	}
+
+	w.PushBit(item.RealmData != nil)
+
+	if item.RealmData != nil {
+		for idx := 0; idx < itemRealmDataCount; idx++ {
+			var data uint32
+			if idx < len(item.RealmData) {
+				data = item.RealmData[idx]
+			}
+
+			w.PushBits(data, 4*byteLen)
+		}
+	}
+
+	_, isArmor := d2datadict.Armors[item.Code]
+	_, isWeapon := d2datadict.Weapons[item.Code]
+
+	if isArmor {
+		w.PushBits(uint32(item.Defense+itemDefenseAdd), itemDefenseBits)
+	}
+
+	if isArmor || isWeapon {
+		w.PushBits(uint32(item.MaxDurability), itemDurabilityBits)
+
+		if item.MaxDurability > 0 {
+			w.PushBits(uint32(item.Durability), itemDurabilityBits)
+			w.PushBit(false)
+		}
+	}
+
+	if record.Stackable {
+		w.PushBits(uint32(item.Quantity), itemQuantityBits)
+	}
+
+	if item.Flags.Has(ItemSocketed) {
+		w.PushBits(uint32(item.Sockets), itemSocketsBits)
+	}
+
+	if item.Quality == QualitySet {
+		var setLists uint32
+
+		for idx, properties := range item.SetProperties {
+			if properties != nil {
+				setLists |= 1 << uint(idx)
+			}
+		}
+
+		w.PushBits(setLists, itemSetListCount)
+	}
+
+	w.writeProperties(item.Properties)
+
+	for _, properties := range item.SetProperties {
+		if properties != nil {
+			w.writeProperties(properties)
+		}
+	}
+
+	if item.Flags.Has(ItemRuneword) {
+		w.writeProperties(item.RunewordProperties)
+	}
+}
+
+func (r *bitReader) readQualityData(item *Item) {
+	switch item.Quality {
+	case QualityLow, QualitySuperior:
+		item.QualityID = uint16(r.bits(itemQualityIDBits))
+	case QualityMagic:
+		item.Affixes[0] = uint16(r.bits(itemAffixBits))
+		item.Affixes[1] = uint16(r.bits(itemAffixBits))
+	case QualitySet, QualityUnique:
+		item.QualityID = uint16(r.bits(itemSetUniqueBits))
+	case QualityRare, QualityCrafted:
+		item.RareNames[0] = byte(r.bits(itemRareNameBits))
+		item.RareNames[1] = byte(r.bits(itemRareNameBits))
+
+		for idx := range item.Affixes {
+			if r.bit() {
+				item.Affixes[idx] = uint16(r.bits(itemAffixBits))
+			}
+		}
+	}
+}
+
+func (w *bitWriter) writeQualityData(item *Item) {
+	switch item.Quality {
+	case QualityLow, QualitySuperior:
+		w.PushBits(uint32(item.QualityID), itemQualityIDBits)
+	case QualityMagic:
+		w.PushBits(uint32(item.Affixes[0]), itemAffixBits)
+		w.PushBits(uint32(item.Affixes[1]), itemAffixBits)
+	case QualitySet, QualityUnique:
+		w.PushBits(uint32(item.QualityID), itemSetUniqueBits)
+	case QualityRare, QualityCrafted:
+		w.PushBits(uint32(item.RareNames[0]), itemRareNameBits)
+		w.PushBits(uint32(item.RareNames[1]), itemRareNameBits)
+
+		for _, affix := range item.Affixes {
+			w.PushBit(affix != 0)
+
+			if affix != 0 {
+				w.PushBits(uint32(affix), itemAffixBits)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package d2d2s
+
+// Property is a stat of ItemStatCost.txt an item gives. Some stats are saved
+// with the next ones, e.g. the minimum fire damage with the maximum, their
+// values follow in the same property.
+type Property struct {
+	ID     StatID
+	Param  uint32 // e.g. the skill of the charged skills
+	Values []int
+}
+
+//nolint:gochecknoglobals // constant lookup table of the stats saved with the next ones
+var propertyStatCounts = map[StatID]int{
+	17: 2, // item_maxdamage_percent, item_mindamage_percent
+	48: 2, // firemindam, firemaxdam
+	50: 2, // lightmindam, lightmaxdam
+	52: 2, // magicmindam, magicmaxdam
+	54: 3, // coldmindam, coldmaxdam, coldlength
+	57: 3, // poisonmindam, poisonmaxdam, poisonlength
+}
+
+func propertyStatCount(id StatID) int {
+	if count, ok := propertyStatCounts[id]; ok {
+		return count
+	}
+
+	return 1
+}
+
+// readProperties reads a list of properties, each saved with the number of
+// bits of the Save Bits and Save Param Bits columns of ItemStatCost.txt
+func (r *bitReader) readProperties() []Property {
+	result := []Property{}
+
+	for r.err == nil {
+		id := StatID(r.bits(statIDBits))
+		if id == statsEnd {
+			break
+		}
+
+		property := Property{ID: id}
+
+		for idx := 0; idx < propertyStatCount(id); idx++ {
+			record := r.stat(id + StatID(idx))
+			if record == nil {
+				break
+			}
+
+			if idx == 0 && record.SaveParamBits > 0 {
+				property.Param = r.bits(record.SaveParamBits)
+			}
+
+			property.Values = append(property.Values, int(r.bits(record.SaveBits))-record.SaveAdd)
+		}
+
+		result = append(result, property)
+	}
+
+	return result
+}
+
+func (w *bitWriter) writeProperties(properties []Property) {
+	for _, property := range properties {
+		w.PushBits(uint32(property.ID), statIDBits)
+
+		for idx := 0; idx < propertyStatCount(property.ID); idx++ {
+			record := w.stat(property.ID + StatID(idx))
+			if record == nil {
+				break
+			}
+
+			if idx == 0 && record.SaveParamBits > 0 {
+				w.PushBits(property.Param, record.SaveParamBits)
+			}
+
+			var value int
+			if idx < len(property.Values) {
+				value = property.Values[idx]
+			}
+
+			w.PushBits(uint32(value+record.SaveAdd), record.SaveBits)
+		}
+	}
+
+	w.PushBits(statsEnd, statIDBits)
+}
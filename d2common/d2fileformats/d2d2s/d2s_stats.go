@@ -0,0 +1,134 @@
+package d2d2s
+
+import (
+	"sort"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+)
+
+// StatID is the index of a stat in ItemStatCost.txt
+type StatID uint16
+
+// Stats of the characters
+const (
+	StatStrength StatID = iota
+	StatEnergy
+	StatDexterity
+	StatVitality
+	StatStatPoints // left to allocate
+	StatSkillPoints
+	StatLife
+	StatMaxLife
+	StatMana
+	StatMaxMana
+	StatStamina
+	StatMaxStamina
+	StatLevel
+	StatExperience
+	StatGold
+	StatGoldBank
+)
+
+const (
+	// StatFractionBits is the number of bits of the fraction of the life,
+	// mana and stamina stats, which are fixed point numbers
+	StatFractionBits = 8
+
+	statIDBits = 9
+	statsEnd   = 0x1FF
+)
+
+// statCosts are the records of ItemStatCost.txt by index
+type statCosts map[StatID]*d2datadict.ItemStatCostRecord
+
+func loadStatCosts() statCosts {
+	result := make(statCosts, len(d2datadict.ItemStatCosts))
+
+	for _, record := range d2datadict.ItemStatCosts {
+		result[StatID(record.Index)] = record
+	}
+
+	return result
+}
+
+func (r *bitReader) stat(id StatID) *d2datadict.ItemStatCostRecord {
+	record, ok := r.stats[id]
+	if !ok {
+		r.fail(ErrUnknownStat)
+	}
+
+	return record
+}
+
+func (w *bitWriter) stat(id StatID) *d2datadict.ItemStatCostRecord {
+	record, ok := w.stats[id]
+	if !ok {
+		w.fail(ErrUnknownStat)
+	}
+
+	return record
+}
+
+// readStats reads the stats of the character, each saved with the number of
+// bits of the CSvBits column of ItemStatCost.txt
+func (r *bitReader) readStats() map[StatID]uint32 {
+	result := make(map[StatID]uint32)
+
+	r.expect(statsMagic)
+
+	for r.err == nil {
+		id := StatID(r.bits(statIDBits))
+		if id == statsEnd {
+			break
+		}
+
+		if record := r.stat(id); record != nil {
+			result[id] = r.bits(record.SavedBits)
+		}
+	}
+
+	r.align()
+
+	return result
+}
+
+func (w *bitWriter) writeStats(stats map[StatID]uint32) {
+	ids := make([]int, 0, len(stats))
+	for id := range stats {
+		ids = append(ids, int(id))
+	}
+
+	sort.Ints(ids)
+
+	w.PushBytes([]byte(statsMagic)...)
+
+	for _, id := range ids {
+		if record := w.stat(StatID(id)); record != nil {
+			w.PushBits(uint32(id), statIDBits)
+			w.PushBits(stats[StatID(id)], record.SavedBits)
+		}
+	}
+
+	w.PushBits(statsEnd, statIDBits)
+}
+
+// Waypoints are the waypoints a character activated in a difficulty
+type Waypoints [waypointsLength]byte
+
+const waypointsOffset = 2
+
+func createWaypoints() Waypoints {
+	// the waypoint of the first town is always active
+	return Waypoints{0x02, 0x01, 0x01}
+}
+
+// Activated returns true if the waypoint of the given index, in the order of
+// the waypoint menu over all acts, is activated
+func (w *Waypoints) Activated(index int) bool {
+	return w[waypointsOffset+index/byteLen]&(1<<uint(index%byteLen)) != 0
+}
+
+// Activate activates the waypoint of the given index
+func (w *Waypoints) Activate(index int) {
+	w[waypointsOffset+index/byteLen] |= 1 << uint(index%byteLen)
+}
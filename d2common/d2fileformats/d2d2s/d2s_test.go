@@ -0,0 +1,155 @@
+package d2d2s
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+func loadTestRecords() {
+	d2datadict.ItemStatCosts = map[string]*d2datadict.ItemStatCostRecord{
+		"strength":           {Index: 0, SavedBits: 10, SaveBits: 8, SaveAdd: 32},
+		"energy":             {Index: 1, SavedBits: 10},
+		"dexterity":          {Index: 2, SavedBits: 10},
+		"vitality":           {Index: 3, SavedBits: 10},
+		"hitpoints":          {Index: 6, SavedBits: 21},
+		"maxhp":              {Index: 7, SavedBits: 21},
+		"level":              {Index: 12, SavedBits: 7},
+		"experience":         {Index: 13, SavedBits: 32},
+		"firemindam":         {Index: 48, SaveBits: 8},
+		"firemaxdam":         {Index: 49, SaveBits: 9},
+		"item_charged_skill": {Index: 204, SaveBits: 16, SaveParamBits: 16},
+	}
+
+	d2datadict.Armors = map[string]*d2datadict.ItemCommonRecord{
+		"cap": {Code: "cap", Source: d2enum.InventoryItemTypeArmor},
+	}
+	d2datadict.Weapons = map[string]*d2datadict.ItemCommonRecord{
+		"jav": {Code: "jav", Source: d2enum.InventoryItemTypeWeapon, Stackable: true},
+	}
+	d2datadict.CommonItems = map[string]*d2datadict.ItemCommonRecord{
+		"cap": d2datadict.Armors["cap"],
+		"jav": d2datadict.Weapons["jav"],
+		"hp1": {Code: "hp1", Source: d2enum.InventoryItemTypeItem},
+		"rin": {Code: "rin", Source: d2enum.InventoryItemTypeItem},
+		"r01": {Code: "r01", Source: d2enum.InventoryItemTypeItem},
+	}
+}
+
+func createTestCharacter() *D2S {
+	character := CreateD2S("Tester", ClassPaladin)
+	character.Level = 12
+	character.SetAct(1, 2)
+	character.Waypoints[0].Activate(9)
+	character.Quests[0][2] = 0x01
+	character.Skills[3] = 5
+	character.Stats[StatStrength] = 40
+	character.Stats[StatLife] = 120 << StatFractionBits
+	character.Stats[StatExperience] = 54321
+
+	ear := &Item{
+		Flags: ItemEar | ItemAlwaysSet, Version: 101, Location: LocationStored, Storage: StorageStash,
+		Ear: &Ear{Class: ClassSorceress, Level: 40, Name: "Victim"},
+	}
+
+	potion := &Item{
+		Flags: ItemIdentified | ItemSimple | ItemAlwaysSet, Version: 101, Location: LocationBelt, X: 5, Code: "hp1",
+	}
+
+	helm := &Item{
+		Flags: ItemIdentified | ItemSocketed | ItemAlwaysSet | ItemRuneword, Version: 101,
+		Location: LocationEquipped, BodyPart: BodyHead, Code: "cap",
+		ID: 0xDEADBEEF, Level: 20, Quality: QualityNormal, Defense: 5, MaxDurability: 12, Durability: 7,
+		Sockets: 1, RunewordID: 27, Properties: []Property{},
+		RunewordProperties: []Property{{ID: 48, Values: []int{3, 9}}},
+		SocketedItems: []*Item{{
+			Flags: ItemIdentified | ItemSimple | ItemAlwaysSet, Version: 101, Location: LocationSocket, Code: "r01",
+		}},
+	}
+
+	ring := &Item{
+		Flags: ItemIdentified | ItemAlwaysSet | ItemPersonalized, Version: 101, Location: LocationStored,
+		X: 3, Y: 1, Storage: StorageInventory, Code: "rin",
+		ID: 7, Level: 50, Quality: QualityRare, HasPicture: true, Picture: 2,
+		RareNames: [2]byte{14, 90}, Affixes: [rareAffixCount]uint16{301, 0, 0, 712, 0, 0},
+		PersonalizedName: "Tester", RealmData: []uint32{1, 2, 3},
+		Properties: []Property{
+			{ID: StatStrength, Values: []int{-5}},
+			{ID: 204, Param: 0x1234, Values: []int{0x0505}},
+		},
+	}
+
+	javelins := &Item{
+		Flags: ItemIdentified | ItemAlwaysSet, Version: 101, Location: LocationStored, Storage: StorageInventory,
+		Code: "jav", ID: 8, Level: 3, Quality: QualitySet, QualityID: 11, Quantity: 60,
+		Properties:    []Property{},
+		SetProperties: [itemSetListCount][]Property{1: {{ID: StatStrength, Values: []int{10}}}},
+	}
+
+	character.Items = []*Item{ear, potion, helm, ring, javelins}
+	character.Corpse = &Corpse{X: 100, Y: 200, Items: []*Item{}}
+	character.Mercenary.ID = 42
+	character.MercenaryItems = []*Item{}
+
+	return character
+}
+
+func TestMarshalLoad(t *testing.T) {
+	loadTestRecords()
+
+	character := createTestCharacter()
+
+	data, err := character.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	loaded, err := Load(data)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if !reflect.DeepEqual(loaded, character) {
+		t.Errorf("expected the character saved, got %+v", loaded)
+	}
+
+	if difficulty, act := loaded.Act(); difficulty != 1 || act != 2 {
+		t.Errorf("expected the third act of nightmare, got %d %d", difficulty, act)
+	}
+
+	if !loaded.Waypoints[0].Activated(0) || !loaded.Waypoints[0].Activated(9) || loaded.Waypoints[1].Activated(9) {
+		t.Error("expected the waypoints activated to be saved")
+	}
+
+	if loaded.Class.Hero() != d2enum.HeroPaladin {
+		t.Errorf("expected a paladin, got %v", loaded.Class.Hero())
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	loadTestRecords()
+
+	data, err := createTestCharacter().Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	corrupted := append([]byte{}, data...)
+	corrupted[headerLength] ^= 0xFF
+
+	if _, err := Load(corrupted); err != ErrChecksum {
+		t.Errorf("expected %v, got %v", ErrChecksum, err)
+	}
+
+	if _, err := Load(data[:len(data)-1]); err != ErrTruncated {
+		t.Errorf("expected %v, got %v", ErrTruncated, err)
+	}
+
+	delete(d2datadict.ItemStatCosts, "firemindam")
+
+	if _, err := Load(data); err != ErrUnknownStat {
+		t.Errorf("expected %v, got %v", ErrUnknownStat, err)
+	}
+}
@@ -0,0 +1,3 @@
+// Package d2d2s provides functionality for loading and saving the characters
+// saved by Diablo II (.d2s files)
+package d2d2s
@@ -94,6 +94,10 @@ const (
 	SkillIcon           = "/data/global/ui/PANEL/Skillicon.DC6"  // TODO: Used for skill icon button
 	AddSkillButton      = "/data/global/ui/PANEL/level.DC6"
 
+	// --- Automap ---
+
+	AutoMapCells = "/data/global/ui/AUTOMAP/MaxiMap.dc6"
+
 	// --- Mouse Pointers ---
 
 	CursorDefault = "/data/global/ui/CURSOR/ohand.DC6"
@@ -140,6 +144,7 @@ const (
 	Frame                   = "/data/global/ui/PANEL/800borderframe.dc6"
 	InventoryCharacterPanel = "/data/global/ui/PANEL/invchar6.DC6"
 	InventoryWeaponsTab     = "/data/global/ui/PANEL/invchar6Tab.DC6"
+	WaypointPanel           = "/data/global/ui/MENU/waygatebackground.dc6"
 	SkillsPanelAmazon       = "/data/global/ui/SPELLS/skltree_a_back.DC6"
 	SkillsPanelBarbarian    = "/data/global/ui/SPELLS/skltree_b_back.DC6"
 	SkillsPanelDruid        = "/data/global/ui/SPELLS/skltree_d_back.DC6"
@@ -183,9 +188,11 @@ const (
 	SoundSettings    = "/data/global/excel/Sounds.txt"
 	ItemStatCost     = "/data/global/excel/ItemStatCost.txt"
 	Hireling         = "/data/global/excel/hireling.txt"
+	NPC              = "/data/global/excel/npc.txt"
 	DifficultyLevels = "/data/global/excel/difficultylevels.txt"
 	AutoMap          = "/data/global/excel/AutoMap.txt"
 	CubeRecipes      = "/data/global/excel/cubemain.txt"
+	ItemTypes        = "/data/global/excel/ItemTypes.txt"
 
 	// --- Animations ---
 
@@ -193,6 +200,7 @@ const (
 	AnimationData       = "/data/global/animdata.d2"
 	PlayerAnimationBase = "/data/global/CHARS"
 	MissileData         = "/data/global/missiles"
+	ItemGraphics        = "/data/global/items"
 
 	// --- Inventory Data ---
 
@@ -201,6 +209,7 @@ const (
 	Misc        = "/data/global/excel/misc.txt"
 	UniqueItems = "/data/global/excel/UniqueItems.txt"
 	Gems        = "/data/global/excel/gems.txt"
+	Runes       = "/data/global/excel/runes.txt"
 
 	// --- Affixes ---
 
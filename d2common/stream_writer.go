@@ -4,7 +4,9 @@ import "bytes"
 
 // StreamWriter allows you to create a byte array by streaming in writes of various sizes
 type StreamWriter struct {
-	data *bytes.Buffer
+	data      *bytes.Buffer
+	bitOffset int // bits of the last byte written by PushBits
+	bitLength int // length of the data after the last PushBits
 }
 
 // CreateStreamWriter creates a new StreamWriter instance
@@ -21,6 +23,11 @@ func (v *StreamWriter) PushByte(val byte) {
 	v.data.WriteByte(val)
 }
 
+// PushBytes writes a byte slice to the stream
+func (v *StreamWriter) PushBytes(val ...byte) {
+	v.data.Write(val)
+}
+
 // PushUint16 writes an uint16 word to the stream
 func (v *StreamWriter) PushUint16(val uint16) {
 	v.data.WriteByte(byte(val) & 0xFF)
@@ -58,6 +65,40 @@ func (v *StreamWriter) PushInt64(val int64) {
 	v.PushUint64(uint64(val))
 }
 
+// PushBits writes the given number of low bits of a value to the stream,
+// least significant bit first, like the BitMuncher reads them. The bits
+// continue the last byte written by PushBits, the other writes start a new
+// byte.
+func (v *StreamWriter) PushBits(val uint32, bits int) {
+	if v.data.Len() != v.bitLength {
+		v.bitOffset = 0
+	}
+
+	for i := 0; i < bits; i++ {
+		if v.bitOffset == 0 {
+			v.data.WriteByte(0)
+		}
+
+		if val&(1<<uint(i)) != 0 {
+			data := v.data.Bytes()
+			data[len(data)-1] |= 1 << uint(v.bitOffset)
+		}
+
+		v.bitOffset = (v.bitOffset + 1) % byteLen
+	}
+
+	v.bitLength = v.data.Len()
+}
+
+// PushBit writes a single bit to the stream
+func (v *StreamWriter) PushBit(val bool) {
+	if val {
+		v.PushBits(1, 1)
+	} else {
+		v.PushBits(0, 1)
+	}
+}
+
 // GetBytes returns the the byte slice of the underlying data
 func (v *StreamWriter) GetBytes() []byte {
 	return v.data.Bytes()
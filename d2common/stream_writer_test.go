@@ -48,3 +48,31 @@ func TestStreamWriterDword(t *testing.T) {
 		}
 	}
 }
+
+func TestStreamWriterBits(t *testing.T) {
+	sw := CreateStreamWriter()
+
+	sw.PushBits(0x5, 3)
+	sw.PushBit(true)
+	sw.PushBits(0x1FF, 9)
+	sw.PushByte(0x12)
+	sw.PushBits(0x3, 2)
+
+	output := sw.GetBytes()
+	data := []byte{0xFD, 0x1F, 0x12, 0x03}
+
+	if len(output) != len(data) {
+		t.Fatalf("sw.PushBits() wrote %d bytes, but %d were expected", len(output), len(data))
+	}
+
+	for i, d := range data {
+		if output[i] != d {
+			t.Fatalf("sw.PushBits() wrote byte %X to %d, but %X was expected instead", output[i], i, d)
+		}
+	}
+
+	bm := CreateBitMuncher(output, 0)
+	if bm.GetBits(3) != 0x5 || bm.GetBit() != 1 || bm.GetBits(9) != 0x1FF {
+		t.Error("expected the BitMuncher to read back the bits pushed")
+	}
+}
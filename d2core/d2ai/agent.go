@@ -0,0 +1,165 @@
+package d2ai
+
+import (
+	"math"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2astar"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+const (
+	attackRecovery = 1.0 // seconds an attack keeps the monster busy
+	leashFactor    = 2.0 // targets are dropped once they get this many aggro radii away
+)
+
+// Agent is the AI state of a single monster. Behaviours use its methods to
+// act on the world.
+type Agent struct {
+	Actor  Actor
+	Record *d2datadict.MonStatsRecord
+	Params Params
+	Target Target
+
+	controller   *Controller
+	behaviour    Behaviour
+	cooldown     float64
+	homeX, homeY float64
+}
+
+// Position returns the agent's position in tiles.
+func (a *Agent) Position() (x, y float64) {
+	return a.Actor.GetPositionF()
+}
+
+// DistanceTo returns the distance in tiles between the agent and the target.
+func (a *Agent) DistanceTo(target Target) float64 {
+	ax, ay := a.Position()
+	tx, ty := target.GetPositionF()
+
+	return math.Hypot(tx-ax, ty-ay)
+}
+
+// InMeleeRange returns true if the current target can be hit in melee.
+func (a *Agent) InMeleeRange() bool {
+	return a.Target != nil && a.DistanceTo(a.Target) <= a.Params.MeleeRange
+}
+
+// AcquireTarget keeps the current target while it stays within the leash
+// distance, otherwise it picks the closest target inside the aggro radius.
+// It returns the new target, which is nil when nothing is in range.
+func (a *Agent) AcquireTarget(world World) Target {
+	targets := world.Targets()
+
+	if a.Target != nil {
+		for _, target := range targets {
+			if target == a.Target && a.DistanceTo(target) <= a.Params.Distance*leashFactor {
+				return a.Target
+			}
+		}
+
+		a.Target = nil
+	}
+
+	closest := math.MaxFloat64
+
+	for _, target := range targets {
+		if distance := a.DistanceTo(target); distance <= a.Params.Distance && distance < closest {
+			closest = distance
+			a.Target = target
+		}
+	}
+
+	return a.Target
+}
+
+// MoveTo paths the agent to the given tile position. It returns false if
+// the position cannot be reached.
+func (a *Agent) MoveTo(world World, x, y float64) bool {
+	ax, ay := a.Position()
+
+	path, _, found := a.pathFind(world, ax, ay, x, y)
+	if !found || len(path) == 0 {
+		return false
+	}
+
+	a.Actor.SetPath(path, nil)
+
+	if a.controller.OnMove != nil {
+		a.controller.OnMove(a, x, y)
+	}
+
+	return true
+}
+
+// pathFind finds a path for the way the agent moves, flying monsters fly over
+// what only blocks the monsters walking when the world tells them apart.
+func (a *Agent) pathFind(world World, startX, startY, endX, endY float64) ([]d2astar.Pather, float64, bool) {
+	if collisionWorld, ok := world.(CollisionWorld); ok && a.Record != nil && a.Record.IsFlying {
+		return collisionWorld.PathFindAs(d2enum.CollisionFly, startX, startY, endX, endY)
+	}
+
+	return world.PathFind(startX, startY, endX, endY)
+}
+
+// Approach paths the agent towards its target.
+func (a *Agent) Approach(world World) bool {
+	if a.Target == nil {
+		return false
+	}
+
+	tx, ty := a.Target.GetPositionF()
+
+	return a.MoveTo(world, tx, ty)
+}
+
+// Retreat moves the agent the given number of tiles directly away from its
+// target.
+func (a *Agent) Retreat(world World, distance float64) bool {
+	if a.Target == nil {
+		return false
+	}
+
+	ax, ay := a.Position()
+	tx, ty := a.Target.GetPositionF()
+	angle := math.Atan2(ay-ty, ax-tx)
+
+	return a.MoveTo(world, ax+math.Cos(angle)*distance, ay+math.Sin(angle)*distance)
+}
+
+// Wander moves the agent to a random position within radius tiles of where
+// it was spawned.
+func (a *Agent) Wander(world World, radius float64) bool {
+	angle := a.controller.rand.Float64() * 2 * math.Pi
+	distance := a.controller.rand.Float64() * radius
+
+	return a.MoveTo(world, a.homeX+math.Cos(angle)*distance, a.homeY+math.Sin(angle)*distance)
+}
+
+// Attack stops the agent and performs an attack on its target using the
+// given animation mode. The agent does not think again until the attack
+// has recovered.
+func (a *Agent) Attack(mode d2enum.MonsterAnimationMode) {
+	if a.Target == nil {
+		return
+	}
+
+	a.Actor.ClearPath()
+	_ = a.Actor.SetAnimationMode(mode)
+
+	if a.controller.OnAttack != nil {
+		a.controller.OnAttack(a, a.Target, mode)
+	}
+
+	a.Wait(attackRecovery)
+}
+
+// Wait delays the next decision of the agent by the given number of seconds.
+func (a *Agent) Wait(seconds float64) {
+	a.cooldown += seconds
+}
+
+// Chance returns true with the given probability in percent.
+func (a *Agent) Chance(percent int) bool {
+	return a.controller.rand.Intn(100) < percent
+}
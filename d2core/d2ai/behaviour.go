@@ -0,0 +1,70 @@
+package d2ai
+
+import (
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2astar"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+// Actor is a map unit which can be driven by an AI behaviour.
+type Actor interface {
+	GetPositionF() (float64, float64)
+	SetPath(path []d2astar.Pather, done func())
+	ClearPath()
+	IsAtTarget() bool
+	SetAnimationMode(mode d2enum.MonsterAnimationMode) error
+}
+
+// Target is a unit an AI can pursue and attack, such as a player.
+type Target interface {
+	GetPositionF() (float64, float64)
+}
+
+// World is the environment the AI acts in.
+type World interface {
+	PathFind(startX, startY, endX, endY float64) (path []d2astar.Pather, distance float64, found bool)
+	Targets() []Target
+}
+
+// CollisionWorld is a World which finds paths for the way a monster moves,
+// e.g. flying over water.
+type CollisionWorld interface {
+	World
+	PathFindAs(collision d2enum.Collision, startX, startY, endX, endY float64) (path []d2astar.Pather, distance float64,
+		found bool)
+}
+
+// Behaviour decides what a monster does each time its AI ticks.
+type Behaviour interface {
+	Think(agent *Agent, world World)
+}
+
+// BehaviourFunc adapts an ordinary function to the Behaviour interface.
+type BehaviourFunc func(agent *Agent, world World)
+
+// Think calls f(agent, world).
+func (f BehaviourFunc) Think(agent *Agent, world World) {
+	f(agent, world)
+}
+
+//nolint:gochecknoglobals // behaviours are registered once at startup
+var behaviours = map[string]Behaviour{
+	"zombie":       BehaviourFunc(zombie),
+	"skeleton":     BehaviourFunc(skeleton),
+	"skeletonbow":  BehaviourFunc(skeletonBow),
+	"fallen":       BehaviourFunc(fallen),
+	"fallenshaman": BehaviourFunc(fallenShaman),
+}
+
+// Register associates a behaviour with an AI key from MonStats.txt. Keys are
+// case insensitive. Registering a key twice replaces the previous behaviour.
+func Register(aiKey string, behaviour Behaviour) {
+	behaviours[strings.ToLower(aiKey)] = behaviour
+}
+
+// GetBehaviour returns the behaviour registered for the given AI key.
+func GetBehaviour(aiKey string) (Behaviour, bool) {
+	behaviour, ok := behaviours[strings.ToLower(aiKey)]
+	return behaviour, ok
+}
@@ -0,0 +1,56 @@
+package d2ai
+
+import "github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+
+// fallen rushes the closest target and sometimes runs away after hitting it.
+//
+// aip1: chance to attack when in melee range (default 100)
+// aip2: chance to run away after an attack (default 20)
+// aip3: distance in tiles it runs away (default 4)
+func fallen(agent *Agent, world World) {
+	if agent.AcquireTarget(world) == nil {
+		return
+	}
+
+	if !agent.InMeleeRange() {
+		agent.Approach(world)
+		return
+	}
+
+	if !agent.Chance(agent.Params.Arg(1, 100)) {
+		return
+	}
+
+	agent.Attack(d2enum.MonsterAnimationModeAttack1)
+
+	if agent.Chance(agent.Params.Arg(2, 20)) {
+		agent.Retreat(world, float64(agent.Params.Arg(3, 4)))
+	}
+}
+
+// fallenShaman stays behind its pack and casts at the closest target from
+// a distance.
+//
+// aip1: chance to cast when the target is in range (default 70)
+// aip2: distance in tiles it tries to keep from the target (default 4)
+func fallenShaman(agent *Agent, world World) {
+	if agent.AcquireTarget(world) == nil {
+		return
+	}
+
+	distance := agent.DistanceTo(agent.Target)
+	keepAway := float64(agent.Params.Arg(2, 4))
+
+	if distance < keepAway && agent.Retreat(world, keepAway-distance+1) {
+		return
+	}
+
+	if distance > agent.Params.Distance {
+		agent.Approach(world)
+		return
+	}
+
+	if agent.Chance(agent.Params.Arg(1, 70)) {
+		agent.Attack(d2enum.MonsterAnimationModeAttack1)
+	}
+}
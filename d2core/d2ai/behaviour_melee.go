@@ -0,0 +1,55 @@
+package d2ai
+
+import "github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+
+const wanderRadius = 3.0
+
+// zombie shambles towards the closest target and hits it once it is next to it.
+//
+// aip1: chance to attack when in melee range (default 100)
+// aip2: chance to wander around when there is no target (default 10)
+func zombie(agent *Agent, world World) {
+	if agent.AcquireTarget(world) == nil {
+		if agent.Chance(agent.Params.Arg(2, 10)) {
+			agent.Wander(world, wanderRadius)
+		}
+
+		return
+	}
+
+	if !agent.InMeleeRange() {
+		agent.Approach(world)
+		return
+	}
+
+	if agent.Chance(agent.Params.Arg(1, 100)) {
+		agent.Attack(d2enum.MonsterAnimationModeAttack1)
+	}
+}
+
+// skeleton walks up to the closest target and alternates between its two
+// melee attacks.
+//
+// aip1: chance to attack when in melee range (default 100)
+// aip2: chance to use the second attack instead of the first (default 0)
+func skeleton(agent *Agent, world World) {
+	if agent.AcquireTarget(world) == nil {
+		return
+	}
+
+	if !agent.InMeleeRange() {
+		agent.Approach(world)
+		return
+	}
+
+	if !agent.Chance(agent.Params.Arg(1, 100)) {
+		return
+	}
+
+	if agent.Chance(agent.Params.Arg(2, 0)) {
+		agent.Attack(d2enum.MonsterAnimationModeAttack2)
+		return
+	}
+
+	agent.Attack(d2enum.MonsterAnimationModeAttack1)
+}
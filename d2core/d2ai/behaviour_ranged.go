@@ -0,0 +1,32 @@
+package d2ai
+
+import "github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+
+// skeletonBow keeps its distance and shoots at the closest target.
+//
+// aip1: chance to shoot when the target is in range (default 80)
+// aip2: distance in tiles below which it backs off from the target (default 3)
+// aip3: chance to back off when the target is too close (default 50)
+func skeletonBow(agent *Agent, world World) {
+	if agent.AcquireTarget(world) == nil {
+		return
+	}
+
+	distance := agent.DistanceTo(agent.Target)
+	minDistance := float64(agent.Params.Arg(2, 3))
+
+	if distance < minDistance && agent.Chance(agent.Params.Arg(3, 50)) {
+		if agent.Retreat(world, minDistance) {
+			return
+		}
+	}
+
+	if distance > agent.Params.Distance {
+		agent.Approach(world)
+		return
+	}
+
+	if agent.Chance(agent.Params.Arg(1, 80)) {
+		agent.Attack(d2enum.MonsterAnimationModeAttack1)
+	}
+}
@@ -0,0 +1,215 @@
+package d2ai
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+const (
+	testTickTime = 0.04
+	testSeed     = 1234
+)
+
+func monster(aiKey string) *d2datadict.MonStatsRecord {
+	return &d2datadict.MonStatsRecord{
+		AiKey:     aiKey,
+		SpeedBase: 10,
+	}
+}
+
+func TestZombieApproachesAndAttacks(t *testing.T) {
+	sim := CreateSimulation(20, 20, testSeed)
+	target := sim.AddTarget(6.5, 2.5)
+
+	actor, agent, ok := sim.AddMonster(monster("Zombie"), 2.5, 2.5, d2enum.DifficultyNormal)
+	if !ok {
+		t.Fatal("no behaviour registered for Zombie")
+	}
+
+	sim.Run(250, testTickTime)
+
+	if agent.Target != target {
+		t.Error("zombie did not acquire the target")
+	}
+
+	if distance := agent.DistanceTo(target); distance > agent.Params.MeleeRange {
+		t.Errorf("zombie is %.2f tiles away from the target, want at most %.2f", distance, agent.Params.MeleeRange)
+	}
+
+	if sim.AttacksOn(target) == 0 {
+		t.Errorf("zombie at %.2f,%.2f never attacked", actor.X, actor.Y)
+	}
+}
+
+func TestOnMoveReportsDestination(t *testing.T) {
+	sim := CreateSimulation(20, 20, testSeed)
+	sim.AddTarget(6.5, 2.5)
+	_, agent, _ := sim.AddMonster(monster("Zombie"), 2.5, 2.5, d2enum.DifficultyNormal)
+
+	moves := 0
+
+	sim.Controller.OnMove = func(moved *Agent, x, y float64) {
+		if moved != agent {
+			t.Error("OnMove was called for another agent")
+		}
+
+		if x != 6.5 || y != 2.5 {
+			t.Errorf("zombie moved to %.2f,%.2f instead of the target", x, y)
+		}
+
+		moves++
+	}
+
+	sim.Run(50, testTickTime)
+
+	if moves == 0 {
+		t.Error("OnMove was never called")
+	}
+}
+
+func TestTargetOutsideAggroRangeIsIgnored(t *testing.T) {
+	sim := CreateSimulation(30, 30, testSeed)
+	target := sim.AddTarget(25.5, 25.5)
+
+	record := monster("Skeleton")
+	record.AiDistanceNormal = 20
+
+	actor, agent, _ := sim.AddMonster(record, 2.5, 2.5, d2enum.DifficultyNormal)
+
+	sim.Run(100, testTickTime)
+
+	if agent.Target != nil || sim.AttacksOn(target) != 0 {
+		t.Error("skeleton reacted to a target outside its aggro range")
+	}
+
+	if actor.X != 2.5 || actor.Y != 2.5 {
+		t.Errorf("skeleton moved to %.2f,%.2f without a target", actor.X, actor.Y)
+	}
+}
+
+func TestMonsterPathsAroundObstacles(t *testing.T) {
+	sim := CreateSimulation(20, 20, testSeed)
+
+	// A wall between the monster and its target with a gap at the bottom
+	for y := 0; y < 8; y++ {
+		sim.Block(5, y)
+	}
+
+	target := sim.AddTarget(8.5, 2.5)
+	_, agent, _ := sim.AddMonster(monster("Fallen"), 2.5, 2.5, d2enum.DifficultyNormal)
+
+	sim.Run(500, testTickTime)
+
+	if sim.AttacksOn(target) == 0 {
+		t.Error("fallen never reached the target behind the wall")
+	}
+
+	if agent.Target != target {
+		t.Error("fallen lost its target")
+	}
+}
+
+func TestSkeletonBowShootsFromRange(t *testing.T) {
+	sim := CreateSimulation(20, 20, testSeed)
+	target := sim.AddTarget(7.5, 2.5)
+	actor, agent, _ := sim.AddMonster(monster("SkeletonBow"), 2.5, 2.5, d2enum.DifficultyNormal)
+
+	sim.Run(100, testTickTime)
+
+	if sim.AttacksOn(target) == 0 {
+		t.Fatal("archer never shot")
+	}
+
+	if agent.DistanceTo(target) <= agent.Params.MeleeRange {
+		t.Errorf("archer walked into melee range, now at %.2f,%.2f", actor.X, actor.Y)
+	}
+}
+
+func TestFallenShamanKeepsDistance(t *testing.T) {
+	sim := CreateSimulation(20, 20, testSeed)
+	target := sim.AddTarget(10.5, 10.5)
+	_, agent, _ := sim.AddMonster(monster("FallenShaman"), 11.5, 10.5, d2enum.DifficultyNormal)
+
+	sim.Run(250, testTickTime)
+
+	if distance := agent.DistanceTo(target); distance < 3 {
+		t.Errorf("shaman stayed %.2f tiles from the target", distance)
+	}
+
+	if sim.AttacksOn(target) == 0 {
+		t.Error("shaman never cast")
+	}
+}
+
+func TestSimulationIsDeterministic(t *testing.T) {
+	run := func() *Simulation {
+		sim := CreateSimulation(20, 20, testSeed)
+		sim.AddTarget(9.5, 9.5)
+		sim.AddMonster(monster("Zombie"), 2.5, 2.5, d2enum.DifficultyNormal)
+		sim.AddMonster(monster("Fallen"), 15.5, 3.5, d2enum.DifficultyNormal)
+		sim.AddMonster(monster("FallenShaman"), 4.5, 15.5, d2enum.DifficultyNormal)
+		sim.Run(300, testTickTime)
+
+		return sim
+	}
+
+	first, second := run(), run()
+
+	if len(first.Attacks) != len(second.Attacks) {
+		t.Fatalf("got %d and %d attacks for the same seed", len(first.Attacks), len(second.Attacks))
+	}
+
+	for idx := range first.Attacks {
+		a, b := first.Attacks[idx], second.Attacks[idx]
+		if a.Time != b.Time || a.Mode != b.Mode {
+			t.Errorf("attack %d differs: %+v != %+v", idx, a, b)
+		}
+	}
+
+	for idx := range first.actors {
+		a, b := first.actors[idx], second.actors[idx]
+		if a.X != b.X || a.Y != b.Y {
+			t.Errorf("actor %d ended at %.2f,%.2f and %.2f,%.2f", idx, a.X, a.Y, b.X, b.Y)
+		}
+	}
+}
+
+func TestUnknownAiKey(t *testing.T) {
+	sim := CreateSimulation(10, 10, testSeed)
+
+	if _, _, ok := sim.AddMonster(monster("Npc"), 1, 1, d2enum.DifficultyNormal); ok {
+		t.Error("monster without a registered behaviour was added")
+	}
+}
+
+func TestParamsForDifficulty(t *testing.T) {
+	record := &d2datadict.MonStatsRecord{
+		AiDelayNormal:         5,
+		AiDelayHell:           2,
+		AiDistanceNightmare:   40,
+		AiParameterNormal1:    11,
+		AiParameterNightmare1: 22,
+		AiParameterHell8:      88,
+	}
+
+	normal := ParamsFor(record, d2enum.DifficultyNormal)
+	if normal.Delay != 5.0/framesPerSecond || normal.Arg(1, 0) != 11 {
+		t.Errorf("unexpected normal params %+v", normal)
+	}
+
+	if normal.Distance != defaultAggroDistance/subTilesPerTile {
+		t.Errorf("expected default aggro distance, got %.2f", normal.Distance)
+	}
+
+	nightmare := ParamsFor(record, d2enum.DifficultyNightmare)
+	if nightmare.Distance != 8 || nightmare.Arg(1, 0) != 22 {
+		t.Errorf("unexpected nightmare params %+v", nightmare)
+	}
+
+	hell := ParamsFor(record, d2enum.DifficultyHell)
+	if hell.Delay != 2.0/framesPerSecond || hell.Arg(8, 0) != 88 || hell.Arg(1, 7) != 7 {
+		t.Errorf("unexpected hell params %+v", hell)
+	}
+}
@@ -0,0 +1,99 @@
+package d2ai
+
+import (
+	"math/rand"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+// AttackFunc is called whenever an agent attacks its target.
+type AttackFunc func(agent *Agent, target Target, mode d2enum.MonsterAnimationMode)
+
+// MoveFunc is called whenever an agent starts moving to a position, in tiles.
+type MoveFunc func(agent *Agent, x, y float64)
+
+// Controller runs the AI of all monsters in a world.
+type Controller struct {
+	world  World
+	agents []*Agent
+	rand   *rand.Rand
+
+	// OnAttack is called when an agent performs an attack.
+	OnAttack AttackFunc
+
+	// OnMove is called when an agent starts moving.
+	OnMove MoveFunc
+}
+
+// CreateController creates a controller for the given world. All random
+// decisions are taken from a source seeded with seed, so a controller
+// stepped with the same inputs always behaves the same way.
+func CreateController(world World, seed int64) *Controller {
+	return &Controller{
+		world:  world,
+		agents: make([]*Agent, 0),
+		rand:   rand.New(rand.NewSource(seed)), //nolint:gosec // not used for security
+	}
+}
+
+// AddAgent puts the actor under the control of the behaviour registered for
+// the monster's AI key. It returns false if no such behaviour exists.
+func (c *Controller) AddAgent(actor Actor, record *d2datadict.MonStatsRecord,
+	difficulty d2enum.DifficultyType) (*Agent, bool) {
+	if record == nil {
+		return nil, false
+	}
+
+	behaviour, ok := GetBehaviour(record.AiKey)
+	if !ok {
+		return nil, false
+	}
+
+	params := ParamsFor(record, difficulty)
+	x, y := actor.GetPositionF()
+
+	agent := &Agent{
+		Actor:      actor,
+		Record:     record,
+		Params:     params,
+		controller: c,
+		behaviour:  behaviour,
+		cooldown:   c.rand.Float64() * params.Delay, // spread out the AI ticks
+		homeX:      x,
+		homeY:      y,
+	}
+
+	c.agents = append(c.agents, agent)
+
+	return agent, true
+}
+
+// RemoveAgent stops controlling the given actor.
+func (c *Controller) RemoveAgent(actor Actor) {
+	for idx := range c.agents {
+		if c.agents[idx].Actor == actor {
+			c.agents = append(c.agents[:idx], c.agents[idx+1:]...)
+			return
+		}
+	}
+}
+
+// Agents returns all agents of this controller.
+func (c *Controller) Agents() []*Agent {
+	return c.agents
+}
+
+// Advance is called once per server tick and lets every agent whose delay
+// has passed make its next decision.
+func (c *Controller) Advance(tickTime float64) {
+	for _, agent := range c.agents {
+		agent.cooldown -= tickTime
+		if agent.cooldown > 0 {
+			continue
+		}
+
+		agent.cooldown = agent.Params.Delay
+		agent.behaviour.Think(agent, c.world)
+	}
+}
@@ -0,0 +1,4 @@
+// Package d2ai implements monster artificial intelligence. Behaviours are
+// looked up by the AI column of MonStats.txt and are driven by a Controller
+// on every server tick.
+package d2ai
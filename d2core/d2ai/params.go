@@ -0,0 +1,96 @@
+package d2ai
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+const (
+	framesPerSecond      = 25.0
+	subTilesPerTile      = 5.0
+	defaultDelayFrames   = 5
+	defaultAggroDistance = 35 // in sub tiles
+	defaultMeleeRange    = 1.0
+	numAiParameters      = 8
+)
+
+// Params are the per difficulty AI settings of a monster, taken from the
+// aidel, aidist and aip1-8 columns of MonStats.txt.
+type Params struct {
+	// Delay is the time in seconds between two decisions of the AI.
+	Delay float64
+
+	// Distance is the distance in tiles at which the AI is activated.
+	Distance float64
+
+	// MeleeRange is the distance in tiles at which the monster can hit a target.
+	MeleeRange float64
+
+	// Args are the AI specific parameters aip1 through aip8.
+	Args [numAiParameters]int
+}
+
+// Arg returns the AI parameter with the given 1-based index (as in aip1),
+// or def if the parameter is not set.
+func (p Params) Arg(index, def int) int {
+	if index < 1 || index > numAiParameters || p.Args[index-1] == 0 {
+		return def
+	}
+
+	return p.Args[index-1]
+}
+
+// ParamsFor returns the AI parameters of the given monster on the given difficulty.
+func ParamsFor(record *d2datadict.MonStatsRecord, difficulty d2enum.DifficultyType) Params {
+	var delay, distance int
+
+	var args [numAiParameters]int
+
+	switch difficulty {
+	case d2enum.DifficultyNightmare:
+		delay, distance = record.AiDelayNightmare, record.AiDistanceNightmare
+		args = [numAiParameters]int{
+			record.AiParameterNightmare1, record.AiParameterNightmare2,
+			record.AiParameterNightmare3, record.AiParameterNightmare4,
+			record.AiParameterNightmare5, record.AiParameterNightmare6,
+			record.AiParameterNightmare7, record.AiParameterNightmare8,
+		}
+	case d2enum.DifficultyHell:
+		delay, distance = record.AiDelayHell, record.AiDistanceHell
+		args = [numAiParameters]int{
+			record.AiParameterHell1, record.AiParameterHell2,
+			record.AiParameterHell3, record.AiParameterHell4,
+			record.AiParameterHell5, record.AiParameterHell6,
+			record.AiParameterHell7, record.AiParameterHell8,
+		}
+	default:
+		delay, distance = record.AiDelayNormal, record.AiDistanceNormal
+		args = [numAiParameters]int{
+			record.AiParameterNormal1, record.AiParameterNormal2,
+			record.AiParameterNormal3, record.AiParameterNormal4,
+			record.AiParameterNormal5, record.AiParameterNormal6,
+			record.AiParameterNormal7, record.AiParameterNormal8,
+		}
+	}
+
+	if delay <= 0 {
+		delay = defaultDelayFrames
+	}
+
+	if distance <= 0 {
+		distance = defaultAggroDistance
+	}
+
+	meleeRange := defaultMeleeRange
+
+	if ex, ok := d2datadict.MonStats2[record.ExtraDataKey]; ok && ex.MeleeRng > 0 {
+		meleeRange += float64(ex.MeleeRng) / subTilesPerTile
+	}
+
+	return Params{
+		Delay:      float64(delay) / framesPerSecond,
+		Distance:   float64(distance) / subTilesPerTile,
+		MeleeRange: meleeRange,
+		Args:       args,
+	}
+}
@@ -0,0 +1,293 @@
+package d2ai
+
+import (
+	"math"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2astar"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+const (
+	maxPathDistance       = 80
+	defaultSimulatedSpeed = 1.0 // tiles per second
+)
+
+// SimulatedAttack records an attack made during a simulation.
+type SimulatedAttack struct {
+	Time   float64
+	Agent  *Agent
+	Target Target
+	Mode   d2enum.MonsterAnimationMode
+}
+
+// Simulation is a deterministic world without rendering or assets, used to
+// exercise behaviours. The map is a grid of tiles that are split into 5x5
+// sub tiles for path finding, the same way the map engine does it.
+type Simulation struct {
+	Controller *Controller
+	Attacks    []SimulatedAttack
+	Time       float64
+
+	width, height int
+	blocked       []bool
+	walkMesh      []d2common.PathTile
+	meshDirty     bool
+	actors        []*SimulatedActor
+	targets       []Target
+}
+
+// CreateSimulation creates an open map of the given size in tiles. The seed
+// is used for all random decisions of the AI.
+func CreateSimulation(width, height int, seed int64) *Simulation {
+	sim := &Simulation{
+		width:     width,
+		height:    height,
+		blocked:   make([]bool, width*height),
+		meshDirty: true,
+	}
+
+	sim.Controller = CreateController(sim, seed)
+	sim.Controller.OnAttack = func(agent *Agent, target Target, mode d2enum.MonsterAnimationMode) {
+		sim.Attacks = append(sim.Attacks, SimulatedAttack{Time: sim.Time, Agent: agent, Target: target, Mode: mode})
+	}
+
+	return sim
+}
+
+// Block makes the tile at the given coordinates unwalkable.
+func (s *Simulation) Block(tileX, tileY int) {
+	s.blocked[tileX+tileY*s.width] = true
+	s.meshDirty = true
+}
+
+// AddMonster places a monster at the given tile position and puts it under
+// AI control. It returns false if there is no behaviour for the monster.
+func (s *Simulation) AddMonster(record *d2datadict.MonStatsRecord, x, y float64,
+	difficulty d2enum.DifficultyType) (*SimulatedActor, *Agent, bool) {
+	speed := defaultSimulatedSpeed
+	if record.SpeedBase > 0 {
+		speed = float64(record.SpeedBase) / subTilesPerTile
+	}
+
+	actor := &SimulatedActor{X: x, Y: y, Speed: speed, Mode: d2enum.MonsterAnimationModeNeutral}
+
+	agent, ok := s.Controller.AddAgent(actor, record, difficulty)
+	if !ok {
+		return nil, nil, false
+	}
+
+	s.actors = append(s.actors, actor)
+
+	return actor, agent, true
+}
+
+// AddTarget places a target at the given tile position.
+func (s *Simulation) AddTarget(x, y float64) *SimulatedTarget {
+	target := &SimulatedTarget{X: x, Y: y}
+	s.targets = append(s.targets, target)
+
+	return target
+}
+
+// AttacksOn returns the number of attacks made on the given target.
+func (s *Simulation) AttacksOn(target Target) int {
+	count := 0
+
+	for _, attack := range s.Attacks {
+		if attack.Target == target {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Step advances the AI and moves all actors by one tick.
+func (s *Simulation) Step(tickTime float64) {
+	if s.meshDirty {
+		s.buildWalkMesh()
+	}
+
+	s.Controller.Advance(tickTime)
+
+	for _, actor := range s.actors {
+		actor.step(tickTime)
+	}
+
+	s.Time += tickTime
+}
+
+// Run steps the simulation the given number of times.
+func (s *Simulation) Run(ticks int, tickTime float64) {
+	for i := 0; i < ticks; i++ {
+		s.Step(tickTime)
+	}
+}
+
+// Targets returns all targets in the simulation.
+func (s *Simulation) Targets() []Target {
+	return s.targets
+}
+
+// PathFind finds a walkable path between two tile positions.
+func (s *Simulation) PathFind(startX, startY, endX, endY float64) (path []d2astar.Pather, distance float64, found bool) {
+	if s.meshDirty {
+		s.buildWalkMesh()
+	}
+
+	startNode := s.nodeAt(startX, startY)
+	endNode := s.nodeAt(endX, endY)
+
+	if startNode == nil || endNode == nil || !endNode.Walkable {
+		return nil, 0, false
+	}
+
+	path, distance, found = d2astar.Path(startNode, endNode, maxPathDistance)
+	if path != nil {
+		for i := len(path)/2 - 1; i >= 0; i-- {
+			opp := len(path) - 1 - i
+			path[i], path[opp] = path[opp], path[i]
+		}
+
+		path = path[1:]
+	}
+
+	return path, distance, found
+}
+
+func (s *Simulation) nodeAt(x, y float64) *d2common.PathTile {
+	subX := int(math.Floor(x * subTilesPerTile))
+	subY := int(math.Floor(y * subTilesPerTile))
+	meshWidth := s.width * subTilesPerTile
+
+	if subX < 0 || subY < 0 || subX >= meshWidth || subY >= s.height*subTilesPerTile {
+		return nil
+	}
+
+	return &s.walkMesh[subX+subY*meshWidth]
+}
+
+func (s *Simulation) buildWalkMesh() {
+	meshWidth, meshHeight := s.width*subTilesPerTile, s.height*subTilesPerTile
+	s.walkMesh = make([]d2common.PathTile, meshWidth*meshHeight)
+
+	for subY := 0; subY < meshHeight; subY++ {
+		for subX := 0; subX < meshWidth; subX++ {
+			index := subX + subY*meshWidth
+			walkable := !s.blocked[subX/subTilesPerTile+(subY/subTilesPerTile)*s.width]
+
+			s.walkMesh[index] = d2common.PathTile{
+				Walkable: walkable,
+				X:        float64(subX) / subTilesPerTile,
+				Y:        float64(subY) / subTilesPerTile,
+			}
+
+			if !walkable {
+				continue
+			}
+
+			if subY > 0 && s.walkMesh[index-meshWidth].Walkable {
+				s.walkMesh[index].Up = &s.walkMesh[index-meshWidth]
+				s.walkMesh[index-meshWidth].Down = &s.walkMesh[index]
+			}
+
+			if subX > 0 && s.walkMesh[index-1].Walkable {
+				s.walkMesh[index].Left = &s.walkMesh[index-1]
+				s.walkMesh[index-1].Right = &s.walkMesh[index]
+			}
+
+			if subX > 0 && subY > 0 && s.walkMesh[index-meshWidth-1].Walkable {
+				s.walkMesh[index].UpLeft = &s.walkMesh[index-meshWidth-1]
+				s.walkMesh[index-meshWidth-1].DownRight = &s.walkMesh[index]
+			}
+
+			if subX < meshWidth-1 && subY > 0 && s.walkMesh[index-meshWidth+1].Walkable {
+				s.walkMesh[index].UpRight = &s.walkMesh[index-meshWidth+1]
+				s.walkMesh[index-meshWidth+1].DownLeft = &s.walkMesh[index]
+			}
+		}
+	}
+
+	s.meshDirty = false
+}
+
+// SimulatedTarget is a stationary target in a simulation.
+type SimulatedTarget struct {
+	X, Y float64
+}
+
+// GetPositionF returns the target position in tiles.
+func (t *SimulatedTarget) GetPositionF() (float64, float64) {
+	return t.X, t.Y
+}
+
+// SimulatedActor is a monster in a simulation. It follows the paths given
+// to it by its agent at a constant speed.
+type SimulatedActor struct {
+	X, Y  float64
+	Speed float64
+	Mode  d2enum.MonsterAnimationMode
+	path  []d2astar.Pather
+	done  func()
+}
+
+// GetPositionF returns the actor position in tiles.
+func (a *SimulatedActor) GetPositionF() (float64, float64) {
+	return a.X, a.Y
+}
+
+// SetPath sets the path the actor walks along.
+func (a *SimulatedActor) SetPath(path []d2astar.Pather, done func()) {
+	a.path = path
+	a.done = done
+	a.Mode = d2enum.MonsterAnimationModeWalk
+}
+
+// ClearPath stops the actor.
+func (a *SimulatedActor) ClearPath() {
+	a.path = nil
+	a.Mode = d2enum.MonsterAnimationModeNeutral
+}
+
+// IsAtTarget returns true if the actor is not walking.
+func (a *SimulatedActor) IsAtTarget() bool {
+	return len(a.path) == 0
+}
+
+// SetAnimationMode sets the animation mode of the actor.
+func (a *SimulatedActor) SetAnimationMode(mode d2enum.MonsterAnimationMode) error {
+	a.Mode = mode
+	return nil
+}
+
+func (a *SimulatedActor) step(tickTime float64) {
+	remaining := a.Speed * tickTime
+
+	for len(a.path) > 0 && remaining > 0 {
+		node := a.path[0].(*d2common.PathTile)
+		dx, dy := node.X-a.X, node.Y-a.Y
+		distance := math.Hypot(dx, dy)
+
+		if distance > remaining {
+			a.X += dx / distance * remaining
+			a.Y += dy / distance * remaining
+
+			return
+		}
+
+		a.X, a.Y = node.X, node.Y
+		remaining -= distance
+		a.path = a.path[1:]
+	}
+
+	if len(a.path) == 0 && a.Mode == d2enum.MonsterAnimationModeWalk {
+		a.Mode = d2enum.MonsterAnimationModeNeutral
+
+		if a.done != nil {
+			a.done()
+			a.done = nil
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package d2automap
+
+import (
+	"math"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+// Size of an automap cell on the screen, in pixels. The cells are drawn
+// isometrically like the tiles they represent.
+const (
+	CellWidth  = 16
+	CellHeight = 8
+)
+
+// tileNames are the TileName of AutoMap.txt for each tile type, see
+// https://d2mods.info/forum/kb/viewarticle?a=468
+var tileNames = map[d2enum.TileType]string{ //nolint:gochecknoglobals // constant lookup table
+	d2enum.TileFloor:                                          "fl",
+	d2enum.TileLeftWall:                                       "wl",
+	d2enum.TileRightWall:                                      "wr",
+	d2enum.TileRightPartOfNorthCornerWall:                     "wtlr",
+	d2enum.TileLeftPartOfNorthCornerWall:                      "wtll",
+	d2enum.TileLeftEndWall:                                    "wtr",
+	d2enum.TileRightEndWall:                                   "wbl",
+	d2enum.TileSouthCornerWall:                                "wbr",
+	d2enum.TileLeftWallWithDoor:                               "wld",
+	d2enum.TileRightWallWithDoor:                              "wrd",
+	d2enum.TileSpecialTile1:                                   "wla",
+	d2enum.TileSpecialTile2:                                   "wra",
+	d2enum.TilePillarsColumnsAndStandaloneObjects:             "co",
+	d2enum.TileShadow:                                         "sh",
+	d2enum.TileTree:                                           "tr",
+	d2enum.TileRoof:                                           "rf",
+	d2enum.TileLowerWallsEquivalentToLeftWall:                 "ld",
+	d2enum.TileLowerWallsEquivalentToRightWall:                "lr",
+	d2enum.TileLowerWallsEquivalentToRightLeftNorthCornerWall: "lu",
+	d2enum.TileLowerWallsEquivalentToSouthCornerwall:          "ls",
+}
+
+// CellFinder finds the frames of the automap cells, in MaxiMap.dc6, which
+// represent the tiles of a level type.
+type CellFinder struct {
+	records []*d2datadict.AutoMapRecord
+}
+
+// CreateCellFinder creates a CellFinder for the level type with the given
+// LvlTypes.txt name, e.g. "Act 1 - Wilderness", from the AutoMap.txt
+// records.
+func CreateCellFinder(levelTypeName string, records []*d2datadict.AutoMapRecord) *CellFinder {
+	name := LevelName(levelTypeName)
+	finder := &CellFinder{records: make([]*d2datadict.AutoMapRecord, 0)}
+
+	for _, record := range records {
+		if strings.EqualFold(record.LevelName, name) {
+			finder.records = append(finder.records, record)
+		}
+	}
+
+	return finder
+}
+
+// LevelName returns the LevelName of AutoMap.txt of a level type, e.g.
+// "1 Wilderness" for "Act 1 - Wilderness".
+func LevelName(levelTypeName string) string {
+	name := strings.TrimPrefix(levelTypeName, "Act ")
+	return strings.Replace(name, " - ", " ", 1)
+}
+
+// Frame returns the frame of the cell of a tile of the given type, style and
+// sequence. When there are several cells for a tile, the variant picks one
+// of them, e.g. the position of the tile so that it is always the same cell.
+func (c *CellFinder) Frame(tileType d2enum.TileType, style, sequence, variant int) (int, bool) {
+	tileName, found := tileNames[tileType]
+	if !found {
+		return 0, false
+	}
+
+	for _, record := range c.records {
+		if record.TileName != tileName || record.Style != style {
+			continue
+		}
+
+		if record.StartSequence >= 0 && (sequence < record.StartSequence || sequence > record.EndSequence) {
+			continue
+		}
+
+		frames := make([]int, 0, len(record.Frames))
+
+		for _, frame := range record.Frames {
+			if frame >= 0 {
+				frames = append(frames, frame)
+			}
+		}
+
+		if len(frames) == 0 {
+			return 0, false
+		}
+
+		if variant < 0 {
+			variant = -variant
+		}
+
+		return frames[variant%len(frames)], true
+	}
+
+	return 0, false
+}
+
+// CellPosition returns the position, in pixels, of the cell of a tile
+// relative to the cell of the origin tile. The positions are in tiles and
+// may be between tiles, e.g. the position of a player.
+func CellPosition(tileX, tileY, originX, originY float64) (x, y int) {
+	dx, dy := tileX-originX, tileY-originY
+
+	return int(math.Round((dx - dy) * CellWidth / 2)), int(math.Round((dx + dy) * CellHeight / 2))
+}
@@ -0,0 +1,68 @@
+package d2automap
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+func TestLevelName(t *testing.T) {
+	if name := LevelName("Act 1 - Wilderness"); name != "1 Wilderness" {
+		t.Errorf("expected 1 Wilderness, got %s", name)
+	}
+}
+
+func TestCellFinderFrame(t *testing.T) {
+	records := []*d2datadict.AutoMapRecord{
+		{LevelName: "1 Town", TileName: "fl", Style: 0, StartSequence: -1, EndSequence: -1, Frames: []int{5, -1, -1, -1}},
+		{LevelName: "1 Wilderness", TileName: "wl", Style: 2, StartSequence: 0, EndSequence: 3, Frames: []int{10, 11, -1, -1}},
+		{LevelName: "1 Wilderness", TileName: "wl", Style: 2, StartSequence: 4, EndSequence: 4, Frames: []int{12, -1, -1, -1}},
+		{LevelName: "1 Wilderness", TileName: "fl", Style: 0, StartSequence: -1, EndSequence: -1, Frames: []int{-1, -1, -1, -1}},
+	}
+
+	finder := CreateCellFinder("Act 1 - Wilderness", records)
+
+	tests := []struct {
+		tileType d2enum.TileType
+		style    int
+		sequence int
+		variant  int
+		frame    int
+		found    bool
+	}{
+		{d2enum.TileLeftWall, 2, 1, 0, 10, true},
+		{d2enum.TileLeftWall, 2, 3, 1, 11, true},
+		{d2enum.TileLeftWall, 2, 4, 1, 12, true},
+		{d2enum.TileLeftWall, 2, 5, 0, 0, false},
+		{d2enum.TileRightWall, 2, 1, 0, 0, false},
+		{d2enum.TileFloor, 0, 0, 0, 0, false}, // only a cell in the town
+	}
+
+	for _, test := range tests {
+		frame, found := finder.Frame(test.tileType, test.style, test.sequence, test.variant)
+		if frame != test.frame || found != test.found {
+			t.Errorf("tile %d %d/%d: expected %d %v, got %d %v", test.tileType, test.style, test.sequence,
+				test.frame, test.found, frame, found)
+		}
+	}
+}
+
+func TestCellPosition(t *testing.T) {
+	tests := []struct {
+		tileX, tileY float64
+		x, y         int
+	}{
+		{10, 10, 0, 0},
+		{11, 10, CellWidth / 2, CellHeight / 2},
+		{10, 11, -CellWidth / 2, CellHeight / 2},
+		{9, 9, 0, -CellHeight},
+		{10.5, 10, CellWidth / 4, CellHeight / 4},
+	}
+
+	for _, test := range tests {
+		if x, y := CellPosition(test.tileX, test.tileY, 10, 10); x != test.x || y != test.y {
+			t.Errorf("tile %g,%g: expected %d,%d, got %d,%d", test.tileX, test.tileY, test.x, test.y, x, y)
+		}
+	}
+}
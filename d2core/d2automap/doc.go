@@ -0,0 +1,3 @@
+// Package d2automap keeps track of the parts of the levels a character has
+// explored and finds the automap cells which represent the tiles of a level.
+package d2automap
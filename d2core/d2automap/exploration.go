@@ -0,0 +1,144 @@
+package d2automap
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2save"
+)
+
+// RevealRadius is the distance, in tiles, around a player within which the
+// tiles are revealed on the automap.
+const RevealRadius = 12
+
+// the version of the schema of the saved explorations
+const saveVersion = 1
+
+// Exploration is the fog of war of a character: the tiles of each level the
+// character has seen. It is saved next to the save file of the character
+// rather than in it, as the player states are sent whole to the server, and
+// the tiles of every level would not fit in a packet.
+type Exploration struct {
+	Levels map[int]*LevelExploration `json:"levels"`
+}
+
+// LevelExploration are the tiles of a level which have been seen, on the
+// map of the level. The levels of a game are generated from its seed, so the
+// tiles only apply to maps of the same seed and size, and the exploration of
+// a level starts over in the games which generate another map of it.
+type LevelExploration struct {
+	Seed     int64  `json:"seed"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Revealed []byte `json:"revealed"` // a bit per tile, row by row
+}
+
+// CreateExploration creates an Exploration without any revealed tiles.
+func CreateExploration() *Exploration {
+	return &Exploration{Levels: make(map[int]*LevelExploration)}
+}
+
+// LoadExploration loads an Exploration from a file, or from its newest valid
+// backup if the file is corrupted. A missing file is an Exploration without
+// any revealed tiles.
+func LoadExploration(filePath string) (*Exploration, error) {
+	data, _, err := d2save.Load(filePath)
+	if os.IsNotExist(err) {
+		return CreateExploration(), nil
+	}
+
+	if err == d2save.ErrCorrupted || err == d2save.ErrChecksum {
+		data, _, err = d2save.LoadBackup(filePath)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	exploration := CreateExploration()
+	if err := json.Unmarshal(data, exploration); err != nil {
+		return nil, err
+	}
+
+	if exploration.Levels == nil {
+		exploration.Levels = make(map[int]*LevelExploration)
+	}
+
+	return exploration, nil
+}
+
+// Save saves the Exploration to a file, keeping backups of the previous
+// saves.
+func (e *Exploration) Save(filePath string) error {
+	return d2save.Save(filePath, saveVersion, e)
+}
+
+// Level returns the exploration of a level on a map of the given seed and
+// size, in tiles. The exploration starts over when the map is not the one
+// the level was explored on.
+func (e *Exploration) Level(levelID int, seed int64, width, height int) *LevelExploration {
+	level := e.Levels[levelID]
+
+	if level == nil || level.Seed != seed || level.Width != width || level.Height != height ||
+		len(level.Revealed) != (width*height+7)/8 {
+		level = &LevelExploration{
+			Seed:     seed,
+			Width:    width,
+			Height:   height,
+			Revealed: make([]byte, (width*height+7)/8),
+		}
+		e.Levels[levelID] = level
+	}
+
+	return level
+}
+
+// Reveal marks a tile as seen.
+func (l *LevelExploration) Reveal(tileX, tileY int) {
+	if tileX < 0 || tileY < 0 || tileX >= l.Width || tileY >= l.Height {
+		return
+	}
+
+	index := tileX + tileY*l.Width
+	l.Revealed[index/8] |= 1 << (index % 8)
+}
+
+// IsRevealed tells whether a tile has been seen.
+func (l *LevelExploration) IsRevealed(tileX, tileY int) bool {
+	if tileX < 0 || tileY < 0 || tileX >= l.Width || tileY >= l.Height {
+		return false
+	}
+
+	index := tileX + tileY*l.Width
+
+	return l.Revealed[index/8]&(1<<(index%8)) != 0
+}
+
+// RevealAround marks the tiles within RevealRadius of the given tile as
+// seen on the levels they belong to. levelAt returns the level of a tile,
+// or 0 for tiles which are not part of a level.
+func (e *Exploration) RevealAround(seed int64, width, height, tileX, tileY int, levelAt func(tileX, tileY int) int) {
+	levels := make(map[int]*LevelExploration)
+
+	for y := tileY - RevealRadius; y <= tileY+RevealRadius; y++ {
+		for x := tileX - RevealRadius; x <= tileX+RevealRadius; x++ {
+			dx, dy := x-tileX, y-tileY
+			if dx*dx+dy*dy > RevealRadius*RevealRadius || x < 0 || y < 0 || x >= width || y >= height {
+				continue
+			}
+
+			levelID := levelAt(x, y)
+			if levelID == 0 {
+				continue
+			}
+
+			level, found := levels[levelID]
+			if !found {
+				level = e.Level(levelID, seed, width, height)
+				levels[levelID] = level
+			}
+
+			level.Reveal(x, y)
+		}
+	}
+}
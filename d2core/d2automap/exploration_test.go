@@ -0,0 +1,121 @@
+package d2automap
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestRevealAround(t *testing.T) {
+	exploration := CreateExploration()
+
+	// the town is the left half of the map, the wilderness the right half
+	levelAt := func(tileX, tileY int) int {
+		if tileX < 50 {
+			return 1
+		}
+
+		return 2
+	}
+
+	exploration.RevealAround(7, 100, 80, 48, 10, levelAt)
+
+	town := exploration.Level(1, 7, 100, 80)
+	wilderness := exploration.Level(2, 7, 100, 80)
+
+	if !town.IsRevealed(48, 10) || !town.IsRevealed(48, 10+RevealRadius) {
+		t.Error("expected the tiles around the player to be revealed in the town")
+	}
+
+	if town.IsRevealed(48, 11+RevealRadius) || town.IsRevealed(48+RevealRadius, 10+RevealRadius) {
+		t.Error("expected the tiles out of the reveal radius to stay hidden")
+	}
+
+	if town.IsRevealed(52, 10) || !wilderness.IsRevealed(52, 10) {
+		t.Error("expected the tiles to be revealed in the level they belong to")
+	}
+
+	if wilderness.IsRevealed(48, 10) {
+		t.Error("expected the town tiles to stay hidden in the wilderness")
+	}
+}
+
+func TestExplorationStartsOverOnAnotherMap(t *testing.T) {
+	exploration := CreateExploration()
+	exploration.Level(1, 7, 10, 10).Reveal(3, 4)
+
+	if !exploration.Level(1, 7, 10, 10).IsRevealed(3, 4) {
+		t.Fatal("expected the tile to be revealed")
+	}
+
+	if exploration.Level(1, 8, 10, 10).IsRevealed(3, 4) {
+		t.Error("expected the exploration of a map of another seed to be empty")
+	}
+}
+
+func TestSaveAndLoadExploration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "d2automap")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	filePath := path.Join(dir, "saves", "0.map")
+
+	missing, err := LoadExploration(filePath)
+	if err != nil || len(missing.Levels) != 0 {
+		t.Fatalf("expected an empty exploration for a missing file, got %v, %v", missing, err)
+	}
+
+	exploration := CreateExploration()
+	exploration.Level(3, 42, 20, 30).Reveal(19, 29)
+
+	if err := exploration.Save(filePath); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadExploration(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if level := loaded.Level(3, 42, 20, 30); !level.IsRevealed(19, 29) || level.IsRevealed(18, 29) {
+		t.Error("expected the loaded exploration to have the saved tiles revealed")
+	}
+}
+
+func TestLoadCorruptedExploration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "d2automap")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	filePath := path.Join(dir, "0.map")
+
+	exploration := CreateExploration()
+	exploration.Level(3, 42, 20, 30).Reveal(1, 2)
+
+	// saved twice, the first save is the backup of the corrupted one
+	for i := 0; i < 2; i++ {
+		if err := exploration.Save(filePath); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ioutil.WriteFile(filePath, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadExploration(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !loaded.Level(3, 42, 20, 30).IsRevealed(1, 2) {
+		t.Error("expected the exploration to be loaded from its backup")
+	}
+}
@@ -0,0 +1,59 @@
+package d2combat
+
+import "math/rand"
+
+// DamageType is a kind of damage an attack can deal
+type DamageType int
+
+// Damage types
+const (
+	DamagePhysical DamageType = iota
+	DamageFire
+	DamageLightning
+	DamageCold
+	DamagePoison
+	DamageMagic
+	numDamageTypes
+)
+
+// damageTypeCodes maps the element codes of MonStats.txt to damage types
+var damageTypeCodes = map[string]DamageType{ //nolint:gochecknoglobals // constant lookup table
+	"fire": DamageFire,
+	"ltng": DamageLightning,
+	"cold": DamageCold,
+	"pois": DamagePoison,
+	"mag":  DamageMagic,
+}
+
+// DamageRange is the minimum and maximum damage of one damage type.
+type DamageRange struct {
+	Min, Max int
+}
+
+// Damage holds the damage range of an attack for every damage type.
+type Damage [numDamageTypes]DamageRange
+
+// Add adds the given range to the damage of the given type.
+func (d *Damage) Add(damageType DamageType, min, max int) {
+	d[damageType].Min += min
+	d[damageType].Max += max
+}
+
+// Roll picks the actual damage of every type from its range.
+func (d *Damage) Roll(rng *rand.Rand) [numDamageTypes]int {
+	var result [numDamageTypes]int
+
+	for idx, damageRange := range d {
+		result[idx] = rollRange(rng, damageRange.Min, damageRange.Max)
+	}
+
+	return result
+}
+
+func rollRange(rng *rand.Rand, min, max int) int {
+	if max <= min {
+		return min
+	}
+
+	return min + rng.Intn(max-min+1)
+}
@@ -0,0 +1,3 @@
+// Package d2combat resolves attacks between units: chance to hit, blocking,
+// physical and elemental damage with resistances, leech and death.
+package d2combat
@@ -0,0 +1,103 @@
+package d2combat
+
+const (
+	minHitChance        = 5
+	maxHitChance        = 95
+	maxBlockChance      = 75
+	maxPlayerResistance = 75
+	minResistance       = -100
+	immuneResistance    = 100
+)
+
+// HitChance returns the chance in percent that an attack hits:
+//
+//	200% * AR / (AR + DR) * alvl / (alvl + dlvl)
+//
+// clamped between 5% and 95%.
+func HitChance(attackRating, defense, attackerLevel, defenderLevel int) int {
+	if attackRating < 0 {
+		attackRating = 0
+	}
+
+	if defense < 0 {
+		defense = 0
+	}
+
+	if attackRating+defense == 0 || attackerLevel+defenderLevel <= 0 {
+		return maxHitChance
+	}
+
+	chance := 200 * attackRating * attackerLevel / ((attackRating + defense) * (attackerLevel + defenderLevel))
+
+	return clamp(chance, minHitChance, maxHitChance)
+}
+
+// PlayerBlockChance returns the chance in percent that a player blocks an
+// attack, given the block of the shield (including the class block factor):
+//
+//	block * (dexterity - 15) / (level * 2)
+//
+// capped at 75%.
+func PlayerBlockChance(shieldBlock, dexterity, level int) int {
+	if shieldBlock <= 0 || level <= 0 {
+		return 0
+	}
+
+	return clamp(shieldBlock*(dexterity-15)/(level*2), 0, maxBlockChance)
+}
+
+// MonsterBlockChance returns the chance in percent that a monster blocks an
+// attack, capped at 75%.
+func MonsterBlockChance(toBlock int) int {
+	return clamp(toBlock, 0, maxBlockChance)
+}
+
+// ApplyResistance reduces damage by a resistance in percent. Resistances of
+// 100% and more make the unit immune, negative resistances increase the
+// damage taken down to -100%.
+func ApplyResistance(damage, resistance int) int {
+	if resistance >= immuneResistance {
+		return 0
+	}
+
+	if resistance < minResistance {
+		resistance = minResistance
+	}
+
+	return damage * (100 - resistance) / 100
+}
+
+// CapPlayerResistance caps a player resistance at 75% plus the given bonus
+// to the maximum resistance.
+func CapPlayerResistance(resistance, maxBonus int) int {
+	return clamp(resistance, minResistance, maxPlayerResistance+maxBonus)
+}
+
+// Leech returns the amount of life or mana stolen from the given damage with
+// the given leech percentage. The divisor is the life or mana steal divisor
+// of the difficulty; zero or one means no penalty.
+func Leech(damage, percent, divisor int) int {
+	if damage <= 0 || percent <= 0 {
+		return 0
+	}
+
+	leeched := damage * percent / 100
+
+	if divisor > 1 {
+		leeched /= divisor
+	}
+
+	return leeched
+}
+
+func clamp(value, min, max int) int {
+	if value < min {
+		return min
+	}
+
+	if value > max {
+		return max
+	}
+
+	return value
+}
@@ -0,0 +1,94 @@
+package d2combat
+
+import "testing"
+
+func TestHitChance(t *testing.T) {
+	tests := []struct {
+		attackRating, defense, attackerLevel, defenderLevel int
+		want                                                int
+	}{
+		{100, 100, 10, 10, 50},
+		{300, 100, 10, 10, 75},
+		{100, 300, 10, 10, 25},
+		{200, 100, 20, 10, 88},
+		{1000, 10, 30, 1, 95}, // capped at 95%
+		{10, 1000, 1, 30, 5},  // at least 5%
+		{0, 0, 1, 1, 95},
+	}
+
+	for _, test := range tests {
+		got := HitChance(test.attackRating, test.defense, test.attackerLevel, test.defenderLevel)
+		if got != test.want {
+			t.Errorf("HitChance(%d, %d, %d, %d) = %d, want %d", test.attackRating, test.defense,
+				test.attackerLevel, test.defenderLevel, got, test.want)
+		}
+	}
+}
+
+func TestPlayerBlockChance(t *testing.T) {
+	tests := []struct {
+		block, dexterity, level int
+		want                    int
+	}{
+		{20, 25, 1, 75}, // 20 * 10 / 2 = 100, capped at 75
+		{20, 25, 10, 10},
+		{46, 75, 30, 46},
+		{0, 100, 10, 0}, // no shield
+		{30, 10, 5, 0},  // dexterity below 15
+	}
+
+	for _, test := range tests {
+		if got := PlayerBlockChance(test.block, test.dexterity, test.level); got != test.want {
+			t.Errorf("PlayerBlockChance(%d, %d, %d) = %d, want %d", test.block, test.dexterity, test.level, got, test.want)
+		}
+	}
+}
+
+func TestApplyResistance(t *testing.T) {
+	tests := []struct {
+		damage, resistance int
+		want               int
+	}{
+		{100, 0, 100},
+		{100, 75, 25},
+		{100, -50, 150},
+		{100, -150, 200}, // capped at -100
+		{100, 100, 0},    // immune
+		{100, 120, 0},
+		{37, 50, 18},
+	}
+
+	for _, test := range tests {
+		if got := ApplyResistance(test.damage, test.resistance); got != test.want {
+			t.Errorf("ApplyResistance(%d, %d) = %d, want %d", test.damage, test.resistance, got, test.want)
+		}
+	}
+}
+
+func TestCapPlayerResistance(t *testing.T) {
+	if got := CapPlayerResistance(90, 0); got != 75 {
+		t.Errorf("expected resistance capped at 75, got %d", got)
+	}
+
+	if got := CapPlayerResistance(90, 5); got != 80 {
+		t.Errorf("expected resistance capped at 80, got %d", got)
+	}
+
+	if got := CapPlayerResistance(-140, 0); got != -100 {
+		t.Errorf("expected resistance capped at -100, got %d", got)
+	}
+}
+
+func TestLeech(t *testing.T) {
+	if got := Leech(200, 6, 1); got != 12 {
+		t.Errorf("Leech(200, 6, 1) = %d, want 12", got)
+	}
+
+	if got := Leech(200, 6, 2); got != 6 {
+		t.Errorf("Leech(200, 6, 2) = %d, want 6", got)
+	}
+
+	if got := Leech(200, 0, 1); got != 0 {
+		t.Errorf("Leech(200, 0, 1) = %d, want 0", got)
+	}
+}
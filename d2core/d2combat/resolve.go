@@ -0,0 +1,101 @@
+package d2combat
+
+import (
+	"math/rand"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+)
+
+// Result is the outcome of an attack.
+type Result struct {
+	Hit     bool
+	Blocked bool
+
+	// Damage is the damage taken by the defender for every damage type,
+	// after resistances.
+	Damage [numDamageTypes]int
+	Total  int
+
+	LifeLeeched int
+	ManaLeeched int
+	Killed      bool
+}
+
+// Attack resolves an attack that has to pass the defender's defense and
+// block, such as a melee swing or an arrow. The defender loses life and
+// the attacker gains any life and mana it leeches.
+func Attack(attacker, defender *Stats, damage Damage, rng *rand.Rand) Result {
+	chance := HitChance(attacker.AttackRating, defender.Defense, attacker.Level, defender.Level)
+	if rng.Intn(100) >= chance {
+		return Result{}
+	}
+
+	if defender.BlockChance > 0 && rng.Intn(100) < defender.BlockChance {
+		return Result{Hit: true, Blocked: true}
+	}
+
+	return ApplyDamage(attacker, defender, damage, rng)
+}
+
+// ApplyDamage deals damage that always hits, such as most spells.
+func ApplyDamage(attacker, defender *Stats, damage Damage, rng *rand.Rand) Result {
+	result := Result{Hit: true}
+
+	if defender.IsDead() {
+		return result
+	}
+
+	rolled := damage.Roll(rng)
+	lifeBefore := defender.Life
+
+	for damageType, amount := range rolled {
+		result.Damage[damageType] = ApplyResistance(amount, defender.Resistances[damageType])
+		result.Total += result.Damage[damageType]
+	}
+
+	if result.Total > lifeBefore {
+		result.Total = lifeBefore
+	}
+
+	defender.Life -= result.Total
+
+	// Leech only applies to the physical part of the damage, and you cannot
+	// leech more than the defender had left
+	physical := minInt(result.Damage[DamagePhysical], lifeBefore)
+
+	if attacker != nil {
+		result.LifeLeeched = Leech(physical, attacker.LifeLeech, attacker.LifeStealDivisor)
+		result.ManaLeeched = Leech(physical, attacker.ManaLeech, attacker.ManaStealDivisor)
+		attacker.Life = minInt(attacker.Life+result.LifeLeeched, attacker.MaxLife)
+		attacker.Mana = minInt(attacker.Mana+result.ManaLeeched, attacker.MaxMana)
+	}
+
+	if defender.Life <= 0 {
+		defender.Life = 0
+		result.Killed = true
+	}
+
+	return result
+}
+
+// MissileDamage returns the base damage of a missile, including its
+// elemental damage.
+func MissileDamage(record *d2datadict.MissileRecord) Damage {
+	var damage Damage
+
+	damage.Add(DamagePhysical, record.Damage.MinDamage, record.Damage.MaxDamage)
+
+	if damageType, ok := damageTypeCodes[record.ElementalDamage.ElementType]; ok {
+		damage.Add(damageType, record.ElementalDamage.Damage.MinDamage, record.ElementalDamage.Damage.MaxDamage)
+	}
+
+	return damage
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,217 @@
+package d2combat
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+)
+
+func TestApplyDamageWithResistances(t *testing.T) {
+	attacker := &Stats{Level: 10}
+	defender := &Stats{Level: 10, Life: 100, MaxLife: 100}
+	defender.Resistances[DamageFire] = 50
+	defender.Resistances[DamageCold] = 100
+
+	var damage Damage
+
+	damage.Add(DamagePhysical, 10, 10)
+	damage.Add(DamageFire, 20, 20)
+	damage.Add(DamageCold, 30, 30)
+
+	result := ApplyDamage(attacker, defender, damage, rand.New(rand.NewSource(1)))
+
+	if result.Damage[DamagePhysical] != 10 || result.Damage[DamageFire] != 10 || result.Damage[DamageCold] != 0 {
+		t.Errorf("unexpected damage %v", result.Damage)
+	}
+
+	if result.Total != 20 || defender.Life != 80 {
+		t.Errorf("expected 20 damage leaving 80 life, got %d leaving %d", result.Total, defender.Life)
+	}
+
+	if result.Killed {
+		t.Error("defender should not have died")
+	}
+}
+
+func TestApplyDamageKillsAndLeeches(t *testing.T) {
+	attacker := &Stats{Level: 10, Life: 50, MaxLife: 100, Mana: 0, MaxMana: 10, LifeLeech: 50, ManaLeech: 100}
+	defender := &Stats{Level: 1, Life: 30, MaxLife: 30}
+
+	var damage Damage
+
+	damage.Add(DamagePhysical, 40, 40)
+
+	result := ApplyDamage(attacker, defender, damage, rand.New(rand.NewSource(1)))
+
+	if !result.Killed || defender.Life != 0 || !defender.IsDead() {
+		t.Errorf("expected the defender to die, life is %d", defender.Life)
+	}
+
+	if result.Total != 30 {
+		t.Errorf("damage should be limited to the remaining life, got %d", result.Total)
+	}
+
+	// 50% of the 30 life the defender had left
+	if result.LifeLeeched != 15 || attacker.Life != 65 {
+		t.Errorf("expected 15 life leeched, got %d (life %d)", result.LifeLeeched, attacker.Life)
+	}
+
+	// mana is capped at the maximum
+	if attacker.Mana != 10 {
+		t.Errorf("expected mana capped at 10, got %d", attacker.Mana)
+	}
+}
+
+func TestAttackHitRate(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	hits := 0
+
+	const attacks = 10000
+
+	for i := 0; i < attacks; i++ {
+		attacker := &Stats{Level: 10, AttackRating: 100}
+		defender := &Stats{Level: 10, Defense: 100, Life: 1000, MaxLife: 1000}
+
+		if Attack(attacker, defender, Damage{}, rng).Hit {
+			hits++
+		}
+	}
+
+	// 50% chance to hit
+	if hits < attacks*45/100 || hits > attacks*55/100 {
+		t.Errorf("expected about half of the attacks to hit, got %d of %d", hits, attacks)
+	}
+}
+
+func TestAttackBlocked(t *testing.T) {
+	attacker := &Stats{Level: 99, AttackRating: 100000}
+	defender := &Stats{Level: 1, Life: 10, MaxLife: 10, BlockChance: 100}
+
+	var damage Damage
+
+	damage.Add(DamagePhysical, 5, 5)
+
+	result := Attack(attacker, defender, damage, rand.New(rand.NewSource(1)))
+
+	if !result.Blocked || defender.Life != 10 {
+		t.Errorf("expected the attack to be blocked, got %+v", result)
+	}
+}
+
+func TestMonsterDamage(t *testing.T) {
+	record := &d2datadict.MonStatsRecord{
+		DamageMinA1Normal:       2,
+		DamageMaxA1Normal:       4,
+		DamageMinA1Hell:         40,
+		DamageMaxA1Hell:         60,
+		ElementAttackMode1:      "A1",
+		ElementType1:            "fire",
+		ElementDamageMin1Normal: 1,
+		ElementDamageMax1Normal: 3,
+		ElementAttackMode2:      "S1",
+		ElementType2:            "cold",
+		ElementDamageMin2Normal: 5,
+		ElementDamageMax2Normal: 5,
+	}
+
+	rng := rand.New(rand.NewSource(1))
+
+	normal := MonsterDamage(record, d2enum.DifficultyNormal, d2enum.MonsterAnimationModeAttack1, rng)
+	if normal[DamagePhysical] != (DamageRange{2, 4}) || normal[DamageFire] != (DamageRange{1, 3}) {
+		t.Errorf("unexpected normal damage %v", normal)
+	}
+
+	if normal[DamageCold] != (DamageRange{}) {
+		t.Errorf("cold damage belongs to S1, got %v", normal[DamageCold])
+	}
+
+	hell := MonsterDamage(record, d2enum.DifficultyHell, d2enum.MonsterAnimationModeAttack1, rng)
+	if hell[DamagePhysical] != (DamageRange{40, 60}) {
+		t.Errorf("unexpected hell damage %v", hell)
+	}
+}
+
+func TestHeroStatsResistancePenalty(t *testing.T) {
+	d2datadict.DifficultyLevels = map[string]*d2datadict.DifficultyLevelRecord{
+		"Normal": {Name: "Normal", LifeStealDivisor: 1},
+		"Hell":   {Name: "Hell", ResistancePenalty: -100, LifeStealDivisor: 3},
+	}
+
+	hero := &d2hero.HeroStatsState{FireResistance: 75, ColdResistance: 20}
+
+	normal := HeroStats(hero, d2enum.DifficultyNormal)
+	if normal.Resistances[DamageFire] != 75 || normal.Resistances[DamageCold] != 20 {
+		t.Errorf("unexpected normal resistances %v", normal.Resistances)
+	}
+
+	hell := HeroStats(hero, d2enum.DifficultyHell)
+	if hell.Resistances[DamageFire] != -25 || hell.Resistances[DamageCold] != -80 {
+		t.Errorf("unexpected hell resistances %v", hell.Resistances)
+	}
+
+	if hell.LifeStealDivisor != 3 {
+		t.Errorf("expected the hell life steal divisor, got %d", hell.LifeStealDivisor)
+	}
+}
+
+func TestHeroStatsItemProperties(t *testing.T) {
+	d2datadict.DifficultyLevels = nil
+
+	hero := &d2hero.HeroStatsState{DefenseRating: 10, FireResistance: 60}
+	stats := HeroStats(hero, d2enum.DifficultyNormal,
+		Property{Code: "ac", Value: 15},
+		Property{Code: "res-all", Value: 30},
+		Property{Code: "red-dmg%", Value: 80},
+		Property{Code: "lifesteal", Value: 5},
+	)
+
+	if stats.Defense != 25 || stats.LifeLeech != 5 {
+		t.Errorf("expected the defense and the leech of the items, got %d and %d", stats.Defense, stats.LifeLeech)
+	}
+
+	if stats.Resistances[DamageFire] != 75 || stats.Resistances[DamageCold] != 30 {
+		t.Errorf("expected the capped resistances of the items, got %v", stats.Resistances)
+	}
+
+	if stats.Resistances[DamagePhysical] != maxDamageReduction {
+		t.Errorf("expected the damage reduction to be capped, got %d", stats.Resistances[DamagePhysical])
+	}
+}
+
+func TestHeroStatsBlock(t *testing.T) {
+	d2datadict.DifficultyLevels = nil
+
+	hero := &d2hero.HeroStatsState{Level: 10, Dexterity: 35}
+
+	if stats := HeroStats(hero, d2enum.DifficultyNormal); stats.BlockChance != 0 {
+		t.Errorf("expected no block without a shield, got %d", stats.BlockChance)
+	}
+
+	// 40 * (35 - 15) / (10 * 2)
+	stats := HeroStats(hero, d2enum.DifficultyNormal, Property{Code: "block", Value: 30}, Property{Code: "block", Value: 10})
+	if stats.BlockChance != 40 {
+		t.Errorf("expected a block chance of 40, got %d", stats.BlockChance)
+	}
+}
+
+func TestMonsterLevel(t *testing.T) {
+	record := &d2datadict.MonStatsRecord{LevelNormal: 2, LevelNightmare: 30, LevelHell: 60}
+	levelDetails := &d2datadict.LevelDetailsRecord{MonsterLevelNightmareEx: 36, MonsterLevelHellEx: 67}
+
+	if level := MonsterLevel(record, levelDetails, d2enum.DifficultyNormal); level != 2 {
+		t.Errorf("expected the normal level of the monster, got %d", level)
+	}
+
+	if level := MonsterLevel(record, levelDetails, d2enum.DifficultyHell); level != 67 {
+		t.Errorf("expected the hell level of the area, got %d", level)
+	}
+
+	record.IsSpecialBoss = true
+
+	if level := MonsterLevel(record, levelDetails, d2enum.DifficultyNightmare); level != 30 {
+		t.Errorf("expected the nightmare level of the boss, got %d", level)
+	}
+}
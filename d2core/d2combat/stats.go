@@ -0,0 +1,304 @@
+package d2combat
+
+import (
+	"math/rand"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+)
+
+const (
+	// bonuses of the shrine effects, in percent
+	armorShrineDefense     = 100
+	combatShrineAttack     = 200
+	resistShrineResistance = 75
+
+	// the most physical damage the items of a hero reduce, in percent
+	maxDamageReduction = 50
+)
+
+// Stats are the combat relevant values of a unit.
+type Stats struct {
+	Level        int
+	AttackRating int
+	Defense      int
+	BlockChance  int // in percent
+	Life         int
+	MaxLife      int
+	Mana         int
+	MaxMana      int
+
+	// Resistances in percent, indexed by DamageType. Physical resistance is
+	// the physical damage reduction of the unit.
+	Resistances [numDamageTypes]int
+
+	// LifeLeech and ManaLeech are the percentage of physical damage dealt
+	// that is returned to the attacker as life and mana.
+	LifeLeech int
+	ManaLeech int
+
+	// LifeStealDivisor and ManaStealDivisor reduce leech on higher
+	// difficulties, see DifficultyLevels.txt.
+	LifeStealDivisor int
+	ManaStealDivisor int
+}
+
+// Property is a modifier of the stats of a hero given by its items, a code
+// of properties.txt with its value.
+type Property struct {
+	Code  string
+	Value int
+}
+
+// IsDead returns true if the unit has no life left.
+func (s *Stats) IsDead() bool {
+	return s.Life <= 0
+}
+
+// HeroStats returns the combat stats of a hero on the given difficulty, with
+// the properties of its items. The block of the items is that of the shield
+// of the hero with its class block factor, the chance to block depends on
+// the dexterity and the level of the hero. The resistance penalty and the
+// leech divisors of the difficulty apply if DifficultyLevels.txt is loaded.
+func HeroStats(hero *d2hero.HeroStatsState, difficulty d2enum.DifficultyType, properties ...Property) *Stats {
+	stats := &Stats{
+		Level:        hero.Level,
+		AttackRating: hero.AttackRating,
+		Defense:      hero.DefenseRating,
+		Life:         hero.Health,
+		MaxLife:      hero.MaxHealth,
+		Mana:         hero.Mana,
+		MaxMana:      hero.MaxMana,
+	}
+
+	resistances := map[DamageType]int{
+		DamageFire:      hero.FireResistance,
+		DamageCold:      hero.ColdResistance,
+		DamageLightning: hero.LightningResistance,
+		DamagePoison:    hero.PoisonResistance,
+	}
+
+	if record := d2datadict.GetDifficultyLevel(difficulty); record != nil {
+		for damageType := range resistances {
+			resistances[damageType] += record.ResistancePenalty
+		}
+
+		stats.LifeStealDivisor = record.LifeStealDivisor
+		stats.ManaStealDivisor = record.ManaStealDivisor
+	}
+
+	damageReduction := 0
+	block := 0
+
+	// TODO: the damage, the attributes, the life and the mana the items add
+	for _, property := range properties {
+		switch property.Code {
+		case "ac":
+			stats.Defense += property.Value
+		case "att":
+			stats.AttackRating += property.Value
+		case "block":
+			block += property.Value
+		case "lifesteal":
+			stats.LifeLeech += property.Value
+		case "manasteal":
+			stats.ManaLeech += property.Value
+		case "red-dmg%":
+			damageReduction += property.Value
+		case "res-fire":
+			resistances[DamageFire] += property.Value
+		case "res-cold":
+			resistances[DamageCold] += property.Value
+		case "res-ltng":
+			resistances[DamageLightning] += property.Value
+		case "res-pois":
+			resistances[DamagePoison] += property.Value
+		case "res-all":
+			for _, damageType := range []DamageType{DamageFire, DamageCold, DamageLightning, DamagePoison} {
+				resistances[damageType] += property.Value
+			}
+		}
+	}
+
+	stats.Resistances[DamagePhysical] = clamp(damageReduction, 0, maxDamageReduction)
+	stats.BlockChance = PlayerBlockChance(block, hero.Dexterity, hero.Level)
+
+	for _, effect := range hero.Effects {
+		switch effect.Type {
+		case d2hero.EffectArmor:
+			stats.Defense += stats.Defense * armorShrineDefense / 100
+		case d2hero.EffectCombat:
+			stats.AttackRating += stats.AttackRating * combatShrineAttack / 100
+		case d2hero.EffectResistFire:
+			resistances[DamageFire] += resistShrineResistance
+		case d2hero.EffectResistCold:
+			resistances[DamageCold] += resistShrineResistance
+		case d2hero.EffectResistLightning:
+			resistances[DamageLightning] += resistShrineResistance
+		case d2hero.EffectResistPoison:
+			resistances[DamagePoison] += resistShrineResistance
+		}
+	}
+
+	for damageType, resistance := range resistances {
+		stats.Resistances[damageType] = CapPlayerResistance(resistance, 0)
+	}
+
+	return stats
+}
+
+// MonsterStats returns the combat stats of a monster on the given
+// difficulty, with its life rolled between the minimum and maximum.
+func MonsterStats(record *d2datadict.MonStatsRecord, difficulty d2enum.DifficultyType, rng *rand.Rand) *Stats {
+	life := rollRange(rng,
+		pick(difficulty, record.MinHPNormal, record.MinHPNightmare, record.MinHPHell),
+		pick(difficulty, record.MaxHPNormal, record.MaxHPNightmare, record.MaxHPHell))
+
+	if life < 1 {
+		life = 1
+	}
+
+	stats := &Stats{
+		Level:        pick(difficulty, record.LevelNormal, record.LevelNightmare, record.LevelHell),
+		AttackRating: pick(difficulty, record.AttackRatingA1Normal, record.AttackRatingA1Nightmare, record.AttackRatingA1Hell),
+		Defense:      pick(difficulty, record.ArmorClassNormal, record.ArmorClassNightmare, record.ArmorClassHell),
+		BlockChance: MonsterBlockChance(pick(difficulty,
+			record.ChanceToBlockNormal, record.ChanceToBlockNightmare, record.ChanceToBlockHell)),
+		Life:    life,
+		MaxLife: life,
+	}
+
+	stats.Resistances = [numDamageTypes]int{
+		DamagePhysical: pick(difficulty, record.ResistancePhysicalNormal,
+			record.ResistancePhysicalNightmare, record.ResistancePhysicalHell),
+		DamageFire: pick(difficulty, record.ResistanceFireNormal,
+			record.ResistanceFireNightmare, record.ResistanceFireHell),
+		DamageLightning: pick(difficulty, record.ResistanceLightningNormal,
+			record.ResistanceLightningNightmare, record.ResistanceLightningHell),
+		DamageCold: pick(difficulty, record.ResistanceColdNormal,
+			record.ResistanceColdNightmare, record.ResistanceColdHell),
+		DamagePoison: pick(difficulty, record.ResistancePoisonNormal,
+			record.ResistancePoisonNightmare, record.ResistancePoisonHell),
+		DamageMagic: pick(difficulty, record.ResistanceMagicNormal,
+			record.ResistanceMagicNightmare, record.ResistanceMagicHell),
+	}
+
+	return stats
+}
+
+// MonsterAttackRating returns the attack rating of a monster attack.
+func MonsterAttackRating(record *d2datadict.MonStatsRecord, difficulty d2enum.DifficultyType,
+	mode d2enum.MonsterAnimationMode) int {
+	switch mode {
+	case d2enum.MonsterAnimationModeAttack2:
+		return pick(difficulty, record.AttackRatingA2Normal, record.AttackRatingA2Nightmare, record.AttackRatingA2Hell)
+	case d2enum.MonsterAnimationModeSkill1:
+		return pick(difficulty, record.AttackRatingS1Normal, record.AttackRatingS1Nightmare, record.AttackRatingS1Hell)
+	default:
+		return pick(difficulty, record.AttackRatingA1Normal, record.AttackRatingA1Nightmare, record.AttackRatingA1Hell)
+	}
+}
+
+// MonsterDamage returns the damage a monster deals with the attack of the
+// given animation mode, including the elemental damage appended to it.
+func MonsterDamage(record *d2datadict.MonStatsRecord, difficulty d2enum.DifficultyType,
+	mode d2enum.MonsterAnimationMode, rng *rand.Rand) Damage {
+	var damage Damage
+
+	switch mode {
+	case d2enum.MonsterAnimationModeAttack2:
+		damage.Add(DamagePhysical,
+			pick(difficulty, record.DamageMinA2Normal, record.DamageMinA2Nightmare, record.DamageMinA2Hell),
+			pick(difficulty, record.DamageMaxA2Normal, record.DamageMaxA2Nightmare, record.DamageMaxA2Hell))
+	case d2enum.MonsterAnimationModeSkill1:
+		damage.Add(DamagePhysical,
+			pick(difficulty, record.DamageMinS1Normal, record.DamageMinS1Nightmare, record.DamageMinS1Hell),
+			pick(difficulty, record.DamageMaxS1Normal, record.DamageMaxS1Nightmare, record.DamageMaxS1Hell))
+	default:
+		damage.Add(DamagePhysical,
+			pick(difficulty, record.DamageMinA1Normal, record.DamageMinA1Nightmare, record.DamageMinA1Hell),
+			pick(difficulty, record.DamageMaxA1Normal, record.DamageMaxA1Nightmare, record.DamageMaxA1Hell))
+	}
+
+	elements := []struct {
+		mode, element    string
+		chance, min, max int
+	}{
+		{record.ElementAttackMode1, record.ElementType1,
+			pick(difficulty, record.ElementChance1Normal, record.ElementChance1Nightmare, record.ElementChance1Hell),
+			pick(difficulty, record.ElementDamageMin1Normal, record.ElementDamageMin1Nightmare, record.ElementDamageMin1Hell),
+			pick(difficulty, record.ElementDamageMax1Normal, record.ElementDamageMax1Nightmare, record.ElementDamageMax1Hell)},
+		{record.ElementAttackMode2, record.ElementType2,
+			pick(difficulty, record.ElementChance2Normal, record.ElementChance2Nightmare, record.ElementChance2Hell),
+			pick(difficulty, record.ElementDamageMin2Normal, record.ElementDamageMin2Nightmare, record.ElementDamageMin2Hell),
+			pick(difficulty, record.ElementDamageMax2Normal, record.ElementDamageMax2Nightmare, record.ElementDamageMax2Hell)},
+		{record.ElementAttackMode3, record.ElementType3,
+			pick(difficulty, record.ElementChance3Normal, record.ElementChance3Nightmare, record.ElementChance3Hell),
+			pick(difficulty, record.ElementDamageMin3Normal, record.ElementDamageMin3Nightmare, record.ElementDamageMin3Hell),
+			pick(difficulty, record.ElementDamageMax3Normal, record.ElementDamageMax3Nightmare, record.ElementDamageMax3Hell)},
+	}
+
+	for _, element := range elements {
+		damageType, ok := damageTypeCodes[element.element]
+		if !ok || element.mode != mode.String() {
+			continue
+		}
+
+		// A blank chance means the element is always applied
+		if element.chance > 0 && rng.Intn(100) >= element.chance {
+			continue
+		}
+
+		damage.Add(damageType, element.min, element.max)
+	}
+
+	return damage
+}
+
+// MonsterExperience returns the base experience awarded for killing a
+// monster on the given difficulty.
+func MonsterExperience(record *d2datadict.MonStatsRecord, difficulty d2enum.DifficultyType) int {
+	return pick(difficulty, record.ExperienceNormal, record.ExperienceNightmare, record.ExperienceHell)
+}
+
+// MonsterTreasureClass returns the treasure class a monster drops its items
+// from on the given difficulty, empty if it drops nothing.
+func MonsterTreasureClass(record *d2datadict.MonStatsRecord, difficulty d2enum.DifficultyType) string {
+	switch difficulty {
+	case d2enum.DifficultyNightmare:
+		return record.TreasureClassNightmare
+	case d2enum.DifficultyHell:
+		return record.TreasureClassHell
+	default:
+		return record.TreasureClassNormal
+	}
+}
+
+// MonsterLevel returns the level of a monster in a level on the given
+// difficulty. On nightmare and hell the monsters take the level of the area,
+// except for the bosses.
+func MonsterLevel(record *d2datadict.MonStatsRecord, levelDetails *d2datadict.LevelDetailsRecord,
+	difficulty d2enum.DifficultyType) int {
+	level := pick(difficulty, record.LevelNormal, record.LevelNightmare, record.LevelHell)
+	if difficulty == d2enum.DifficultyNormal || record.IsSpecialBoss || levelDetails == nil {
+		return level
+	}
+
+	if areaLevel := pick(difficulty, 0, levelDetails.MonsterLevelNightmareEx, levelDetails.MonsterLevelHellEx); areaLevel > 0 {
+		return areaLevel
+	}
+
+	return level
+}
+
+func pick(difficulty d2enum.DifficultyType, normal, nightmare, hell int) int {
+	switch difficulty {
+	case d2enum.DifficultyNightmare:
+		return nightmare
+	case d2enum.DifficultyHell:
+		return hell
+	default:
+		return normal
+	}
+}
@@ -10,6 +10,12 @@ import (
 // Config holds the configuration from config.json
 var Config *Configuration //nolint:gochecknoglobals // Currently global by design
 
+// Backends of the renderer, see Configuration.Backend
+const (
+	BackendEbiten   = "Ebiten"
+	BackendSoftware = "Software" // draws in memory, without a window
+)
+
 // Configuration defines the configuration for the engine, loaded from config.json
 type Configuration struct {
 	MpqLoadOrder    []string
@@ -22,7 +28,9 @@ type Configuration struct {
 	FullScreen      bool
 	RunInBackground bool
 	VsyncEnabled    bool
-	Backend         string
+	Backend         string // the renderer, one of the Backend constants
+	OpaqueWalls     bool   // the walls in front of the player are not see-through
+	SaveDirectory   string // the games are saved to, OpenDiablo2/Saves of the user configuration directory if empty
 }
 
 // Load loads a configuration object from disk
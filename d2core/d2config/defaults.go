@@ -21,7 +21,7 @@ func defaultConfig() *Configuration {
 		SfxVolume:       defaultSfxVolume,
 		BgmVolume:       defaultBgmVolume,
 		MpqPath:         "C:/Program Files (x86)/Diablo II",
-		Backend:         "Ebiten",
+		Backend:         BackendEbiten,
 		MpqLoadOrder: []string{
 			"Patch_D2.mpq",
 			"d2exp.mpq",
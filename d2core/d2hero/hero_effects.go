@@ -0,0 +1,56 @@
+package d2hero
+
+// EffectType is a timed effect on a hero, e.g. granted by a shrine.
+type EffectType int
+
+// Effect types
+const (
+	EffectArmor EffectType = iota
+	EffectCombat
+	EffectResistFire
+	EffectResistCold
+	EffectResistLightning
+	EffectResistPoison
+	EffectExperience
+)
+
+// TimedEffect is an effect which wears off after the remaining seconds.
+type TimedEffect struct {
+	Type      EffectType `json:"type"`
+	Remaining float64    `json:"remaining"`
+}
+
+// AddEffect grants the hero an effect for the given seconds. Shrine effects
+// do not stack, a hero only has the effect it got last.
+func (s *HeroStatsState) AddEffect(effect EffectType, duration float64) {
+	s.Effects = []TimedEffect{{Type: effect, Remaining: duration}}
+}
+
+// HasEffect returns true if the hero currently has the given effect.
+func (s *HeroStatsState) HasEffect(effect EffectType) bool {
+	for idx := range s.Effects {
+		if s.Effects[idx].Type == effect {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AdvanceEffects counts down the remaining time of the hero's effects and
+// removes those which wore off. It returns true if any effect wore off.
+func (s *HeroStatsState) AdvanceEffects(elapsed float64) bool {
+	active := s.Effects[:0]
+
+	for _, effect := range s.Effects {
+		effect.Remaining -= elapsed
+		if effect.Remaining > 0 {
+			active = append(active, effect)
+		}
+	}
+
+	expired := len(active) != len(s.Effects)
+	s.Effects = active
+
+	return expired
+}
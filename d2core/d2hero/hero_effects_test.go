@@ -0,0 +1,21 @@
+package d2hero
+
+import "testing"
+
+func TestEffectsWearOff(t *testing.T) {
+	stats := &HeroStatsState{}
+	stats.AddEffect(EffectArmor, 10)
+	stats.AddEffect(EffectCombat, 5)
+
+	if stats.HasEffect(EffectArmor) || !stats.HasEffect(EffectCombat) {
+		t.Fatal("a new shrine effect should replace the previous one")
+	}
+
+	if stats.AdvanceEffects(4) || !stats.HasEffect(EffectCombat) {
+		t.Fatal("effect wore off too early")
+	}
+
+	if !stats.AdvanceEffects(1) || stats.HasEffect(EffectCombat) {
+		t.Fatal("effect did not wear off")
+	}
+}
@@ -0,0 +1,207 @@
+package d2hero
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+)
+
+const (
+	// monsters more than this many levels away from the hero give reduced experience
+	levelDifferenceThreshold = 5
+
+	// heroes from this level on get experience proportional to their level when
+	// killing higher level monsters, instead of the level difference table
+	proportionalExperienceLevel = 25
+
+	// every additional party member adds this percentage to the shared experience
+	partyExperienceBonus = 35
+
+	// skill points granted per level
+	skillPointsPerLevel = 1
+
+	// the life, mana and stamina gains of CharStats.txt are in quarter points
+	quartersPerPoint = 4
+)
+
+// levelDifferencePenalty is the percentage of experience kept when the level
+// difference exceeds the threshold, indexed by the excess difference.
+var levelDifferencePenalty = []int{100, 81, 62, 43, 24, 5} //nolint:gochecknoglobals // lookup table
+
+// StatType is a stat a hero can spend stat points on.
+type StatType int
+
+// Stat types
+const (
+	StatStrength StatType = iota
+	StatDexterity
+	StatVitality
+	StatEnergy
+)
+
+// ExperienceForLevel returns the experience a hero of the given level gets
+// for a kill worth the given base experience of a monster of the given level.
+func ExperienceForLevel(experience, heroLevel, monsterLevel int) int {
+	if monsterLevel > heroLevel && heroLevel >= proportionalExperienceLevel {
+		return experience * heroLevel / monsterLevel
+	}
+
+	difference := monsterLevel - heroLevel
+	if difference < 0 {
+		difference = -difference
+	}
+
+	excess := difference - levelDifferenceThreshold
+	if excess <= 0 {
+		return experience
+	}
+
+	if excess >= len(levelDifferencePenalty) {
+		excess = len(levelDifferencePenalty) - 1
+	}
+
+	return experience * levelDifferencePenalty[excess] / 100
+}
+
+// PartyExperience splits the experience of a kill between the members of a
+// party with the given levels. Every member beyond the first adds a bonus to
+// the total, which is shared proportionally to the members' levels before the
+// level difference penalty of each member is applied.
+func PartyExperience(experience, monsterLevel int, levels []int) []int {
+	shares := make([]int, len(levels))
+	if len(levels) == 0 {
+		return shares
+	}
+
+	total := experience * (100 + partyExperienceBonus*(len(levels)-1)) / 100
+
+	levelSum := 0
+	for _, level := range levels {
+		levelSum += level
+	}
+
+	for idx, level := range levels {
+		share := total / len(levels)
+		if levelSum > 0 {
+			share = total * level / levelSum
+		}
+
+		shares[idx] = ExperienceForLevel(share, level, monsterLevel)
+	}
+
+	return shares
+}
+
+// AddExperience adds experience to the hero and levels it up as often as the
+// experience allows. It returns the number of levels gained.
+func (s *HeroStatsState) AddExperience(experience int, classStats *d2datadict.CharStatsRecord) int {
+	s.Experience += experience
+
+	levels := 0
+
+	for s.canLevelUp(classStats) {
+		s.levelUp(classStats)
+		levels++
+	}
+
+	return levels
+}
+
+// LoseExperience takes the given percentage of the experience needed for the
+// next level from the hero, as when it dies. The hero does not lose levels.
+// It returns the experience lost.
+func (s *HeroStatsState) LoseExperience(percent int, classStats *d2datadict.CharStatsRecord) int {
+	levelStart := 0
+	if s.Level > 1 {
+		levelStart = d2datadict.GetExperienceBreakpoint(classStats.Class, s.Level-1)
+	}
+
+	lost := (s.NextLevelExp - levelStart) * percent / 100
+	if s.Experience-lost < levelStart {
+		lost = s.Experience - levelStart
+	}
+
+	if lost <= 0 {
+		return 0
+	}
+
+	s.Experience -= lost
+
+	return lost
+}
+
+// AllocateStatPoint spends a stat point on the given stat and updates the
+// values derived from it. It returns false if there are no points to spend.
+func (s *HeroStatsState) AllocateStatPoint(stat StatType, classStats *d2datadict.CharStatsRecord) bool {
+	if s.StatPoints <= 0 {
+		return false
+	}
+
+	switch stat {
+	case StatStrength:
+		s.Strength++
+	case StatDexterity:
+		s.Dexterity++
+		s.AttackRating = attackRating(s.Dexterity, classStats)
+		s.DefenseRating = defenseRating(s.Dexterity)
+	case StatVitality:
+		s.Vitality++
+		life := gainQuarters(&s.LifeQuarters, classStats.LifePerVit)
+		s.MaxHealth += life
+		s.Health += life
+		stamina := gainQuarters(&s.StaminaQuarters, classStats.StaminaPerVit)
+		s.MaxStamina += stamina
+		s.Stamina += stamina
+	case StatEnergy:
+		s.Energy++
+		mana := gainQuarters(&s.ManaQuarters, classStats.ManaPerEne)
+		s.MaxMana += mana
+		s.Mana += mana
+	default:
+		return false
+	}
+
+	s.StatPoints--
+
+	return true
+}
+
+func (s *HeroStatsState) canLevelUp(classStats *d2datadict.CharStatsRecord) bool {
+	if _, found := d2datadict.ExperienceBreakpoints[s.Level+1]; !found {
+		return false
+	}
+
+	if maxLevel := d2datadict.GetMaxLevelByHero(classStats.Class); maxLevel > 0 && s.Level >= maxLevel {
+		return false
+	}
+
+	return s.Experience >= s.NextLevelExp
+}
+
+// levelUp raises the level of the hero, granting stat and skill points and
+// the per level life, mana and stamina of the class. The hero is fully
+// restored, as in the original game.
+func (s *HeroStatsState) levelUp(classStats *d2datadict.CharStatsRecord) {
+	s.Level++
+	s.NextLevelExp = d2datadict.GetExperienceBreakpoint(classStats.Class, s.Level)
+
+	s.StatPoints += classStats.StatPerLevel
+	s.SkillPoints += skillPointsPerLevel
+
+	s.MaxHealth += gainQuarters(&s.LifeQuarters, classStats.LifePerLevel)
+	s.MaxMana += gainQuarters(&s.ManaQuarters, classStats.ManaPerLevel)
+	s.MaxStamina += gainQuarters(&s.StaminaQuarters, classStats.StaminaPerLevel)
+
+	s.Health = s.MaxHealth
+	s.Mana = s.MaxMana
+	s.Stamina = s.MaxStamina
+}
+
+// gainQuarters adds quarter points to the fraction of a point the hero has
+// of a stat and returns the whole points it makes, the rest of the quarters
+// is kept for the next gain.
+func gainQuarters(fraction *int, quarters int) int {
+	*fraction += quarters
+	points := *fraction / quartersPerPoint
+	*fraction %= quartersPerPoint
+
+	return points
+}
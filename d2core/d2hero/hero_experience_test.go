@@ -0,0 +1,188 @@
+package d2hero
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+func testClassStats() *d2datadict.CharStatsRecord {
+	d2datadict.ExperienceBreakpoints = map[int]*d2datadict.ExperienceBreakpointsRecord{}
+
+	for level, experience := range []int{0, 500, 1500, 3750} {
+		d2datadict.ExperienceBreakpoints[level] = &d2datadict.ExperienceBreakpointsRecord{
+			Level:           level,
+			HeroBreakpoints: map[d2enum.Hero]int{d2enum.HeroSorceress: experience},
+		}
+	}
+
+	return &d2datadict.CharStatsRecord{
+		Class:           d2enum.HeroSorceress,
+		InitStr:         10,
+		InitDex:         25,
+		InitVit:         10,
+		InitEne:         35,
+		InitStamina:     74,
+		LifePerLevel:    4,
+		ManaPerLevel:    8,
+		StaminaPerLevel: 4,
+		LifePerVit:      8,
+		ManaPerEne:      8,
+		StaminaPerVit:   4,
+		StatPerLevel:    5,
+	}
+}
+
+func TestCreateHeroStatsState(t *testing.T) {
+	classStats := testClassStats()
+	classStats.InitVit, classStats.InitEne = 11, 35
+	stats := CreateHeroStatsState(d2enum.HeroSorceress, classStats)
+
+	// 11 vitality of 8 quarters of life, 35 energy of 8 quarters of mana
+	if stats.MaxHealth != 22 || stats.Health != 22 || stats.MaxMana != 70 || stats.Mana != 70 {
+		t.Errorf("want 22 life and 70 mana, got %d/%d life and %d/%d mana",
+			stats.Health, stats.MaxHealth, stats.Mana, stats.MaxMana)
+	}
+
+	if stats.MaxStamina != classStats.InitStamina || stats.Level != 1 || stats.Vitality != 11 {
+		t.Errorf("want the stamina, the level and the vitality of the class, got %d, %d and %d",
+			stats.MaxStamina, stats.Level, stats.Vitality)
+	}
+
+	// a class whose life per vitality does not make whole points keeps the rest
+	classStats.LifePerVit = 3
+	stats = CreateHeroStatsState(d2enum.HeroSorceress, classStats)
+
+	if stats.MaxHealth != 8 || stats.LifeQuarters != 1 {
+		t.Errorf("want 8 life and a quarter left, got %d and %d quarters", stats.MaxHealth, stats.LifeQuarters)
+	}
+}
+
+func TestAddExperienceLevelsUp(t *testing.T) {
+	classStats := testClassStats()
+	stats := CreateHeroStatsState(d2enum.HeroSorceress, classStats)
+	maxHealth, maxMana := stats.MaxHealth, stats.MaxMana
+
+	if levels := stats.AddExperience(499, classStats); levels != 0 {
+		t.Fatalf("gained %d levels below the breakpoint", levels)
+	}
+
+	if levels := stats.AddExperience(1100, classStats); levels != 2 {
+		t.Fatalf("want 2 levels, got %d", levels)
+	}
+
+	if stats.Level != 3 || stats.NextLevelExp != 3750 {
+		t.Errorf("want level 3 needing 3750, got level %d needing %d", stats.Level, stats.NextLevelExp)
+	}
+
+	if stats.StatPoints != 10 || stats.SkillPoints != 2 {
+		t.Errorf("want 10 stat and 2 skill points, got %d and %d", stats.StatPoints, stats.SkillPoints)
+	}
+
+	// 4 quarters of life and 8 quarters of mana per level
+	if stats.MaxHealth != maxHealth+2 || stats.MaxMana != maxMana+4 {
+		t.Errorf("life and mana did not grow per level: %d/%d", stats.MaxHealth, stats.MaxMana)
+	}
+
+	if stats.Health != stats.MaxHealth {
+		t.Error("hero was not restored on level up")
+	}
+}
+
+func TestAllocateStatPoint(t *testing.T) {
+	classStats := testClassStats()
+	stats := CreateHeroStatsState(d2enum.HeroSorceress, classStats)
+
+	if stats.AllocateStatPoint(StatVitality, classStats) {
+		t.Fatal("allocated a stat point without having any")
+	}
+
+	stats.StatPoints = 2
+	maxHealth, attack := stats.MaxHealth, stats.AttackRating
+
+	if !stats.AllocateStatPoint(StatVitality, classStats) || !stats.AllocateStatPoint(StatDexterity, classStats) {
+		t.Fatal("failed to allocate stat points")
+	}
+
+	if stats.StatPoints != 0 || stats.Vitality != 11 || stats.Dexterity != 26 {
+		t.Errorf("unexpected stats after allocation: %+v", stats)
+	}
+
+	if stats.MaxHealth != maxHealth+classStats.LifePerVit/4 || stats.AttackRating != attack+5 {
+		t.Errorf("derived stats not updated: life %d, attack rating %d", stats.MaxHealth, stats.AttackRating)
+	}
+}
+
+func TestAllocateStatPointKeepsQuarters(t *testing.T) {
+	classStats := testClassStats()
+	classStats.LifePerVit = 6
+	stats := CreateHeroStatsState(d2enum.HeroSorceress, classStats)
+	stats.StatPoints = 2
+	maxHealth := stats.MaxHealth
+
+	stats.AllocateStatPoint(StatVitality, classStats)
+
+	if stats.MaxHealth != maxHealth+1 || stats.LifeQuarters != 2 {
+		t.Errorf("want 1 life and 2 quarters, got %d life and %d quarters", stats.MaxHealth-maxHealth, stats.LifeQuarters)
+	}
+
+	stats.AllocateStatPoint(StatVitality, classStats)
+
+	if stats.MaxHealth != maxHealth+3 || stats.LifeQuarters != 0 {
+		t.Errorf("want 3 life and no quarters, got %d life and %d quarters", stats.MaxHealth-maxHealth, stats.LifeQuarters)
+	}
+}
+
+func TestLoseExperience(t *testing.T) {
+	classStats := testClassStats()
+	stats := CreateHeroStatsState(d2enum.HeroSorceress, classStats)
+	stats.AddExperience(1600, classStats)
+
+	// level 3 starts at 1500 and ends at 3750
+	if lost := stats.LoseExperience(10, classStats); lost != 100 || stats.Experience != 1500 {
+		t.Errorf("want 100 experience lost down to the start of the level, got %d leaving %d", lost, stats.Experience)
+	}
+
+	stats.AddExperience(1000, classStats)
+
+	if lost := stats.LoseExperience(20, classStats); lost != 450 || stats.Experience != 2050 {
+		t.Errorf("want 450 experience lost, got %d leaving %d", lost, stats.Experience)
+	}
+
+	if stats.Level != 3 {
+		t.Errorf("lost a level, now level %d", stats.Level)
+	}
+}
+
+func TestExperienceForLevel(t *testing.T) {
+	tests := []struct {
+		heroLevel, monsterLevel, want int
+	}{
+		{10, 10, 1000},
+		{10, 15, 1000},
+		{10, 16, 810},
+		{16, 10, 810},
+		{10, 30, 50},
+		{30, 60, 500},
+	}
+
+	for _, test := range tests {
+		if got := ExperienceForLevel(1000, test.heroLevel, test.monsterLevel); got != test.want {
+			t.Errorf("hero level %d, monster level %d: want %d, got %d",
+				test.heroLevel, test.monsterLevel, test.want, got)
+		}
+	}
+}
+
+func TestPartyExperience(t *testing.T) {
+	shares := PartyExperience(1000, 10, []int{10, 10})
+	if shares[0] != 675 || shares[1] != 675 {
+		t.Errorf("want equal shares of 675, got %v", shares)
+	}
+
+	shares = PartyExperience(1000, 10, []int{10, 5})
+	if shares[0] <= shares[1] {
+		t.Errorf("higher level member should get the larger share, got %v", shares)
+	}
+}
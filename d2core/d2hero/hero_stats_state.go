@@ -15,6 +15,9 @@ type HeroStatsState struct {
 	Strength  int `json:"strength"`
 	Dexterity int `json:"dexterity"`
 
+	StatPoints  int `json:"statPoints"`
+	SkillPoints int `json:"skillPoints"`
+
 	AttackRating  int `json:"attackRating"`
 	DefenseRating int `json:"defenseRating"`
 
@@ -24,11 +27,19 @@ type HeroStatsState struct {
 	Mana       int `json:"mana"`
 	MaxMana    int `json:"maxMana"`
 
+	// quarter points of life, mana and stamina gained which do not make a
+	// whole point yet
+	LifeQuarters    int `json:"lifeQuarters"`
+	ManaQuarters    int `json:"manaQuarters"`
+	StaminaQuarters int `json:"staminaQuarters"`
+
 	FireResistance      int `json:"fireResistance"`
 	ColdResistance      int `json:"coldResistance"`
 	LightningResistance int `json:"lightningResistance"`
 	PoisonResistance    int `json:"poisonResistance"`
 
+	Effects []TimedEffect `json:"effects"`
+
 	// values which are not saved/loaded(computed)
 	Stamina      int // only MaxStamina is saved, Stamina gets reset on entering world
 	NextLevelExp int
@@ -45,20 +56,39 @@ func CreateHeroStatsState(heroClass d2enum.Hero, classStats *d2datadict.CharStat
 		Vitality:     classStats.InitVit,
 		Energy:       classStats.InitEne,
 
-		MaxHealth:  classStats.InitVit * classStats.LifePerVit,
-		MaxMana:    classStats.InitEne * classStats.ManaPerEne,
 		MaxStamina: classStats.InitStamina,
-		// TODO: chance to hit, defense rating
+
+		AttackRating:  attackRating(classStats.InitDex, classStats),
+		DefenseRating: defenseRating(classStats.InitDex),
 	}
 
+	// the life and mana per point of vitality and energy are in quarter
+	// points, as when the hero spends stat points on them
+	result.MaxHealth = gainQuarters(&result.LifeQuarters, classStats.InitVit*classStats.LifePerVit)
+	result.MaxMana = gainQuarters(&result.ManaQuarters, classStats.InitEne*classStats.ManaPerEne)
+
 	result.Mana = result.MaxMana
 	result.Health = result.MaxHealth
 	result.Stamina = result.MaxStamina
 
-	// TODO: For demonstration purposes (hp, mana, exp, & character stats panel gets updated depending on stats)
-	result.Health = 20
-	result.Mana = 9
-	result.Experience = 166
-
 	return &result
 }
+
+// UpdateRatings sets the attack and defense ratings derived from the
+// dexterity of the hero, e.g. after the stats were set from a saved
+// character.
+func (s *HeroStatsState) UpdateRatings(classStats *d2datadict.CharStatsRecord) {
+	s.AttackRating = attackRating(s.Dexterity, classStats)
+	s.DefenseRating = defenseRating(s.Dexterity)
+}
+
+// attackRating returns the base attack rating for the given dexterity,
+// 5 per point of dexterity minus 35 plus the class to-hit factor.
+func attackRating(dexterity int, classStats *d2datadict.CharStatsRecord) int {
+	return dexterity*5 - 35 + classStats.ToHitFactor
+}
+
+// defenseRating returns the base defense for the given dexterity.
+func defenseRating(dexterity int) int {
+	return dexterity / 4
+}
@@ -0,0 +1,574 @@
+package d2inventory
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+)
+
+// the codes of cubemain.txt which stand for items instead of naming them
+const (
+	cubeAnyItem  = "any"     // an input which is any item
+	cubeUseItem  = "useitem" // an output which is the first input, changed
+	cubeUseType  = "usetype" // an output which is a new item of the code of the first input
+	percentScale = 100
+)
+
+// ErrNoRecipe is returned when the items in the Horadric Cube match none of
+// the recipes the player can use.
+var ErrNoRecipe = errors.New("no recipe matches the items in the cube")
+
+// cubeStats are the stats of the heroes which the recipes of cubemain.txt can
+// require, by their ID in ItemStatCost.txt.
+//
+//nolint:gochecknoglobals // constant lookup table
+var cubeStats = map[int]func(stats *d2hero.HeroStatsState) int{
+	0:  func(stats *d2hero.HeroStatsState) int { return stats.Strength },
+	1:  func(stats *d2hero.HeroStatsState) int { return stats.Energy },
+	2:  func(stats *d2hero.HeroStatsState) int { return stats.Dexterity },
+	3:  func(stats *d2hero.HeroStatsState) int { return stats.Vitality },
+	4:  func(stats *d2hero.HeroStatsState) int { return stats.StatPoints },
+	5:  func(stats *d2hero.HeroStatsState) int { return stats.SkillPoints },
+	12: func(stats *d2hero.HeroStatsState) int { return stats.Level },
+	13: func(stats *d2hero.HeroStatsState) int { return stats.Experience },
+}
+
+// cubeOperations are the comparisons of the op column of cubemain.txt between
+// the stat of the param column and the value column. The unusual ones, which
+// compare the stats of items or the state of quests, are not supported.
+//
+//nolint:gochecknoglobals // constant lookup table
+var cubeOperations = map[int]func(stat, value int) bool{
+	3: func(stat, value int) bool { return stat != value },
+	4: func(stat, value int) bool { return stat > value },
+	5: func(stat, value int) bool { return stat < value },
+	6: func(stat, value int) bool { return stat >= value },
+	7: func(stat, value int) bool { return stat <= value },
+	8: func(stat, value int) bool { return stat == value },
+}
+
+// cubeQualifiers are the qualifiers of the inputs of cubemain.txt, besides
+// the qualities, which hasCubeQualifier checks. The recipes with the other
+// qualifiers, such as those of the ethereal items and of the affixes, are
+// not allowed.
+//
+//nolint:gochecknoglobals // constant lookup table
+var cubeQualifiers = map[string]bool{
+	"bas": true, "exc": true, "eli": true, "upg": true,
+	"sock": true, "nos": true, "nru": true, "noe": true,
+}
+
+// cubeParams are the parameters of the outputs of cubemain.txt, besides the
+// qualities, which applyCubeParam applies. The recipes with the other
+// parameters, such as those of the ethereal items, of the affixes and of the
+// charges, are not allowed.
+//
+//nolint:gochecknoglobals // constant lookup table
+var cubeParams = map[string]bool{
+	"exc": true, "eli": true, "upg": true, "rep": true,
+	"mod": true, "sock": true, "uns": true, "rem": true,
+}
+
+//nolint:gochecknoglobals // constant lookup table of the qualifiers of the qualities in cubemain.txt
+var cubeQualities = map[string]d2enum.ItemQuality{
+	"low": d2enum.ItemQualityLow,
+	"nor": d2enum.ItemQualityNormal,
+	"hiq": d2enum.ItemQualitySuperior,
+	"mag": d2enum.ItemQualityMagic,
+	"set": d2enum.ItemQualitySet,
+	"rar": d2enum.ItemQualityRare,
+	"uni": d2enum.ItemQualityUnique,
+	"crf": d2enum.ItemQualityCrafted,
+}
+
+// TransmuteRules tell which recipes of cubemain.txt a player can use, and
+// the level of the items the recipes create for it.
+type TransmuteRules struct {
+	Difficulty d2enum.DifficultyType
+	Hero       d2enum.Hero
+	Level      int  // of the player
+	Ladder     bool // the game is a ladder game
+
+	// the stats of the player, which the stat requirements of the recipes
+	// are compared with
+	Stats *d2hero.HeroStatsState
+}
+
+// FindRecipe returns the first recipe the player can use which the items
+// match, all of them and nothing more. The items are returned in the order
+// of the inputs of the recipe.
+func FindRecipe(items []*Item, rules TransmuteRules) (*d2datadict.CubeRecipeRecord, []*Item, bool) {
+	for _, recipe := range d2datadict.CubeRecipes {
+		if !isRecipeAllowed(recipe, rules) {
+			continue
+		}
+
+		if inputs, ok := matchRecipe(recipe, items); ok {
+			return recipe, inputs, true
+		}
+	}
+
+	return nil, nil, false
+}
+
+// Transmute creates the items of the recipe the items match. The items the
+// recipe uses up are not returned, the item a recipe changes is returned
+// changed, and the new items have no ID yet. The given items are left as
+// they are.
+func Transmute(items []*Item, rules TransmuteRules, rng *rand.Rand) ([]*Item, error) {
+	recipe, inputs, ok := FindRecipe(cloneItems(items), rules)
+	if !ok {
+		return nil, ErrNoRecipe
+	}
+
+	results := make([]*Item, 0, len(recipe.Outputs))
+
+	for idx := range recipe.Outputs {
+		output := &recipe.Outputs[idx]
+		if output.Item.Code == "" {
+			continue
+		}
+
+		results = append(results, createCubeOutput(output, inputs, rules, rng)...)
+	}
+
+	return results, nil
+}
+
+// Transmute transmutes the items in the Horadric Cube, which are replaced by
+// the items the recipe creates. The cube is left as it was if they do not
+// fit in it.
+func (p *PlayerInventory) Transmute(rules TransmuteRules, rng *rand.Rand) error {
+	results, err := Transmute(p.Cube, rules, rng)
+	if err != nil {
+		return err
+	}
+
+	cube := make([]*Item, 0, len(results))
+	nextID := p.NextID
+
+	for _, item := range results {
+		x, y, found := freeGridSlot(cube, item, CubeWidth, CubeHeight)
+		if !found {
+			return ErrInventoryFull
+		}
+
+		if item.ID == 0 {
+			item.ID = nextID
+			nextID++
+		}
+
+		item.SetInventoryGridSlot(x, y)
+		cube = append(cube, item)
+	}
+
+	p.Cube, p.NextID = cube, nextID
+
+	return nil
+}
+
+// isRecipeAllowed returns true if the recipe is enabled for the game and the
+// player, creates items this game has and uses only the qualifiers and the
+// parameters this game supports.
+func isRecipeAllowed(recipe *d2datadict.CubeRecipeRecord, rules TransmuteRules) bool {
+	if !recipe.Enabled || (recipe.Ladder && !rules.Ladder) || recipe.MinDiff > int(rules.Difficulty) {
+		return false
+	}
+
+	if !meetsStatRequirement(recipe, rules.Stats) {
+		return false
+	}
+
+	if len(recipe.Class) > 0 && !hasHero(recipe.Class, rules.Hero) {
+		return false
+	}
+
+	for idx := range recipe.Inputs {
+		if !isSupported(recipe.Inputs[idx].Params, cubeQualifiers) {
+			return false
+		}
+	}
+
+	for idx := range recipe.Outputs {
+		output := &recipe.Outputs[idx].Item
+		if (output.Code != "" && !isCubeOutput(output.Code)) || !isSupported(output.Params, cubeParams) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isSupported returns true if every qualifier or parameter is a quality or
+// is one of the supported ones.
+func isSupported(params []string, supported map[string]bool) bool {
+	for _, param := range params {
+		name, _ := splitCubeQualifier(param)
+		if _, ok := cubeQualities[name]; !ok && !supported[name] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// meetsStatRequirement returns true if the recipe requires no stat of the
+// player, or if the stat compares with the value of the recipe as its
+// operation asks. The recipes with an operation or a stat which is not
+// supported are never allowed.
+func meetsStatRequirement(recipe *d2datadict.CubeRecipeRecord, stats *d2hero.HeroStatsState) bool {
+	if recipe.ReqOperation == 0 {
+		return true
+	}
+
+	compare, ok := cubeOperations[recipe.ReqOperation]
+	stat, known := cubeStats[recipe.ReqStatID]
+
+	if !ok || !known || stats == nil {
+		return false
+	}
+
+	return compare(stat(stats), recipe.ReqValue)
+}
+
+func hasHero(heroes []d2enum.Hero, hero d2enum.Hero) bool {
+	for _, other := range heroes {
+		if other == hero {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isCubeOutput returns true if the output code stands for items this game
+// has. The portals some recipes open are not.
+// TODO: open the portals of the Cow Level and of Pandemonium
+func isCubeOutput(code string) bool {
+	if code == cubeUseItem || code == cubeUseType || d2datadict.CommonItems[code] != nil {
+		return true
+	}
+
+	return len(itemCodesOfType(code)) > 0
+}
+
+// matchRecipe matches each item with an input of the recipe, an input with
+// a count takes as many items. It returns the items in the order of the
+// inputs, false if the items are not exactly what the recipe takes.
+func matchRecipe(recipe *d2datadict.CubeRecipeRecord, items []*Item) ([]*Item, bool) {
+	inputs := make([]*d2datadict.CubeRecipeItem, 0, len(items))
+
+	for idx := range recipe.Inputs {
+		input := &recipe.Inputs[idx]
+		if input.Code == "" {
+			continue
+		}
+
+		for n := 0; n < d2common.MaxInt(input.Count, 1); n++ {
+			inputs = append(inputs, input)
+		}
+	}
+
+	if len(inputs) == 0 || len(inputs) != len(items) {
+		return nil, false
+	}
+
+	matched := make([]*Item, len(inputs))
+	used := make([]bool, len(items))
+
+	// a broad input, e.g. any weapon, may take the item a narrower input
+	// needs, so the other matches are tried before giving up
+	var match func(idx int) bool
+
+	match = func(idx int) bool {
+		if idx == len(inputs) {
+			return true
+		}
+
+		for itemIdx, item := range items {
+			if used[itemIdx] || !matchesCubeInput(item, inputs[idx]) {
+				continue
+			}
+
+			used[itemIdx], matched[idx] = true, item
+
+			if match(idx + 1) {
+				return true
+			}
+
+			used[itemIdx] = false
+		}
+
+		return false
+	}
+
+	if !match(0) {
+		return nil, false
+	}
+
+	return matched, true
+}
+
+// matchesCubeInput returns true if the item is of the code or the type of
+// the input, and has all of its qualifiers.
+func matchesCubeInput(item *Item, input *d2datadict.CubeRecipeItem) bool {
+	if input.Code != cubeAnyItem && input.Code != item.Code && !item.IsOfType(input.Code) {
+		return false
+	}
+
+	for _, qualifier := range input.Params {
+		if !hasCubeQualifier(item, qualifier) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasCubeQualifier returns true if the item has the quality, is of the
+// version of its weapon or armor, or has the sockets the qualifier of an
+// input asks for.
+func hasCubeQualifier(item *Item, qualifier string) bool {
+	name, value := splitCubeQualifier(qualifier)
+
+	if quality, ok := cubeQualities[name]; ok {
+		return item.Quality == quality
+	}
+
+	record := item.Record()
+	if record == nil {
+		return false
+	}
+
+	switch name {
+	case "bas":
+		return record.NormalCode == "" || record.Code == record.NormalCode
+	case "exc":
+		return record.UberCode != "" && record.Code == record.UberCode
+	case "eli":
+		return record.UltraCode != "" && record.Code == record.UltraCode
+	case "upg":
+		return upgradedCode(record) != ""
+	case "sock":
+		if sockets, err := strconv.Atoi(value); err == nil {
+			return item.Sockets == sockets
+		}
+
+		return item.Sockets > 0
+	case "nos":
+		return item.Sockets == 0
+	case "nru":
+		return item.Runeword == ""
+	case "noe":
+		// the items are never ethereal yet
+		return true
+	}
+
+	// isRecipeAllowed does not allow the recipes with the other qualifiers
+	return false
+}
+
+// splitCubeQualifier splits a qualifier such as "sock=3" into its name and
+// its value.
+func splitCubeQualifier(qualifier string) (name, value string) {
+	parts := strings.SplitN(qualifier, "=", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
+
+// createCubeOutput creates the items of an output of a recipe.
+func createCubeOutput(output *d2datadict.CubeRecipeResult, inputs []*Item, rules TransmuteRules,
+	rng *rand.Rand) []*Item {
+	first := inputs[0]
+	count := d2common.MaxInt(output.Item.Count, 1)
+
+	var items []*Item
+
+	switch code := output.Item.Code; {
+	case code == cubeUseItem:
+		items = []*Item{first}
+	case code == cubeUseType:
+		items = createCubeItems(first.Code, count)
+	case d2datadict.CommonItems[code] != nil:
+		items = createCubeItems(code, count)
+	default:
+		codes := itemCodesOfType(code)
+		for n := 0; n < count; n++ {
+			items = append(items, CreateItem(codes[rng.Intn(len(codes))]))
+		}
+	}
+
+	level := cubeOutputLevel(output, first, rules)
+	removed := make([]*Item, 0)
+
+	for _, item := range items {
+		if level > 0 {
+			item.Level = level
+		}
+
+		// the created items have sockets by chance, before the parameters
+		// of the output change them
+		if item != first {
+			item.RollSocketsByChance(rng)
+		}
+
+		for _, param := range output.Item.Params {
+			removed = append(removed, applyCubeParam(item, param, first, rng)...)
+		}
+
+		for _, property := range output.Properties {
+			if property.Code == "" || (property.Chance > 0 && rng.Intn(percentScale) >= property.Chance) {
+				continue
+			}
+
+			value := property.Min
+			if property.Max > property.Min {
+				value += rng.Intn(property.Max - property.Min + 1)
+			}
+
+			item.Properties = append(item.Properties, ItemProperty{Code: property.Code, Param: property.Param, Value: value})
+		}
+	}
+
+	return append(items, removed...)
+}
+
+// createCubeItems creates the given count of items of the code, a stack of
+// them for the stackable items.
+func createCubeItems(code string, count int) []*Item {
+	item := CreateItem(code)
+
+	if record := item.Record(); record != nil && record.Stackable {
+		if count > 1 {
+			item.Quantity = d2common.MinInt(count, record.MaxStack)
+		}
+
+		return []*Item{item}
+	}
+
+	items := []*Item{item}
+	for n := 1; n < count; n++ {
+		items = append(items, CreateItem(code))
+	}
+
+	return items
+}
+
+// cubeOutputLevel returns the item level of the output: the level of the
+// recipe, or else the given shares of the level of the player and of the
+// level of the first input.
+func cubeOutputLevel(output *d2datadict.CubeRecipeResult, first *Item, rules TransmuteRules) int {
+	if output.Level > 0 {
+		return output.Level
+	}
+
+	firstLevel := first.Level
+	if record := first.Record(); firstLevel == 0 && record != nil {
+		firstLevel = record.Level
+	}
+
+	return rules.Level*output.PLevel/percentScale + firstLevel*output.ILevel/percentScale
+}
+
+// applyCubeParam changes the created item as the parameter of the output
+// asks: its quality, its version, its durability, its properties or its
+// sockets. It returns the items the parameter takes out of the sockets.
+func applyCubeParam(item *Item, param string, first *Item, rng *rand.Rand) []*Item {
+	name, value := splitCubeQualifier(param)
+
+	if quality, ok := cubeQualities[name]; ok {
+		item.Quality = quality
+		return nil
+	}
+
+	record := item.Record()
+	if record == nil {
+		return nil
+	}
+
+	switch name {
+	case "exc":
+		upgradeItem(item, record.UberCode)
+	case "eli":
+		upgradeItem(item, record.UltraCode)
+	case "upg":
+		upgradeItem(item, upgradedCode(record))
+	case "rep":
+		item.Repair()
+	case "mod":
+		if item != first {
+			item.Properties = append(item.Properties, first.Properties...)
+		}
+	case "sock":
+		if sockets, err := strconv.Atoi(value); err == nil {
+			item.Sockets = d2common.MinInt(sockets, item.MaxSockets())
+		} else {
+			item.RollSockets(rng)
+		}
+	case "uns":
+		// the gems, runes and jewels are destroyed
+		item.Unsocket()
+	case "rem":
+		return item.Unsocket()
+	}
+
+	// isRecipeAllowed does not allow the recipes with the other parameters
+	return nil
+}
+
+// upgradedCode returns the code of the next version of the weapon or armor,
+// an empty string if it is an elite item or has no versions.
+func upgradedCode(record *d2datadict.ItemCommonRecord) string {
+	switch record.Code {
+	case "":
+		return ""
+	case record.NormalCode:
+		return record.UberCode
+	case record.UberCode:
+		return record.UltraCode
+	}
+
+	return ""
+}
+
+// upgradeItem turns the item into another version of its weapon or armor,
+// with the full durability of that version.
+func upgradeItem(item *Item, code string) {
+	record := d2datadict.CommonItems[code]
+	if record == nil {
+		return
+	}
+
+	item.Code = code
+
+	if !record.NoDurability {
+		item.Durability, item.MaxDurability = record.Durability, record.Durability
+	}
+}
+
+// itemCodesOfType returns the sorted codes of the items of the type, which
+// can be generated.
+func itemCodesOfType(typeCode string) []string {
+	codes := make([]string, 0)
+
+	for code, record := range d2datadict.CommonItems {
+		if record.Spawnable && (d2datadict.IsItemTypeOf(record.Type, typeCode) ||
+			d2datadict.IsItemTypeOf(record.Type2, typeCode)) {
+			codes = append(codes, code)
+		}
+	}
+
+	sort.Strings(codes)
+
+	return codes
+}
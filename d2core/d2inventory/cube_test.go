@@ -0,0 +1,228 @@
+package d2inventory
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+)
+
+func cubeRecipe(description string, inputs []d2datadict.CubeRecipeItem,
+	outputs ...d2datadict.CubeRecipeResult) *d2datadict.CubeRecipeRecord {
+	return &d2datadict.CubeRecipeRecord{
+		Description: description,
+		Enabled:     true,
+		Inputs:      inputs,
+		Outputs:     outputs,
+	}
+}
+
+func loadTestRecipes() {
+	loadTestItems()
+
+	d2datadict.ItemTypes = map[string]*d2datadict.ItemTypeRecord{
+		"axe":  {Code: "axe", Equiv1: "mele"},
+		"mele": {Code: "mele", Equiv1: "weap"},
+		"weap": {Code: "weap"},
+		"hpot": {Code: "hpot"},
+	}
+
+	hellOnly := cubeRecipe("3 potions -> quiver",
+		[]d2datadict.CubeRecipeItem{{Code: "hpot", Count: 3}},
+		d2datadict.CubeRecipeResult{Item: d2datadict.CubeRecipeItem{Code: "aqv", Count: 40}})
+	hellOnly.MinDiff = int(d2enum.DifficultyHell)
+
+	barbarian := cubeRecipe("cap + potion -> magic cap",
+		[]d2datadict.CubeRecipeItem{{Code: "cap", Params: []string{"nor"}}, {Code: "hp1"}},
+		d2datadict.CubeRecipeResult{
+			Item:       d2datadict.CubeRecipeItem{Code: "useitem", Params: []string{"mag"}},
+			Level:      30,
+			Properties: []d2datadict.CubeRecipeItemProperty{{Code: "str", Min: 5, Max: 5}},
+		})
+	barbarian.Class = []d2enum.Hero{d2enum.HeroBarbarian}
+
+	ladder := cubeRecipe("belt -> buckler",
+		[]d2datadict.CubeRecipeItem{{Code: "lbl"}},
+		d2datadict.CubeRecipeResult{Item: d2datadict.CubeRecipeItem{Code: "buc"}})
+	ladder.Ladder = true
+
+	disabled := cubeRecipe("buckler -> cap",
+		[]d2datadict.CubeRecipeItem{{Code: "buc"}},
+		d2datadict.CubeRecipeResult{Item: d2datadict.CubeRecipeItem{Code: "cap"}})
+	disabled.Enabled = false
+
+	// requires a player of level 10 or above
+	leveled := cubeRecipe("shield -> buckler",
+		[]d2datadict.CubeRecipeItem{{Code: "pa1"}},
+		d2datadict.CubeRecipeResult{Item: d2datadict.CubeRecipeItem{Code: "buc"}})
+	leveled.ReqStatID, leveled.ReqOperation, leveled.ReqValue = 12, 6, 10
+
+	// compares a stat with an operation which is not supported
+	unsupported := cubeRecipe("shield + belt -> buckler",
+		[]d2datadict.CubeRecipeItem{{Code: "pa1"}, {Code: "lbl"}},
+		d2datadict.CubeRecipeResult{Item: d2datadict.CubeRecipeItem{Code: "buc"}})
+	unsupported.ReqStatID, unsupported.ReqOperation, unsupported.ReqValue = 12, 27, 1
+
+	d2datadict.CubeRecipes = []*d2datadict.CubeRecipeRecord{
+		hellOnly,
+		barbarian,
+		ladder,
+		disabled,
+		leveled,
+		unsupported,
+		cubeRecipe("staff -> buckler with a prefix",
+			[]d2datadict.CubeRecipeItem{{Code: "sst"}},
+			d2datadict.CubeRecipeResult{Item: d2datadict.CubeRecipeItem{Code: "buc", Params: []string{"pre=100"}}}),
+		cubeRecipe("weapon + axe -> buckler",
+			[]d2datadict.CubeRecipeItem{{Code: "weap"}, {Code: "axe"}, {}},
+			d2datadict.CubeRecipeResult{Item: d2datadict.CubeRecipeItem{Code: "buc", Params: []string{"rep"}}}),
+	}
+}
+
+func TestFindRecipe(t *testing.T) {
+	loadTestRecipes()
+
+	potions := []*Item{CreateItem("hp1"), CreateItem("hp1"), CreateItem("hp1")}
+	capAndPotion := []*Item{CreateItem("hp1"), CreateItem("cap")}
+	magicCap := CreateItem("cap")
+	magicCap.Quality = d2enum.ItemQualityMagic
+
+	tests := []struct {
+		name   string
+		items  []*Item
+		rules  TransmuteRules
+		recipe string
+	}{
+		{"difficulty too low", potions, TransmuteRules{Difficulty: d2enum.DifficultyNightmare}, ""},
+		{"difficulty", potions, TransmuteRules{Difficulty: d2enum.DifficultyHell}, "3 potions -> quiver"},
+		{"too few items", potions[:2], TransmuteRules{Difficulty: d2enum.DifficultyHell}, ""},
+		{"other class", capAndPotion, TransmuteRules{Hero: d2enum.HeroSorceress}, ""},
+		{"class", capAndPotion, TransmuteRules{Hero: d2enum.HeroBarbarian}, "cap + potion -> magic cap"},
+		{"quality", []*Item{CreateItem("hp1"), magicCap}, TransmuteRules{Hero: d2enum.HeroBarbarian}, ""},
+		{"not ladder", []*Item{CreateItem("lbl")}, TransmuteRules{}, ""},
+		{"ladder", []*Item{CreateItem("lbl")}, TransmuteRules{Ladder: true}, "belt -> buckler"},
+		{"disabled", []*Item{CreateItem("buc")}, TransmuteRules{}, ""},
+		{"item types", []*Item{CreateItem("hax"), CreateItem("hax")}, TransmuteRules{}, "weapon + axe -> buckler"},
+		{"not of the type", []*Item{CreateItem("sst"), CreateItem("hax")}, TransmuteRules{}, ""},
+		{"no stats", []*Item{CreateItem("pa1")}, TransmuteRules{}, ""},
+		{"stat too low", []*Item{CreateItem("pa1")}, TransmuteRules{Stats: &d2hero.HeroStatsState{Level: 9}}, ""},
+		{"stat", []*Item{CreateItem("pa1")}, TransmuteRules{Stats: &d2hero.HeroStatsState{Level: 10}},
+			"shield -> buckler"},
+		{"unsupported operation", []*Item{CreateItem("pa1"), CreateItem("lbl")},
+			TransmuteRules{Stats: &d2hero.HeroStatsState{Level: 10}}, ""},
+		{"unsupported parameter", []*Item{CreateItem("sst")}, TransmuteRules{}, ""},
+	}
+
+	for _, test := range tests {
+		recipe, inputs, found := FindRecipe(test.items, test.rules)
+
+		switch {
+		case test.recipe == "" && found:
+			t.Errorf("%s: expected no recipe, got %q", test.name, recipe.Description)
+		case test.recipe == "":
+		case !found:
+			t.Errorf("%s: expected recipe %q, got none", test.name, test.recipe)
+		case recipe.Description != test.recipe || len(inputs) != len(test.items):
+			t.Errorf("%s: expected recipe %q, got %q with %d inputs", test.name, test.recipe,
+				recipe.Description, len(inputs))
+		}
+	}
+}
+
+func TestUnsupportedRecipes(t *testing.T) {
+	loadTestItems()
+
+	recipes := []*d2datadict.CubeRecipeRecord{
+		cubeRecipe("ethereal axe -> buckler",
+			[]d2datadict.CubeRecipeItem{{Code: "hax", Params: []string{"eth"}}},
+			d2datadict.CubeRecipeResult{Item: d2datadict.CubeRecipeItem{Code: "buc"}}),
+		cubeRecipe("axe -> buckler with charges",
+			[]d2datadict.CubeRecipeItem{{Code: "hax"}},
+			d2datadict.CubeRecipeResult{Item: d2datadict.CubeRecipeItem{Code: "buc", Params: []string{"rch"}}}),
+		cubeRecipe("magic axe -> socketed buckler",
+			[]d2datadict.CubeRecipeItem{{Code: "hax", Params: []string{"mag"}}},
+			d2datadict.CubeRecipeResult{Item: d2datadict.CubeRecipeItem{Code: "buc", Params: []string{"sock=2"}}}),
+	}
+
+	for idx, want := range []bool{false, false, true} {
+		if allowed := isRecipeAllowed(recipes[idx], TransmuteRules{}); allowed != want {
+			t.Errorf("%s: expected allowed to be %t, got %t", recipes[idx].Description, want, allowed)
+		}
+	}
+}
+
+func TestTransmute(t *testing.T) {
+	loadTestRecipes()
+
+	rng := rand.New(rand.NewSource(1))
+	potions := []*Item{CreateItem("hp1"), CreateItem("hp1"), CreateItem("hp1")}
+
+	results, err := Transmute(potions, TransmuteRules{Difficulty: d2enum.DifficultyHell}, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 1 || results[0].Code != "aqv" || results[0].Quantity != 40 {
+		t.Errorf("expected a quiver of 40 arrows, got %v", results)
+	}
+
+	if _, err := Transmute(potions, TransmuteRules{}, rng); err != ErrNoRecipe {
+		t.Errorf("expected no recipe in normal difficulty, got %v", err)
+	}
+
+	axe := CreateItem("hax")
+	axe.Durability = 0
+
+	results, err = Transmute([]*Item{axe, CreateItem("hax")}, TransmuteRules{}, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 1 || results[0].Code != "buc" || results[0].Durability != results[0].MaxDurability {
+		t.Errorf("expected a repaired buckler, got %v", results)
+	}
+
+	if axe.Durability != 0 {
+		t.Error("expected the given items to be left as they are")
+	}
+}
+
+func TestPlayerInventoryTransmute(t *testing.T) {
+	loadTestRecipes()
+
+	inventory := CreatePlayerInventory()
+	helm := CreateItem("cap")
+	potion := CreateItem("hp1")
+
+	for _, item := range []*Item{helm, potion} {
+		item.ID = inventory.NextID
+		inventory.NextID++
+		inventory.Cube = append(inventory.Cube, item)
+	}
+
+	rules := TransmuteRules{Hero: d2enum.HeroBarbarian}
+	if err := inventory.Transmute(rules, rand.New(rand.NewSource(1))); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(inventory.Cube) != 1 {
+		t.Fatalf("expected the potion to be used up, got %v", inventory.Cube)
+	}
+
+	magicCap := inventory.Cube[0]
+
+	switch {
+	case magicCap.ID != helm.ID:
+		t.Errorf("expected the cap to keep its ID %d, got %d", helm.ID, magicCap.ID)
+	case magicCap.Quality != d2enum.ItemQualityMagic || magicCap.Level != 30:
+		t.Errorf("expected a magic cap of level 30, got %v", magicCap)
+	case len(magicCap.Properties) != 1 || magicCap.Properties[0] != (ItemProperty{Code: "str", Value: 5}):
+		t.Errorf("expected +5 strength, got %v", magicCap.Properties)
+	}
+
+	if err := inventory.Transmute(rules, rand.New(rand.NewSource(1))); err != ErrNoRecipe {
+		t.Errorf("expected no recipe for a magic cap, got %v", err)
+	}
+}
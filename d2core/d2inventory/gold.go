@@ -0,0 +1,61 @@
+package d2inventory
+
+import (
+	"errors"
+)
+
+const (
+	// the gold a player carries at most, for each of its levels
+	goldPerLevel = 10000
+
+	// the gold a stash holds at most, for every ten levels of the player
+	stashedGoldPerTenLevels = 50000
+)
+
+// Errors of the changes to the gold of a player
+var (
+	ErrNotEnoughGold = errors.New("not enough gold")
+	ErrTooMuchGold   = errors.New("can not hold that much gold")
+)
+
+// MaxGold returns the most gold a player of the given level carries.
+func MaxGold(level int) int {
+	return level * goldPerLevel
+}
+
+// MaxStashedGold returns the most gold the stash of a player of the given
+// level holds.
+func MaxStashedGold(level int) int {
+	return (level/10 + 1) * stashedGoldPerTenLevels
+}
+
+// AddGold gives gold to a player of the given level, or takes it when the
+// amount is negative.
+func (p *PlayerInventory) AddGold(amount, level int) error {
+	switch {
+	case p.Gold+amount < 0:
+		return ErrNotEnoughGold
+	case amount > 0 && p.Gold+amount > MaxGold(level):
+		return ErrTooMuchGold
+	}
+
+	p.Gold += amount
+
+	return nil
+}
+
+// StashGold moves gold of a player of the given level to its stash, or
+// withdraws it from the stash when the amount is negative.
+func (p *PlayerInventory) StashGold(amount, level int) error {
+	switch {
+	case amount > p.Gold || -amount > p.StashedGold:
+		return ErrNotEnoughGold
+	case p.StashedGold+amount > MaxStashedGold(level) || p.Gold-amount > MaxGold(level):
+		return ErrTooMuchGold
+	}
+
+	p.Gold -= amount
+	p.StashedGold += amount
+
+	return nil
+}
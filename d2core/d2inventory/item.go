@@ -0,0 +1,236 @@
+package d2inventory
+
+import (
+	"errors"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+)
+
+// ErrRequirementsNotMet is returned when a player equips an item it can not
+// use
+var ErrRequirementsNotMet = errors.New("item requirements not met")
+
+//nolint:gochecknoglobals // constant lookup table of the item types of itemtypes.txt only a class can use
+var classItemTypes = map[string]d2enum.Hero{
+	"abow": d2enum.HeroAmazon,
+	"aspe": d2enum.HeroAmazon,
+	"ajav": d2enum.HeroAmazon,
+	"h2h":  d2enum.HeroAssassin,
+	"h2h2": d2enum.HeroAssassin,
+	"phlm": d2enum.HeroBarbarian,
+	"pelt": d2enum.HeroDruid,
+	"head": d2enum.HeroNecromancer,
+	"ashd": d2enum.HeroPaladin,
+	"orb":  d2enum.HeroSorceress,
+}
+
+// Potion is what drinking a potion restores. The rejuvenation potions
+// restore a percentage of the maximum life and mana.
+type Potion struct {
+	Life    int
+	Mana    int
+	Percent bool
+}
+
+//nolint:gochecknoglobals // constant lookup table of the potions of misc.txt
+var potions = map[string]Potion{
+	"hp1": {Life: 30},
+	"hp2": {Life: 60},
+	"hp3": {Life: 100},
+	"hp4": {Life: 180},
+	"hp5": {Life: 320},
+	"mp1": {Mana: 20},
+	"mp2": {Mana: 40},
+	"mp3": {Mana: 80},
+	"mp4": {Mana: 150},
+	"mp5": {Mana: 250},
+	"rvs": {Life: 35, Mana: 35, Percent: true},
+	"rvl": {Life: 100, Mana: 100, Percent: true},
+}
+
+// Item is an instance of an item carried by a player. What kind of item it
+// is comes from the record of its code in weapons.txt, armor.txt or misc.txt.
+type Item struct {
+	ID           int                `json:"id"` // unique among the items of a player
+	Code         string             `json:"code"`
+	Quantity     int                `json:"quantity,omitempty"` // of the stackable items, e.g. arrows
+	SlotX        int                `json:"slotX"`              // in the grid or the belt the item is in
+	SlotY        int                `json:"slotY"`
+	Quality      d2enum.ItemQuality `json:"quality,omitempty"`
+	Unidentified bool               `json:"unidentified,omitempty"`
+
+	// the durability left and the full durability, both 0 for the items
+	// which do not wear
+	Durability    int `json:"durability,omitempty"`
+	MaxDurability int `json:"maxDurability,omitempty"`
+
+	Level      int            `json:"level,omitempty"` // the item level, 0 if the item was not generated at a level
+	Properties []ItemProperty `json:"properties,omitempty"`
+
+	// the number of sockets, and the gems, runes and jewels in them in the
+	// order they were socketed
+	Sockets  int     `json:"sockets,omitempty"`
+	Socketed []*Item `json:"socketed,omitempty"`
+
+	// the name in runes.txt of the runeword the item is, and the properties
+	// it got from the runeword
+	Runeword           string         `json:"runeword,omitempty"`
+	RunewordProperties []ItemProperty `json:"runewordProperties,omitempty"`
+}
+
+// ItemProperty is a modifier of an item, e.g. added by a cube recipe.
+type ItemProperty struct {
+	Code  string `json:"code"` // the code in properties.txt
+	Param int    `json:"param,omitempty"`
+	Value int    `json:"value"`
+}
+
+// CreateItem creates an item of the given code. The stackable items come as
+// a full stack, the items which wear with their full durability.
+func CreateItem(code string) *Item {
+	item := &Item{Code: code}
+
+	record := item.Record()
+	if record == nil {
+		return item
+	}
+
+	if record.Stackable {
+		item.Quantity = record.MaxStack
+	}
+
+	if !record.NoDurability {
+		item.Durability, item.MaxDurability = record.Durability, record.Durability
+	}
+
+	return item
+}
+
+// Clone returns a copy of the item.
+func (i *Item) Clone() *Item {
+	clone := *i
+
+	if i.Properties != nil {
+		clone.Properties = append([]ItemProperty(nil), i.Properties...)
+	}
+
+	if i.Socketed != nil {
+		clone.Socketed = cloneItems(i.Socketed)
+	}
+
+	if i.RunewordProperties != nil {
+		clone.RunewordProperties = append([]ItemProperty(nil), i.RunewordProperties...)
+	}
+
+	return &clone
+}
+
+// Record returns the record of the item, nil if its code is unknown.
+func (i *Item) Record() *d2datadict.ItemCommonRecord {
+	return d2datadict.CommonItems[i.Code]
+}
+
+// IsOfType returns true if the item is of the given type in ItemTypes.txt,
+// or of one of its narrower types.
+func (i *Item) IsOfType(typeCode string) bool {
+	record := i.Record()
+	if record == nil {
+		return false
+	}
+
+	return d2datadict.IsItemTypeOf(record.Type, typeCode) || d2datadict.IsItemTypeOf(record.Type2, typeCode)
+}
+
+// MeetsRequirements returns true if a hero of the given class and stats can
+// use the item.
+func (i *Item) MeetsRequirements(hero d2enum.Hero, stats *d2hero.HeroStatsState) bool {
+	record := i.Record()
+	if record == nil || stats == nil {
+		return false
+	}
+
+	for _, itemType := range []string{record.Type, record.Type2} {
+		if class, ok := classItemTypes[itemType]; ok && class != hero {
+			return false
+		}
+	}
+
+	return stats.Level >= record.RequiredLevel &&
+		stats.Strength >= record.RequiredStrength &&
+		stats.Dexterity >= record.RequiredDexterity
+}
+
+// Wear takes a point of durability off the item. It returns false if the
+// item does not wear, or is broken already.
+func (i *Item) Wear() bool {
+	if i.Durability <= 0 {
+		return false
+	}
+
+	i.Durability--
+
+	return true
+}
+
+// Repair restores the full durability of the item.
+func (i *Item) Repair() {
+	i.Durability = i.MaxDurability
+}
+
+// IsBroken returns true if the item wore out.
+func (i *Item) IsBroken() bool {
+	return i.MaxDurability > 0 && i.Durability <= 0
+}
+
+// Potion returns what drinking the item restores, false if it is not a
+// potion.
+func (i *Item) Potion() (Potion, bool) {
+	potion, ok := potions[i.Code]
+	return potion, ok
+}
+
+// InventoryItemName returns the name of the item
+func (i *Item) InventoryItemName() string {
+	if record := i.Record(); record != nil {
+		return record.Name
+	}
+
+	return i.Code
+}
+
+// InventoryItemType returns whether the item is a weapon, an armor or
+// another item
+func (i *Item) InventoryItemType() d2enum.InventoryItemType {
+	if record := i.Record(); record != nil {
+		return record.Source
+	}
+
+	return d2enum.InventoryItemTypeItem
+}
+
+// InventoryGridSize returns the size of the item in the grid
+func (i *Item) InventoryGridSize() (sizeX, sizeY int) {
+	record := i.Record()
+	if record == nil {
+		return 1, 1
+	}
+
+	return record.InventoryWidth, record.InventoryHeight
+}
+
+// InventoryGridSlot returns the grid slot coordinates of the item
+func (i *Item) InventoryGridSlot() (slotX, slotY int) {
+	return i.SlotX, i.SlotY
+}
+
+// SetInventoryGridSlot sets the grid slot coordinates of the item
+func (i *Item) SetInventoryGridSlot(x, y int) {
+	i.SlotX, i.SlotY = x, y
+}
+
+// GetItemCode returns the item code
+func (i *Item) GetItemCode() string {
+	return i.Code
+}
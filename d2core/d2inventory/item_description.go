@@ -0,0 +1,79 @@
+package d2inventory
+
+import (
+	"fmt"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+)
+
+//nolint:gochecknoglobals // constant lookup table of the descriptions of the codes in properties.txt
+var propertyDescriptions = map[string]string{
+	"ac":         "%+d Defense",
+	"ac%":        "%+d%% Enhanced Defense",
+	"att":        "%+d to Attack Rating",
+	"att%":       "%+d%% Bonus to Attack Rating",
+	"block":      "%+d%% Increased Chance of Blocking",
+	"dmg%":       "%+d%% Enhanced Damage",
+	"dmg-min":    "%+d to Minimum Damage",
+	"dmg-max":    "%+d to Maximum Damage",
+	"dmg-fire":   "Adds %d Fire Damage",
+	"dmg-cold":   "Adds %d Cold Damage",
+	"dmg-ltng":   "Adds %d Lightning Damage",
+	"dmg-pois":   "Adds %d Poison Damage",
+	"str":        "%+d to Strength",
+	"dex":        "%+d to Dexterity",
+	"vit":        "%+d to Vitality",
+	"enr":        "%+d to Energy",
+	"hp":         "%+d to Life",
+	"mana":       "%+d to Mana",
+	"res-fire":   "Fire Resist %+d%%",
+	"res-cold":   "Cold Resist %+d%%",
+	"res-ltng":   "Lightning Resist %+d%%",
+	"res-pois":   "Poison Resist %+d%%",
+	"res-all":    "All Resistances %+d",
+	"red-dmg%":   "Damage Reduced by %d%%",
+	"lifesteal":  "%d%% Life Stolen per Hit",
+	"manasteal":  "%d%% Mana Stolen per Hit",
+	"light":      "%+d to Light Radius",
+	"mag%":       "%d%% Better Chance of Getting Magic Items",
+	"gold%":      "%d%% Extra Gold from Monsters",
+	"regen":      "Replenish Life %+d",
+	"regen-mana": "Regenerate Mana %d%%",
+	"swing2":     "%d%% Increased Attack Speed",
+	"move2":      "%d%% Faster Run/Walk",
+	"allskills":  "%+d to All Skills",
+}
+
+// String describes the property as the item tooltip shows it.
+func (p ItemProperty) String() string {
+	if description, ok := propertyDescriptions[p.Code]; ok {
+		return fmt.Sprintf(description, p.Value)
+	}
+
+	return fmt.Sprintf("%s %+d", p.Code, p.Value)
+}
+
+// Description returns the lines of the item tooltip: the name of the item,
+// its sockets and what is in them, and all of its properties.
+func (i *Item) Description() []string {
+	lines := []string{i.InventoryItemName()}
+
+	if record := d2datadict.Runewords[i.Runeword]; record != nil {
+		lines[0] = fmt.Sprintf("%s\n'%s'", d2common.TranslateString(record.RuneName), lines[0])
+	}
+
+	if i.Sockets > 0 {
+		lines = append(lines, fmt.Sprintf("Socketed (%d)", i.Sockets))
+	}
+
+	for _, socketed := range i.Socketed {
+		lines = append(lines, socketed.InventoryItemName())
+	}
+
+	for _, property := range i.AllProperties() {
+		lines = append(lines, property.String())
+	}
+
+	return lines
+}
@@ -0,0 +1,557 @@
+package d2inventory
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+// Sizes of the grids the items of a player are kept in, in slots
+const (
+	InventoryWidth  = 10
+	InventoryHeight = 4
+	StashWidth      = 6
+	StashHeight     = 8
+	CubeWidth       = 3
+	CubeHeight      = 4
+	BeltColumns     = 4
+
+	// the rows of the belt without a belt, and of the belts not listed
+	defaultBeltRows = 1
+	fullBeltRows    = 4
+)
+
+// ItemStore tells where the items of a player are kept
+type ItemStore int
+
+// Item stores
+const (
+	StoreInventory ItemStore = iota // the grid of the inventory panel
+	StoreEquipped                   // the equipment slots of the inventory panel
+	StoreStash                      // the stash in town
+	StoreBelt                       // the belt, for potions
+	StoreCursor                     // picked up with the mouse cursor
+	StoreCube                       // the Horadric Cube
+)
+
+// ItemLocation is where an item of a player is kept
+type ItemLocation struct {
+	Store ItemStore           `json:"store"`
+	X     int                 `json:"x"` // the slot in the grid or the belt
+	Y     int                 `json:"y"`
+	Slot  d2enum.EquippedSlot `json:"slot"` // the equipment slot
+}
+
+// Errors of the changes to an inventory
+var (
+	ErrItemNotFound  = errors.New("item not found")
+	ErrItemDoesntFit = errors.New("item does not fit there")
+	ErrInventoryFull = errors.New("inventory full")
+	ErrCursorFull    = errors.New("an item is held with the cursor already")
+	ErrNotUsable     = errors.New("item can not be used")
+)
+
+//nolint:gochecknoglobals // constant lookup table of the item types each slot holds, besides weapons
+var equippedSlotTypes = map[d2enum.EquippedSlot][]string{
+	d2enum.EquippedSlotHead:      {"helm", "pelt", "phlm", "circ"},
+	d2enum.EquippedSlotTorso:     {"tors"},
+	d2enum.EquippedSlotLegs:      {"boot"},
+	d2enum.EquippedSlotGloves:    {"glov"},
+	d2enum.EquippedSlotBelt:      {"belt"},
+	d2enum.EquippedSlotNeck:      {"amul"},
+	d2enum.EquippedSlotLeftHand:  {"ring"},
+	d2enum.EquippedSlotRightHand: {"ring"},
+	d2enum.EquippedSlotLeftArm:   {"shie", "ashd", "head"},
+	d2enum.EquippedSlotRightArm:  {"shie", "ashd", "head"},
+}
+
+//nolint:gochecknoglobals // constant lookup table of the rows of the normal belts, the others hold four
+var beltRows = map[string]int{
+	"lbl": 2, // sash
+	"vbl": 2, // light belt
+	"mbl": 3, // belt
+	"tbl": 3, // heavy belt
+}
+
+// PlayerInventory holds all items of a player: the items in the inventory
+// panel, the equipped items, the stash, the belt and the Horadric Cube.
+type PlayerInventory struct {
+	Inventory []*Item                       `json:"inventory"`
+	Equipped  map[d2enum.EquippedSlot]*Item `json:"equipped"`
+	Stash     []*Item                       `json:"stash"`
+	Belt      []*Item                       `json:"belt"`
+	Cursor    *Item                         `json:"cursor,omitempty"`
+	Cube      []*Item                       `json:"cube"`
+	NextID    int                           `json:"nextId"`
+
+	// the gold carried by the player and the gold in its stash
+	Gold        int `json:"gold"`
+	StashedGold int `json:"stashedGold"`
+}
+
+// CreatePlayerInventory creates an empty inventory.
+func CreatePlayerInventory() *PlayerInventory {
+	return &PlayerInventory{
+		Inventory: make([]*Item, 0),
+		Equipped:  make(map[d2enum.EquippedSlot]*Item),
+		Stash:     make([]*Item, 0),
+		Belt:      make([]*Item, 0),
+		Cube:      make([]*Item, 0),
+		NextID:    1,
+	}
+}
+
+// CreateEquippedInventory creates an inventory which holds the weapons and
+// the shield of the given equipment, e.g. the equipment a new hero starts
+// with in HeroObjects. The items which can not be equipped are put in the
+// inventory panel.
+func CreateEquippedInventory(equipment CharacterEquipment) *PlayerInventory {
+	inventory := CreatePlayerInventory()
+
+	inventory.equip(equipment.RightHand.GetItemCode(), d2enum.EquippedSlotLeftArm)
+	inventory.equip(equipment.LeftHand.GetItemCode(), d2enum.EquippedSlotRightArm)
+	inventory.equip(equipment.Shield.GetItemCode(), d2enum.EquippedSlotRightArm)
+
+	return inventory
+}
+
+// Clone returns a copy of the inventory which holds copies of the items.
+func (p *PlayerInventory) Clone() *PlayerInventory {
+	clone := &PlayerInventory{
+		Inventory: cloneItems(p.Inventory),
+		Equipped:  make(map[d2enum.EquippedSlot]*Item, len(p.Equipped)),
+		Stash:     cloneItems(p.Stash),
+		Belt:      cloneItems(p.Belt),
+		Cube:      cloneItems(p.Cube),
+		NextID:    p.NextID,
+
+		Gold:        p.Gold,
+		StashedGold: p.StashedGold,
+	}
+
+	for slot, item := range p.Equipped {
+		if item != nil {
+			clone.Equipped[slot] = item.Clone()
+		}
+	}
+
+	if p.Cursor != nil {
+		clone.Cursor = p.Cursor.Clone()
+	}
+
+	return clone
+}
+
+// Add puts an item in the first free slot of the inventory panel.
+func (p *PlayerInventory) Add(item *Item) error {
+	width, height := item.InventoryGridSize()
+
+	for y := 0; y+height <= InventoryHeight; y++ {
+		for x := 0; x+width <= InventoryWidth; x++ {
+			location := ItemLocation{Store: StoreInventory, X: x, Y: y}
+			if p.Place(item, location) == nil {
+				return nil
+			}
+		}
+	}
+
+	return ErrInventoryFull
+}
+
+// Place puts an item at the given location, if it fits there. The item gets
+// an ID if it has none.
+func (p *PlayerInventory) Place(item *Item, location ItemLocation) error {
+	if !p.fits(item, location) {
+		return ErrItemDoesntFit
+	}
+
+	if item.ID == 0 {
+		item.ID = p.NextID
+		p.NextID++
+	}
+
+	if p.Equipped == nil {
+		p.Equipped = make(map[d2enum.EquippedSlot]*Item)
+	}
+
+	switch location.Store {
+	case StoreInventory:
+		item.SetInventoryGridSlot(location.X, location.Y)
+		p.Inventory = append(p.Inventory, item)
+	case StoreStash:
+		item.SetInventoryGridSlot(location.X, location.Y)
+		p.Stash = append(p.Stash, item)
+	case StoreBelt:
+		item.SetInventoryGridSlot(location.X, location.Y)
+		p.Belt = append(p.Belt, item)
+	case StoreCube:
+		item.SetInventoryGridSlot(location.X, location.Y)
+		p.Cube = append(p.Cube, item)
+	case StoreEquipped:
+		item.SetInventoryGridSlot(0, 0)
+		p.Equipped[location.Slot] = item
+	case StoreCursor:
+		item.SetInventoryGridSlot(0, 0)
+		p.Cursor = item
+	}
+
+	return nil
+}
+
+// Find returns the item with the given ID and where it is kept.
+func (p *PlayerInventory) Find(id int) (*Item, ItemLocation, bool) {
+	stores := map[ItemStore][]*Item{StoreInventory: p.Inventory, StoreStash: p.Stash, StoreBelt: p.Belt, StoreCube: p.Cube}
+
+	for store, items := range stores {
+		for _, item := range items {
+			if item.ID == id {
+				return item, ItemLocation{Store: store, X: item.SlotX, Y: item.SlotY}, true
+			}
+		}
+	}
+
+	for slot, item := range p.Equipped {
+		if item != nil && item.ID == id {
+			return item, ItemLocation{Store: StoreEquipped, Slot: slot}, true
+		}
+	}
+
+	if p.Cursor != nil && p.Cursor.ID == id {
+		return p.Cursor, ItemLocation{Store: StoreCursor}, true
+	}
+
+	return nil, ItemLocation{}, false
+}
+
+// Remove takes the item with the given ID out of the inventory.
+func (p *PlayerInventory) Remove(id int) (*Item, error) {
+	item, location, found := p.Find(id)
+	if !found {
+		return nil, ErrItemNotFound
+	}
+
+	switch location.Store {
+	case StoreInventory:
+		p.Inventory = removeItem(p.Inventory, item)
+	case StoreStash:
+		p.Stash = removeItem(p.Stash, item)
+	case StoreBelt:
+		p.Belt = removeItem(p.Belt, item)
+	case StoreCube:
+		p.Cube = removeItem(p.Cube, item)
+	case StoreEquipped:
+		delete(p.Equipped, location.Slot)
+	case StoreCursor:
+		p.Cursor = nil
+	}
+
+	return item, nil
+}
+
+// Move moves the item with the given ID to another location. An item held
+// with the cursor is swapped with the single item it is put on, which is
+// then held with the cursor. The items are left where they were if the item
+// does not fit there, or if the potions would not fit in the belt anymore.
+func (p *PlayerInventory) Move(id int, to ItemLocation) error {
+	_, from, found := p.Find(id)
+	if !found {
+		return ErrItemNotFound
+	}
+
+	if to.Store == StoreCursor && from.Store != StoreCursor && p.Cursor != nil {
+		return ErrCursorFull
+	}
+
+	item, err := p.Remove(id)
+	if err != nil {
+		return err
+	}
+
+	var (
+		swapped     *Item
+		swappedFrom ItemLocation
+	)
+
+	if from.Store == StoreCursor {
+		if swapped = p.swappedItem(item, to); swapped != nil {
+			_, swappedFrom, _ = p.Find(swapped.ID)
+			_, _ = p.Remove(swapped.ID)
+		}
+	}
+
+	err = p.Place(item, to)
+	if err == nil && swapped != nil {
+		err = p.Place(swapped, ItemLocation{Store: StoreCursor})
+	}
+
+	if err == nil {
+		for _, potion := range p.Belt {
+			if potion.SlotY >= p.BeltRows() {
+				err = ErrItemDoesntFit
+			}
+		}
+	}
+
+	if err != nil {
+		if _, _, found := p.Find(id); found {
+			_, _ = p.Remove(id)
+		}
+
+		if swapped != nil {
+			_, _ = p.Remove(swapped.ID)
+			_ = p.Place(swapped, swappedFrom)
+		}
+
+		_ = p.Place(item, from)
+
+		return err
+	}
+
+	return nil
+}
+
+// WearArmor takes a point of durability off one of the equipped armor pieces,
+// picked at random. It returns true if an armor piece wore.
+func (p *PlayerInventory) WearArmor(rng *rand.Rand) bool {
+	armor := make([]*Item, 0, len(p.Equipped))
+
+	for slot := d2enum.EquippedSlotHead; slot <= d2enum.EquippedSlotGloves; slot++ {
+		if item := p.Equipped[slot]; item != nil && item.Durability > 0 &&
+			item.InventoryItemType() == d2enum.InventoryItemTypeArmor {
+			armor = append(armor, item)
+		}
+	}
+
+	if len(armor) == 0 {
+		return false
+	}
+
+	return armor[rng.Intn(len(armor))].Wear()
+}
+
+// FreeBeltSlot returns the first slot of the belt the item fits in.
+func (p *PlayerInventory) FreeBeltSlot(item *Item) (ItemLocation, bool) {
+	for y := 0; y < p.BeltRows(); y++ {
+		for x := 0; x < BeltColumns; x++ {
+			location := ItemLocation{Store: StoreBelt, X: x, Y: y}
+			if p.fits(item, location) {
+				return location, true
+			}
+		}
+	}
+
+	return ItemLocation{}, false
+}
+
+// BeltRows returns the number of rows of potions the equipped belt holds.
+func (p *PlayerInventory) BeltRows() int {
+	belt := p.Equipped[d2enum.EquippedSlotBelt]
+	if belt == nil {
+		return defaultBeltRows
+	}
+
+	if rows, ok := beltRows[belt.Code]; ok {
+		return rows
+	}
+
+	return fullBeltRows
+}
+
+// CharacterEquipment returns what the equipped items look like on the
+// player.
+func (p *PlayerInventory) CharacterEquipment() CharacterEquipment {
+	equipment := CharacterEquipment{}
+
+	if item := p.Equipped[d2enum.EquippedSlotHead]; item != nil {
+		equipment.Head = GetArmorItemByCode(item.Code)
+	}
+
+	if item := p.Equipped[d2enum.EquippedSlotTorso]; item != nil {
+		equipment.Torso = GetArmorItemByCode(item.Code)
+	}
+
+	if item := p.Equipped[d2enum.EquippedSlotLeftArm]; item != nil && item.InventoryItemType() == d2enum.InventoryItemTypeWeapon {
+		equipment.RightHand = GetWeaponItemByCode(item.Code)
+	}
+
+	if item := p.Equipped[d2enum.EquippedSlotRightArm]; item != nil {
+		if item.InventoryItemType() == d2enum.InventoryItemTypeWeapon {
+			equipment.LeftHand = GetWeaponItemByCode(item.Code)
+		} else {
+			equipment.Shield = GetArmorItemByCode(item.Code)
+		}
+	}
+
+	return equipment
+}
+
+// swappedItem returns the single item in the way of putting the item at the
+// given location, nil if there is none or more than one.
+func (p *PlayerInventory) swappedItem(item *Item, location ItemLocation) *Item {
+	var items []*Item
+
+	switch location.Store {
+	case StoreInventory:
+		items = p.Inventory
+	case StoreStash:
+		items = p.Stash
+	case StoreBelt:
+		items = p.Belt
+	case StoreCube:
+		items = p.Cube
+	case StoreEquipped:
+		return p.Equipped[location.Slot]
+	default:
+		return nil
+	}
+
+	var swapped *Item
+
+	for _, other := range items {
+		if overlaps(item, location.X, location.Y, other) {
+			if swapped != nil {
+				return nil
+			}
+
+			swapped = other
+		}
+	}
+
+	return swapped
+}
+
+// equip puts a new item of the given code in the equipment slot, or in the
+// inventory panel if it can not be equipped there.
+func (p *PlayerInventory) equip(code string, slot d2enum.EquippedSlot) {
+	if code == "" {
+		return
+	}
+
+	item := CreateItem(code)
+	if p.Place(item, ItemLocation{Store: StoreEquipped, Slot: slot}) != nil {
+		_ = p.Add(item)
+	}
+}
+
+// fits returns true if the item can be put at the given location.
+func (p *PlayerInventory) fits(item *Item, location ItemLocation) bool {
+	record := item.Record()
+	if record == nil {
+		return false
+	}
+
+	switch location.Store {
+	case StoreInventory:
+		return gridFits(p.Inventory, item, location.X, location.Y, InventoryWidth, InventoryHeight)
+	case StoreStash:
+		return gridFits(p.Stash, item, location.X, location.Y, StashWidth, StashHeight)
+	case StoreBelt:
+		return record.AutoBelt && gridFits(p.Belt, item, location.X, location.Y, BeltColumns, p.BeltRows())
+	case StoreCube:
+		return gridFits(p.Cube, item, location.X, location.Y, CubeWidth, CubeHeight)
+	case StoreEquipped:
+		return p.canEquip(item, location.Slot)
+	case StoreCursor:
+		return p.Cursor == nil
+	}
+
+	return false
+}
+
+// canEquip returns true if the equipment slot is free and holds this kind of
+// item. A weapon held in two hands takes both arms.
+func (p *PlayerInventory) canEquip(item *Item, slot d2enum.EquippedSlot) bool {
+	if p.Equipped[slot] != nil {
+		return false
+	}
+
+	record := item.Record()
+	isArm := slot == d2enum.EquippedSlotLeftArm || slot == d2enum.EquippedSlotRightArm
+
+	if isArm {
+		otherArm := d2enum.EquippedSlotLeftArm
+		if slot == otherArm {
+			otherArm = d2enum.EquippedSlotRightArm
+		}
+
+		if other := p.Equipped[otherArm]; other != nil && (record.UsesTwoHands || other.Record().UsesTwoHands) {
+			return false
+		}
+
+		if record.Source == d2enum.InventoryItemTypeWeapon {
+			return true
+		}
+	}
+
+	for _, itemType := range equippedSlotTypes[slot] {
+		if record.Type == itemType || record.Type2 == itemType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// gridFits returns true if the item is inside the grid of the given size,
+// and does not overlap the items already in it.
+func gridFits(items []*Item, item *Item, x, y, width, height int) bool {
+	itemWidth, itemHeight := item.InventoryGridSize()
+	if x < 0 || y < 0 || x+itemWidth > width || y+itemHeight > height {
+		return false
+	}
+
+	for _, other := range items {
+		if overlaps(item, x, y, other) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// freeGridSlot returns the first slot of a grid of the given size the item
+// fits in, next to the other items of the grid.
+func freeGridSlot(items []*Item, item *Item, width, height int) (int, int, bool) {
+	itemWidth, itemHeight := item.InventoryGridSize()
+
+	for y := 0; y+itemHeight <= height; y++ {
+		for x := 0; x+itemWidth <= width; x++ {
+			if gridFits(items, item, x, y, width, height) {
+				return x, y, true
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// overlaps returns true if the item put at the given slot would overlap the
+// other item.
+func overlaps(item *Item, x, y int, other *Item) bool {
+	width, height := item.InventoryGridSize()
+	otherX, otherY := other.InventoryGridSlot()
+	otherWidth, otherHeight := other.InventoryGridSize()
+
+	return x < otherX+otherWidth && otherX < x+width && y < otherY+otherHeight && otherY < y+height
+}
+
+func cloneItems(items []*Item) []*Item {
+	clones := make([]*Item, len(items))
+
+	for idx, item := range items {
+		clones[idx] = item.Clone()
+	}
+
+	return clones
+}
+
+func removeItem(items []*Item, item *Item) []*Item {
+	for idx := range items {
+		if items[idx] == item {
+			return append(items[:idx:idx], items[idx+1:]...)
+		}
+	}
+
+	return items
+}
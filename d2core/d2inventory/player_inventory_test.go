@@ -0,0 +1,225 @@
+package d2inventory
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+)
+
+func loadTestItems() {
+	weapon := d2enum.InventoryItemTypeWeapon
+	armor := d2enum.InventoryItemTypeArmor
+	item := d2enum.InventoryItemTypeItem
+
+	d2datadict.Weapons = map[string]*d2datadict.ItemCommonRecord{
+		"hax": {Code: "hax", Source: weapon, Type: "axe", InventoryWidth: 1, InventoryHeight: 3},
+		"sst": {Code: "sst", Source: weapon, Type: "staf", InventoryWidth: 1, InventoryHeight: 3, UsesTwoHands: true},
+	}
+	d2datadict.Armors = map[string]*d2datadict.ItemCommonRecord{
+		"buc": {Code: "buc", Source: armor, Type: "shie", InventoryWidth: 2, InventoryHeight: 2},
+		"pa1": {Code: "pa1", Source: armor, Type: "ashd", InventoryWidth: 2, InventoryHeight: 2, RequiredStrength: 25},
+		"cap": {Code: "cap", Source: armor, Type: "helm", InventoryWidth: 2, InventoryHeight: 2},
+		"lbl": {Code: "lbl", Source: armor, Type: "belt", InventoryWidth: 2, InventoryHeight: 1},
+	}
+	misc := map[string]*d2datadict.ItemCommonRecord{
+		"hp1": {Code: "hp1", Source: item, Type: "hpot", InventoryWidth: 1, InventoryHeight: 1, AutoBelt: true},
+		"aqv": {Code: "aqv", Source: item, Type: "bowq", InventoryWidth: 1, InventoryHeight: 3, Stackable: true, MaxStack: 250},
+	}
+
+	d2datadict.CommonItems = make(map[string]*d2datadict.ItemCommonRecord)
+
+	for _, records := range []map[string]*d2datadict.ItemCommonRecord{d2datadict.Weapons, d2datadict.Armors, misc} {
+		for code, record := range records {
+			d2datadict.CommonItems[code] = record
+		}
+	}
+}
+
+func TestCreateEquippedInventory(t *testing.T) {
+	loadTestItems()
+
+	inventory := CreateEquippedInventory(CharacterEquipment{
+		RightHand: GetWeaponItemByCode("hax"),
+		Shield:    GetArmorItemByCode("buc"),
+	})
+
+	if item := inventory.Equipped[d2enum.EquippedSlotLeftArm]; item == nil || item.Code != "hax" {
+		t.Errorf("expected the axe in the weapon hand, got %v", item)
+	}
+
+	if item := inventory.Equipped[d2enum.EquippedSlotRightArm]; item == nil || item.Code != "buc" {
+		t.Errorf("expected the buckler in the shield hand, got %v", item)
+	}
+
+	// a staff takes both hands, the second one goes in the inventory panel
+	inventory = CreateEquippedInventory(CharacterEquipment{
+		RightHand: GetWeaponItemByCode("sst"),
+		LeftHand:  GetWeaponItemByCode("sst"),
+	})
+
+	if inventory.Equipped[d2enum.EquippedSlotRightArm] != nil || len(inventory.Inventory) != 1 {
+		t.Errorf("expected the second staff in the inventory panel, got %v", inventory.Inventory)
+	}
+
+	if quiver := CreateItem("aqv"); quiver.Quantity != 250 {
+		t.Errorf("expected a full stack of arrows, got %d", quiver.Quantity)
+	}
+}
+
+func TestMoveItem(t *testing.T) {
+	loadTestItems()
+
+	inventory := CreatePlayerInventory()
+	helm, shield := CreateItem("cap"), CreateItem("buc")
+
+	if inventory.Add(helm) != nil || inventory.Add(shield) != nil {
+		t.Fatal("expected the items to fit in the inventory panel")
+	}
+
+	if helm.ID == shield.ID {
+		t.Fatal("expected the items to get unique IDs")
+	}
+
+	if shield.SlotX != 2 || shield.SlotY != 0 {
+		t.Errorf("expected the buckler next to the cap, got %d,%d", shield.SlotX, shield.SlotY)
+	}
+
+	tests := []struct {
+		name string
+		item *Item
+		to   ItemLocation
+		err  error
+	}{
+		{"overlaps", helm, ItemLocation{Store: StoreInventory, X: 3, Y: 1}, ErrItemDoesntFit},
+		{"outside", helm, ItemLocation{Store: StoreInventory, X: 9, Y: 0}, ErrItemDoesntFit},
+		{"wrong slot", helm, ItemLocation{Store: StoreEquipped, Slot: d2enum.EquippedSlotTorso}, ErrItemDoesntFit},
+		{"not a potion", helm, ItemLocation{Store: StoreBelt}, ErrItemDoesntFit},
+		{"equip", helm, ItemLocation{Store: StoreEquipped, Slot: d2enum.EquippedSlotHead}, nil},
+		{"stash", shield, ItemLocation{Store: StoreStash, X: 4, Y: 6}, nil},
+		{"unknown", &Item{ID: 99}, ItemLocation{}, ErrItemNotFound},
+	}
+
+	for _, test := range tests {
+		_, from, _ := inventory.Find(test.item.ID)
+		if err := inventory.Move(test.item.ID, test.to); err != test.err {
+			t.Errorf("%s: expected %v, got %v", test.name, test.err, err)
+		}
+
+		expected := test.to
+		if test.err != nil {
+			expected = from
+		}
+
+		if _, location, found := inventory.Find(test.item.ID); found && location != expected {
+			t.Errorf("%s: expected the item at %v, got %v", test.name, expected, location)
+		}
+	}
+
+	if len(inventory.Inventory) != 0 || len(inventory.Stash) != 1 {
+		t.Errorf("expected the items out of the inventory panel, got %v", inventory.Inventory)
+	}
+
+	if equipment := inventory.CharacterEquipment(); equipment.Head.GetItemCode() != "cap" {
+		t.Errorf("expected the cap to be seen on the head, got %v", equipment.Head)
+	}
+}
+
+func TestBeltRows(t *testing.T) {
+	loadTestItems()
+
+	inventory := CreatePlayerInventory()
+	belt, potion := CreateItem("lbl"), CreateItem("hp1")
+
+	_ = inventory.Add(belt)
+	_ = inventory.Add(potion)
+
+	if inventory.Move(potion.ID, ItemLocation{Store: StoreBelt, X: 3, Y: 1}) != ErrItemDoesntFit {
+		t.Error("expected a single row of potions without a belt")
+	}
+
+	if inventory.Move(belt.ID, ItemLocation{Store: StoreEquipped, Slot: d2enum.EquippedSlotBelt}) != nil {
+		t.Fatal("expected the sash to be equipped")
+	}
+
+	if inventory.Move(potion.ID, ItemLocation{Store: StoreBelt, X: 3, Y: 1}) != nil {
+		t.Fatal("expected two rows of potions in the sash")
+	}
+
+	// the potion would not fit in the belt anymore
+	if inventory.Move(belt.ID, ItemLocation{Store: StoreInventory}) != ErrItemDoesntFit {
+		t.Error("expected the sash to stay equipped while it holds the potion")
+	}
+
+	if inventory.Equipped[d2enum.EquippedSlotBelt] != belt {
+		t.Error("expected the sash back in the belt slot")
+	}
+}
+
+func TestSwapWithCursor(t *testing.T) {
+	loadTestItems()
+
+	inventory := CreatePlayerInventory()
+	axe, shield, potion := CreateItem("hax"), CreateItem("buc"), CreateItem("hp1")
+
+	_ = inventory.Place(axe, ItemLocation{Store: StoreEquipped, Slot: d2enum.EquippedSlotLeftArm})
+	_ = inventory.Add(shield)
+	_ = inventory.Add(potion)
+
+	if inventory.Move(shield.ID, ItemLocation{Store: StoreCursor}) != nil || inventory.Cursor != shield {
+		t.Fatal("expected the buckler to be picked up")
+	}
+
+	if inventory.Move(potion.ID, ItemLocation{Store: StoreCursor}) != ErrCursorFull {
+		t.Error("expected a single item held with the cursor")
+	}
+
+	// the buckler is put on the axe, the axe is held instead
+	if inventory.Move(shield.ID, ItemLocation{Store: StoreEquipped, Slot: d2enum.EquippedSlotLeftArm}) != nil {
+		t.Fatal("expected the buckler to be swapped with the axe")
+	}
+
+	if inventory.Cursor != axe || inventory.Equipped[d2enum.EquippedSlotLeftArm] != shield {
+		t.Errorf("expected the axe held with the cursor, got %v", inventory.Cursor)
+	}
+
+	// the axe is put on the potion in the inventory panel
+	if inventory.Move(axe.ID, ItemLocation{Store: StoreInventory, X: potion.SlotX, Y: potion.SlotY}) != nil {
+		t.Fatal("expected the axe to be swapped with the potion")
+	}
+
+	if inventory.Cursor != potion || len(inventory.Inventory) != 1 {
+		t.Errorf("expected the potion held with the cursor, got %v", inventory.Cursor)
+	}
+
+	location, ok := inventory.FreeBeltSlot(potion)
+	if !ok || inventory.Move(potion.ID, location) != nil || inventory.Cursor != nil {
+		t.Error("expected the potion to be put in the belt")
+	}
+}
+
+func TestMeetsRequirements(t *testing.T) {
+	loadTestItems()
+
+	stats := &d2hero.HeroStatsState{Level: 1, Strength: 20}
+	shield := CreateItem("pa1")
+
+	if shield.MeetsRequirements(d2enum.HeroPaladin, stats) {
+		t.Error("expected the shield to require more strength")
+	}
+
+	stats.Strength = 25
+
+	if !shield.MeetsRequirements(d2enum.HeroPaladin, stats) {
+		t.Error("expected the paladin to use the shield")
+	}
+
+	if shield.MeetsRequirements(d2enum.HeroBarbarian, stats) {
+		t.Error("expected the shield to be for paladins only")
+	}
+
+	if potion, ok := CreateItem("rvl").Potion(); !ok || !potion.Percent || potion.Life != 100 {
+		t.Errorf("expected the full rejuvenation potion, got %v", potion)
+	}
+}
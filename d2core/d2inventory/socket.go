@@ -0,0 +1,274 @@
+package d2inventory
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+// SocketedChance is the chance of a dropped, sold or transmuted item which can
+// have sockets to have them, in percent.
+const SocketedChance = 10
+
+// Errors of the sockets
+var (
+	ErrNotSocketable = errors.New("only gems, runes and jewels go into sockets")
+	ErrNoFreeSocket  = errors.New("the item has no free socket")
+)
+
+// the mods of gems.txt an item gets from its gems and runes, see the
+// gemapplytype column of weapons.txt, armor.txt and misc.txt
+const (
+	gemApplyWeapon = iota
+	gemApplyArmor
+	gemApplyShield
+)
+
+//nolint:gochecknoglobals // constant list of the item types of ItemTypes.txt which go into sockets
+var socketableTypes = []string{"gem", "rune", "jewl"}
+
+// IsSocketable returns true if the item is a gem, a rune or a jewel.
+func (i *Item) IsSocketable() bool {
+	for _, itemType := range socketableTypes {
+		if i.IsOfType(itemType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FreeSockets returns the number of empty sockets of the item.
+func (i *Item) FreeSockets() int {
+	return i.Sockets - len(i.Socketed)
+}
+
+// MaxSockets returns the most sockets the item can have at its item level,
+// the fewest of the limits of its record and of its item type.
+func (i *Item) MaxSockets() int {
+	record := i.Record()
+	if record == nil {
+		return 0
+	}
+
+	level := i.Level
+	if level == 0 {
+		level = record.Level
+	}
+
+	sockets := record.GemSockets
+	if itemType := d2datadict.ItemTypes[record.Type]; itemType != nil {
+		sockets = d2common.MinInt(sockets, itemType.MaxSockets(level))
+	}
+
+	return sockets
+}
+
+// RollSockets gives the item between one and the most sockets it can have.
+// The items which have sockets already keep them.
+func (i *Item) RollSockets(rng *rand.Rand) {
+	if i.Sockets > 0 {
+		return
+	}
+
+	if sockets := i.MaxSockets(); sockets > 0 {
+		i.Sockets = 1 + rng.Intn(sockets)
+	}
+}
+
+// RollSocketsByChance gives the item sockets as RollSockets does, by the
+// SocketedChance.
+func (i *Item) RollSocketsByChance(rng *rand.Rand) {
+	if rng.Intn(percentScale) < SocketedChance {
+		i.RollSockets(rng)
+	}
+}
+
+// Socket puts the gem, rune or jewel into the first free socket of the item.
+// The item becomes a runeword once its sockets are filled with the runes of
+// one, in order.
+func (i *Item) Socket(socketable *Item, rng *rand.Rand) error {
+	if !socketable.IsSocketable() {
+		return ErrNotSocketable
+	}
+
+	if i.FreeSockets() <= 0 {
+		return ErrNoFreeSocket
+	}
+
+	socketable.SlotX, socketable.SlotY = len(i.Socketed), 0
+	i.Socketed = append(i.Socketed, socketable)
+
+	if record := i.FindRuneword(); record != nil {
+		i.Runeword = record.Name
+		i.RunewordProperties = make([]ItemProperty, 0, len(record.Properties))
+
+		for _, property := range record.Properties {
+			value := property.Min
+			if property.Max > property.Min {
+				value += rng.Intn(property.Max - property.Min + 1)
+			}
+
+			i.RunewordProperties = append(i.RunewordProperties,
+				ItemProperty{Code: property.Code, Param: property.Param, Value: value})
+		}
+	}
+
+	return nil
+}
+
+// Unsocket takes the gems, runes and jewels out of the sockets of the item,
+// which stops being a runeword.
+func (i *Item) Unsocket() []*Item {
+	socketed := i.Socketed
+	i.Socketed, i.Runeword, i.RunewordProperties = nil, "", nil
+
+	return socketed
+}
+
+// FindRuneword returns the runeword the runes in the filled sockets of the
+// item make, nil if they make none. Only the items of normal or superior
+// quality become runewords.
+func (i *Item) FindRuneword() *d2datadict.RunesRecord {
+	if len(i.Socketed) == 0 || i.FreeSockets() != 0 || i.Quality > d2enum.ItemQualitySuperior {
+		return nil
+	}
+
+	names := make([]string, 0, len(d2datadict.Runewords))
+	for name := range d2datadict.Runewords {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if record := d2datadict.Runewords[name]; i.isRuneword(record) {
+			return record
+		}
+	}
+
+	return nil
+}
+
+// isRuneword returns true if the item is of a type of the runeword and has
+// its runes in its sockets, in order.
+func (i *Item) isRuneword(record *d2datadict.RunesRecord) bool {
+	if !record.Complete || len(record.Runes) != len(i.Socketed) {
+		return false
+	}
+
+	for idx, socketed := range i.Socketed {
+		if socketed.Code != record.Runes[idx] {
+			return false
+		}
+	}
+
+	for _, excluded := range record.ExcludedTypes {
+		if i.IsOfType(excluded) {
+			return false
+		}
+	}
+
+	for _, itemType := range record.Types {
+		if i.IsOfType(itemType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SocketProperties returns the properties the item gets from what is in its
+// sockets: the weapon, armor or shield mods of the gems and runes, as the
+// record of the item applies them, and the properties of the jewels.
+func (i *Item) SocketProperties() []ItemProperty {
+	record := i.Record()
+	if record == nil {
+		return nil
+	}
+
+	properties := make([]ItemProperty, 0)
+
+	for _, socketed := range i.Socketed {
+		gem := d2datadict.GetGemByCode(socketed.Code)
+		if gem == nil {
+			properties = append(properties, socketed.Properties...)
+			continue
+		}
+
+		// the mods of the gems and runes have a fixed value
+		for _, mod := range gemMods(gem, record.GemApplyType) {
+			if mod.Code != "" {
+				properties = append(properties, ItemProperty{Code: mod.Code, Param: mod.Param, Value: mod.Min})
+			}
+		}
+	}
+
+	return properties
+}
+
+// AllProperties returns the properties of the item, of what is in its
+// sockets, and of its runeword.
+func (i *Item) AllProperties() []ItemProperty {
+	properties := append([]ItemProperty(nil), i.Properties...)
+	properties = append(properties, i.SocketProperties()...)
+
+	return append(properties, i.RunewordProperties...)
+}
+
+// gemMods returns the mods of the gem for the items which apply the given
+// kind of mods.
+func gemMods(gem *d2datadict.GemsRecord, applyType int) []d2datadict.RunesProperty {
+	switch applyType {
+	case gemApplyWeapon:
+		return []d2datadict.RunesProperty{
+			{Code: gem.WeaponMod1Code, Param: gem.WeaponMod1Param, Min: gem.WeaponMod1Min, Max: gem.WeaponMod1Max},
+			{Code: gem.WeaponMod2Code, Param: gem.WeaponMod2Param, Min: gem.WeaponMod2Min, Max: gem.WeaponMod2Max},
+			{Code: gem.WeaponMod3Code, Param: gem.WeaponMod3Param, Min: gem.WeaponMod3Min, Max: gem.WeaponMod3Max},
+		}
+	case gemApplyArmor:
+		return []d2datadict.RunesProperty{
+			{Code: gem.HelmMod1Code, Param: gem.HelmMod1Param, Min: gem.HelmMod1Min, Max: gem.HelmMod1Max},
+			{Code: gem.HelmMod2Code, Param: gem.HelmMod2Param, Min: gem.HelmMod2Min, Max: gem.HelmMod2Max},
+			{Code: gem.HelmMod3Code, Param: gem.HelmMod3Param, Min: gem.HelmMod3Min, Max: gem.HelmMod3Max},
+		}
+	case gemApplyShield:
+		return []d2datadict.RunesProperty{
+			{Code: gem.ShieldMod1Code, Param: gem.ShieldMod1Param, Min: gem.ShieldMod1Min, Max: gem.ShieldMod1Max},
+			{Code: gem.ShieldMod2Code, Param: gem.ShieldMod2Param, Min: gem.ShieldMod2Min, Max: gem.ShieldMod2Max},
+			{Code: gem.ShieldMod3Code, Param: gem.ShieldMod3Param, Min: gem.ShieldMod3Min, Max: gem.ShieldMod3Max},
+		}
+	}
+
+	return nil
+}
+
+// Socket puts the gem, rune or jewel with the socketable ID into the item
+// with the item ID, wherever the player carries them. Nothing changes if it
+// does not go in.
+func (p *PlayerInventory) Socket(itemID, socketableID int, rng *rand.Rand) error {
+	item, _, found := p.Find(itemID)
+	socketable, _, foundSocketable := p.Find(socketableID)
+
+	if !found || !foundSocketable || itemID == socketableID {
+		return ErrItemNotFound
+	}
+
+	if !socketable.IsSocketable() {
+		return ErrNotSocketable
+	}
+
+	if item.FreeSockets() <= 0 {
+		return ErrNoFreeSocket
+	}
+
+	if _, err := p.Remove(socketableID); err != nil {
+		return err
+	}
+
+	return item.Socket(socketable, rng)
+}
@@ -0,0 +1,268 @@
+package d2inventory
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+func loadTestSocketables() {
+	loadTestRecipes()
+
+	item := d2enum.InventoryItemTypeItem
+
+	d2datadict.CommonItems["hax"].GemSockets = 5
+	d2datadict.CommonItems["buc"].GemSockets = 4
+	d2datadict.CommonItems["buc"].GemApplyType = gemApplyShield
+
+	for code, itemType := range map[string]string{"gcv": "gema", "r01": "rune", "r02": "rune"} {
+		d2datadict.CommonItems[code] = &d2datadict.ItemCommonRecord{
+			Code: code, Source: item, Type: itemType, InventoryWidth: 1, InventoryHeight: 1,
+		}
+	}
+
+	axe := d2datadict.ItemTypes["axe"]
+	axe.MaxSock1, axe.MaxSock25, axe.MaxSock40 = 2, 4, 6
+	d2datadict.ItemTypes["shie"] = &d2datadict.ItemTypeRecord{Code: "shie", MaxSock1: 3, MaxSock25: 3, MaxSock40: 3}
+	d2datadict.ItemTypes["gema"] = &d2datadict.ItemTypeRecord{Code: "gema", Equiv1: "gem"}
+	d2datadict.ItemTypes["gem"] = &d2datadict.ItemTypeRecord{Code: "gem"}
+	d2datadict.ItemTypes["rune"] = &d2datadict.ItemTypeRecord{Code: "rune"}
+
+	d2datadict.Gems = map[string]*d2datadict.GemsRecord{
+		"Chipped Amethyst": {
+			Name: "Chipped Amethyst", Code: "gcv",
+			WeaponMod1Code: "att", WeaponMod1Min: 40, WeaponMod1Max: 40,
+			ShieldMod1Code: "ac", ShieldMod1Min: 8, ShieldMod1Max: 8,
+		},
+	}
+
+	d2datadict.Runewords = map[string]*d2datadict.RunesRecord{
+		"Runeword1": {
+			Name: "Runeword1", Complete: true, Types: []string{"mele"}, Runes: []string{"r01", "r02"},
+			Properties: []d2datadict.RunesProperty{{Code: "dmg%", Min: 20, Max: 20}},
+		},
+		"Runeword2": {
+			Name: "Runeword2", Complete: false, Types: []string{"shie"}, Runes: []string{"r01", "r02"},
+		},
+	}
+}
+
+func TestMaxSockets(t *testing.T) {
+	loadTestSocketables()
+
+	axe := CreateItem("hax")
+
+	if sockets := axe.MaxSockets(); sockets != 2 {
+		t.Errorf("expected 2 sockets at level 1, got %d", sockets)
+	}
+
+	axe.Level = 30
+	if sockets := axe.MaxSockets(); sockets != 4 {
+		t.Errorf("expected 4 sockets at level 30, got %d", sockets)
+	}
+
+	axe.Level = 40
+	if sockets := axe.MaxSockets(); sockets != 5 {
+		t.Errorf("expected the 5 sockets of the record at level 40, got %d", sockets)
+	}
+
+	if sockets := CreateItem("cap").MaxSockets(); sockets != 0 {
+		t.Errorf("expected a cap without sockets, got %d", sockets)
+	}
+
+	shield := CreateItem("buc")
+	rng := rand.New(rand.NewSource(1))
+
+	for n := 0; n < 20; n++ {
+		shield.Sockets = 0
+		shield.RollSockets(rng)
+
+		if shield.Sockets < 1 || shield.Sockets > 3 {
+			t.Fatalf("expected 1 to 3 sockets, got %d", shield.Sockets)
+		}
+	}
+}
+
+func TestVendorSockets(t *testing.T) {
+	loadTestSocketables()
+
+	buckler := d2datadict.CommonItems["buc"]
+	buckler.Spawnable = true
+	buckler.Vendors = map[string]*d2datadict.ItemVendorParams{"Charsi": {Min: 20, Max: 20}}
+
+	vendor := CreateVendor("charsi", d2enum.DifficultyNormal, rand.New(rand.NewSource(1)))
+	socketed := 0
+
+	for _, item := range vendor.Items {
+		if item.Sockets > 3 {
+			t.Fatalf("expected at most 3 sockets, got %d", item.Sockets)
+		}
+
+		if item.Sockets > 0 {
+			socketed++
+		}
+	}
+
+	if len(vendor.Items) != 20 || socketed == 0 || socketed == len(vendor.Items) {
+		t.Errorf("expected some of the 20 bucklers to have sockets, got %d of %d", socketed, len(vendor.Items))
+	}
+}
+
+func TestSocket(t *testing.T) {
+	loadTestSocketables()
+
+	rng := rand.New(rand.NewSource(1))
+	shield := CreateItem("buc")
+	shield.Sockets = 1
+
+	if err := shield.Socket(CreateItem("cap"), rng); err != ErrNotSocketable {
+		t.Errorf("expected a cap not to go into a socket, got %v", err)
+	}
+
+	if err := shield.Socket(CreateItem("gcv"), rng); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := shield.Socket(CreateItem("gcv"), rng); err != ErrNoFreeSocket {
+		t.Errorf("expected no free socket, got %v", err)
+	}
+
+	properties := shield.AllProperties()
+	if len(properties) != 1 || properties[0] != (ItemProperty{Code: "ac", Value: 8}) {
+		t.Errorf("expected the shield mod of the amethyst, got %v", properties)
+	}
+
+	clone := shield.Clone()
+	clone.Socketed[0].Code = "r01"
+
+	if shield.Socketed[0].Code != "gcv" {
+		t.Error("expected the clone to have copies of the socketed items")
+	}
+}
+
+func TestRuneword(t *testing.T) {
+	loadTestSocketables()
+
+	rng := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		name     string
+		code     string
+		quality  d2enum.ItemQuality
+		runes    []string
+		runeword string
+	}{
+		{"runeword", "hax", d2enum.ItemQualityNormal, []string{"r01", "r02"}, "Runeword1"},
+		{"order of the runes", "hax", d2enum.ItemQualityNormal, []string{"r02", "r01"}, ""},
+		{"magic item", "hax", d2enum.ItemQualityMagic, []string{"r01", "r02"}, ""},
+		{"incomplete runeword", "buc", d2enum.ItemQualityNormal, []string{"r01", "r02"}, ""},
+	}
+
+	for _, test := range tests {
+		item := CreateItem(test.code)
+		item.Quality, item.Sockets = test.quality, len(test.runes)
+
+		for _, code := range test.runes {
+			if err := item.Socket(CreateItem(code), rng); err != nil {
+				t.Fatalf("%s: %s", test.name, err)
+			}
+		}
+
+		if item.Runeword != test.runeword {
+			t.Errorf("%s: expected runeword %q, got %q", test.name, test.runeword, item.Runeword)
+		}
+	}
+
+	axe := CreateItem("hax")
+	axe.Sockets = 2
+
+	for _, code := range []string{"r01", "r02"} {
+		if err := axe.Socket(CreateItem(code), rng); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(axe.RunewordProperties) != 1 || axe.RunewordProperties[0].Value != 20 {
+		t.Errorf("expected the properties of the runeword, got %v", axe.RunewordProperties)
+	}
+
+	if runes := axe.Unsocket(); len(runes) != 2 || axe.Runeword != "" || len(axe.AllProperties()) != 0 {
+		t.Errorf("expected the runes out of the sockets and no runeword left, got %v", axe)
+	}
+}
+
+func TestPlayerInventorySocket(t *testing.T) {
+	loadTestSocketables()
+
+	inventory := CreatePlayerInventory()
+	rng := rand.New(rand.NewSource(1))
+
+	axe := CreateItem("hax")
+	axe.Sockets = 1
+
+	for _, item := range []*Item{axe, CreateItem("gcv"), CreateItem("cap")} {
+		if err := inventory.Add(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	axeID, gemID, capID := inventory.Inventory[0].ID, inventory.Inventory[1].ID, inventory.Inventory[2].ID
+
+	if err := inventory.Socket(axeID, capID, rng); err != ErrNotSocketable {
+		t.Errorf("expected the cap not to go into the socket, got %v", err)
+	}
+
+	if err := inventory.Socket(axeID, gemID, rng); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, found := inventory.Find(gemID); found {
+		t.Error("expected the gem to leave the inventory")
+	}
+
+	if axe, _, _ := inventory.Find(axeID); len(axe.Socketed) != 1 || axe.AllProperties()[0].Code != "att" {
+		t.Errorf("expected the gem in the socket of the axe, got %v", axe.Socketed)
+	}
+}
+
+func TestTransmuteSockets(t *testing.T) {
+	loadTestSocketables()
+
+	d2datadict.CubeRecipes = []*d2datadict.CubeRecipeRecord{
+		cubeRecipe("socket a normal axe",
+			[]d2datadict.CubeRecipeItem{{Code: "axe", Params: []string{"nor", "nos"}}, {Code: "hp1"}},
+			d2datadict.CubeRecipeResult{Item: d2datadict.CubeRecipeItem{Code: "useitem", Params: []string{"sock=2"}}}),
+		cubeRecipe("empty the sockets",
+			[]d2datadict.CubeRecipeItem{{Code: "weap", Params: []string{"sock"}}, {Code: "hp1"}},
+			d2datadict.CubeRecipeResult{Item: d2datadict.CubeRecipeItem{Code: "useitem", Params: []string{"rem"}}}),
+	}
+
+	rng := rand.New(rand.NewSource(1))
+
+	results, err := Transmute([]*Item{CreateItem("hax"), CreateItem("hp1")}, TransmuteRules{}, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 1 || results[0].Sockets != 2 {
+		t.Fatalf("expected an axe with 2 sockets, got %v", results)
+	}
+
+	axe := results[0]
+	for _, code := range []string{"r01", "r02"} {
+		if err := axe.Socket(CreateItem(code), rng); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err = Transmute([]*Item{axe, CreateItem("hp1")}, TransmuteRules{}, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 3 || results[0].Runeword != "" || len(results[0].Socketed) != 0 || results[0].Sockets != 2 {
+		t.Errorf("expected the axe and its runes out of the sockets, got %v", results)
+	}
+}
@@ -0,0 +1,66 @@
+package d2inventory
+
+// Size of the grid of the items a player offers in a trade, in slots
+const (
+	TradeWidth  = 10
+	TradeHeight = 4
+)
+
+// TradeOffer holds the items and the gold a player offers another player in
+// a trade. The items are copies of the items of the player, which keep their
+// IDs, the items themselves stay with the player until the trade completes.
+type TradeOffer struct {
+	Items []*Item `json:"items"`
+	Gold  int     `json:"gold"`
+}
+
+// CreateTradeOffer creates an offer of nothing.
+func CreateTradeOffer() *TradeOffer {
+	return &TradeOffer{Items: make([]*Item, 0)}
+}
+
+// Add puts a copy of an item in the first free slot of the offer.
+func (o *TradeOffer) Add(item *Item) error {
+	if _, found := o.Find(item.ID); found {
+		return ErrItemDoesntFit
+	}
+
+	x, y, found := freeGridSlot(o.Items, item, TradeWidth, TradeHeight)
+	if !found {
+		return ErrInventoryFull
+	}
+
+	offered := item.Clone()
+	offered.SetInventoryGridSlot(x, y)
+	o.Items = append(o.Items, offered)
+
+	return nil
+}
+
+// Find returns the offered item with the given ID.
+func (o *TradeOffer) Find(id int) (*Item, bool) {
+	for _, item := range o.Items {
+		if item.ID == id {
+			return item, true
+		}
+	}
+
+	return nil, false
+}
+
+// Remove takes the item with the given ID out of the offer.
+func (o *TradeOffer) Remove(id int) (*Item, error) {
+	item, found := o.Find(id)
+	if !found {
+		return nil, ErrItemNotFound
+	}
+
+	o.Items = removeItem(o.Items, item)
+
+	return item, nil
+}
+
+// Clone returns a copy of the offer which holds copies of the items.
+func (o *TradeOffer) Clone() *TradeOffer {
+	return &TradeOffer{Items: cloneItems(o.Items), Gold: o.Gold}
+}
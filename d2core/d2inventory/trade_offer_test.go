@@ -0,0 +1,38 @@
+package d2inventory
+
+import (
+	"testing"
+)
+
+func TestTradeOffer(t *testing.T) {
+	loadTestItems()
+
+	inventory := CreatePlayerInventory()
+	if err := inventory.Add(CreateItem("cap")); err != nil {
+		t.Fatal(err)
+	}
+
+	item := inventory.Inventory[0]
+	offer := CreateTradeOffer()
+
+	if err := offer.Add(item); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := offer.Add(item); err == nil {
+		t.Error("expected an item not to be offered twice")
+	}
+
+	offered, found := offer.Find(item.ID)
+	if !found || offered == item {
+		t.Fatalf("expected a copy of the item in the offer, got %v", offered)
+	}
+
+	if _, err := offer.Remove(item.ID); err != nil || len(offer.Items) != 0 {
+		t.Errorf("expected the item to be withdrawn, got %v", err)
+	}
+
+	if len(inventory.Inventory) != 1 {
+		t.Error("expected the offered item to stay in the inventory")
+	}
+}
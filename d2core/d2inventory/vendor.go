@@ -0,0 +1,218 @@
+package d2inventory
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+// Size of the grid of the items a vendor sells, in slots
+const (
+	VendorWidth  = 10
+	VendorHeight = 10
+)
+
+// the multipliers of npc.txt are in 1024ths
+const priceMultiplierBase = 1024
+
+//nolint:gochecknoglobals // constant lookup table of the Ids in monstats.txt of the vendors and their columns in weapons.txt, armor.txt and misc.txt
+var vendorColumns = map[string]string{
+	"charsi":   "Charsi",
+	"gheed":    "Gheed",
+	"akara":    "Akara",
+	"fara":     "Fara",
+	"lysander": "Lysander",
+	"drognan":  "Drognan",
+	"elzix":    "Elzix",
+	"hratli":   "Hralti",
+	"alkor":    "Alkor",
+	"ormus":    "Ormus",
+	"asheara":  "Asheara",
+	"halbu":    "Halbu",
+	"jamella":  "Jamella",
+	"larzuk":   "Larzuk",
+	"malah":    "Malah",
+	"drehya":   "Drehya",
+}
+
+//nolint:gochecknoglobals // constant lookup table of the cost of the items of each quality, in percent of the cost of their record
+var qualityCostPercent = map[d2enum.ItemQuality]int{
+	d2enum.ItemQualityLow:      50,
+	d2enum.ItemQualityNormal:   100,
+	d2enum.ItemQualitySuperior: 150,
+	d2enum.ItemQualityMagic:    200,
+	d2enum.ItemQualitySet:      300,
+	d2enum.ItemQualityRare:     300,
+	d2enum.ItemQualityCrafted:  350,
+	d2enum.ItemQualityUnique:   400,
+}
+
+// IsVendor returns true if the NPC with the given Id in monstats.txt sells
+// items.
+func IsVendor(npc string) bool {
+	_, ok := vendorColumns[npc]
+	return ok
+}
+
+// Vendor holds the items an NPC sells.
+type Vendor struct {
+	NPC    string  `json:"npc"` // the Id of the NPC in monstats.txt
+	Items  []*Item `json:"items"`
+	NextID int     `json:"nextId"`
+}
+
+// CreateVendor stocks the NPC with the items it sells on the difficulty: a
+// random count between the minimum and the maximum of its columns in
+// weapons.txt, armor.txt and misc.txt of each item. Of the weapons and armor
+// it sells the normal versions on normal, the exceptional ones on nightmare
+// and the elite ones on hell. The items which can have sockets have them by
+// the SocketedChance.
+// TODO: stock the magic items of the vendors
+func CreateVendor(npc string, difficulty d2enum.DifficultyType, rng *rand.Rand) *Vendor {
+	vendor := &Vendor{NPC: npc, Items: make([]*Item, 0), NextID: 1}
+
+	column, ok := vendorColumns[npc]
+	if !ok {
+		return vendor
+	}
+
+	// sorted, so that the same seed stocks the same items
+	codes := make([]string, 0, len(d2datadict.CommonItems))
+	for code := range d2datadict.CommonItems {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		record := d2datadict.CommonItems[code]
+		params := record.Vendors[column]
+
+		if params == nil || params.Max <= 0 || !record.Spawnable || !isDifficultyVersion(record, difficulty) {
+			continue
+		}
+
+		count := params.Min
+		if params.Max > params.Min {
+			count += rng.Intn(params.Max - params.Min + 1)
+		}
+
+		for i := 0; i < count; i++ {
+			item := CreateItem(code)
+			item.RollSocketsByChance(rng)
+
+			if vendor.Add(item) != nil {
+				break
+			}
+		}
+	}
+
+	return vendor
+}
+
+// isDifficultyVersion returns true if the item is the version of its weapon
+// or armor sold on the difficulty. The other items have a single version.
+func isDifficultyVersion(record *d2datadict.ItemCommonRecord, difficulty d2enum.DifficultyType) bool {
+	versions := [3]string{record.NormalCode, record.UberCode, record.UltraCode}
+	if versions[difficulty] == "" {
+		return true
+	}
+
+	return versions[difficulty] == record.Code
+}
+
+// Add puts an item in the first free slot of the vendor, and gives it an ID.
+func (v *Vendor) Add(item *Item) error {
+	x, y, found := freeGridSlot(v.Items, item, VendorWidth, VendorHeight)
+	if !found {
+		return ErrInventoryFull
+	}
+
+	item.ID = v.NextID
+	v.NextID++
+	item.SetInventoryGridSlot(x, y)
+	v.Items = append(v.Items, item)
+
+	return nil
+}
+
+// Find returns the item of the vendor with the given ID.
+func (v *Vendor) Find(id int) (*Item, bool) {
+	for _, item := range v.Items {
+		if item.ID == id {
+			return item, true
+		}
+	}
+
+	return nil, false
+}
+
+// Remove takes the item with the given ID out of the vendor.
+func (v *Vendor) Remove(id int) (*Item, error) {
+	item, found := v.Find(id)
+	if !found {
+		return nil, ErrItemNotFound
+	}
+
+	v.Items = removeItem(v.Items, item)
+
+	return item, nil
+}
+
+// Clone returns a copy of the vendor which holds copies of the items.
+func (v *Vendor) Clone() *Vendor {
+	return &Vendor{NPC: v.NPC, Items: cloneItems(v.Items), NextID: v.NextID}
+}
+
+// Cost returns the value of the item: the cost of its record by the quality
+// of the item, and by the share of the stack left of the stackable items.
+func (i *Item) Cost() int {
+	record := i.Record()
+	if record == nil {
+		return 0
+	}
+
+	cost := record.Cost * qualityCostPercent[i.Quality] / 100
+
+	if record.Stackable && record.MaxStack > 0 {
+		cost = cost * i.Quantity / record.MaxStack
+	}
+
+	return d2common.MaxInt(cost, 1)
+}
+
+// BuyPrice returns the gold the NPC charges for the item.
+func BuyPrice(item *Item, npc *d2datadict.NPCRecord) int {
+	return d2common.MaxInt(item.Cost()*npc.BuyMultiplier/priceMultiplierBase, 1)
+}
+
+// SellPrice returns the gold the NPC pays for the item, at most the most it
+// pays on the difficulty. The unidentified items are paid as if they were
+// of normal quality.
+func SellPrice(item *Item, npc *d2datadict.NPCRecord, difficulty d2enum.DifficultyType) int {
+	valued := item
+	if item.Unidentified {
+		valued = item.Clone()
+		valued.Quality = d2enum.ItemQualityNormal
+	}
+
+	price := d2common.MaxInt(valued.Cost()*npc.SellMultiplier/priceMultiplierBase, 1)
+
+	return d2common.MinInt(price, npc.MaxBuy[difficulty])
+}
+
+// RepairCost returns the gold the NPC charges to repair the item: the share
+// of its repair price of the durability the item lost.
+func RepairCost(item *Item, npc *d2datadict.NPCRecord) int {
+	if item.MaxDurability <= 0 || item.Durability >= item.MaxDurability {
+		return 0
+	}
+
+	lost := item.MaxDurability - item.Durability
+	cost := item.Cost() * npc.RepairMultiplier / priceMultiplierBase * lost / item.MaxDurability
+
+	return d2common.MaxInt(cost, 1)
+}
@@ -0,0 +1,92 @@
+package d2inventory
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+func TestPrices(t *testing.T) {
+	loadTestItems()
+	d2datadict.CommonItems["hax"].Cost = 1000
+
+	npc := &d2datadict.NPCRecord{
+		Name:             "charsi",
+		BuyMultiplier:    1024,
+		SellMultiplier:   256,
+		RepairMultiplier: 512,
+		MaxBuy:           [3]int{150, 300, 600},
+	}
+
+	item := CreateItem("hax")
+	item.Quality = d2enum.ItemQualityMagic
+
+	if price := BuyPrice(item, npc); price != 2000 {
+		t.Errorf("expected a magic item to cost twice its record, got %d", price)
+	}
+
+	if price := SellPrice(item, npc, d2enum.DifficultyHell); price != 500 {
+		t.Errorf("expected a quarter of the cost, got %d", price)
+	}
+
+	if price := SellPrice(item, npc, d2enum.DifficultyNormal); price != 150 {
+		t.Errorf("expected the most the NPC pays on normal, got %d", price)
+	}
+
+	item.Unidentified = true
+	if price := SellPrice(item, npc, d2enum.DifficultyHell); price != 250 {
+		t.Errorf("expected an unidentified item to be paid as a normal one, got %d", price)
+	}
+
+	item.MaxDurability, item.Durability = 10, 5
+	if cost := RepairCost(item, npc); cost != 500 {
+		t.Errorf("expected half the repair price for half the durability, got %d", cost)
+	}
+}
+
+func TestGold(t *testing.T) {
+	inventory := &PlayerInventory{}
+
+	if err := inventory.AddGold(MaxGold(1)+1, 1); err != ErrTooMuchGold {
+		t.Errorf("expected a level 1 player not to carry more than %d gold, got %v", MaxGold(1), err)
+	}
+
+	if err := inventory.AddGold(500, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := inventory.StashGold(600, 1); err != ErrNotEnoughGold {
+		t.Errorf("expected not to stash more gold than carried, got %v", err)
+	}
+
+	if err := inventory.StashGold(200, 1); err != nil || inventory.Gold != 300 || inventory.StashedGold != 200 {
+		t.Errorf("expected 300 gold carried and 200 stashed, got %d and %d (%v)",
+			inventory.Gold, inventory.StashedGold, err)
+	}
+
+	if err := inventory.StashGold(-200, 1); err != nil || inventory.Gold != 500 || inventory.StashedGold != 0 {
+		t.Errorf("expected the stashed gold to be withdrawn, got %d and %d (%v)",
+			inventory.Gold, inventory.StashedGold, err)
+	}
+}
+
+func TestCreateVendor(t *testing.T) {
+	loadTestItems()
+	d2datadict.CommonItems["hp1"].Spawnable = true
+	d2datadict.CommonItems["hp1"].Vendors = map[string]*d2datadict.ItemVendorParams{"Akara": {Min: 2, Max: 2}}
+
+	vendor := CreateVendor("akara", d2enum.DifficultyNormal, rand.New(rand.NewSource(1)))
+	if len(vendor.Items) != 2 || vendor.Items[0].Code != "hp1" {
+		t.Fatalf("expected two potions, got %v", vendor.Items)
+	}
+
+	if vendor.Items[0].ID == vendor.Items[1].ID {
+		t.Error("expected the items of the vendor to have distinct IDs")
+	}
+
+	if _, err := vendor.Remove(vendor.Items[0].ID); err != nil || len(vendor.Items) != 1 {
+		t.Errorf("expected the item to be removed, got %v", err)
+	}
+}
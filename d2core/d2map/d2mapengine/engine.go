@@ -11,7 +11,9 @@ import (
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapstamp"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2object"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
@@ -27,9 +29,15 @@ type MapEngine struct {
 	levelType     d2datadict.LevelTypeRecord // Level type of this map
 	dt1TileData   []d2dt1.Tile               // DT1 tile data
 	walkMesh      []d2common.PathTile        // Sub tiles representing the walkable map area
+	collision     []d2enum.Collision         // What the tiles and the objects block, per sub tile
+	occupancy     []d2enum.Collision         // What the units block, per sub tile
+	occupied      []int                      // The sub tiles the units stand on
 	startSubTileX int                        // Starting X position
 	startSubTileY int                        // Starting Y position
 	dt1Files      []string                   // List of DS1 strings
+	levels        []levelArea                // Levels.txt levels on the map
+	warps         []*Warp                    // Warps between levels, found on demand
+	addedWarps    []*Warp                    // Warps which are not marked by warp tiles
 }
 
 // CreateMapEngine creates a new instance of the map engine and
@@ -59,7 +67,13 @@ func (m *MapEngine) ResetMap(levelType d2enum.RegionIdType, width, height int) {
 	m.tiles = make([]d2ds1.TileRecord, width*height)
 	m.dt1TileData = make([]d2dt1.Tile, 0)
 	m.walkMesh = make([]d2common.PathTile, width*height*25)
+	m.collision = make([]d2enum.Collision, width*height*25)
+	m.occupancy = make([]d2enum.Collision, width*height*25)
+	m.occupied = nil
 	m.dt1Files = make([]string, 0)
+	m.levels = make([]levelArea, 0)
+	m.warps = nil
+	m.addedWarps = nil
 
 	for idx := range m.levelType.Files {
 		m.addDT1(m.levelType.Files[idx])
@@ -182,8 +196,36 @@ func (m *MapEngine) PlaceStamp(stamp *d2mapstamp.Stamp, tileOffsetX, tileOffsetY
 		}
 	}
 
+	m.warps = nil
+
 	// Copy over the entities
 	m.entities = append(m.entities, stamp.Entities(tileOffsetX, tileOffsetY)...)
+	m.updateOccupancy()
+}
+
+// PlaceStampArea places a part of a stamp, in tiles of the stamp, on the
+// map at the given tile. Only the tiles are placed, the entities of the
+// stamp are not.
+func (m *MapEngine) PlaceStampArea(stamp *d2mapstamp.Stamp, source d2common.Rectangle, tileOffsetX, tileOffsetY int) {
+	stampSize := stamp.Size()
+
+	if source.Left < 0 || source.Top < 0 || source.Right() > stampSize.Width || source.Bottom() > stampSize.Height {
+		panic("Tried placing an area outside the bounds of the stamp")
+	}
+
+	if tileOffsetX < 0 || tileOffsetY < 0 || tileOffsetX+source.Width > m.size.Width ||
+		tileOffsetY+source.Height > m.size.Height {
+		panic("Tried placing a stamp outside the bounds of the map")
+	}
+
+	for y := 0; y < source.Height; y++ {
+		for x := 0; x < source.Width; x++ {
+			targetTileIndex := m.tileCoordinateToIndex(x+tileOffsetX, y+tileOffsetY)
+			m.tiles[targetTileIndex] = *stamp.Tile(source.Left+x, source.Top+y)
+		}
+	}
+
+	m.warps = nil
 }
 
 // converts x,y tile coordinate into index in MapEngine.tiles
@@ -220,14 +262,50 @@ func (m *MapEngine) Seed() int64 {
 // AddEntity adds an entity to a slice containing all entities.
 func (m *MapEngine) AddEntity(entity d2interface.MapEntity) {
 	m.entities = append(m.entities, entity)
+	m.updateOccupancy()
 }
 
-// RemoveEntity is not currently implemented.
+// ObjectAt returns the object at the given sub-tile position, or nil if
+// there is none.
+func (m *MapEngine) ObjectAt(subTileX, subTileY int) *d2object.Object {
+	for _, entity := range m.entities {
+		object, ok := entity.(*d2object.Object)
+		if ok && int(object.LocationX) == subTileX && int(object.LocationY) == subTileY {
+			return object
+		}
+	}
+
+	return nil
+}
+
+// ItemAt returns the item lying on the ground at the given sub-tile
+// position, nil if there is none.
+func (m *MapEngine) ItemAt(subTileX, subTileY int) *d2mapentity.Item {
+	for _, entity := range m.entities {
+		item, ok := entity.(*d2mapentity.Item)
+		if ok && int(item.LocationX) == subTileX && int(item.LocationY) == subTileY {
+			return item
+		}
+	}
+
+	return nil
+}
+
+// RemoveEntity removes an entity from the map. The entities are copied, so
+// entities can be removed while the map is advanced.
 func (m *MapEngine) RemoveEntity(entity d2interface.MapEntity) {
 	if entity == nil {
 		return
 	}
-	// m.entities.Remove(entity)
+
+	for idx := range m.entities {
+		if m.entities[idx] == entity {
+			m.entities = append(m.entities[:idx:idx], m.entities[idx+1:]...)
+			m.updateOccupancy()
+
+			return
+		}
+	}
 }
 
 // GetTiles returns a slice of all tiles matching the given style,
@@ -273,11 +351,13 @@ func (m *MapEngine) GetCenterPosition() (float64, float64) {
 }
 
 // Advance calls the Advance() method for all entities,
-// processing a single tick.
+// processing a single tick, and updates the sub tiles they occupy.
 func (m *MapEngine) Advance(tickTime float64) {
-	for idx := range m.entities {
-		m.entities[idx].Advance(tickTime)
+	for _, entity := range m.entities {
+		entity.Advance(tickTime)
 	}
+
+	m.updateOccupancy()
 }
 
 // TileExists returns true if the tile at the given coordinates exists.
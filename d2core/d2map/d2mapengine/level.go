@@ -0,0 +1,217 @@
+package d2mapengine
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+)
+
+// warpTileStyle is the style of the special wall tiles which mark warps to
+// other levels, the sequence of a warp tile is the Vis index of its level.
+const warpTileStyle = 8
+
+// levelArea is the area of the map, in tiles, which belongs to a level of
+// Levels.txt.
+type levelArea struct {
+	id          int
+	area        d2common.Rectangle
+	monsterArea d2common.Rectangle // where the monsters of the level spawn
+	hasMonsters bool
+}
+
+// Warp is an exit from one level to another, e.g. a cave entrance. Its
+// position and graphics offsets come from LvlWarp.txt, all in sub-tiles.
+type Warp struct {
+	LevelID       int // the level the warp is in
+	DestinationID int // the level the warp leads to
+	TileX, TileY  int // the warp tile
+	Record        *d2datadict.LevelWarpRecord
+}
+
+// origin returns the position the offsets of the warp are relative to.
+func (w *Warp) origin() (x, y int) {
+	return w.TileX*5 + w.Record.OffsetX, w.TileY*5 + w.Record.OffsetY
+}
+
+// SelectArea returns the area which can be clicked to use the warp.
+func (w *Warp) SelectArea() d2common.Rectangle {
+	x, y := w.origin()
+
+	return d2common.Rectangle{
+		Left:   x + w.Record.SelectX,
+		Top:    y + w.Record.SelectY,
+		Width:  w.Record.SelectDX,
+		Height: w.Record.SelectDY,
+	}
+}
+
+// ExitWalk returns the position players walk to before using the warp, and
+// where players arrive when they come from the level the warp leads to.
+func (w *Warp) ExitWalk() (x, y int) {
+	x, y = w.origin()
+	return x + w.Record.ExitWalkX, y + w.Record.ExitWalkY
+}
+
+// AddLevel marks an area of the map, in tiles, as belonging to a level.
+func (m *MapEngine) AddLevel(levelID int, area d2common.Rectangle) {
+	m.levels = append(m.levels, levelArea{id: levelID, area: area})
+	m.warps = nil
+}
+
+// Levels returns the IDs of the levels on the map.
+func (m *MapEngine) Levels() []int {
+	levels := make([]int, len(m.levels))
+
+	for idx := range m.levels {
+		levels[idx] = m.levels[idx].id
+	}
+
+	return levels
+}
+
+// LevelAt returns the ID of the level at the given tile, or 0 if the tile
+// does not belong to a level.
+func (m *MapEngine) LevelAt(tileX, tileY int) int {
+	for idx := range m.levels {
+		if m.levels[idx].area.IsInRect(tileX, tileY) {
+			return m.levels[idx].id
+		}
+	}
+
+	return 0
+}
+
+// LevelArea returns the area of a level, in tiles.
+func (m *MapEngine) LevelArea(levelID int) (d2common.Rectangle, bool) {
+	for idx := range m.levels {
+		if m.levels[idx].id == levelID {
+			return m.levels[idx].area, true
+		}
+	}
+
+	return d2common.Rectangle{}, false
+}
+
+// SetMonsterArea marks an area of a level, in tiles, as the area the
+// monsters of the level spawn in once the level is populated.
+func (m *MapEngine) SetMonsterArea(levelID int, area d2common.Rectangle) {
+	for idx := range m.levels {
+		if m.levels[idx].id == levelID {
+			m.levels[idx].monsterArea, m.levels[idx].hasMonsters = area, true
+		}
+	}
+}
+
+// MonsterArea returns the area the monsters of a level spawn in, in tiles,
+// or false if no monsters spawn in the level.
+func (m *MapEngine) MonsterArea(levelID int) (d2common.Rectangle, bool) {
+	for idx := range m.levels {
+		if m.levels[idx].id == levelID {
+			return m.levels[idx].monsterArea, m.levels[idx].hasMonsters
+		}
+	}
+
+	return d2common.Rectangle{}, false
+}
+
+// AddWarp adds a warp which is not marked by a warp tile, e.g. the edge of
+// an outdoor level which leads to the level next to it.
+func (m *MapEngine) AddWarp(warp *Warp) {
+	m.addedWarps = append(m.addedWarps, warp)
+	m.warps = nil
+}
+
+// Warps returns the warps between the levels on the map and other levels.
+// Warps to levels on the same map are left out, as players simply walk
+// there.
+func (m *MapEngine) Warps() []*Warp {
+	if m.warps == nil {
+		m.warps = append(m.findWarps(), m.addedWarps...)
+	}
+
+	return m.warps
+}
+
+// WarpAt returns the warp which can be selected at the given sub-tile, or
+// nil if there is none.
+func (m *MapEngine) WarpAt(subTileX, subTileY int) *Warp {
+	for _, warp := range m.Warps() {
+		if area := warp.SelectArea(); area.IsInRect(subTileX, subTileY) {
+			return warp
+		}
+	}
+
+	return nil
+}
+
+// WarpTo returns the warp in a level which leads to the given level, or nil
+// if there is none.
+func (m *MapEngine) WarpTo(levelID, destinationID int) *Warp {
+	for _, warp := range m.Warps() {
+		if warp.LevelID == levelID && warp.DestinationID == destinationID {
+			return warp
+		}
+	}
+
+	return nil
+}
+
+// findWarps resolves the warp tiles of the map with the Vis and Warp
+// columns of the levels they are in.
+func (m *MapEngine) findWarps() []*Warp {
+	warps := make([]*Warp, 0)
+
+	for tileY := 0; tileY < m.size.Height; tileY++ {
+		for tileX := 0; tileX < m.size.Width; tileX++ {
+			tile := &m.tiles[tileX+(tileY*m.size.Width)]
+
+			for idx := range tile.Walls {
+				wall := &tile.Walls[idx]
+				if !wall.Type.Special() || wall.Style != warpTileStyle {
+					continue
+				}
+
+				if warp := m.resolveWarp(tileX, tileY, int(wall.Sequence)); warp != nil {
+					warps = append(warps, warp)
+				}
+			}
+		}
+	}
+
+	return warps
+}
+
+func (m *MapEngine) resolveWarp(tileX, tileY, vis int) *Warp {
+	levelID := m.LevelAt(tileX, tileY)
+
+	levelDetails := d2datadict.GetLevelDetails(levelID)
+	if levelDetails == nil || vis < 0 || vis > 7 {
+		return nil
+	}
+
+	links := [8]int{
+		levelDetails.LevelLinkID0, levelDetails.LevelLinkID1, levelDetails.LevelLinkID2, levelDetails.LevelLinkID3,
+		levelDetails.LevelLinkID4, levelDetails.LevelLinkID5, levelDetails.LevelLinkID6, levelDetails.LevelLinkID7,
+	}
+	graphics := [8]int{
+		levelDetails.WarpGraphicsID0, levelDetails.WarpGraphicsID1, levelDetails.WarpGraphicsID2,
+		levelDetails.WarpGraphicsID3, levelDetails.WarpGraphicsID4, levelDetails.WarpGraphicsID5,
+		levelDetails.WarpGraphicsID6, levelDetails.WarpGraphicsID7,
+	}
+
+	record := d2datadict.LevelWarps[graphics[vis]]
+	if links[vis] <= 0 || record == nil {
+		return nil
+	}
+
+	if _, sameMap := m.LevelArea(links[vis]); sameMap {
+		return nil
+	}
+
+	return &Warp{
+		LevelID:       levelID,
+		DestinationID: links[vis],
+		TileX:         tileX,
+		TileY:         tileY,
+		Record:        record,
+	}
+}
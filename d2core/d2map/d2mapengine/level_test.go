@@ -0,0 +1,62 @@
+package d2mapengine
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2ds1"
+)
+
+func TestWarpsLeadToOtherMaps(t *testing.T) {
+	d2datadict.LevelDetails = map[int]*d2datadict.LevelDetailsRecord{
+		0: {Id: 1, LevelLinkID0: 2, WarpGraphicsID0: 5},
+		1: {Id: 2, LevelLinkID0: 1, WarpGraphicsID0: 5, LevelLinkID1: 8, WarpGraphicsID1: 6},
+	}
+	d2datadict.LevelWarps = map[int]*d2datadict.LevelWarpRecord{
+		5: {ID: 5, SelectDX: 5, SelectDY: 5, ExitWalkX: 2, ExitWalkY: 7},
+		6: {ID: 6, SelectX: -2, SelectY: -2, SelectDX: 4, SelectDY: 4, ExitWalkX: 1, ExitWalkY: 6, OffsetX: 1},
+	}
+
+	m := &MapEngine{size: d2common.Size{Width: 10, Height: 10}}
+	m.tiles = make([]d2ds1.TileRecord, 100)
+	m.AddLevel(1, d2common.Rectangle{Width: 5, Height: 10})
+	m.AddLevel(2, d2common.Rectangle{Width: 10, Height: 10})
+
+	warpTile := d2ds1.WallRecord{Type: d2enum.TileSpecialTile1, Style: warpTileStyle}
+	m.tiles[2+2*10].Walls = []d2ds1.WallRecord{warpTile} // to the Blood Moor, on the same map
+
+	warpTile.Sequence = 1
+	m.tiles[7+3*10].Walls = []d2ds1.WallRecord{warpTile} // to the Den of Evil
+
+	if levelID := m.LevelAt(2, 2); levelID != 1 {
+		t.Errorf("expected level 1 at 2,2, got %d", levelID)
+	}
+
+	if levelID := m.LevelAt(7, 3); levelID != 2 {
+		t.Errorf("expected level 2 at 7,3, got %d", levelID)
+	}
+
+	warps := m.Warps()
+	if len(warps) != 1 {
+		t.Fatalf("expected 1 warp, got %d", len(warps))
+	}
+
+	warp := warps[0]
+	if warp.LevelID != 2 || warp.DestinationID != 8 {
+		t.Errorf("expected a warp from level 2 to 8, got %d to %d", warp.LevelID, warp.DestinationID)
+	}
+
+	if m.WarpAt(36, 15) != warp || m.WarpAt(40, 15) != nil {
+		t.Error("expected the warp to be selectable from 34,13 to 37,16")
+	}
+
+	if x, y := warp.ExitWalk(); x != 37 || y != 21 {
+		t.Errorf("expected the warp exit at 37,21, got %d,%d", x, y)
+	}
+
+	if m.WarpTo(2, 8) != warp || m.WarpTo(1, 2) != nil {
+		t.Error("expected only the warp to level 8")
+	}
+}
@@ -0,0 +1,66 @@
+package d2mapengine
+
+import (
+	"math"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+)
+
+// Occupant is a map entity which blocks the sub-tile it stands on, e.g. a
+// player or a monster. The sub-tiles are updated as the occupants move.
+type Occupant interface {
+	d2interface.MapEntity
+
+	// Occupancy returns the collision classes blocked by the entity, none
+	// when it does not block the way, e.g. once it is dead.
+	Occupancy() d2enum.Collision
+}
+
+// IsBlocked returns true if the sub-tile at the given sub-tile coordinates
+// does not exist, or blocks any of the given collision classes, because of
+// the tiles, the objects or the units standing on it.
+func (m *MapEngine) IsBlocked(subTileX, subTileY int, collision d2enum.Collision) bool {
+	index, ok := m.subTileIndex(subTileX, subTileY)
+	if !ok {
+		return true
+	}
+
+	return (m.collision[index] | m.occupancy[index]).Blocks(collision)
+}
+
+// updateOccupancy blocks the sub-tiles the occupants stand on, and releases
+// those they left.
+func (m *MapEngine) updateOccupancy() {
+	if len(m.occupancy) == 0 {
+		return
+	}
+
+	for _, index := range m.occupied {
+		m.occupancy[index] = d2enum.CollisionNone
+	}
+
+	m.occupied = m.occupied[:0]
+
+	for _, entity := range m.entities {
+		occupant, ok := entity.(Occupant)
+		if !ok {
+			continue
+		}
+
+		collision := occupant.Occupancy()
+		if collision == d2enum.CollisionNone {
+			continue
+		}
+
+		x, y := occupant.GetPositionF()
+
+		index, ok := m.subTileIndex(int(math.Floor(x*5)), int(math.Floor(y*5)))
+		if !ok {
+			continue
+		}
+
+		m.occupancy[index] |= collision
+		m.occupied = append(m.occupied, index)
+	}
+}
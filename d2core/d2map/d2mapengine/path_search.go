@@ -0,0 +1,78 @@
+package d2mapengine
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2astar"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+// the longest path searched, in sub-tiles
+const maxPathDistance = 80
+
+// pathSearch is a search for a path on the walk mesh, for an entity moving as
+// the given collision classes.
+type pathSearch struct {
+	mapEngine *MapEngine
+	collision d2enum.Collision
+	end       int
+}
+
+// passable returns true if the sub-tile at the given index can be moved on.
+// The end is reached even when a unit stands on it.
+func (s *pathSearch) passable(index int) bool {
+	if s.mapEngine.collision[index].Blocks(s.collision) {
+		return false
+	}
+
+	return index == s.end || !s.mapEngine.occupancy[index].Blocks(s.collision)
+}
+
+// pathNode is a sub-tile of the walk mesh visited by a path search.
+type pathNode struct {
+	search *pathSearch
+	index  int
+}
+
+// PathNeighbors returns the neighbouring sub-tiles which can be moved on.
+func (n pathNode) PathNeighbors() []d2astar.Pather {
+	width := n.search.mapEngine.size.Width * 5
+	x, y := n.index%width, n.index/width
+	result := make([]d2astar.Pather, 0, len(neighbourLinks))
+
+	for _, neighbour := range neighbourLinks {
+		index, ok := n.search.mapEngine.subTileIndex(x+neighbour.offsetX, y+neighbour.offsetY)
+		if ok && n.search.passable(index) {
+			result = append(result, pathNode{n.search, index})
+		}
+	}
+
+	return result
+}
+
+// PathNeighborCost returns the cost of moving to a neighbouring sub-tile.
+func (n pathNode) PathNeighborCost(to d2astar.Pather) float64 {
+	return 1
+}
+
+// PathEstimatedCost returns the number of moves to another sub-tile, when
+// nothing is in the way.
+func (n pathNode) PathEstimatedCost(to d2astar.Pather) float64 {
+	width := n.search.mapEngine.size.Width * 5
+	other := to.(pathNode)
+
+	dx := abs(other.index%width - n.index%width)
+	dy := abs(other.index/width - n.index/width)
+
+	if dx > dy {
+		return float64(dx)
+	}
+
+	return float64(dy)
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
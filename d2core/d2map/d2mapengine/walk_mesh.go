@@ -6,49 +6,46 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2astar"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2dt1"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2object"
 )
 
+// neighbourLink is the link from a sub-tile to the neighbour at the given
+// offset, and the link back from that neighbour.
+type neighbourLink struct {
+	offsetX, offsetY int
+	link, back       func(tile *d2common.PathTile) **d2common.PathTile
+}
+
+//nolint:gochecknoglobals // constant lookup table
+var neighbourLinks = []neighbourLink{
+	{0, -1, func(t *d2common.PathTile) **d2common.PathTile { return &t.Up },
+		func(t *d2common.PathTile) **d2common.PathTile { return &t.Down }},
+	{0, 1, func(t *d2common.PathTile) **d2common.PathTile { return &t.Down },
+		func(t *d2common.PathTile) **d2common.PathTile { return &t.Up }},
+	{-1, 0, func(t *d2common.PathTile) **d2common.PathTile { return &t.Left },
+		func(t *d2common.PathTile) **d2common.PathTile { return &t.Right }},
+	{1, 0, func(t *d2common.PathTile) **d2common.PathTile { return &t.Right },
+		func(t *d2common.PathTile) **d2common.PathTile { return &t.Left }},
+	{-1, -1, func(t *d2common.PathTile) **d2common.PathTile { return &t.UpLeft },
+		func(t *d2common.PathTile) **d2common.PathTile { return &t.DownRight }},
+	{1, -1, func(t *d2common.PathTile) **d2common.PathTile { return &t.UpRight },
+		func(t *d2common.PathTile) **d2common.PathTile { return &t.DownLeft }},
+	{-1, 1, func(t *d2common.PathTile) **d2common.PathTile { return &t.DownLeft },
+		func(t *d2common.PathTile) **d2common.PathTile { return &t.UpRight }},
+	{1, 1, func(t *d2common.PathTile) **d2common.PathTile { return &t.DownRight },
+		func(t *d2common.PathTile) **d2common.PathTile { return &t.UpLeft }},
+}
+
 // RegenerateWalkPaths based on current tile data.
 func (m *MapEngine) RegenerateWalkPaths() {
 	for subTileY := 0; subTileY < m.size.Height*5; subTileY++ {
-		tileY := int(float64(subTileY) / 5.0)
-
 		for subTileX := 0; subTileX < m.size.Width*5; subTileX++ {
-			tileX := int(float64(subTileX) / 5.0)
-			tile := m.TileAt(tileX, tileY)
-			isBlocked := false
-
-			for _, floor := range tile.Floors {
-				tileData := m.GetTileData(int32(floor.Style), int32(floor.Sequence), d2enum.TileFloor)
-				if tileData == nil {
-					continue
-				}
-
-				tileSubAttributes := tileData.GetSubTileFlags(subTileX%5, subTileY%5)
-
-				isBlocked = isBlocked || tileSubAttributes.BlockWalk
-				if isBlocked {
-					break
-				}
-			}
-
-			if !isBlocked {
-				for _, wall := range tile.Walls {
-					tileData := m.GetTileData(int32(wall.Style), int32(wall.Sequence), wall.Type)
-					if tileData == nil {
-						continue
-					}
-
-					tileSubAttributes := tileData.GetSubTileFlags(subTileX%5, subTileY%5)
-
-					isBlocked = isBlocked || tileSubAttributes.BlockWalk
-					if isBlocked {
-						break
-					}
-				}
-			}
+			collision := m.tileCollision(subTileX, subTileY)
+			isBlocked := collision.Blocks(d2enum.CollisionWalk)
 
 			index := subTileX + (subTileY * m.size.Width * 5)
+			m.collision[index] = collision
 			m.walkMesh[index] = d2common.PathTile{
 				Walkable: !isBlocked,
 				X:        float64(subTileX) / 5.0,
@@ -77,54 +74,212 @@ func (m *MapEngine) RegenerateWalkPaths() {
 			}
 		}
 	}
+
+	m.blockObjects()
 }
 
-// PathFind finds a walkable path between two points.
-func (m *MapEngine) PathFind(startX, startY, endX, endY float64) (path []d2astar.Pather, distance float64, found bool) {
-	startTileX := int(math.Floor(startX))
-	startTileY := int(math.Floor(startY))
+// tileCollision returns what the floors and the walls of a tile block on the
+// given sub-tile. The walls blocking the line of sight also block the units
+// flying and the missiles, while e.g. water only blocks the units walking.
+func (m *MapEngine) tileCollision(subTileX, subTileY int) d2enum.Collision {
+	tile := m.TileAt(subTileX/5, subTileY/5)
+	collision := d2enum.CollisionNone
+
+	addFlags := func(tileData *d2dt1.Tile) {
+		if tileData == nil {
+			return
+		}
+
+		tileSubAttributes := tileData.GetSubTileFlags(subTileX%5, subTileY%5)
+
+		if tileSubAttributes.BlockWalk {
+			collision |= d2enum.CollisionWalk
+		}
+
+		if tileSubAttributes.BlockLOS {
+			collision |= d2enum.CollisionFly | d2enum.CollisionMissile
+		}
+	}
+
+	for _, floor := range tile.Floors {
+		addFlags(m.GetTileData(int32(floor.Style), int32(floor.Sequence), d2enum.TileFloor))
+	}
+
+	for _, wall := range tile.Walls {
+		addFlags(m.GetTileData(int32(wall.Style), int32(wall.Sequence), wall.Type))
+	}
+
+	return collision
+}
+
+// blockObjects blocks the sub-tiles covered by objects which have collision,
+// e.g. closed doors and chests.
+func (m *MapEngine) blockObjects() {
+	for _, entity := range m.entities {
+		object, ok := entity.(*d2object.Object)
+		if !ok || !object.HasCollision() {
+			continue
+		}
+
+		m.SetAreaCollision(object.Footprint(), object.Collision())
+	}
+}
+
+// SetAreaCollision changes what all sub-tiles of the given rectangle, in
+// sub-tiles, block, e.g. when a door opens.
+func (m *MapEngine) SetAreaCollision(area d2common.Rectangle, collision d2enum.Collision) {
+	for y := area.Top; y < area.Top+area.Height; y++ {
+		for x := area.Left; x < area.Left+area.Width; x++ {
+			m.SetCollision(x, y, collision)
+		}
+	}
+}
+
+// SetAreaWalkable changes whether all sub-tiles of the given rectangle, in
+// sub-tiles, can be walked on.
+func (m *MapEngine) SetAreaWalkable(area d2common.Rectangle, walkable bool) {
+	for y := area.Top; y < area.Top+area.Height; y++ {
+		for x := area.Left; x < area.Left+area.Width; x++ {
+			m.SetWalkable(x, y, walkable)
+		}
+	}
+}
 
-	if !m.TileExists(startTileX, startTileY) {
+// SetWalkable changes whether the sub-tile at the given sub-tile
+// coordinates can be walked on, or flown over. What it blocks for missiles is
+// left as is.
+func (m *MapEngine) SetWalkable(subTileX, subTileY int, walkable bool) {
+	index, ok := m.subTileIndex(subTileX, subTileY)
+	if !ok {
 		return
 	}
 
-	startSubtileX := int((startX - float64(int(startX))) * 5)
-	startSubtileY := int((startY - float64(int(startY))) * 5)
-	startNodeIndex := ((startSubtileY + (startTileY * 5)) * m.size.Width * 5) + startSubtileX + ((startTileX) * 5)
+	collision := m.collision[index] &^ d2enum.CollisionUnit
+	if !walkable {
+		collision |= d2enum.CollisionUnit
+	}
 
-	if startNodeIndex < 0 || startNodeIndex >= len(m.walkMesh) {
+	m.SetCollision(subTileX, subTileY, collision)
+}
+
+// SetCollision changes what the sub-tile at the given sub-tile coordinates
+// blocks, and links or unlinks it from its walkable neighbours accordingly.
+func (m *MapEngine) SetCollision(subTileX, subTileY int, collision d2enum.Collision) {
+	index, ok := m.subTileIndex(subTileX, subTileY)
+	if !ok {
 		return
 	}
 
-	startNode := &m.walkMesh[startNodeIndex]
+	width, height := m.size.Width*5, m.size.Height*5
+	walkable := !collision.Blocks(d2enum.CollisionWalk)
+
+	m.collision[index] = collision
+	tile := &m.walkMesh[index]
+	tile.Walkable = walkable
+
+	for _, neighbour := range neighbourLinks {
+		x, y := subTileX+neighbour.offsetX, subTileY+neighbour.offsetY
+		if x < 0 || y < 0 || x >= width || y >= height {
+			continue
+		}
 
-	endTileX := int(math.Floor(endX))
-	endTileY := int(math.Floor(endY))
+		other := &m.walkMesh[x+(y*width)]
+
+		if walkable && other.Walkable {
+			*neighbour.link(tile) = other
+			*neighbour.back(other) = tile
+		} else {
+			*neighbour.link(tile) = nil
+			*neighbour.back(other) = nil
+		}
+	}
+}
+
+// subTileIndex returns the index in the walk mesh of the sub-tile at the
+// given sub-tile coordinates, and false if it is outside of the map.
+func (m *MapEngine) subTileIndex(subTileX, subTileY int) (int, bool) {
+	width, height := m.size.Width*5, m.size.Height*5
+	if subTileX < 0 || subTileY < 0 || subTileX >= width || subTileY >= height {
+		return 0, false
+	}
+
+	return subTileX + (subTileY * width), true
+}
+
+// IsWalkable returns true if the sub-tile at the given sub-tile coordinates
+// exists and is not blocked.
+func (m *MapEngine) IsWalkable(subTileX, subTileY int) bool {
+	width, height := m.size.Width*5, m.size.Height*5
+	if subTileX < 0 || subTileY < 0 || subTileX >= width || subTileY >= height {
+		return false
+	}
 
-	if !m.TileExists(endTileX, endTileY) {
+	return m.walkMesh[subTileX+(subTileY*width)].Walkable
+}
+
+// ClosestWalkable returns the walkable sub-tile closest to the given one,
+// searching at most maxDistance sub-tiles away, e.g. to stand next to an
+// object which blocks the way.
+func (m *MapEngine) ClosestWalkable(subTileX, subTileY, maxDistance int) (x, y int, found bool) {
+	for distance := 0; distance <= maxDistance; distance++ {
+		for offsetY := -distance; offsetY <= distance; offsetY++ {
+			for offsetX := -distance; offsetX <= distance; offsetX++ {
+				// only look at the ring at the current distance
+				if offsetX != -distance && offsetX != distance && offsetY != -distance && offsetY != distance {
+					continue
+				}
+
+				if m.IsWalkable(subTileX+offsetX, subTileY+offsetY) {
+					return subTileX + offsetX, subTileY + offsetY, true
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// PathFind finds a walkable path between two points, around the units
+// standing in the way.
+func (m *MapEngine) PathFind(startX, startY, endX, endY float64) (path []d2astar.Pather, distance float64, found bool) {
+	return m.PathFindAs(d2enum.CollisionWalk, startX, startY, endX, endY)
+}
+
+// PathFindAs finds a path between two points for an entity moving as the
+// given collision classes, e.g. flying over water. The path goes around the
+// sub-tiles blocking these classes, including those the units stand on, but
+// it may end on a unit, e.g. to attack it.
+func (m *MapEngine) PathFindAs(collision d2enum.Collision, startX, startY, endX, endY float64) (path []d2astar.Pather,
+	distance float64, found bool) {
+	if !m.TileExists(int(math.Floor(startX)), int(math.Floor(startY))) ||
+		!m.TileExists(int(math.Floor(endX)), int(math.Floor(endY))) {
 		return
 	}
 
-	endSubtileX := int((endX - float64(int(endX))) * 5)
-	endSubtileY := int((endY - float64(int(endY))) * 5)
+	startIndex, ok := m.subTileIndex(int(math.Floor(startX*5)), int(math.Floor(startY*5)))
+	if !ok {
+		return
+	}
 
-	endNodeIndex := ((endSubtileY + (endTileY * 5)) * m.size.Width * 5) + endSubtileX + ((endTileX) * 5)
-	if endNodeIndex < 0 || endNodeIndex >= len(m.walkMesh) {
+	endIndex, ok := m.subTileIndex(int(math.Floor(endX*5)), int(math.Floor(endY*5)))
+	if !ok {
 		return
 	}
 
-	endNode := &m.walkMesh[endNodeIndex]
+	search := &pathSearch{mapEngine: m, collision: collision, end: endIndex}
 
-	path, distance, found = d2astar.Path(startNode, endNode, 80)
-	if path != nil {
-		// Reverse the path to fit what the game expects.
-		for i := len(path)/2 - 1; i >= 0; i-- {
-			opp := len(path) - 1 - i
-			path[i], path[opp] = path[opp], path[i]
-		}
+	nodes, distance, found := d2astar.Path(pathNode{search, startIndex}, pathNode{search, endIndex}, maxPathDistance)
+	if nodes == nil {
+		return nil, distance, found
+	}
+
+	// The nodes go from the end to the start, the path goes from the sub-tile
+	// after the start to the end.
+	path = make([]d2astar.Pather, 0, len(nodes)-1)
 
-		path = path[1:]
+	for idx := len(nodes) - 2; idx >= 0; idx-- {
+		path = append(path, &m.walkMesh[nodes[idx].(pathNode).index])
 	}
 
-	return
+	return path, distance, found
 }
@@ -0,0 +1,148 @@
+package d2mapengine
+
+import (
+	"math"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2ds1"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+)
+
+// unit is a map entity standing on the walk mesh
+type unit struct {
+	d2interface.MapEntity
+	x, y float64
+	dead bool
+}
+
+func (u *unit) GetPositionF() (float64, float64) {
+	return u.x, u.y
+}
+
+func (u *unit) Advance(float64) {}
+
+func (u *unit) Occupancy() d2enum.Collision {
+	if u.dead {
+		return d2enum.CollisionNone
+	}
+
+	return d2enum.CollisionUnit
+}
+
+// createWalledMap creates an open map of 4x4 tiles split in two halves by a
+// wall, which has a gap of a single sub-tile at 10,10.
+func createWalledMap() *MapEngine {
+	d2datadict.LevelTypes = make([]d2datadict.LevelTypeRecord, 1)
+
+	m := CreateMapEngine()
+	m.ResetMap(0, 4, 4)
+
+	for idx := range m.tiles {
+		m.tiles[idx].Floors = []d2ds1.FloorShadowRecord{{}}
+	}
+
+	m.RegenerateWalkPaths()
+	m.SetAreaWalkable(d2common.Rectangle{Left: 10, Width: 1, Height: 20}, false)
+	m.SetWalkable(10, 10, true)
+
+	return m
+}
+
+func expectPath(t *testing.T, m *MapEngine, collision d2enum.Collision, expected bool) {
+	t.Helper()
+
+	path, _, found := m.PathFindAs(collision, 1.1, 2.1, 3.1, 2.1)
+	if found != expected {
+		t.Fatalf("expected found to be %v, got %v", expected, found)
+	}
+
+	if !found {
+		return
+	}
+
+	last := path[len(path)-1].(*d2common.PathTile)
+	if last.X != 3.0 || last.Y != 2.0 {
+		t.Errorf("expected the path to end at 3,2, got %g,%g", last.X, last.Y)
+	}
+
+	for _, node := range path {
+		tile := node.(*d2common.PathTile)
+		if m.IsBlocked(int(math.Round(tile.X*5)), int(math.Round(tile.Y*5)), collision) {
+			t.Errorf("expected a clear path, went through %g,%g", tile.X, tile.Y)
+		}
+	}
+}
+
+func TestPathFindAroundUnits(t *testing.T) {
+	m := createWalledMap()
+	expectPath(t, m, d2enum.CollisionWalk, true)
+
+	// a monster stands in the gap
+	monster := &unit{x: 2.05, y: 2.05}
+	m.AddEntity(monster)
+
+	if !m.IsBlocked(10, 10, d2enum.CollisionWalk) || m.IsBlocked(10, 10, d2enum.CollisionMissile) {
+		t.Error("expected the monster to block the units but not the missiles")
+	}
+
+	expectPath(t, m, d2enum.CollisionWalk, false)
+
+	// the monster itself can reach the other half, and be reached
+	if _, _, found := m.PathFind(2.05, 2.05, 3.1, 2.1); !found {
+		t.Error("expected the monster to leave the gap")
+	}
+
+	if _, _, found := m.PathFind(1.1, 2.1, 2.05, 2.05); !found {
+		t.Error("expected the monster to be reachable")
+	}
+
+	monster.dead = true
+	m.Advance(0)
+
+	expectPath(t, m, d2enum.CollisionWalk, true)
+
+	monster.dead, monster.x = false, 0.5
+	m.Advance(0)
+
+	expectPath(t, m, d2enum.CollisionWalk, true)
+
+	if !m.IsBlocked(2, 10, d2enum.CollisionWalk) {
+		t.Error("expected the monster to block the sub-tile it moved to")
+	}
+}
+
+func TestPathFindAsCollision(t *testing.T) {
+	m := createWalledMap()
+
+	// water fills the gap
+	m.SetCollision(10, 10, d2enum.CollisionWalk)
+
+	expectPath(t, m, d2enum.CollisionWalk, false)
+	expectPath(t, m, d2enum.CollisionFly, true)
+
+	if m.IsWalkable(10, 10) {
+		t.Error("expected the water not to be walkable")
+	}
+}
+
+func TestDoorOpens(t *testing.T) {
+	m := createWalledMap()
+	door := d2common.Rectangle{Left: 10, Top: 10, Width: 1, Height: 1}
+
+	m.SetAreaCollision(door, d2enum.CollisionSolid)
+	expectPath(t, m, d2enum.CollisionWalk, false)
+
+	if !m.IsBlocked(10, 10, d2enum.CollisionMissile) {
+		t.Error("expected the closed door to block the missiles")
+	}
+
+	m.SetAreaCollision(door, d2enum.CollisionNone)
+	expectPath(t, m, d2enum.CollisionWalk, true)
+
+	if tile := (*m.WalkMesh())[10+10*20]; tile.Left == nil || tile.Right == nil {
+		t.Error("expected the open door to be linked to its neighbours")
+	}
+}
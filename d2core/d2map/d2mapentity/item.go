@@ -0,0 +1,101 @@
+package d2mapentity
+
+import (
+	"fmt"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+)
+
+// Item is an item lying on the ground. It plays its flippy animation once
+// when dropped and then rests on the last frame.
+type Item struct {
+	*AnimatedEntity
+	record *d2datadict.ItemCommonRecord
+	item   *d2inventory.Item // the item a player dropped, nil for new items
+	name   string
+	// highlight is set by the game controls while the mouse is over the item
+	highlight bool
+}
+
+// CreateItem creates a new dropped item at the given sub-tile position.
+func CreateItem(x, y int, record *d2datadict.ItemCommonRecord) (*Item, error) {
+	animation, err := d2asset.LoadAnimation(
+		fmt.Sprintf("%s/%s.dc6", d2resource.ItemGraphics, record.FlippyFile),
+		d2resource.PaletteUnits,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	animation.SetPlayLoop(false)
+	animation.PlayForward()
+
+	return &Item{
+		AnimatedEntity: CreateAnimatedEntity(x, y, animation),
+		record:         record,
+		name:           d2common.TranslateString(record.NameString),
+	}, nil
+}
+
+// CreateDroppedItem creates an item a player dropped at the given sub-tile
+// position.
+func CreateDroppedItem(x, y int, item *d2inventory.Item) (*Item, error) {
+	record := item.Record()
+	if record == nil {
+		return nil, fmt.Errorf("unknown item code %s", item.Code)
+	}
+
+	result, err := CreateItem(x, y, record)
+	if err != nil {
+		return nil, err
+	}
+
+	result.item = item
+
+	return result, nil
+}
+
+// Instance returns the item a player picks up.
+func (i *Item) Instance() *d2inventory.Item {
+	if i.item == nil {
+		return d2inventory.CreateItem(i.record.Code)
+	}
+
+	return i.item
+}
+
+// ItemRecord returns the item's record.
+func (i *Item) ItemRecord() *d2datadict.ItemCommonRecord {
+	return i.record
+}
+
+// Name returns the item's translated name.
+func (i *Item) Name() string {
+	return i.name
+}
+
+// Selectable returns true, dropped items can always be picked up.
+func (i *Item) Selectable() bool {
+	return true
+}
+
+// Highlight makes the item render brighter on the next frame.
+func (i *Item) Highlight() {
+	i.highlight = true
+}
+
+// Render draws the item onto the target.
+func (i *Item) Render(target d2interface.Surface) {
+	if i.highlight {
+		target.PushBrightness(2)
+		defer target.Pop()
+	}
+
+	i.AnimatedEntity.Render(target)
+	i.highlight = false
+}
@@ -147,6 +147,19 @@ func (m *mapEntity) Step(tickTime float64) {
 	}
 }
 
+// SetPosition moves the entity to the given sub-tile position immediately,
+// e.g. when travelling by waypoint, and stops its current movement.
+func (m *mapEntity) SetPosition(x, y float64) {
+	m.LocationX, m.LocationY = x, y
+	m.TargetX, m.TargetY = x, y
+	m.subcellX = 1 + math.Mod(x, 5)
+	m.subcellY = 1 + math.Mod(y, 5)
+	m.TileX = int(x / 5)
+	m.TileY = int(y / 5)
+	m.path = []d2astar.Pather{}
+	m.done = nil
+}
+
 // HasPathFinding returns false if the length of the entity movement path is 0.
 func (m *mapEntity) HasPathFinding() bool {
 	return len(m.path) > 0
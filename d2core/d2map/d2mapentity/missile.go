@@ -46,6 +46,12 @@ func CreateMissile(x, y int, record *d2datadict.MissileRecord) (*Missile, error)
 	return result, nil
 }
 
+// LightRadius returns the radius, in sub-tiles, of the light around the
+// missile, e.g. around a fire bolt.
+func (m *Missile) LightRadius() float64 {
+	return float64(m.record.Light.Diameter) / 2
+}
+
 // SetRadians adjusts the entity target based on it's range, rotating it's
 // current destination by the value of angle in radians.
 func (m *Missile) SetRadians(angle float64, done func()) {
@@ -2,6 +2,7 @@ package d2mapentity
 
 import (
 	"math/rand"
+	"strings"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
 )
 
 // NPC is a passive complex entity with which the player can interact.
@@ -25,7 +27,11 @@ type NPC struct {
 	repetitions   int
 	monstatRecord *d2datadict.MonStatsRecord
 	monstatEx     *d2datadict.MonStats2Record
+	superUnique   *d2datadict.SuperUniqueRecord
 	name          string
+	isActing      bool
+	isDead        bool
+	isCorpse      bool
 }
 
 // CreateNPC creates a new NPC and returns a pointer to it.
@@ -107,9 +113,19 @@ func (v *NPC) SetPaths(paths []d2common.Path) {
 // Advance is called once per frame and processes a
 // single game tick.
 func (v *NPC) Advance(tickTime float64) {
+	if v.isDead {
+		v.advanceDeath(tickTime)
+		return
+	}
+
 	v.Step(tickTime)
 	v.composite.Advance(tickTime)
 
+	if v.isActing && v.composite.GetPlayedCount() >= 1 {
+		v.isActing = false
+		v.rotate(v.composite.GetDirection())
+	}
+
 	if v.HasPaths && v.wait() {
 		// If at the target, set target to the next path.
 		v.isDone = false
@@ -124,6 +140,60 @@ func (v *NPC) Advance(tickTime float64) {
 	}
 }
 
+// Kill stops the NPC and plays its death animation, after which it stays
+// on the map as a corpse.
+func (v *NPC) Kill() {
+	if v.isDead {
+		return
+	}
+
+	v.isDead = true
+	v.isActing = false
+	v.HasPaths = false
+	v.ClearPath()
+	v.TargetX, v.TargetY = v.LocationX, v.LocationY
+
+	if err := v.composite.SetMode(d2enum.MonsterAnimationModeDeath, v.composite.GetWeaponClass()); err == nil {
+		v.composite.SetPlayLoop(false)
+	}
+}
+
+// IsDead returns true if the NPC has been killed.
+func (v *NPC) IsDead() bool {
+	return v.isDead
+}
+
+// IsCorpse returns true once the death animation of the NPC has finished.
+func (v *NPC) IsCorpse() bool {
+	return v.isCorpse
+}
+
+// Occupancy returns the collision classes blocked by the NPC, its corpse
+// does not block the way.
+func (v *NPC) Occupancy() d2enum.Collision {
+	if v.isDead {
+		return d2enum.CollisionNone
+	}
+
+	return d2enum.CollisionUnit
+}
+
+func (v *NPC) advanceDeath(tickTime float64) {
+	v.composite.Advance(tickTime)
+
+	if v.isCorpse || v.composite.GetPlayedCount() < 1 {
+		return
+	}
+
+	v.isCorpse = true
+
+	// Not every monster has a dead mode, those keep showing the last frame
+	// of their death animation
+	if err := v.composite.SetMode(d2enum.MonsterAnimationModeDead, v.composite.GetWeaponClass()); err == nil {
+		v.composite.SetPlayLoop(false)
+	}
+}
+
 // If an npc has a path to pause at each location.
 // Waits for animation to end and all repetitions to be exhausted.
 func (v *NPC) wait() bool {
@@ -154,6 +224,26 @@ func (v *NPC) next() {
 	}
 }
 
+// SetAnimationMode sets the animation mode of the NPC. Modes other than
+// neutral and walk, such as attacks, are played once before the NPC goes
+// back to standing or walking.
+func (v *NPC) SetAnimationMode(animationMode d2enum.MonsterAnimationMode) error {
+	v.isActing = animationMode != d2enum.MonsterAnimationModeNeutral &&
+		animationMode != d2enum.MonsterAnimationModeWalk
+
+	return v.composite.SetMode(animationMode, v.composite.GetWeaponClass())
+}
+
+// Direction returns the direction the NPC faces.
+func (v *NPC) Direction() int {
+	return v.composite.GetDirection()
+}
+
+// MonStatsRecord returns the MonStats.txt record of the NPC.
+func (v *NPC) MonStatsRecord() *d2datadict.MonStatsRecord {
+	return v.monstatRecord
+}
+
 // rotate sets direction and changes animation
 func (v *NPC) rotate(direction int) {
 	var newMode d2enum.MonsterAnimationMode
@@ -174,6 +264,10 @@ func (v *NPC) rotate(direction int) {
 
 // Selectable returns true if the object can be highlighted/selected.
 func (m *NPC) Selectable() bool {
+	if m.isDead {
+		return false
+	}
+
 	// is there something handy that determines selectable npc's?
 	if m.name != "" {
 		return true
@@ -182,7 +276,59 @@ func (m *NPC) Selectable() bool {
 	return false
 }
 
+// SetSuperUnique makes the NPC the given SuperUnique boss, giving it the
+// boss' name.
+func (m *NPC) SetSuperUnique(record *d2datadict.SuperUniqueRecord) {
+	m.superUnique = record
+	m.name = d2common.TranslateString(record.Name)
+}
+
+// SuperUnique returns the SuperUniques.txt record of the NPC, or nil if it is
+// not a SuperUnique.
+func (m *NPC) SuperUnique() *d2datadict.SuperUniqueRecord {
+	return m.superUnique
+}
+
 // Name returns the NPC's in-game name (e.g. "Deckard Cain") or an empty string if it does not have a name.
 func (m *NPC) Name() string {
 	return m.name
 }
+
+//nolint:gochecknoglobals // constant lookup table of the Ids in monstats.txt of the NPCs which repair items
+var smiths = map[string]bool{"charsi": true, "fara": true, "hratli": true, "halbu": true, "larzuk": true}
+
+// Services returns what the NPC does for the players which talk to it. The
+// NPCs players can not interact with offer none.
+func (v *NPC) Services() []d2enum.NPCService {
+	if v.monstatRecord == nil || !v.monstatRecord.IsInteractable || v.isDead {
+		return nil
+	}
+
+	key := v.monstatRecord.Key
+	services := []d2enum.NPCService{d2enum.NPCServiceTalk}
+
+	if d2inventory.IsVendor(key) {
+		services = append(services, d2enum.NPCServiceTrade)
+	}
+
+	if smiths[key] {
+		services = append(services, d2enum.NPCServiceRepair)
+	}
+
+	if strings.HasPrefix(key, "cain") {
+		services = append(services, d2enum.NPCServiceIdentify)
+	}
+
+	return services
+}
+
+// HasService returns true if the NPC offers the service.
+func (v *NPC) HasService(service d2enum.NPCService) bool {
+	for _, offered := range v.Services() {
+		if offered == service {
+			return true
+		}
+	}
+
+	return false
+}
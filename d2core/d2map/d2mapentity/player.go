@@ -1,6 +1,8 @@
 package d2mapentity
 
 import (
+	"log"
+
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
@@ -27,25 +29,18 @@ type Player struct {
 	isRunToggled  bool
 	isRunning     bool
 	isCasting     bool
+	isDead        bool
 }
 
 // run speed should be walkspeed * 1.5, since in the original game it is 6 yards walk and 9 yards run.
 var baseWalkSpeed = 6.0
 var baseRunSpeed = 9.0
 
+// the radius, in sub-tiles, of the light around a player
+const playerLightRadius = 15
+
 // CreatePlayer creates a new player entity and returns a pointer to it.
 func CreatePlayer(id, name string, x, y int, direction int, heroType d2enum.Hero, stats d2hero.HeroStatsState, equipment d2inventory.CharacterEquipment) *Player {
-	layerEquipment := &[d2enum.CompositeTypeMax]string{
-		d2enum.CompositeTypeHead:      equipment.Head.GetArmorClass(),
-		d2enum.CompositeTypeTorso:     equipment.Torso.GetArmorClass(),
-		d2enum.CompositeTypeLegs:      equipment.Legs.GetArmorClass(),
-		d2enum.CompositeTypeRightArm:  equipment.RightArm.GetArmorClass(),
-		d2enum.CompositeTypeLeftArm:   equipment.LeftArm.GetArmorClass(),
-		d2enum.CompositeTypeRightHand: equipment.RightHand.GetItemCode(),
-		d2enum.CompositeTypeLeftHand:  equipment.LeftHand.GetItemCode(),
-		d2enum.CompositeTypeShield:    equipment.Shield.GetItemCode(),
-	}
-
 	composite, err := d2asset.LoadComposite(d2enum.ObjectTypePlayer, heroType.GetToken(),
 		d2resource.PaletteUnits)
 	if err != nil {
@@ -79,11 +74,38 @@ func CreatePlayer(id, name string, x, y int, direction int, heroType d2enum.Hero
 	}
 
 	composite.SetDirection(direction)
-	composite.Equip(layerEquipment)
+	composite.Equip(compositeEquipment(equipment))
 
 	return result
 }
 
+// Equip changes the items the player is seen with.
+func (v *Player) Equip(equipment d2inventory.CharacterEquipment) {
+	v.Equipment = equipment
+
+	if err := v.composite.Equip(compositeEquipment(equipment)); err != nil {
+		log.Printf("failed to equip player %s: %v", v.name, err)
+	}
+
+	if err := v.composite.SetMode(v.GetAnimationMode(), equipment.RightHand.GetWeaponClass()); err != nil {
+		log.Printf("failed to set the weapon class of player %s: %v", v.name, err)
+	}
+}
+
+// compositeEquipment returns the composite layers the equipment is drawn with.
+func compositeEquipment(equipment d2inventory.CharacterEquipment) *[d2enum.CompositeTypeMax]string {
+	return &[d2enum.CompositeTypeMax]string{
+		d2enum.CompositeTypeHead:      equipment.Head.GetArmorClass(),
+		d2enum.CompositeTypeTorso:     equipment.Torso.GetArmorClass(),
+		d2enum.CompositeTypeLegs:      equipment.Legs.GetArmorClass(),
+		d2enum.CompositeTypeRightArm:  equipment.RightArm.GetArmorClass(),
+		d2enum.CompositeTypeLeftArm:   equipment.LeftArm.GetArmorClass(),
+		d2enum.CompositeTypeRightHand: equipment.RightHand.GetItemCode(),
+		d2enum.CompositeTypeLeftHand:  equipment.LeftHand.GetItemCode(),
+		d2enum.CompositeTypeShield:    equipment.Shield.GetItemCode(),
+	}
+}
+
 // SetIsInTown sets a flag indicating that the player is in town.
 func (p *Player) SetIsInTown(isInTown bool) {
 	p.isInTown = isInTown
@@ -125,6 +147,18 @@ func (p Player) IsInTown() bool {
 // Advance is called once per frame and processes a
 // single game tick.
 func (v *Player) Advance(tickTime float64) {
+	if v.isDead {
+		v.composite.Advance(tickTime)
+
+		if v.composite.GetAnimationMode() == d2enum.PlayerAnimationModeDeath.String() && v.composite.GetPlayedCount() >= 1 {
+			if err := v.SetAnimationMode(d2enum.PlayerAnimationModeDead); err == nil {
+				v.composite.SetPlayLoop(false)
+			}
+		}
+
+		return
+	}
+
 	v.Step(tickTime)
 
 	if v.IsCasting() && v.composite.GetPlayedCount() >= 1 {
@@ -184,8 +218,58 @@ func (v *Player) SetAnimationMode(animationMode d2enum.PlayerAnimationMode) erro
 	return v.composite.SetMode(animationMode, v.composite.GetWeaponClass())
 }
 
+// Kill stops the player and plays the death animation.
+func (v *Player) Kill() {
+	if v.isDead {
+		return
+	}
+
+	v.isDead = true
+	v.isCasting = false
+	v.ClearPath()
+	v.TargetX, v.TargetY = v.LocationX, v.LocationY
+	v.Stats.Health = 0
+
+	if err := v.SetAnimationMode(d2enum.PlayerAnimationModeDeath); err == nil {
+		v.composite.SetPlayLoop(false)
+	}
+}
+
+// Revive brings a dead player back to life.
+func (v *Player) Revive() {
+	if !v.isDead {
+		return
+	}
+
+	v.isDead = false
+	v.composite.SetPlayLoop(true)
+
+	if err := v.SetAnimationMode(v.GetAnimationMode()); err != nil {
+		log.Printf("failed to revive player %s: %s", v.Id, err)
+	}
+}
+
+// IsDead returns true if the player has been killed.
+func (v *Player) IsDead() bool {
+	return v.isDead
+}
+
+// Occupancy returns the collision classes blocked by the player, a dead
+// player does not block the way.
+func (v *Player) Occupancy() d2enum.Collision {
+	if v.isDead {
+		return d2enum.CollisionNone
+	}
+
+	return d2enum.CollisionUnit
+}
+
 // rotate sets direction and changes animation
 func (v *Player) rotate(direction int) {
+	if v.isDead {
+		return
+	}
+
 	newAnimationMode := v.GetAnimationMode()
 
 	if newAnimationMode.String() != v.composite.GetAnimationMode() {
@@ -197,6 +281,12 @@ func (v *Player) rotate(direction int) {
 	}
 }
 
+// LightRadius returns the radius, in sub-tiles, of the light around the
+// player.
+func (v *Player) LightRadius() float64 {
+	return playerLightRadius
+}
+
 // Name returns the player name.
 func (v *Player) Name() string {
 	return v.name
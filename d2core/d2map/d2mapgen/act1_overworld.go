@@ -17,18 +17,24 @@ import (
 var wildernessGrass = d2ds1.FloorShadowRecord{Prop1: 1, Style: 0, Sequence: 0}
 
 func loadPreset(mapEngine *d2mapengine.MapEngine, id, index int) *d2mapstamp.Stamp {
+	return loadRegionPreset(mapEngine, d2enum.RegionAct1Wilderness, id, index)
+}
+
+func loadRegionPreset(mapEngine *d2mapengine.MapEngine, regionType d2enum.RegionIdType, id, index int) *d2mapstamp.Stamp {
 	for _, file := range d2datadict.LevelPreset(id).Files {
 		mapEngine.AddDS1(file)
 	}
 
-	return d2mapstamp.LoadStamp(d2enum.RegionAct1Wilderness, id, index)
+	return d2mapstamp.LoadStamp(regionType, id, index)
 }
 
 // GenerateAct1Overworld generates the map and entities for the first town and surrounding area.
 func GenerateAct1Overworld(mapEngine *d2mapengine.MapEngine) {
 	rand.Seed(mapEngine.Seed())
 
-	wilderness1Details := d2datadict.GetLevelDetails(2)
+	wilderness1Details := d2datadict.GetLevelDetails(LevelBloodMoor)
+
+	var wildernessArea d2common.Rectangle
 
 	mapEngine.ResetMap(d2enum.RegionAct1Town, 150, 150)
 	mapWidth := mapEngine.Size().Width
@@ -37,15 +43,17 @@ func GenerateAct1Overworld(mapEngine *d2mapengine.MapEngine) {
 	townStamp := d2mapstamp.LoadStamp(d2enum.RegionAct1Town, 1, -1)
 	townStamp.RegionPath()
 	townSize := townStamp.Size()
+	townArea := d2common.Rectangle{Width: townSize.Width, Height: townSize.Height}
 
 	log.Printf("Region Path: %s", townStamp.RegionPath())
 	if strings.Contains(townStamp.RegionPath(), "E1") {
 		// East Exit
 		mapEngine.PlaceStamp(townStamp, 0, 0)
-		generateWilderness1TownEast(mapEngine, townSize.Width, 0)
+		wildernessArea = generateWilderness1TownEast(mapEngine, townSize.Width, 0)
 	} else if strings.Contains(townStamp.RegionPath(), "S1") {
 		// South Exit
 		mapEngine.PlaceStamp(townStamp, mapWidth-townSize.Width, 0)
+		townArea.Left = mapWidth - townSize.Width
 
 		// Generate the river running along the edge of the map
 		rightWaterBorderStamp := loadPreset(mapEngine, d2wilderness.WaterBorderEast, 0)
@@ -55,21 +63,36 @@ func GenerateAct1Overworld(mapEngine *d2mapengine.MapEngine) {
 			mapEngine.PlaceStamp(rightWaterBorderStamp, mapWidth-17, y)
 			mapEngine.PlaceStamp(rightWaterBorderStamp2, mapWidth-9, y)
 		}
-		generateWilderness1TownSouth(mapEngine, mapWidth-wilderness1Details.SizeXNormal-14, townSize.Height)
+		wildernessArea = generateWilderness1TownSouth(mapEngine, mapWidth-wilderness1Details.SizeXNormal-14, townSize.Height)
 	} else if strings.Contains(townStamp.RegionPath(), "W1") {
 		// West Exit
 		mapEngine.PlaceStamp(townStamp, mapWidth-townSize.Width, mapHeight-townSize.Height)
+		townArea.Left, townArea.Top = mapWidth-townSize.Width, mapHeight-townSize.Height
 
-		generateWilderness1TownWest(mapEngine, mapWidth-townSize.Width-wilderness1Details.SizeXNormal, mapHeight-wilderness1Details.SizeYNormal)
+		wildernessArea = generateWilderness1TownWest(mapEngine, mapWidth-townSize.Width-wilderness1Details.SizeXNormal, mapHeight-wilderness1Details.SizeYNormal)
 	} else {
 		// North Exit
 		mapEngine.PlaceStamp(townStamp, mapWidth-townSize.Width, mapHeight-townSize.Height)
+		townArea.Left, townArea.Top = mapWidth-townSize.Width, mapHeight-townSize.Height
+	}
+
+	// The town comes first, as the wilderness is the rest of the map
+	mapEngine.AddLevel(LevelRogueEncampment, townArea)
+	mapEngine.AddLevel(LevelBloodMoor, d2common.Rectangle{Width: mapWidth, Height: mapHeight})
+
+	// the Cold Plains are generated on a map of their own
+	if wildernessArea.Width > 0 {
+		addOutdoorExits(mapEngine, LevelBloodMoor, wildernessArea, outdoorExits(wilderness1Details))
 	}
 
 	mapEngine.RegenerateWalkPaths()
+	PopulateObjects(mapEngine, wilderness1Details, wildernessArea)
+	SubstituteTiles(mapEngine, wilderness1Details, wildernessArea)
+
+	mapEngine.SetMonsterArea(LevelBloodMoor, wildernessArea)
 }
 
-func generateWilderness1TownEast(mapEngine *d2mapengine.MapEngine, startX, startY int) {
+func generateWilderness1TownEast(mapEngine *d2mapengine.MapEngine, startX, startY int) d2common.Rectangle {
 	levelDetails := d2datadict.GetLevelDetails(2)
 
 	fenceNorthStamp := []*d2mapstamp.Stamp{
@@ -129,9 +152,11 @@ func generateWilderness1TownEast(mapEngine *d2mapengine.MapEngine, startX, start
 	mapEngine.PlaceStamp(fenceWestEdge, startX, startY+(levelDetails.SizeYNormal-3)-45)
 	mapEngine.PlaceStamp(fenceNorthEastStamp, startX+levelDetails.SizeXNormal, startY)
 	mapEngine.PlaceStamp(fenceSouthEastStamp, startX+levelDetails.SizeXNormal, startY+levelDetails.SizeYNormal+6)
+
+	return areaRect
 }
 
-func generateWilderness1TownSouth(mapEngine *d2mapengine.MapEngine, startX, startY int) {
+func generateWilderness1TownSouth(mapEngine *d2mapengine.MapEngine, startX, startY int) d2common.Rectangle {
 	levelDetails := d2datadict.GetLevelDetails(2)
 
 	fenceNorthStamp := []*d2mapstamp.Stamp{
@@ -182,9 +207,11 @@ func generateWilderness1TownSouth(mapEngine *d2mapengine.MapEngine, startX, star
 	mapEngine.PlaceStamp(fenceNorthWestStamp, startX, startY-6)
 	mapEngine.PlaceStamp(fenceSouthWestStamp, startX, startY+(8*9)+3)
 	mapEngine.PlaceStamp(fenceWaterBorderSouthEast, startX+(9*9)-4, startY+(8*9)+1)
+
+	return areaRect
 }
 
-func generateWilderness1TownWest(mapEngine *d2mapengine.MapEngine, startX, startY int) {
+func generateWilderness1TownWest(mapEngine *d2mapengine.MapEngine, startX, startY int) d2common.Rectangle {
 	levelDetails := d2datadict.GetLevelDetails(2)
 
 	fenceEastEdge := loadPreset(mapEngine, d2wilderness.TreeBoxSouthWest, 0)
@@ -248,6 +275,8 @@ func generateWilderness1TownWest(mapEngine *d2mapengine.MapEngine, startX, start
 		Height: levelDetails.SizeYNormal - 2,
 	}
 	generateWilderness1Contents(mapEngine, areaRect)
+
+	return areaRect
 }
 
 func generateWilderness1Contents(mapEngine *d2mapengine.MapEngine, rect d2common.Rectangle) {
@@ -259,14 +288,7 @@ func generateWilderness1Contents(mapEngine *d2mapengine.MapEngine, rect d2common
 		Y: rect.Top + (rect.Height / 2) + rand.Intn(10),
 	}
 
-	// Fill in the grass
-	for y := 0; y < rect.Height; y++ {
-		for x := 0; x < rect.Width; x++ {
-			tile := mapEngine.Tile(rect.Left+x, rect.Top+y)
-			tile.RegionType = d2enum.RegionIdType(levelDetails.LevelType)
-			tile.Floors = []d2ds1.FloorShadowRecord{wildernessGrass}
-		}
-	}
+	fillGround(mapEngine, rect, d2enum.RegionIdType(levelDetails.LevelType))
 
 	stuff := []*d2mapstamp.Stamp{
 		loadPreset(mapEngine, d2wilderness.StoneFill1, 0),
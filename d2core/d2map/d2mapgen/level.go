@@ -0,0 +1,93 @@
+package d2mapgen
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+)
+
+// Levels.txt IDs of the levels generated by GenerateAct1Overworld
+const (
+	LevelRogueEncampment = 1
+	LevelBloodMoor       = 2
+)
+
+// LevelSeed returns the seed of the map of a level in a game with the given
+// seed. Levels which share a map share its seed.
+func LevelSeed(gameSeed int64, levelID int) int64 {
+	if levelID == LevelRogueEncampment || levelID == LevelBloodMoor {
+		return gameSeed
+	}
+
+	return gameSeed + int64(levelID)
+}
+
+// GenerateLevel generates the map of the level with the given Levels.txt ID
+// on the given difficulty, along with the levels which share its map, e.g.
+// the Blood Moor is generated along with the Rogue Encampment. The map is
+// generated without its monsters, see PopulateMonsters.
+func GenerateLevel(mapEngine *d2mapengine.MapEngine, levelID int, difficulty d2enum.DifficultyType) error {
+	if levelID == LevelRogueEncampment || levelID == LevelBloodMoor {
+		GenerateAct1Overworld(mapEngine)
+		return nil
+	}
+
+	levelDetails := d2datadict.GetLevelDetails(levelID)
+	if levelDetails == nil {
+		return fmt.Errorf("unknown level %d", levelID)
+	}
+
+	switch levelDetails.LevelGenerationType {
+	case d2enum.LevelTypePreset:
+		return generatePresetLevel(mapEngine, levelDetails)
+	case d2enum.LevelTypeRandomMaze:
+		return GenerateMaze(mapEngine, levelDetails, difficulty)
+	case d2enum.LevelTypeWilderness:
+		return GenerateOutdoors(mapEngine, levelDetails, difficulty)
+	default:
+		return fmt.Errorf("no generator for level %d (%s)", levelID, levelDetails.Name)
+	}
+}
+
+// generatePresetLevel generates a level which is a single preset, e.g.
+// Tristram.
+func generatePresetLevel(mapEngine *d2mapengine.MapEngine, levelDetails *d2datadict.LevelDetailsRecord) error {
+	preset, found := levelPreset(levelDetails.Id)
+	if !found {
+		return fmt.Errorf("no preset for level %d (%s)", levelDetails.Id, levelDetails.Name)
+	}
+
+	rand.Seed(mapEngine.Seed())
+
+	mapEngine.GenerateMap(d2enum.RegionIdType(levelDetails.LevelType), preset.DefinitionID, -1, false)
+
+	for _, file := range preset.Files {
+		mapEngine.AddDS1(file)
+	}
+
+	area := d2common.Rectangle{Width: mapEngine.Size().Width, Height: mapEngine.Size().Height}
+	mapEngine.AddLevel(levelDetails.Id, area)
+	mapEngine.RegenerateWalkPaths()
+
+	if preset.Populate {
+		PopulateObjects(mapEngine, levelDetails, area)
+		mapEngine.SetMonsterArea(levelDetails.Id, area)
+	}
+
+	return nil
+}
+
+// levelPreset returns the preset which makes up the whole level.
+func levelPreset(levelID int) (d2datadict.LevelPresetRecord, bool) {
+	for _, preset := range d2datadict.LevelPresets {
+		if preset.LevelID == levelID {
+			return preset, true
+		}
+	}
+
+	return d2datadict.LevelPresetRecord{}, false
+}
@@ -0,0 +1,356 @@
+package d2mapgen
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapstamp"
+)
+
+// mazeDirection is a set of sides of a maze room which lead to other rooms.
+type mazeDirection int
+
+// Sides of a maze room, as named by the presets in LvlPrest.txt
+const (
+	mazeNorth mazeDirection = 1 << iota
+	mazeEast
+	mazeSouth
+	mazeWest
+)
+
+// mazeSides are the sides of a maze room and the room they lead to.
+var mazeSides = []struct { //nolint:gochecknoglobals // constant lookup table
+	direction mazeDirection
+	letter    rune
+	step      d2common.Point
+}{
+	{mazeNorth, 'N', d2common.Point{X: 0, Y: -1}},
+	{mazeEast, 'E', d2common.Point{X: 1, Y: 0}},
+	{mazeSouth, 'S', d2common.Point{X: 0, Y: 1}},
+	{mazeWest, 'W', d2common.Point{X: -1, Y: 0}},
+}
+
+// opposite returns the side of the neighbouring room which faces this one.
+func (d mazeDirection) opposite() mazeDirection {
+	return (d<<2 | d>>2) & (mazeNorth | mazeEast | mazeSouth | mazeWest)
+}
+
+// mazeRoomKind tells whether a maze room leads to another level.
+type mazeRoomKind int
+
+// Maze room kinds
+const (
+	mazeRoomRegular  mazeRoomKind = iota
+	mazeRoomEntrance              // leads to the previous level, named Prev or Up in LvlPrest.txt
+	mazeRoomExit                  // leads to the next level, named Next or Down in LvlPrest.txt
+)
+
+// mazeRoom is a room of a maze, in rooms from the top left of the maze.
+type mazeRoom struct {
+	x, y     int
+	openings mazeDirection
+	kind     mazeRoomKind
+	depth    int // the number of rooms between this room and the entrance
+}
+
+// mazeLayout is the arrangement of the rooms of a maze, in rooms.
+type mazeLayout struct {
+	rooms         []*mazeRoom
+	width, height int
+}
+
+// mazePresetKey identifies the presets which fit a maze room.
+type mazePresetKey struct {
+	kind     mazeRoomKind
+	openings mazeDirection
+}
+
+// roomPlacer puts the map of a maze room on the map at the given tile.
+type roomPlacer func(room *mazeRoom, tileX, tileY int) error
+
+// GenerateMaze generates a level of connected rooms, e.g. a cave, from the
+// presets of its level type. The rooms are arranged as a tree which starts
+// at the entrance from the previous level, the exits to the following
+// levels are put in the rooms furthest from it.
+func GenerateMaze(mapEngine *d2mapengine.MapEngine, levelDetails *d2datadict.LevelDetailsRecord,
+	difficulty d2enum.DifficultyType) error {
+	mazeDetails := d2datadict.LevelMazeDetails[levelDetails.Id]
+	if mazeDetails == nil {
+		return fmt.Errorf("no maze details for level %d (%s)", levelDetails.Id, levelDetails.Name)
+	}
+
+	regionType := d2enum.RegionIdType(levelDetails.LevelType)
+	if levelDetails.LevelType < 0 || levelDetails.LevelType >= len(d2datadict.LevelTypes) {
+		return fmt.Errorf("unknown level type %d of level %d", levelDetails.LevelType, levelDetails.Id)
+	}
+
+	presets := mazePresets(d2datadict.LevelTypes[regionType].Name)
+
+	// the stamps pick their files with the global random numbers
+	rand.Seed(mapEngine.Seed())
+	rng := rand.New(rand.NewSource(mapEngine.Seed())) //nolint:gosec // not used for security
+
+	rooms := pick(difficulty, mazeDetails.NumRoomsNormal, mazeDetails.NumRoomsNightmare, mazeDetails.NumRoomsHell)
+	layout := planMaze(rng, rooms, mazeExits(levelDetails))
+
+	err := placeMaze(mapEngine, layout, mazeDetails.SizeX, mazeDetails.SizeY, regionType,
+		func(room *mazeRoom, tileX, tileY int) error {
+			candidates := presets[mazePresetKey{kind: room.kind, openings: room.openings}]
+			if len(candidates) == 0 {
+				return fmt.Errorf("no preset for maze room %v with openings %04b", room.kind, room.openings)
+			}
+
+			preset := d2datadict.LevelPresets[candidates[rng.Intn(len(candidates))]]
+			for _, file := range preset.Files {
+				mapEngine.AddDS1(file)
+			}
+
+			mapEngine.PlaceStamp(d2mapstamp.LoadStamp(regionType, preset.DefinitionID, -1), tileX, tileY)
+
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	area := d2common.Rectangle{Width: mapEngine.Size().Width, Height: mapEngine.Size().Height}
+	mapEngine.AddLevel(levelDetails.Id, area)
+	mapEngine.RegenerateWalkPaths()
+
+	PopulateObjects(mapEngine, levelDetails, area)
+	mapEngine.SetMonsterArea(levelDetails.Id, area)
+
+	return nil
+}
+
+// mazeExits returns the number of levels a maze leads to besides the level
+// it is entered from.
+func mazeExits(levelDetails *d2datadict.LevelDetailsRecord) int {
+	links := []int{
+		levelDetails.LevelLinkID1, levelDetails.LevelLinkID2, levelDetails.LevelLinkID3,
+		levelDetails.LevelLinkID4, levelDetails.LevelLinkID5, levelDetails.LevelLinkID6, levelDetails.LevelLinkID7,
+	}
+
+	exits := 0
+
+	for _, link := range links {
+		if link > 0 {
+			exits++
+		}
+	}
+
+	return exits
+}
+
+// placeMaze sizes the map for the maze and places its rooms, each room
+// takes up sizeX by sizeY tiles.
+func placeMaze(mapEngine *d2mapengine.MapEngine, layout *mazeLayout, sizeX, sizeY int,
+	regionType d2enum.RegionIdType, place roomPlacer) error {
+	mapEngine.ResetMap(regionType, layout.width*sizeX, layout.height*sizeY)
+
+	for _, room := range layout.rooms {
+		if err := place(room, room.x*sizeX, room.y*sizeY); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// planMaze arranges at least two rooms, plus one for each exit, as a tree
+// which starts at the entrance. Rooms are added next to random rooms of the
+// tree, the entrance and the exits only have a single opening.
+func planMaze(rng *rand.Rand, numRooms, exits int) *mazeLayout {
+	entrance := &mazeRoom{kind: mazeRoomEntrance}
+	rooms := []*mazeRoom{entrance}
+	cells := map[d2common.Point]*mazeRoom{{}: entrance}
+
+	regularRooms := numRooms - exits
+	if regularRooms < 2 {
+		regularRooms = 2
+	}
+
+	for len(rooms) < regularRooms {
+		candidates := mazeCandidates(rooms, cells)
+		candidate := candidates[rng.Intn(len(candidates))]
+		rooms = append(rooms, addMazeRoom(cells, candidate.room, candidate.side, mazeRoomRegular))
+	}
+
+	for ; exits > 0; exits-- {
+		candidates := mazeCandidates(rooms, cells)
+
+		deepest := make([]mazeCandidate, 0)
+		for _, candidate := range candidates {
+			if len(deepest) > 0 && candidate.room.depth < deepest[0].room.depth {
+				continue
+			}
+
+			if len(deepest) > 0 && candidate.room.depth > deepest[0].room.depth {
+				deepest = deepest[:0]
+			}
+
+			deepest = append(deepest, candidate)
+		}
+
+		candidate := deepest[rng.Intn(len(deepest))]
+		rooms = append(rooms, addMazeRoom(cells, candidate.room, candidate.side, mazeRoomExit))
+	}
+
+	return normalizeMaze(rooms)
+}
+
+// mazeCandidate is a free cell next to a room of the maze.
+type mazeCandidate struct {
+	room *mazeRoom
+	side int // index of mazeSides
+}
+
+// mazeCandidates returns the free cells next to the rooms a room can be
+// added to, in the order of the rooms.
+func mazeCandidates(rooms []*mazeRoom, cells map[d2common.Point]*mazeRoom) []mazeCandidate {
+	candidates := make([]mazeCandidate, 0)
+
+	for _, room := range rooms {
+		if room.kind == mazeRoomExit || (room.kind == mazeRoomEntrance && room.openings != 0) {
+			continue
+		}
+
+		for side := range mazeSides {
+			step := mazeSides[side].step
+			if _, taken := cells[d2common.Point{X: room.x + step.X, Y: room.y + step.Y}]; !taken {
+				candidates = append(candidates, mazeCandidate{room: room, side: side})
+			}
+		}
+	}
+
+	return candidates
+}
+
+// addMazeRoom adds a room next to the given side of a room and opens the
+// way between them.
+func addMazeRoom(cells map[d2common.Point]*mazeRoom, from *mazeRoom, side int, kind mazeRoomKind) *mazeRoom {
+	direction := mazeSides[side].direction
+	step := mazeSides[side].step
+
+	room := &mazeRoom{
+		x:        from.x + step.X,
+		y:        from.y + step.Y,
+		openings: direction.opposite(),
+		kind:     kind,
+		depth:    from.depth + 1,
+	}
+
+	from.openings |= direction
+	cells[d2common.Point{X: room.x, Y: room.y}] = room
+
+	return room
+}
+
+// normalizeMaze moves the rooms so that the top left room of the maze is at
+// 0,0, and measures the maze.
+func normalizeMaze(rooms []*mazeRoom) *mazeLayout {
+	minX, minY, maxX, maxY := 0, 0, 0, 0
+
+	for _, room := range rooms {
+		if room.x < minX {
+			minX = room.x
+		}
+
+		if room.y < minY {
+			minY = room.y
+		}
+
+		if room.x > maxX {
+			maxX = room.x
+		}
+
+		if room.y > maxY {
+			maxY = room.y
+		}
+	}
+
+	for _, room := range rooms {
+		room.x -= minX
+		room.y -= minY
+	}
+
+	return &mazeLayout{rooms: rooms, width: maxX - minX + 1, height: maxY - minY + 1}
+}
+
+// mazePresets returns the IDs of the maze room presets of a level type,
+// e.g. "Act 1 - Cave NE" or "Act 1 - Cave Prev W" for "Act 1 - Cave", by
+// the rooms they fit.
+func mazePresets(levelTypeName string) map[mazePresetKey][]int {
+	presets := make(map[mazePresetKey][]int)
+
+	for id := range d2datadict.LevelPresets {
+		key, ok := parseMazePreset(d2datadict.LevelPresets[id].Name, levelTypeName)
+		if ok {
+			presets[key] = append(presets[key], id)
+		}
+	}
+
+	// the presets of a room are picked at random, so their order has to be
+	// the same everywhere the maze is generated
+	for key := range presets {
+		sort.Ints(presets[key])
+	}
+
+	return presets
+}
+
+// parseMazePreset reads the kind of room and the openings of a maze room
+// preset from its name.
+func parseMazePreset(name, levelTypeName string) (mazePresetKey, bool) {
+	if levelTypeName == "" || !strings.HasPrefix(name, levelTypeName+" ") {
+		return mazePresetKey{}, false
+	}
+
+	words := strings.Fields(strings.TrimPrefix(name, levelTypeName))
+	key := mazePresetKey{kind: mazeRoomRegular}
+
+	if len(words) == 2 {
+		switch words[0] {
+		case "Prev", "Up":
+			key.kind = mazeRoomEntrance
+		case "Next", "Down":
+			key.kind = mazeRoomExit
+		default:
+			return mazePresetKey{}, false
+		}
+
+		words = words[1:]
+	}
+
+	if len(words) != 1 {
+		return mazePresetKey{}, false
+	}
+
+	for _, letter := range words[0] {
+		found := false
+
+		for _, side := range mazeSides {
+			if side.letter == letter && key.openings&side.direction == 0 {
+				key.openings |= side.direction
+				found = true
+			}
+		}
+
+		if !found {
+			return mazePresetKey{}, false
+		}
+	}
+
+	if key.kind != mazeRoomRegular && key.openings != mazeNorth && key.openings != mazeEast &&
+		key.openings != mazeSouth && key.openings != mazeWest {
+		return mazePresetKey{}, false
+	}
+
+	return key, true
+}
@@ -0,0 +1,171 @@
+package d2mapgen
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+)
+
+const testRoomSize = 8 // in tiles
+
+func TestParseMazePreset(t *testing.T) {
+	tests := []struct {
+		name string
+		key  mazePresetKey
+		ok   bool
+	}{
+		{"Act 1 - Cave NE", mazePresetKey{mazeRoomRegular, mazeNorth | mazeEast}, true},
+		{"Act 1 - Cave NESW", mazePresetKey{mazeRoomRegular, mazeNorth | mazeEast | mazeSouth | mazeWest}, true},
+		{"Act 1 - Cave Prev W", mazePresetKey{mazeRoomEntrance, mazeWest}, true},
+		{"Act 1 - Cave Down S", mazePresetKey{mazeRoomExit, mazeSouth}, true},
+		{"Act 1 - Cave Next EW", mazePresetKey{}, false},
+		{"Act 1 - Cave Den Of Evil", mazePresetKey{}, false},
+		{"Act 1 - Crypt N", mazePresetKey{}, false},
+	}
+
+	for _, test := range tests {
+		key, ok := parseMazePreset(test.name, "Act 1 - Cave")
+		if ok != test.ok || key != test.key {
+			t.Errorf("%s: expected %v %v, got %v %v", test.name, test.key, test.ok, key, ok)
+		}
+	}
+}
+
+func TestPlanMazeOpeningsMatch(t *testing.T) {
+	layout := planMaze(rand.New(rand.NewSource(1)), 12, 2)
+
+	if len(layout.rooms) != 12 {
+		t.Fatalf("expected 12 rooms, got %d", len(layout.rooms))
+	}
+
+	cells := make(map[d2common.Point]*mazeRoom)
+	kinds := make(map[mazeRoomKind]int)
+
+	for _, room := range layout.rooms {
+		cells[d2common.Point{X: room.x, Y: room.y}] = room
+		kinds[room.kind]++
+
+		if room.kind != mazeRoomRegular && (room.openings == 0 || room.openings&(room.openings-1) != 0) {
+			t.Errorf("room %v at %d,%d should have a single opening, has %04b", room.kind, room.x, room.y, room.openings)
+		}
+	}
+
+	if kinds[mazeRoomEntrance] != 1 || kinds[mazeRoomExit] != 2 {
+		t.Errorf("expected 1 entrance and 2 exits, got %d and %d", kinds[mazeRoomEntrance], kinds[mazeRoomExit])
+	}
+
+	for _, room := range layout.rooms {
+		for _, side := range mazeSides {
+			neighbour := cells[d2common.Point{X: room.x + side.step.X, Y: room.y + side.step.Y}]
+			opened := room.openings&side.direction != 0
+
+			if opened && (neighbour == nil || neighbour.openings&side.direction.opposite() == 0) {
+				t.Errorf("room at %d,%d opens %c to a room which does not open back", room.x, room.y, side.letter)
+			}
+		}
+	}
+}
+
+func TestMazeWalkMeshIsConnected(t *testing.T) {
+	d2datadict.LevelTypes = make([]d2datadict.LevelTypeRecord, 1)
+
+	layout := planMaze(rand.New(rand.NewSource(2)), 15, 1)
+	mapEngine := d2mapengine.CreateMapEngine()
+	placed := make([]*mazeRoom, 0)
+
+	err := placeMaze(mapEngine, layout, testRoomSize, testRoomSize, 0, func(room *mazeRoom, tileX, tileY int) error {
+		if tileX != room.x*testRoomSize || tileY != room.y*testRoomSize {
+			t.Errorf("room %d,%d placed at tile %d,%d", room.x, room.y, tileX, tileY)
+		}
+
+		placed = append(placed, room)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mapEngine.RegenerateWalkPaths()
+	carveRooms(mapEngine, placed)
+
+	start := roomCenter(layout.rooms[0])
+	reached := walkableFrom(mapEngine, start)
+
+	for _, room := range layout.rooms {
+		if center := roomCenter(room); !reached[center] {
+			t.Errorf("room at %d,%d can not be reached from the entrance", room.x, room.y)
+		}
+	}
+
+	walkable := 0
+	size := mapEngine.Size()
+
+	for y := 0; y < size.Height*5; y++ {
+		for x := 0; x < size.Width*5; x++ {
+			if mapEngine.IsWalkable(x, y) {
+				walkable++
+			}
+		}
+	}
+
+	if walkable != len(reached) {
+		t.Errorf("%d walkable sub-tiles, but only %d can be reached", walkable, len(reached))
+	}
+}
+
+// carveRooms blocks the whole walk mesh and opens a corridor from the
+// center of each room to each side it opens to, like the presets do.
+func carveRooms(mapEngine *d2mapengine.MapEngine, rooms []*mazeRoom) {
+	size := mapEngine.Size()
+	mapEngine.SetAreaWalkable(d2common.Rectangle{Width: size.Width * 5, Height: size.Height * 5}, false)
+
+	half := testRoomSize * 5 / 2
+
+	for _, room := range rooms {
+		center := roomCenter(room)
+		mapEngine.SetAreaWalkable(d2common.Rectangle{Left: center.X - 1, Top: center.Y - 1, Width: 3, Height: 3}, true)
+
+		for _, side := range mazeSides {
+			if room.openings&side.direction == 0 {
+				continue
+			}
+
+			for step := 0; step <= half; step++ {
+				mapEngine.SetWalkable(center.X+side.step.X*step, center.Y+side.step.Y*step, true)
+			}
+		}
+	}
+}
+
+func roomCenter(room *mazeRoom) d2common.Point {
+	return d2common.Point{
+		X: room.x*testRoomSize*5 + testRoomSize*5/2,
+		Y: room.y*testRoomSize*5 + testRoomSize*5/2,
+	}
+}
+
+// walkableFrom returns the walkable sub-tiles which can be reached from the
+// given one.
+func walkableFrom(mapEngine *d2mapengine.MapEngine, start d2common.Point) map[d2common.Point]bool {
+	reached := map[d2common.Point]bool{start: true}
+	queue := []d2common.Point{start}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, side := range mazeSides {
+			next := d2common.Point{X: current.X + side.step.X, Y: current.Y + side.step.Y}
+			if !reached[next] && mapEngine.IsWalkable(next.X, next.Y) {
+				reached[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return reached
+}
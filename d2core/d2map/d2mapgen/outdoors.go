@@ -0,0 +1,389 @@
+package d2mapgen
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2ds1"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapgen/d2wilderness"
+)
+
+const (
+	// the border presets are squares of this many tiles
+	outdoorBorderSize = 9
+
+	// one filler stamp is placed per this many tiles of an outdoor level
+	outdoorFillerTiles = 256
+
+	// attempts to find free room for a filler stamp before giving up
+	outdoorFillerAttempts = 20
+
+	// the exits to neighbouring outdoor levels are this many tiles wide
+	outdoorExitWidth = outdoorBorderSize
+
+	// players walk this many tiles into a level before leaving it
+	outdoorExitDepth = 2
+)
+
+// outdoorStyle are the presets the outdoor levels of an act are made of:
+// the border of each side and corner, e.g. mazeNorth|mazeEast for the
+// north east corner, and the stamps scattered over the ground.
+type outdoorStyle struct {
+	borders map[mazeDirection]int
+	fillers []int
+}
+
+// act1Outdoors is the style of the outdoor levels of act 1, which have tree
+// borders.
+func act1Outdoors() outdoorStyle {
+	return outdoorStyle{
+		borders: map[mazeDirection]int{
+			mazeNorth:            d2wilderness.TreeBorderNorth,
+			mazeEast:             d2wilderness.TreeBorderEast,
+			mazeSouth:            d2wilderness.TreeBorderSouth,
+			mazeWest:             d2wilderness.TreeBorderWest,
+			mazeNorth | mazeWest: d2wilderness.TreeBorderNorthWest,
+			mazeNorth | mazeEast: d2wilderness.TreeBorderNorthEast,
+			mazeSouth | mazeWest: d2wilderness.TreeBorderSouthWest,
+			mazeSouth | mazeEast: d2wilderness.TreeBorderSouthEast,
+		},
+		fillers: []int{
+			d2wilderness.StoneFill1,
+			d2wilderness.StoneFill2,
+			d2wilderness.Cottages1,
+			d2wilderness.FallenCamp1,
+			d2wilderness.Pond,
+			d2wilderness.SwampFill1,
+			d2wilderness.SwampFill2,
+		},
+	}
+}
+
+// outdoorStyleOf returns the style of the outdoor levels of an act, act 0
+// being act 1 as in Levels.txt. Other acts than act 1 are not bordered, as
+// the presets do not tell which side a border is for, and are filled with
+// the outdoor presets of the act which are named as fillers in
+// LvlPrest.txt.
+func outdoorStyleOf(act int) outdoorStyle {
+	if act == 0 {
+		return act1Outdoors()
+	}
+
+	prefix := fmt.Sprintf("Act %d - ", act+1)
+	style := outdoorStyle{fillers: make([]int, 0)}
+
+	for id, preset := range d2datadict.LevelPresets {
+		if preset.LevelID == 0 && preset.Outdoors && strings.HasPrefix(preset.Name, prefix) &&
+			strings.Contains(preset.Name, "Fill") {
+			style.fillers = append(style.fillers, id)
+		}
+	}
+
+	// the fillers are picked at random, so their order has to be the same
+	// everywhere the level is generated
+	sort.Ints(style.fillers)
+
+	return style
+}
+
+// GenerateOutdoors generates an outdoor level of any act from the size in
+// Levels.txt and the outdoor presets of its act: the ground of its level
+// type, scattered filler stamps and a border which is open towards the
+// outdoor levels next to it. The openings lead to those levels.
+func GenerateOutdoors(mapEngine *d2mapengine.MapEngine, levelDetails *d2datadict.LevelDetailsRecord,
+	difficulty d2enum.DifficultyType) error {
+	width := pick(difficulty, levelDetails.SizeXNormal, levelDetails.SizeXNightmare, levelDetails.SizeXHell)
+	height := pick(difficulty, levelDetails.SizeYNormal, levelDetails.SizeYNightmare, levelDetails.SizeYHell)
+
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("level %d (%s) has no size", levelDetails.Id, levelDetails.Name)
+	}
+
+	rand.Seed(mapEngine.Seed())
+
+	style := outdoorStyleOf(levelDetails.Act)
+	regionType := d2enum.RegionIdType(levelDetails.LevelType)
+
+	border := 0
+	if len(style.borders) > 0 {
+		border = outdoorBorderSize
+	}
+
+	area := d2common.Rectangle{
+		Left:   border,
+		Top:    border,
+		Width:  width,
+		Height: height,
+	}
+
+	mapEngine.ResetMap(regionType, area.Width+border*2, area.Height+border*2)
+	mapArea := d2common.Rectangle{Width: mapEngine.Size().Width, Height: mapEngine.Size().Height}
+
+	// the ground goes under the border too, so that the openings are walkable
+	fillGround(mapEngine, mapArea, regionType)
+	placeFillers(mapEngine, area, regionType, style.fillers)
+
+	exits := outdoorExits(levelDetails)
+	placeBorders(mapEngine, regionType, style, exits)
+
+	mapEngine.AddLevel(levelDetails.Id, mapArea)
+	addOutdoorExits(mapEngine, levelDetails.Id, area, exits)
+	mapEngine.RegenerateWalkPaths()
+
+	PopulateObjects(mapEngine, levelDetails, area)
+	SubstituteTiles(mapEngine, levelDetails, area)
+
+	mapEngine.SetMonsterArea(levelDetails.Id, area)
+
+	return nil
+}
+
+// fillGround covers an area, in tiles, with the plain ground of a level
+// type.
+func fillGround(mapEngine *d2mapengine.MapEngine, area d2common.Rectangle, regionType d2enum.RegionIdType) {
+	for y := area.Top; y < area.Bottom(); y++ {
+		for x := area.Left; x < area.Right(); x++ {
+			tile := mapEngine.Tile(x, y)
+			tile.RegionType = regionType
+			tile.Floors = []d2ds1.FloorShadowRecord{wildernessGrass}
+		}
+	}
+}
+
+// placeFillers scatters filler stamps over the plain ground of an area, in
+// tiles.
+func placeFillers(mapEngine *d2mapengine.MapEngine, area d2common.Rectangle, regionType d2enum.RegionIdType,
+	fillers []int) {
+	if len(fillers) == 0 || area.Width <= 0 || area.Height <= 0 {
+		return
+	}
+
+	for count := area.Width * area.Height / outdoorFillerTiles; count > 0; count-- {
+		for attempt := 0; attempt < outdoorFillerAttempts; attempt++ {
+			stamp := loadRegionPreset(mapEngine, regionType, fillers[rand.Intn(len(fillers))], -1)
+
+			stampRect := d2common.Rectangle{
+				Left:   area.Left + rand.Intn(area.Width) - stamp.Size().Width,
+				Top:    area.Top + rand.Intn(area.Height) - stamp.Size().Height,
+				Width:  stamp.Size().Width,
+				Height: stamp.Size().Height,
+			}
+
+			if stampRect.Left >= area.Left && stampRect.Top >= area.Top && stampRect.Right() <= area.Right() &&
+				stampRect.Bottom() <= area.Bottom() && areaEmpty(mapEngine, stampRect) {
+				mapEngine.PlaceStamp(stamp, stampRect.Left, stampRect.Top)
+				break
+			}
+		}
+	}
+}
+
+// outdoorExit is a side of an outdoor level which leads to another outdoor
+// level.
+type outdoorExit struct {
+	levelID int
+	side    mazeDirection
+}
+
+// outdoorExits returns the outdoor levels linked to a level and the side
+// of the level they are on, which is found from their position in the
+// world of the act.
+func outdoorExits(levelDetails *d2datadict.LevelDetailsRecord) []outdoorExit {
+	links := []int{
+		levelDetails.LevelLinkID0, levelDetails.LevelLinkID1, levelDetails.LevelLinkID2, levelDetails.LevelLinkID3,
+		levelDetails.LevelLinkID4, levelDetails.LevelLinkID5, levelDetails.LevelLinkID6, levelDetails.LevelLinkID7,
+	}
+
+	exits := make([]outdoorExit, 0)
+
+	for _, link := range links {
+		neighbour := d2datadict.GetLevelDetails(link)
+		if link <= 0 || neighbour == nil || neighbour.IsInside || neighbour.Act != levelDetails.Act {
+			continue
+		}
+
+		exits = append(exits, outdoorExit{levelID: link, side: sideOf(levelDetails, neighbour)})
+	}
+
+	return exits
+}
+
+// sideOf returns the side of a level the other level is on.
+func sideOf(levelDetails, other *d2datadict.LevelDetailsRecord) mazeDirection {
+	dx := (other.WorldOffsetX + other.SizeXNormal/2) - (levelDetails.WorldOffsetX + levelDetails.SizeXNormal/2)
+	dy := (other.WorldOffsetY + other.SizeYNormal/2) - (levelDetails.WorldOffsetY + levelDetails.SizeYNormal/2)
+
+	switch {
+	case abs(dx) >= abs(dy) && dx >= 0:
+		return mazeEast
+	case abs(dx) >= abs(dy):
+		return mazeWest
+	case dy >= 0:
+		return mazeSouth
+	default:
+		return mazeNorth
+	}
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
+
+// placeBorders surrounds the map with the border presets of the style,
+// leaving a gap in the middle of the sides which lead to other levels.
+func placeBorders(mapEngine *d2mapengine.MapEngine, regionType d2enum.RegionIdType, style outdoorStyle,
+	exits []outdoorExit) {
+	if len(style.borders) == 0 {
+		return
+	}
+
+	open := make(map[mazeDirection]bool)
+	for _, exit := range exits {
+		open[exit.side] = true
+	}
+
+	size := mapEngine.Size()
+
+	for _, segment := range planBorders(size.Width, size.Height, outdoorBorderSize, open) {
+		stamp := loadRegionPreset(mapEngine, regionType, style.borders[segment.side], -1)
+
+		x := minInt(segment.x, size.Width-stamp.Size().Width)
+		y := minInt(segment.y, size.Height-stamp.Size().Height)
+
+		if x >= 0 && y >= 0 {
+			mapEngine.PlaceStamp(stamp, x, y)
+		}
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// borderSegment is a border preset on a side or in a corner of a map, in
+// tiles.
+type borderSegment struct {
+	side mazeDirection
+	x, y int
+}
+
+// planBorders lays out the border segments around a map of the given size,
+// in tiles. The corners come first, then the segments along the sides. The
+// last segment of a side overlaps the one before it when the side is not a
+// multiple of the border size, and the segments which would cover the
+// opening in the middle of an open side are left out.
+func planBorders(width, height, border int, open map[mazeDirection]bool) []borderSegment {
+	segments := []borderSegment{
+		{mazeNorth | mazeWest, 0, 0},
+		{mazeNorth | mazeEast, width - border, 0},
+		{mazeSouth | mazeWest, 0, height - border},
+		{mazeSouth | mazeEast, width - border, height - border},
+	}
+
+	for _, x := range sideSegments(width, border) {
+		if !open[mazeNorth] || !coversOpening(x, width, border) {
+			segments = append(segments, borderSegment{mazeNorth, x, 0})
+		}
+
+		if !open[mazeSouth] || !coversOpening(x, width, border) {
+			segments = append(segments, borderSegment{mazeSouth, x, height - border})
+		}
+	}
+
+	for _, y := range sideSegments(height, border) {
+		if !open[mazeWest] || !coversOpening(y, height, border) {
+			segments = append(segments, borderSegment{mazeWest, 0, y})
+		}
+
+		if !open[mazeEast] || !coversOpening(y, height, border) {
+			segments = append(segments, borderSegment{mazeEast, width - border, y})
+		}
+	}
+
+	return segments
+}
+
+// sideSegments returns where the segments between the corners of a side of
+// the given length start.
+func sideSegments(length, border int) []int {
+	starts := make([]int, 0)
+
+	for start := border; start < length-border; start += border {
+		starts = append(starts, minInt(start, length-border*2))
+	}
+
+	return starts
+}
+
+// coversOpening tells whether a segment covers the opening in the middle of
+// a side of the given length, which is as wide as a segment.
+func coversOpening(start, length, border int) bool {
+	opening := (length - border) / 2
+
+	return start < opening+border && opening < start+border
+}
+
+// addOutdoorExits adds the warps to the outdoor levels next to a level, in
+// the middle of the side of its area, in tiles, they are on.
+func addOutdoorExits(mapEngine *d2mapengine.MapEngine, levelID int, area d2common.Rectangle, exits []outdoorExit) {
+	for _, exit := range exits {
+		if _, sameMap := mapEngine.LevelArea(exit.levelID); sameMap {
+			continue
+		}
+
+		mapEngine.AddWarp(edgeWarp(levelID, exit.levelID, exit.side, area))
+	}
+}
+
+// edgeWarp returns a warp along the middle of a side of an area, in tiles,
+// which players leave through by walking to the edge of the area.
+func edgeWarp(levelID, destinationID int, side mazeDirection, area d2common.Rectangle) *d2mapengine.Warp {
+	warp := &d2mapengine.Warp{LevelID: levelID, DestinationID: destinationID}
+	record := &d2datadict.LevelWarpRecord{Name: "Edge"}
+
+	const (
+		depth = outdoorExitDepth * subTilesPerTile
+		width = outdoorExitWidth * subTilesPerTile
+	)
+
+	switch side {
+	case mazeNorth, mazeSouth:
+		warp.TileX = area.Left + (area.Width-outdoorExitWidth)/2
+		warp.TileY = area.Top
+		record.SelectDX, record.SelectDY = width, subTilesPerTile
+		record.ExitWalkX, record.ExitWalkY = width/2, depth
+
+		if side == mazeSouth {
+			warp.TileY = area.Bottom() - 1
+			record.ExitWalkY = subTilesPerTile - depth
+		}
+	default:
+		warp.TileX = area.Left
+		warp.TileY = area.Top + (area.Height-outdoorExitWidth)/2
+		record.SelectDX, record.SelectDY = subTilesPerTile, width
+		record.ExitWalkX, record.ExitWalkY = depth, width/2
+
+		if side == mazeEast {
+			warp.TileX = area.Right() - 1
+			record.ExitWalkX = subTilesPerTile - depth
+		}
+	}
+
+	warp.Record = record
+
+	return warp
+}
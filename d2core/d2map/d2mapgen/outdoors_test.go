@@ -0,0 +1,119 @@
+package d2mapgen
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+)
+
+func TestSideOf(t *testing.T) {
+	level := &d2datadict.LevelDetailsRecord{WorldOffsetX: 100, WorldOffsetY: 100, SizeXNormal: 80, SizeYNormal: 80}
+
+	tests := []struct {
+		x, y int
+		side mazeDirection
+	}{
+		{100, 20, mazeNorth},
+		{180, 90, mazeEast},
+		{110, 180, mazeSouth},
+		{20, 120, mazeWest},
+	}
+
+	for _, test := range tests {
+		other := &d2datadict.LevelDetailsRecord{WorldOffsetX: test.x, WorldOffsetY: test.y, SizeXNormal: 80, SizeYNormal: 80}
+		if side := sideOf(level, other); side != test.side {
+			t.Errorf("level at %d,%d: expected side %04b, got %04b", test.x, test.y, test.side, side)
+		}
+	}
+}
+
+func TestPlanBordersLeavesOpenings(t *testing.T) {
+	const width, height, border = 50, 41, 9
+
+	segments := planBorders(width, height, border, map[mazeDirection]bool{mazeEast: true})
+
+	covered := make(map[mazeDirection]map[int]bool)
+
+	for _, segment := range segments {
+		if segment.x < 0 || segment.y < 0 || segment.x+border > width || segment.y+border > height {
+			t.Errorf("segment %04b at %d,%d is outside the map", segment.side, segment.x, segment.y)
+		}
+
+		if covered[segment.side] == nil {
+			covered[segment.side] = make(map[int]bool)
+		}
+
+		// the sides are measured along them, the corners are only counted
+		start := segment.x
+		if segment.side == mazeEast || segment.side == mazeWest {
+			start = segment.y
+		}
+
+		for offset := 0; offset < border; offset++ {
+			covered[segment.side][start+offset] = true
+		}
+	}
+
+	for _, corner := range []mazeDirection{mazeNorth | mazeWest, mazeNorth | mazeEast, mazeSouth | mazeWest, mazeSouth | mazeEast} {
+		if len(covered[corner]) == 0 {
+			t.Errorf("no border in corner %04b", corner)
+		}
+	}
+
+	for x := border; x < width-border; x++ {
+		if !covered[mazeNorth][x] || !covered[mazeSouth][x] {
+			t.Errorf("closed sides have a gap at x %d", x)
+		}
+	}
+
+	opening := (height - border) / 2
+
+	for y := border; y < height-border; y++ {
+		if !covered[mazeWest][y] {
+			t.Errorf("west side has a gap at y %d", y)
+		}
+
+		if inOpening := y >= opening && y < opening+border; inOpening && covered[mazeEast][y] {
+			t.Errorf("east side is closed at y %d", y)
+		}
+	}
+}
+
+func TestEdgeWarp(t *testing.T) {
+	area := d2common.Rectangle{Left: 9, Top: 9, Width: 40, Height: 30}
+
+	tests := []struct {
+		side    mazeDirection
+		inside  d2common.Point // a sub-tile on the edge of the area
+		outside d2common.Point // a sub-tile a tile away from the edge
+		exitX   int
+		exitY   int
+	}{
+		{mazeNorth, d2common.Point{X: 28 * 5, Y: 9 * 5}, d2common.Point{X: 28 * 5, Y: 10 * 5}, 142, 11 * 5},
+		{mazeSouth, d2common.Point{X: 28 * 5, Y: 38*5 + 4}, d2common.Point{X: 28 * 5, Y: 37*5 + 4}, 142, 37 * 5},
+		{mazeWest, d2common.Point{X: 9 * 5, Y: 23 * 5}, d2common.Point{X: 10 * 5, Y: 23 * 5}, 11 * 5, 117},
+		{mazeEast, d2common.Point{X: 48*5 + 4, Y: 23 * 5}, d2common.Point{X: 47*5 + 4, Y: 23 * 5}, 47 * 5, 117},
+	}
+
+	for _, test := range tests {
+		warp := edgeWarp(2, 3, test.side, area)
+
+		if warp.LevelID != 2 || warp.DestinationID != 3 {
+			t.Errorf("side %04b: expected a warp from level 2 to 3, got %d to %d", test.side, warp.LevelID, warp.DestinationID)
+		}
+
+		selectArea := warp.SelectArea()
+		if !selectArea.IsInRect(test.inside.X, test.inside.Y) {
+			t.Errorf("side %04b: %v is not in the select area %v", test.side, test.inside, selectArea)
+		}
+
+		if selectArea.IsInRect(test.outside.X, test.outside.Y) {
+			t.Errorf("side %04b: the select area %v is more than a tile deep", test.side, selectArea)
+		}
+
+		if x, y := warp.ExitWalk(); x != test.exitX || y != test.exitY {
+			t.Errorf("side %04b: expected the exit at %d,%d, got %d,%d", test.side, test.exitX, test.exitY, x, y)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package d2mapgen
+
+import (
+	"math/rand"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+)
+
+const (
+	// MonDen in Levels.txt is the number of monster groups per this many tiles
+	densityTiles = 100000
+
+	// attempts to find a free walkable sub-tile before giving up on a spawn
+	spawnAttempts = 20
+
+	// monsters of a group are spawned within this many sub-tiles of its leader
+	groupRadius = 8
+
+	subTilesPerTile = 5
+)
+
+// spawn is a monster which is going to be placed on the map, in sub-tiles.
+type spawn struct {
+	record *d2datadict.MonStatsRecord
+	x, y   int
+}
+
+// walkable tells whether a sub-tile can be walked on.
+type walkable func(subTileX, subTileY int) bool
+
+// spawnPlanner picks the monsters of a level and free walkable sub-tiles to
+// place them on.
+type spawnPlanner struct {
+	walkable walkable
+	rng      *rand.Rand
+	area     d2common.Rectangle // in sub-tiles
+	occupied map[d2common.Point]bool
+	spawns   []spawn
+}
+
+// PopulateLevel spawns the monsters of a level in the given area, in tiles:
+// groups of the level's monster types according to its monster density and
+// unique packs according to its MonUMin and MonUMax. The minions of
+// SuperUniques already placed on the map by their presets are spawned around
+// them. Monsters are only placed on walkable sub-tiles, so the walk mesh has
+// to be generated before. The same map seed always spawns the same monsters.
+func PopulateLevel(mapEngine *d2mapengine.MapEngine, levelDetails *d2datadict.LevelDetailsRecord,
+	area d2common.Rectangle, difficulty d2enum.DifficultyType) {
+	planner := createSpawnPlanner(mapEngine.IsWalkable, area, populationRand(mapEngine, levelDetails.Id))
+
+	for _, entity := range *mapEngine.Entities() {
+		npc, ok := entity.(*d2mapentity.NPC)
+		if !ok {
+			continue
+		}
+
+		x, y := int(npc.LocationX), int(npc.LocationY)
+		planner.occupy(x, y)
+
+		if superUnique := npc.SuperUnique(); superUnique != nil {
+			planner.planMinions(npc.MonStatsRecord(), superUnique.MinGrp, superUnique.MaxGrp, x, y)
+		}
+	}
+
+	planner.planLevel(levelDetails, difficulty)
+
+	for _, monster := range planner.spawns {
+		mapEngine.AddEntity(d2mapentity.CreateNPC(monster.x, monster.y, monster.record, planner.rng.Intn(8)))
+	}
+}
+
+// populationRand returns the random numbers the monsters and objects of a
+// level are spawned with. They only depend on the seed of the map and on the
+// level, so that every machine generating the level spawns the same ones.
+func populationRand(mapEngine *d2mapengine.MapEngine, levelID int) *rand.Rand {
+	return rand.New(rand.NewSource(mapEngine.Seed() + int64(levelID))) //nolint:gosec // not used for security
+}
+
+// PopulateMonsters spawns the monsters of the levels on a generated map in
+// the areas the generators set aside for them, see PopulateLevel. The game
+// server populates the maps and sends the monsters to its clients.
+func PopulateMonsters(mapEngine *d2mapengine.MapEngine, difficulty d2enum.DifficultyType) {
+	for _, levelID := range mapEngine.Levels() {
+		area, ok := mapEngine.MonsterArea(levelID)
+		levelDetails := d2datadict.GetLevelDetails(levelID)
+
+		if ok && levelDetails != nil {
+			PopulateLevel(mapEngine, levelDetails, area, difficulty)
+		}
+	}
+}
+
+func createSpawnPlanner(isWalkable walkable, area d2common.Rectangle, rng *rand.Rand) *spawnPlanner {
+	return &spawnPlanner{
+		walkable: isWalkable,
+		rng:      rng,
+		area: d2common.Rectangle{
+			Left:   area.Left * subTilesPerTile,
+			Top:    area.Top * subTilesPerTile,
+			Width:  area.Width * subTilesPerTile,
+			Height: area.Height * subTilesPerTile,
+		},
+		occupied: make(map[d2common.Point]bool),
+	}
+}
+
+// planLevel plans the regular monster groups and the unique packs of a level.
+func (p *spawnPlanner) planLevel(levelDetails *d2datadict.LevelDetailsRecord, difficulty d2enum.DifficultyType) {
+	monsters := p.pickMonsterTypes(levelMonsters(levelDetails, difficulty), levelDetails.NumMonsterTypes)
+	if len(monsters) == 0 {
+		return
+	}
+
+	density := pick(difficulty, levelDetails.MonsterDensityNormal,
+		levelDetails.MonsterDensityNightmare, levelDetails.MonsterDensityHell)
+	tiles := (p.area.Width / subTilesPerTile) * (p.area.Height / subTilesPerTile)
+
+	for groups := tiles * density / densityTiles; groups > 0; groups-- {
+		record := monsters[p.rng.Intn(len(monsters))]
+		p.planGroup(record, record.MinionGroupMin, record.MinionGroupMax)
+	}
+
+	uniques := levelUniques(levelDetails)
+	if len(uniques) == 0 {
+		uniques = monsters
+	}
+
+	uniqueMin := pick(difficulty, levelDetails.MonsterUniqueMinNormal,
+		levelDetails.MonsterUniqueMinNightmare, levelDetails.MonsterUniqueMinHell)
+	uniqueMax := pick(difficulty, levelDetails.MonsterUniqueMaxNormal,
+		levelDetails.MonsterUniqueMaxNightmare, levelDetails.MonsterUniqueMaxHell)
+
+	// TODO: turn the leaders into uniques and champions with MonUMod.txt modifiers
+	for packs := p.randomBetween(uniqueMin, uniqueMax); packs > 0; packs-- {
+		leader := uniques[p.rng.Intn(len(uniques))]
+
+		x, y, ok := p.freeSubTile(p.area)
+		if !ok {
+			return
+		}
+
+		p.add(leader, x, y)
+		p.planMinions(leader, leader.MinionPartyMin, leader.MinionPartyMax, x, y)
+	}
+}
+
+// planGroup plans a group of between min and max monsters of the given type
+// at a random position of the area.
+func (p *spawnPlanner) planGroup(record *d2datadict.MonStatsRecord, min, max int) {
+	x, y, ok := p.freeSubTile(p.area)
+	if !ok {
+		return
+	}
+
+	p.add(record, x, y)
+	p.planAround(record, p.randomBetween(min, max)-1, x, y)
+}
+
+// planMinions plans between min and max minions around a leader. Minions are
+// of the leader's minion type, or of the leader's type if it has none.
+func (p *spawnPlanner) planMinions(leader *d2datadict.MonStatsRecord, min, max, x, y int) {
+	minions := make([]*d2datadict.MonStatsRecord, 0)
+
+	for _, key := range []string{leader.MinionId1, leader.MinionId2} {
+		if record := d2datadict.MonStats[key]; record != nil {
+			minions = append(minions, record)
+		}
+	}
+
+	if len(minions) == 0 {
+		minions = append(minions, leader)
+	}
+
+	for count := p.randomBetween(min, max); count > 0; count-- {
+		p.planAround(minions[p.rng.Intn(len(minions))], 1, x, y)
+	}
+}
+
+// planAround plans count monsters of the given type close to a position.
+func (p *spawnPlanner) planAround(record *d2datadict.MonStatsRecord, count, x, y int) {
+	around := d2common.Rectangle{
+		Left:   x - groupRadius,
+		Top:    y - groupRadius,
+		Width:  groupRadius * 2,
+		Height: groupRadius * 2,
+	}
+
+	for ; count > 0; count-- {
+		memberX, memberY, ok := p.freeSubTile(around)
+		if !ok {
+			return
+		}
+
+		p.add(record, memberX, memberY)
+	}
+}
+
+// freeSubTile returns a random walkable and unoccupied sub-tile of the given
+// rectangle, in sub-tiles.
+func (p *spawnPlanner) freeSubTile(rect d2common.Rectangle) (x, y int, ok bool) {
+	if rect.Width <= 0 || rect.Height <= 0 {
+		return 0, 0, false
+	}
+
+	for attempt := 0; attempt < spawnAttempts; attempt++ {
+		x, y = rect.Left+p.rng.Intn(rect.Width), rect.Top+p.rng.Intn(rect.Height)
+		if p.walkable(x, y) && !p.occupied[d2common.Point{X: x, Y: y}] {
+			return x, y, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+func (p *spawnPlanner) add(record *d2datadict.MonStatsRecord, x, y int) {
+	p.occupy(x, y)
+	p.spawns = append(p.spawns, spawn{record: record, x: x, y: y})
+}
+
+func (p *spawnPlanner) occupy(x, y int) {
+	p.occupied[d2common.Point{X: x, Y: y}] = true
+}
+
+// levelMonsters returns the MonStats records of the monsters which can spawn
+// in a level on the given difficulty.
+func levelMonsters(levelDetails *d2datadict.LevelDetailsRecord, difficulty d2enum.DifficultyType) []*d2datadict.MonStatsRecord {
+	keys := []string{
+		levelDetails.MonsterID1Normal, levelDetails.MonsterID2Normal, levelDetails.MonsterID3Normal,
+		levelDetails.MonsterID4Normal, levelDetails.MonsterID5Normal, levelDetails.MonsterID6Normal,
+		levelDetails.MonsterID7Normal, levelDetails.MonsterID8Normal, levelDetails.MonsterID9Normal,
+		levelDetails.MonsterID10Normal,
+	}
+
+	switch difficulty {
+	case d2enum.DifficultyNightmare:
+		keys = []string{
+			levelDetails.MonsterID1Nightmare, levelDetails.MonsterID2Nightmare, levelDetails.MonsterID3Nightmare,
+			levelDetails.MonsterID4Nightmare, levelDetails.MonsterID5Nightmare, levelDetails.MonsterID6Nightmare,
+			levelDetails.MonsterID7Nightmare, levelDetails.MonsterID8Nightmare, levelDetails.MonsterID9Nightmare,
+			levelDetails.MonsterID10Nightmare,
+		}
+	case d2enum.DifficultyHell:
+		keys = []string{
+			levelDetails.MonsterID1Hell, levelDetails.MonsterID2Hell, levelDetails.MonsterID3Hell,
+			levelDetails.MonsterID4Hell, levelDetails.MonsterID5Hell, levelDetails.MonsterID6Hell,
+			levelDetails.MonsterID7Hell, levelDetails.MonsterID8Hell, levelDetails.MonsterID9Hell,
+			levelDetails.MonsterID10Hell,
+		}
+	}
+
+	return monStatsRecords(keys)
+}
+
+// levelUniques returns the MonStats records of the monsters which can lead
+// unique packs in a level.
+func levelUniques(levelDetails *d2datadict.LevelDetailsRecord) []*d2datadict.MonStatsRecord {
+	return monStatsRecords([]string{
+		levelDetails.MonsterUniqueID1, levelDetails.MonsterUniqueID2, levelDetails.MonsterUniqueID3,
+		levelDetails.MonsterUniqueID4, levelDetails.MonsterUniqueID5, levelDetails.MonsterUniqueID6,
+		levelDetails.MonsterUniqueID7, levelDetails.MonsterUniqueID8, levelDetails.MonsterUniqueID9,
+		levelDetails.MonsterUniqueID10,
+	})
+}
+
+func monStatsRecords(keys []string) []*d2datadict.MonStatsRecord {
+	records := make([]*d2datadict.MonStatsRecord, 0, len(keys))
+
+	for _, key := range keys {
+		if record := d2datadict.MonStats[key]; record != nil {
+			records = append(records, record)
+		}
+	}
+
+	return records
+}
+
+// pickMonsterTypes randomly selects at most count of the given monster types.
+func (p *spawnPlanner) pickMonsterTypes(records []*d2datadict.MonStatsRecord, count int) []*d2datadict.MonStatsRecord {
+	if count <= 0 || count >= len(records) {
+		return records
+	}
+
+	picked := make([]*d2datadict.MonStatsRecord, len(records))
+	copy(picked, records)
+	p.rng.Shuffle(len(picked), func(i, j int) { picked[i], picked[j] = picked[j], picked[i] })
+
+	return picked[:count]
+}
+
+func pick(difficulty d2enum.DifficultyType, normal, nightmare, hell int) int {
+	switch difficulty {
+	case d2enum.DifficultyNightmare:
+		return nightmare
+	case d2enum.DifficultyHell:
+		return hell
+	default:
+		return normal
+	}
+}
+
+func (p *spawnPlanner) randomBetween(min, max int) int {
+	if max <= min {
+		return min
+	}
+
+	return min + p.rng.Intn(max-min+1)
+}
@@ -0,0 +1,126 @@
+package d2mapgen
+
+import (
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2object"
+)
+
+const (
+	// number of object clusters per densityTiles tiles
+	objectDensity = 1500
+
+	// objects of a cluster are placed within this many sub-tiles of each other
+	clusterRadius = 4
+)
+
+// populateFunctions are the populate functions of objects.txt which scatter
+// objects over a level, with the minimum and maximum size of their clusters.
+var populateFunctions = map[int][2]int{ //nolint:gochecknoglobals // constant lookup table
+	1: {1, 1},
+	3: {2, 4},
+	5: {1, 3},
+}
+
+// objectSpawn is an object which is going to be placed on the map, in
+// sub-tiles.
+type objectSpawn struct {
+	record *d2datadict.ObjectRecord
+	x, y   int
+}
+
+// PopulateObjects scatters the objects of the act of a level which have a
+// populate function, e.g. barrels and urns, in clusters over the given area,
+// in tiles. Objects are only placed on walkable sub-tiles, so the walk mesh
+// has to be generated before. The same map seed always places the same
+// objects.
+func PopulateObjects(mapEngine *d2mapengine.MapEngine, levelDetails *d2datadict.LevelDetailsRecord,
+	area d2common.Rectangle) {
+	planner := createSpawnPlanner(mapEngine.IsWalkable, area, populationRand(mapEngine, levelDetails.Id))
+
+	for _, entity := range *mapEngine.Entities() {
+		if object, ok := entity.(*d2object.Object); ok {
+			planner.occupy(int(object.LocationX), int(object.LocationY))
+		}
+	}
+
+	objects := planner.planObjects(populatedObjects(levelDetails.Act + 1))
+
+	for _, object := range objects {
+		entity, err := d2object.CreateObject(object.x, object.y, object.record, d2resource.PaletteUnits)
+		if err != nil {
+			log.Printf("failed to create object %s: %v", object.record.Name, err)
+			continue
+		}
+
+		mapEngine.AddEntity(entity)
+
+		if entity.HasCollision() {
+			mapEngine.SetAreaCollision(entity.Footprint(), entity.Collision())
+		}
+	}
+}
+
+// populatedObjects returns the records of the objects of an act which have
+// a populate function.
+func populatedObjects(act int) []*d2datadict.ObjectRecord {
+	records := make([]*d2datadict.ObjectRecord, 0)
+
+	for _, lookup := range d2datadict.LookupObjects(act, int(d2enum.ObjectTypeItem)) {
+		record := d2datadict.Objects[lookup.ObjectsTxtId]
+		if record == nil {
+			continue
+		}
+
+		if _, ok := populateFunctions[record.PopulateFn]; ok {
+			records = append(records, record)
+		}
+	}
+
+	return records
+}
+
+// planObjects plans clusters of the given objects on free walkable sub-tiles
+// of the area.
+func (p *spawnPlanner) planObjects(records []*d2datadict.ObjectRecord) []objectSpawn {
+	objects := make([]objectSpawn, 0)
+
+	if len(records) == 0 {
+		return objects
+	}
+
+	tiles := (p.area.Width / subTilesPerTile) * (p.area.Height / subTilesPerTile)
+
+	for clusters := tiles * objectDensity / densityTiles; clusters > 0; clusters-- {
+		record := records[p.rng.Intn(len(records))]
+
+		x, y, ok := p.freeSubTile(p.area)
+		if !ok {
+			continue
+		}
+
+		size := populateFunctions[record.PopulateFn]
+		around := d2common.Rectangle{
+			Left:   x - clusterRadius,
+			Top:    y - clusterRadius,
+			Width:  clusterRadius * 2,
+			Height: clusterRadius * 2,
+		}
+
+		for count := p.randomBetween(size[0], size[1]); count > 0; count-- {
+			p.occupy(x, y)
+			objects = append(objects, objectSpawn{record: record, x: x, y: y})
+
+			if x, y, ok = p.freeSubTile(around); !ok {
+				break
+			}
+		}
+	}
+
+	return objects
+}
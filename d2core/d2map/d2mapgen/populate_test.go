@@ -0,0 +1,143 @@
+package d2mapgen
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+func testLevel() *d2datadict.LevelDetailsRecord {
+	d2datadict.MonStats = map[string]*d2datadict.MonStatsRecord{
+		"zombie1": {Key: "zombie1", MinionGroupMin: 2, MinionGroupMax: 4},
+		"fallen1": {Key: "fallen1", MinionGroupMin: 3, MinionGroupMax: 5},
+		"fallenshaman1": {
+			Key: "fallenshaman1", MinionId1: "fallen1", MinionPartyMin: 2, MinionPartyMax: 3,
+		},
+	}
+
+	return &d2datadict.LevelDetailsRecord{
+		MonsterID1Normal:       "zombie1",
+		MonsterID2Normal:       "fallen1",
+		MonsterUniqueID1:       "fallenshaman1",
+		MonsterDensityNormal:   10000,
+		MonsterUniqueMinNormal: 1,
+		MonsterUniqueMaxNormal: 2,
+		NumMonsterTypes:        2,
+	}
+}
+
+func TestPlanLevelOnlyUsesFreeWalkableSubTiles(t *testing.T) {
+	// the left half of the area is blocked
+	walkable := func(x, y int) bool { return x >= 50 }
+	rng := rand.New(rand.NewSource(1))
+	planner := createSpawnPlanner(walkable, d2common.Rectangle{Left: 0, Top: 0, Width: 20, Height: 20}, rng)
+	planner.planLevel(testLevel(), d2enum.DifficultyNormal)
+
+	if len(planner.spawns) == 0 {
+		t.Fatal("no monsters were planned")
+	}
+
+	seen := make(map[d2common.Point]bool)
+
+	for _, monster := range planner.spawns {
+		if !walkable(monster.x, monster.y) {
+			t.Errorf("monster %s planned on blocked sub-tile %d,%d", monster.record.Key, monster.x, monster.y)
+		}
+
+		position := d2common.Point{X: monster.x, Y: monster.y}
+		if seen[position] {
+			t.Errorf("two monsters planned on sub-tile %d,%d", monster.x, monster.y)
+		}
+
+		seen[position] = true
+	}
+}
+
+func TestPlanLevelDensity(t *testing.T) {
+	level := testLevel()
+	level.MonsterUniqueMaxNormal, level.MonsterUniqueMinNormal = 0, 0
+
+	rng := rand.New(rand.NewSource(1))
+	planner := createSpawnPlanner(func(x, y int) bool { return true }, d2common.Rectangle{Width: 40, Height: 40}, rng)
+	planner.planLevel(level, d2enum.DifficultyNormal)
+
+	// 1600 tiles at 10000 groups per 100000 tiles are 160 groups of 2 to 5
+	if count := len(planner.spawns); count < 160*2 || count > 160*5 {
+		t.Errorf("unexpected number of monsters for the density: %d", count)
+	}
+}
+
+func TestPlanLevelIsSeeded(t *testing.T) {
+	plan := func(seed int64) []spawn {
+		planner := createSpawnPlanner(func(x, y int) bool { return true }, d2common.Rectangle{Width: 40, Height: 40},
+			rand.New(rand.NewSource(seed)))
+		planner.planLevel(testLevel(), d2enum.DifficultyNormal)
+
+		return planner.spawns
+	}
+
+	first, second := plan(7), plan(7)
+	if len(first) != len(second) {
+		t.Fatalf("the same seed planned %d and %d monsters", len(first), len(second))
+	}
+
+	for idx := range first {
+		if first[idx].record.Key != second[idx].record.Key || first[idx].x != second[idx].x || first[idx].y != second[idx].y {
+			t.Fatalf("the same seed planned %s at %d,%d and %s at %d,%d", first[idx].record.Key, first[idx].x,
+				first[idx].y, second[idx].record.Key, second[idx].x, second[idx].y)
+		}
+	}
+}
+
+func TestPlanMinions(t *testing.T) {
+	testLevel()
+
+	rng := rand.New(rand.NewSource(1))
+	planner := createSpawnPlanner(func(x, y int) bool { return true }, d2common.Rectangle{Width: 10, Height: 10}, rng)
+	planner.planMinions(d2datadict.MonStats["fallenshaman1"], 4, 4, 25, 25)
+
+	if len(planner.spawns) != 4 {
+		t.Fatalf("want 4 minions, got %d", len(planner.spawns))
+	}
+
+	for _, minion := range planner.spawns {
+		if minion.record.Key != "fallen1" {
+			t.Errorf("minion should be of the leader's minion type, got %s", minion.record.Key)
+		}
+
+		if minion.x < 25-groupRadius || minion.x > 25+groupRadius || minion.y < 25-groupRadius || minion.y > 25+groupRadius {
+			t.Errorf("minion spawned too far from its leader at %d,%d", minion.x, minion.y)
+		}
+	}
+}
+
+func TestPlanObjectsInClusters(t *testing.T) {
+	barrel := &d2datadict.ObjectRecord{Name: "barrel", PopulateFn: 3}
+	walkable := func(x, y int) bool { return y >= 50 }
+	rng := rand.New(rand.NewSource(1))
+	planner := createSpawnPlanner(walkable, d2common.Rectangle{Width: 40, Height: 40}, rng)
+	objects := planner.planObjects([]*d2datadict.ObjectRecord{barrel})
+
+	// 1600 tiles at 1500 clusters per 100000 tiles are 24 clusters of 2 to 4
+	if count := len(objects); count < 24 || count > 24*4 {
+		t.Errorf("unexpected number of objects for the density: %d", count)
+	}
+
+	seen := make(map[d2common.Point]bool)
+
+	for _, object := range objects {
+		if !walkable(object.x, object.y) {
+			t.Errorf("object planned on blocked sub-tile %d,%d", object.x, object.y)
+		}
+
+		position := d2common.Point{X: object.x, Y: object.y}
+		if seen[position] {
+			t.Errorf("two objects planned on sub-tile %d,%d", object.x, object.y)
+		}
+
+		seen[position] = true
+	}
+}
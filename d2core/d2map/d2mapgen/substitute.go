@@ -0,0 +1,257 @@
+package d2mapgen
+
+import (
+	"math/rand"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapstamp"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2object"
+)
+
+const (
+	// the blocks of a substitution are GridSize times this many tiles wide
+	substitutionGridTiles = 4
+
+	// the number of themes, the columns of LvlSub.txt which end in 0 to 4
+	substitutionThemes = 5
+)
+
+// substitution is a block of a substitution file which replaces the tiles
+// of the map at the given tile.
+type substitution struct {
+	row   int // index of the substitution in its group
+	block d2common.Rectangle
+	x, y  int
+}
+
+// substitutionPlanner picks the cells of the map, in tiles, which are
+// replaced by blocks of substitution files. Only cells of plain ground are
+// replaced, and each cell at most once.
+type substitutionPlanner struct {
+	walkable      walkable
+	plain         func(tileX, tileY int) bool
+	area          d2common.Rectangle // in tiles
+	taken         map[d2common.Point]bool
+	substitutions []substitution
+}
+
+// SubstituteTiles replaces blocks of tiles of a level in the given area, in
+// tiles, with the substitutions of its SubType in LvlSub.txt, so that levels
+// of the same type look different. The SubTheme of the level is the column
+// of the chances of each substitution, the substitutions at the SubWaypoint
+// and SubShrine indices of the group are put next to the waypoints and
+// shrines instead. Only walkable plain ground is replaced, the walk mesh is
+// generated again afterwards.
+func SubstituteTiles(mapEngine *d2mapengine.MapEngine, levelDetails *d2datadict.LevelDetailsRecord,
+	area d2common.Rectangle) {
+	rows := d2datadict.LevelSubstitutions[levelDetails.SubType]
+	if levelDetails.SubType < 0 || len(rows) == 0 {
+		return
+	}
+
+	regionType := d2enum.RegionIdType(levelDetails.LevelType)
+	stamps := make([]*d2mapstamp.Stamp, len(rows))
+	blocks := make([][]d2common.Rectangle, len(rows))
+
+	for idx, row := range rows {
+		mapEngine.AddDS1(row.File)
+		stamps[idx] = d2mapstamp.LoadStampFile(regionType, row.File)
+		blocks[idx] = substitutionBlocks(stamps[idx].Size(), gridSize(row))
+	}
+
+	planner := createSubstitutionPlanner(mapEngine.IsWalkable, func(tileX, tileY int) bool {
+		return isPlainGround(mapEngine, tileX, tileY)
+	}, area)
+
+	for _, entity := range *mapEngine.Entities() {
+		object, ok := entity.(*d2object.Object)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case object.IsWaypoint():
+			planner.planAround(levelDetails.SubWaypoint, blocks, object.TileX, object.TileY)
+		case object.IsShrine():
+			planner.planAround(levelDetails.SubShrine, blocks, object.TileX, object.TileY)
+		}
+	}
+
+	for idx, row := range rows {
+		if idx != levelDetails.SubWaypoint && idx != levelDetails.SubShrine {
+			chance, trials, max := substitutionChances(row, levelDetails.SubTheme)
+			planner.planRandom(idx, blocks[idx], chance, trials, max)
+		}
+	}
+
+	if len(planner.substitutions) == 0 {
+		return
+	}
+
+	for _, sub := range planner.substitutions {
+		mapEngine.PlaceStampArea(stamps[sub.row], sub.block, sub.x, sub.y)
+	}
+
+	mapEngine.RegenerateWalkPaths()
+}
+
+// isPlainGround tells whether a tile only has a floor, which substitutions
+// can replace without breaking walls or warps.
+func isPlainGround(mapEngine *d2mapengine.MapEngine, tileX, tileY int) bool {
+	tile := mapEngine.TileAt(tileX, tileY)
+
+	return tile != nil && len(tile.Floors) > 0 && len(tile.Walls) == 0
+}
+
+func createSubstitutionPlanner(isWalkable walkable, plain func(tileX, tileY int) bool,
+	area d2common.Rectangle) *substitutionPlanner {
+	return &substitutionPlanner{
+		walkable:      isWalkable,
+		plain:         plain,
+		area:          area,
+		taken:         make(map[d2common.Point]bool),
+		substitutions: make([]substitution, 0),
+	}
+}
+
+// planRandom tries to substitute random cells of the area with a block of a
+// substitution: each trial succeeds with the given chance, in percent,
+// until the maximum is placed. A negative number of trials tries each cell
+// once, a maximum of zero or less does not limit the substitutions.
+func (p *substitutionPlanner) planRandom(row int, blocks []d2common.Rectangle, chance, trials, max int) {
+	if chance <= 0 || len(blocks) == 0 {
+		return
+	}
+
+	size := blocks[0].Width
+
+	cellsX, cellsY := p.area.Width/size, p.area.Height/size
+	if cellsX == 0 || cellsY == 0 {
+		return
+	}
+
+	if trials < 0 {
+		trials = cellsX * cellsY
+	}
+
+	placed := 0
+
+	for ; trials > 0 && (max <= 0 || placed < max); trials-- {
+		if rand.Intn(100) >= chance {
+			continue
+		}
+
+		x := p.area.Left + rand.Intn(cellsX)*size
+		y := p.area.Top + rand.Intn(cellsY)*size
+
+		if p.free(x, y, size, true) {
+			p.add(row, blocks[rand.Intn(len(blocks))], x, y)
+			placed++
+		}
+	}
+}
+
+// planAround substitutes the cell which contains the given tile, e.g. the
+// ground around a waypoint, with a random block of the substitution at the
+// given index of its group. The cell is not walkable because of the
+// object, so only plain ground is required.
+func (p *substitutionPlanner) planAround(row int, blocks [][]d2common.Rectangle, tileX, tileY int) {
+	if row < 0 || row >= len(blocks) || len(blocks[row]) == 0 || !p.area.IsInRect(tileX, tileY) {
+		return
+	}
+
+	size := blocks[row][0].Width
+
+	x := p.area.Left + (tileX-p.area.Left)/size*size
+	y := p.area.Top + (tileY-p.area.Top)/size*size
+
+	if x+size <= p.area.Right() && y+size <= p.area.Bottom() && p.free(x, y, size, false) {
+		p.add(row, blocks[row][rand.Intn(len(blocks[row]))], x, y)
+	}
+}
+
+// free tells whether a cell of the given size at the given tile can be
+// substituted.
+func (p *substitutionPlanner) free(x, y, size int, walkable bool) bool {
+	for tileY := y; tileY < y+size; tileY++ {
+		for tileX := x; tileX < x+size; tileX++ {
+			if p.taken[d2common.Point{X: tileX, Y: tileY}] || !p.plain(tileX, tileY) {
+				return false
+			}
+
+			if walkable && !p.walkableTile(tileX, tileY) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (p *substitutionPlanner) walkableTile(tileX, tileY int) bool {
+	for subY := 0; subY < subTilesPerTile; subY++ {
+		for subX := 0; subX < subTilesPerTile; subX++ {
+			if !p.walkable(tileX*subTilesPerTile+subX, tileY*subTilesPerTile+subY) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (p *substitutionPlanner) add(row int, block d2common.Rectangle, x, y int) {
+	for tileY := y; tileY < y+block.Height; tileY++ {
+		for tileX := x; tileX < x+block.Width; tileX++ {
+			p.taken[d2common.Point{X: tileX, Y: tileY}] = true
+		}
+	}
+
+	p.substitutions = append(p.substitutions, substitution{row: row, block: block, x: x, y: y})
+}
+
+// gridSize returns the size of the blocks of a substitution, in tiles.
+func gridSize(record *d2datadict.LevelSubstitutionRecord) int {
+	if record.GridSize < 1 {
+		return substitutionGridTiles
+	}
+
+	return record.GridSize * substitutionGridTiles
+}
+
+// substitutionBlocks cuts a substitution file of the given size, in tiles,
+// into the blocks one of which replaces a cell of the map.
+func substitutionBlocks(fileSize d2common.Size, size int) []d2common.Rectangle {
+	blocks := make([]d2common.Rectangle, 0)
+
+	for y := 0; y+size <= fileSize.Height; y += size {
+		for x := 0; x+size <= fileSize.Width; x += size {
+			blocks = append(blocks, d2common.Rectangle{Left: x, Top: y, Width: size, Height: size})
+		}
+	}
+
+	return blocks
+}
+
+// substitutionChances returns the probability, in percent, the number of
+// trials and the maximum number of substitutions of a theme.
+func substitutionChances(record *d2datadict.LevelSubstitutionRecord, theme int) (chance, trials, max int) {
+	if theme < 0 || theme >= substitutionThemes {
+		return 0, 0, 0
+	}
+
+	chances := [substitutionThemes]int{
+		record.ChanceSpawn0, record.ChanceSpawn1, record.ChanceSpawn2, record.ChanceSpawn3, record.ChanceSpawn4,
+	}
+	allTrials := [substitutionThemes]int{
+		record.ChanceFloor0, record.ChanceFloor1, record.ChanceFloor2, record.ChanceFloor3, record.ChanceFloor4,
+	}
+	maxima := [substitutionThemes]int{
+		record.GridMax0, record.GridMax1, record.GridMax2, record.GridMax3, record.GridMax4,
+	}
+
+	return chances[theme], allTrials[theme], maxima[theme]
+}
@@ -0,0 +1,104 @@
+package d2mapgen
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+)
+
+func TestSubstitutionBlocks(t *testing.T) {
+	blocks := substitutionBlocks(d2common.Size{Width: 17, Height: 8}, 8)
+
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %d", len(blocks))
+	}
+
+	if blocks[1] != (d2common.Rectangle{Left: 8, Top: 0, Width: 8, Height: 8}) {
+		t.Errorf("unexpected second block %v", blocks[1])
+	}
+}
+
+func TestSubstitutionChances(t *testing.T) {
+	record := &d2datadict.LevelSubstitutionRecord{ChanceSpawn2: 30, ChanceFloor2: 10, GridMax2: 4}
+
+	if chance, trials, max := substitutionChances(record, 2); chance != 30 || trials != 10 || max != 4 {
+		t.Errorf("expected 30, 10, 4 for theme 2, got %d, %d, %d", chance, trials, max)
+	}
+
+	if chance, _, _ := substitutionChances(record, -1); chance != 0 {
+		t.Errorf("expected no chance without a theme, got %d", chance)
+	}
+}
+
+func TestPlanSubstitutionsOnlyOnWalkablePlainGround(t *testing.T) {
+	rand.Seed(1)
+
+	area := d2common.Rectangle{Width: 32, Height: 32}
+	blocked := d2common.Rectangle{Left: 40, Top: 40, Width: 20, Height: 20} // in sub-tiles
+	wall := d2common.Point{X: 20, Y: 3}
+
+	planner := createSubstitutionPlanner(func(x, y int) bool {
+		return !blocked.IsInRect(x, y)
+	}, func(tileX, tileY int) bool {
+		return d2common.Point{X: tileX, Y: tileY} != wall
+	}, area)
+
+	blocks := []d2common.Rectangle{{Width: 4, Height: 4}, {Left: 4, Width: 4, Height: 4}}
+	planner.planRandom(0, blocks, 100, -1, 0)
+	planner.planRandom(1, blocks, 100, -1, 5)
+
+	if len(planner.substitutions) == 0 {
+		t.Fatal("expected substitutions")
+	}
+
+	placed := make(map[int]int)
+
+	for _, sub := range planner.substitutions {
+		placed[sub.row]++
+
+		cell := d2common.Rectangle{Left: sub.x, Top: sub.y, Width: 4, Height: 4}
+		if sub.x%4 != 0 || sub.y%4 != 0 {
+			t.Errorf("substitution at %d,%d is not on the grid", sub.x, sub.y)
+		}
+
+		if cell.IsInRect(wall.X, wall.Y) || cell.IsInRect(blocked.Left/5, blocked.Top/5) {
+			t.Errorf("substitution at %d,%d replaces a wall or unwalkable ground", sub.x, sub.y)
+		}
+	}
+
+	if placed[1] > 5 {
+		t.Errorf("expected at most 5 substitutions of the second row, got %d", placed[1])
+	}
+
+	cells := make(map[d2common.Point]bool)
+
+	for _, sub := range planner.substitutions {
+		cell := d2common.Point{X: sub.x, Y: sub.y}
+		if cells[cell] {
+			t.Errorf("cell %d,%d is substituted twice", sub.x, sub.y)
+		}
+
+		cells[cell] = true
+	}
+}
+
+func TestPlanSubstitutionAroundObject(t *testing.T) {
+	area := d2common.Rectangle{Left: 2, Top: 2, Width: 16, Height: 16}
+	planner := createSubstitutionPlanner(func(x, y int) bool { return false },
+		func(tileX, tileY int) bool { return true }, area)
+
+	blocks := [][]d2common.Rectangle{{{Width: 8, Height: 8}}}
+	planner.planAround(0, blocks, 12, 5)
+	planner.planAround(0, blocks, 13, 6) // the same cell
+	planner.planAround(1, blocks, 5, 5)  // no such substitution
+
+	if len(planner.substitutions) != 1 {
+		t.Fatalf("expected 1 substitution, got %d", len(planner.substitutions))
+	}
+
+	if sub := planner.substitutions[0]; sub.x != 10 || sub.y != 2 {
+		t.Errorf("expected the cell at 10,2, got %d,%d", sub.x, sub.y)
+	}
+}
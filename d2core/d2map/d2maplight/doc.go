@@ -0,0 +1,4 @@
+// Package d2maplight computes the light of the sub-tiles of a map, from the
+// light of its levels and the light sources on it, like the light radius of
+// the hero and the torches.
+package d2maplight
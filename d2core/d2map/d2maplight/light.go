@@ -0,0 +1,120 @@
+package d2maplight
+
+import (
+	"math"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+)
+
+const (
+	// DayLength is the length of a day outdoors, in seconds.
+	DayLength = 20 * 60
+
+	// NightLight is the light outdoors at midnight.
+	NightLight = 0.35
+
+	maxIntensity = 255
+)
+
+// Emitter is a map entity which is a light source, e.g. the hero, a torch
+// or a fire missile.
+type Emitter interface {
+	// LightRadius returns the radius of the light around the entity, in
+	// sub-tiles, or 0 when it does not emit light.
+	LightRadius() float64
+}
+
+// Source is a light around a position of the map.
+type Source struct {
+	X, Y   float64 // in sub-tiles
+	Radius float64 // in sub-tiles
+}
+
+// LightMap is the light, between 0 and 1, of each sub-tile of an area of
+// the map.
+type LightMap struct {
+	area  d2common.Rectangle // in sub-tiles
+	light []float64
+}
+
+// Compute computes the light of the sub-tiles of an area, in sub-tiles, from
+// their ambient light and the light sources. The lights add up, a sub-tile is
+// lit the most at the center of a source and not lit at its radius.
+func Compute(area d2common.Rectangle, ambient func(subTileX, subTileY int) float64, sources []Source) *LightMap {
+	lightMap := &LightMap{
+		area:  area,
+		light: make([]float64, area.Width*area.Height),
+	}
+
+	for y := 0; y < area.Height; y++ {
+		for x := 0; x < area.Width; x++ {
+			lightMap.light[x+y*area.Width] = ambient(area.Left+x, area.Top+y)
+		}
+	}
+
+	for _, source := range sources {
+		lightMap.addSource(source)
+	}
+
+	return lightMap
+}
+
+func (l *LightMap) addSource(source Source) {
+	if source.Radius <= 0 {
+		return
+	}
+
+	left := int(math.Max(float64(l.area.Left), math.Floor(source.X-source.Radius)))
+	top := int(math.Max(float64(l.area.Top), math.Floor(source.Y-source.Radius)))
+	right := int(math.Min(float64(l.area.Right()), math.Ceil(source.X+source.Radius)))
+	bottom := int(math.Min(float64(l.area.Bottom()), math.Ceil(source.Y+source.Radius)))
+
+	for y := top; y < bottom; y++ {
+		for x := left; x < right; x++ {
+			// the light of a sub-tile is the light at its center
+			dx, dy := float64(x)+0.5-source.X, float64(y)+0.5-source.Y
+
+			distance := math.Sqrt(dx*dx+dy*dy) / source.Radius
+			if distance >= 1 {
+				continue
+			}
+
+			index := (x - l.area.Left) + (y-l.area.Top)*l.area.Width
+			l.light[index] = math.Min(1, l.light[index]+1-distance*distance)
+		}
+	}
+}
+
+// At returns the light of a sub-tile. The sub-tiles out of the area are
+// fully lit.
+func (l *LightMap) At(subTileX, subTileY int) float64 {
+	if !l.area.IsInRect(subTileX, subTileY) {
+		return 1
+	}
+
+	return l.light[(subTileX-l.area.Left)+(subTileY-l.area.Top)*l.area.Width]
+}
+
+// Ambient returns the light of a level without any light source. Indoors it
+// is the light intensity of Levels.txt. Outdoors the levels are fully lit,
+// unless they follow the day and night cycle, where the time of the day goes
+// from 0 at midnight to 0.5 at noon and back to 1 at midnight.
+func Ambient(levelDetails *d2datadict.LevelDetailsRecord, dayNightCycle bool, timeOfDay float64) float64 {
+	switch {
+	case levelDetails == nil:
+		return 1
+	case levelDetails.IsInside:
+		return math.Min(1, float64(levelDetails.LightIntensity)/maxIntensity)
+	case dayNightCycle:
+		return DayLight(timeOfDay)
+	default:
+		return 1
+	}
+}
+
+// DayLight returns the light outdoors at a time of the day, between
+// NightLight at midnight and 1 at noon.
+func DayLight(timeOfDay float64) float64 {
+	return NightLight + (1-NightLight)*(1-math.Cos(2*math.Pi*timeOfDay))/2
+}
@@ -0,0 +1,69 @@
+package d2maplight
+
+import (
+	"math"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+)
+
+func TestComputeLightSources(t *testing.T) {
+	area := d2common.Rectangle{Left: 10, Top: 10, Width: 20, Height: 20}
+	dark := func(subTileX, subTileY int) float64 {
+		return 0.1
+	}
+
+	lightMap := Compute(area, dark, []Source{
+		{X: 15.5, Y: 15.5, Radius: 4},
+		{X: 17.5, Y: 15.5, Radius: 4},
+		{X: 0, Y: 0, Radius: 0},
+	})
+
+	if light := lightMap.At(15, 15); light != 1 {
+		t.Errorf("expected the center of the sources to be fully lit, got %g", light)
+	}
+
+	if light := lightMap.At(25, 25); light != 0.1 {
+		t.Errorf("expected the ambient light away from the sources, got %g", light)
+	}
+
+	// the sub-tile is lit by the first source only
+	if light := lightMap.At(13, 15); math.Abs(light-(0.1+1-0.25)) > 1e-9 {
+		t.Errorf("expected the light to decrease away from the source, got %g", light)
+	}
+
+	if lightMap.At(12, 15) <= 0.1 || lightMap.At(12, 15) >= lightMap.At(13, 15) || lightMap.At(11, 15) != 0.1 {
+		t.Error("expected the light to decrease up to the radius of the source")
+	}
+
+	if light := lightMap.At(0, 0); light != 1 {
+		t.Errorf("expected the sub-tiles out of the area to be fully lit, got %g", light)
+	}
+}
+
+func TestAmbient(t *testing.T) {
+	cave := &d2datadict.LevelDetailsRecord{IsInside: true, LightIntensity: 51}
+	wilderness := &d2datadict.LevelDetailsRecord{}
+
+	tests := []struct {
+		levelDetails  *d2datadict.LevelDetailsRecord
+		dayNightCycle bool
+		timeOfDay     float64
+		expected      float64
+	}{
+		{nil, true, 0, 1},
+		{cave, false, 0.5, 0.2},
+		{cave, true, 0.5, 0.2},
+		{wilderness, false, 0, 1},
+		{wilderness, true, 0, NightLight},
+		{wilderness, true, 0.5, 1},
+		{wilderness, true, 0.25, (1 + NightLight) / 2},
+	}
+
+	for i, test := range tests {
+		if light := Ambient(test.levelDetails, test.dayNightCycle, test.timeOfDay); math.Abs(light-test.expected) > 1e-9 {
+			t.Errorf("test %d: expected %g, got %g", i, test.expected, light)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package d2maprenderer
+
+import (
+	"math"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2maplight"
+)
+
+const (
+	// what is drawn in the dark is still slightly visible
+	minBrightness = 0.05
+
+	// the time of the day the day and night cycle starts at
+	noon = 0.5
+)
+
+// SetLighting sets whether the map is lit by the levels and the light sources,
+// or fully lit.
+func (mr *MapRenderer) SetLighting(lighting bool) {
+	mr.lighting = lighting
+}
+
+// SetDayNightCycle sets whether the light outdoors follows the time of the day.
+func (mr *MapRenderer) SetDayNightCycle(dayNightCycle bool) {
+	mr.dayNightCycle = dayNightCycle
+}
+
+// advanceTimeOfDay moves the time of the day forward
+func (mr *MapRenderer) advanceTimeOfDay(elapsed float64) {
+	if mr.dayNightCycle {
+		mr.timeOfDay = math.Mod(mr.timeOfDay+elapsed/d2maplight.DayLength, 1)
+	}
+}
+
+// computeLight computes the light of the sub-tiles of the given tiles, there
+// is no light map without lighting.
+func (mr *MapRenderer) computeLight(startX, startY, endX, endY int) *d2maplight.LightMap {
+	if !mr.lighting {
+		return nil
+	}
+
+	area := d2common.Rectangle{
+		Left:   startX * subTilesPerTile,
+		Top:    startY * subTilesPerTile,
+		Width:  (endX - startX) * subTilesPerTile,
+		Height: (endY - startY) * subTilesPerTile,
+	}
+
+	levelLights := make(map[int]float64)
+
+	ambient := func(subTileX, subTileY int) float64 {
+		levelID := mr.mapEngine.LevelAt(subTileX/subTilesPerTile, subTileY/subTilesPerTile)
+
+		light, found := levelLights[levelID]
+		if !found {
+			light = d2maplight.Ambient(d2datadict.GetLevelDetails(levelID), mr.dayNightCycle, mr.timeOfDay)
+			levelLights[levelID] = light
+		}
+
+		return light
+	}
+
+	sources := make([]d2maplight.Source, 0)
+
+	for _, mapEntity := range *mr.mapEngine.Entities() {
+		emitter, ok := mapEntity.(d2maplight.Emitter)
+		if !ok {
+			continue
+		}
+
+		x, y := mapEntity.GetPositionF()
+		sources = append(sources, d2maplight.Source{
+			X:      x * subTilesPerTile,
+			Y:      y * subTilesPerTile,
+			Radius: emitter.LightRadius(),
+		})
+	}
+
+	return d2maplight.Compute(area, ambient, sources)
+}
+
+// pushTileLight sets the brightness of the tiles drawn to the light of a
+// tile. It returns false when the tile is fully lit and nothing was pushed.
+func (mr *MapRenderer) pushTileLight(target d2interface.Surface, tileX, tileY int) bool {
+	center := subTilesPerTile / 2
+
+	return mr.pushLight(target, tileX*subTilesPerTile+center, tileY*subTilesPerTile+center)
+}
+
+// pushLight sets the brightness of what is drawn to the light of a sub-tile.
+// It returns false when the sub-tile is fully lit and nothing was pushed.
+func (mr *MapRenderer) pushLight(target d2interface.Surface, subTileX, subTileY int) bool {
+	if mr.lightMap == nil {
+		return false
+	}
+
+	light := mr.lightMap.At(subTileX, subTileY)
+	if light >= 1 {
+		return false
+	}
+
+	target.PushBrightness(math.Max(minBrightness, light))
+
+	return true
+}
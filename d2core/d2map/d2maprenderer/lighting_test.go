@@ -0,0 +1,69 @@
+package d2maprenderer
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+)
+
+// torch is a map entity which emits light
+type torch struct {
+	d2interface.MapEntity
+	x, y float64
+}
+
+func (t *torch) GetPositionF() (float64, float64) {
+	return t.x, t.y
+}
+
+func (t *torch) LightRadius() float64 {
+	return 10
+}
+
+func TestComputeLight(t *testing.T) {
+	d2datadict.LevelTypes = make([]d2datadict.LevelTypeRecord, 1)
+	d2datadict.LevelDetails = make(map[int]*d2datadict.LevelDetailsRecord)
+
+	for id := 0; id <= 8; id++ {
+		d2datadict.LevelDetails[id] = &d2datadict.LevelDetailsRecord{Id: id}
+	}
+
+	d2datadict.LevelDetails[8].IsInside = true
+
+	// a cave on the left half of the map, the right half is outdoors
+	mapEngine := d2mapengine.CreateMapEngine()
+	mapEngine.ResetMap(0, 20, 10)
+	mapEngine.AddLevel(8, d2common.Rectangle{Width: 10, Height: 10})
+	mapEngine.AddEntity(&torch{x: 2.5, y: 2.5})
+
+	mapRenderer := &MapRenderer{mapEngine: mapEngine, lighting: true}
+	mapRenderer.lightMap = mapRenderer.computeLight(0, 0, 20, 10)
+
+	tests := []struct {
+		tileX, tileY int
+		light        float64
+	}{
+		{2, 2, 1},    // at the torch
+		{3, 2, 0.75}, // a tile away from the torch
+		{8, 8, 0},    // in the dark
+		{15, 5, 1},   // outdoors
+	}
+
+	for _, test := range tests {
+		center := subTilesPerTile / 2
+		light := mapRenderer.lightMap.At(test.tileX*subTilesPerTile+center, test.tileY*subTilesPerTile+center)
+
+		if light < test.light-1e-9 || light > test.light+1e-9 {
+			t.Errorf("tile %d,%d: expected %g, got %g", test.tileX, test.tileY, test.light, light)
+		}
+	}
+
+	mapRenderer.SetLighting(false)
+
+	if mapRenderer.computeLight(0, 0, 20, 10) != nil {
+		t.Error("expected no light map without lighting")
+	}
+}
@@ -0,0 +1,97 @@
+package d2maprenderer
+
+import (
+	"image/color"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+)
+
+const (
+	// the walls and the trees of the top tiles rise above the map
+	mapImageMarginTop = 400
+	// the floor of the bottom tiles goes below the last row of tiles
+	mapImageMarginBottom = 80
+
+	subTilesPerTile = 5
+)
+
+// MapImageOptions tells what is drawn on an image of a whole map in addition
+// to its floors, walls and shadows.
+type MapImageOptions struct {
+	Entities bool // the NPCs, monsters and objects
+	WalkMesh bool // the sub-tiles which cannot be walked on
+}
+
+// MapImageSize returns the size, in pixels, of an image of the whole map.
+func (mr *MapRenderer) MapImageSize() (width, height int) {
+	size := mr.mapEngine.Size()
+
+	return (size.Width + size.Height) * 80, (size.Width+size.Height)*40 + mapImageMarginTop + mapImageMarginBottom
+}
+
+// RenderMap draws the whole map on a target of the size returned by
+// MapImageSize, e.g. to export the map to an image. The camera is left where
+// it was.
+func (mr *MapRenderer) RenderMap(target d2interface.Surface, options MapImageOptions) {
+	width, height := mr.MapImageSize()
+	size := mr.mapEngine.Size()
+
+	viewport, camera, lightMap, occluded := mr.viewport, mr.camera, mr.lightMap, mr.occluded
+
+	defer func() {
+		mr.viewport, mr.camera, mr.lightMap, mr.occluded = viewport, camera, lightMap, occluded
+	}()
+
+	// the map is fully lit and its walls are opaque
+	mr.lightMap, mr.occluded = nil, nil
+
+	// the left corner of the map is on the left side of the image
+	mr.viewport = NewViewport(0, 0, width, height)
+	mr.viewport.SetCamera(&mr.camera)
+	mr.camera.MoveTo(float64(width/2-size.Height*80), float64(height/2-mapImageMarginTop))
+
+	mr.renderPass1(target, 0, 0, size.Width, size.Height)
+
+	if options.Entities {
+		mr.renderPass2(target, 0, 0, size.Width, size.Height)
+	}
+
+	mr.renderPass3(target, 0, 0, size.Width, size.Height, options.Entities)
+	mr.renderPass4(target, 0, 0, size.Width, size.Height)
+
+	if options.WalkMesh {
+		mr.renderWalkMesh(target, size.Width, size.Height)
+	}
+}
+
+// renderWalkMesh marks the sub-tiles which cannot be walked on.
+func (mr *MapRenderer) renderWalkMesh(target d2interface.Surface, width, height int) {
+	blockedColor := color.RGBA{R: 255, A: 120}
+	walkMesh := *mr.mapEngine.WalkMesh()
+
+	for tileY := 0; tileY < height; tileY++ {
+		for tileX := 0; tileX < width; tileX++ {
+			mr.viewport.PushTranslationWorld(float64(tileX), float64(tileY))
+			target.PushTranslation(mr.viewport.GetTranslationScreen())
+
+			for yy := 0; yy < subTilesPerTile; yy++ {
+				for xx := 0; xx < subTilesPerTile; xx++ {
+					index := (yy+tileY*subTilesPerTile)*width*subTilesPerTile + xx + tileX*subTilesPerTile
+					if index >= len(walkMesh) || walkMesh[index].Walkable {
+						continue
+					}
+
+					isoX := (xx - yy) * 16
+					isoY := (xx + yy) * 8
+
+					target.PushTranslation(isoX-6, isoY+5)
+					target.DrawRect(12, 6, blockedColor)
+					target.Pop()
+				}
+			}
+
+			target.Pop()
+			mr.viewport.PopTranslation()
+		}
+	}
+}
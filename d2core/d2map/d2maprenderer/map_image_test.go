@@ -0,0 +1,48 @@
+package d2maprenderer
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2render/software"
+)
+
+func TestRenderMapWalkMesh(t *testing.T) {
+	d2datadict.LevelTypes = make([]d2datadict.LevelTypeRecord, 1)
+
+	// a map without tiles, none of its sub-tiles can be walked on
+	mapEngine := d2mapengine.CreateMapEngine()
+	mapEngine.ResetMap(0, 3, 2)
+
+	mapRenderer := &MapRenderer{mapEngine: mapEngine, viewport: NewViewport(0, 0, 800, 600)}
+	mapRenderer.viewport.SetCamera(&mapRenderer.camera)
+	mapRenderer.MoveCameraTo(12, 34)
+
+	width, height := mapRenderer.MapImageSize()
+	if width != 400 || height != 200+mapImageMarginTop+mapImageMarginBottom {
+		t.Fatalf("unexpected image size %dx%d", width, height)
+	}
+
+	renderer, _ := software.CreateRenderer()
+
+	for _, walkMesh := range []bool{false, true} {
+		target, err := renderer.NewSurface(width, height, d2enum.FilterNearest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mapRenderer.RenderMap(target, MapImageOptions{WalkMesh: walkMesh})
+
+		// the center of the top tile, on the middle sub-tile
+		x, y := 2*80, mapImageMarginTop+40
+		if marked := target.Screenshot().RGBAAt(x, y).A != 0; marked != walkMesh {
+			t.Errorf("walk mesh %v: expected the sub-tile to be marked %v", walkMesh, walkMesh)
+		}
+	}
+
+	if x, y := mapRenderer.camera.GetPosition(); x != 12 || y != 34 {
+		t.Errorf("expected the camera to be left at 12,34, got %g,%g", x, y)
+	}
+}
@@ -0,0 +1,96 @@
+package d2maprenderer
+
+import (
+	"math"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+)
+
+const (
+	// how far from the focused entity, in tiles, the selectable entities are
+	// kept visible behind the walls
+	occlusionRadius = 6
+
+	// how far in front of an entity, in tiles along the depth of the screen,
+	// an upper wall is tall enough to hide it
+	occlusionDepth = 4
+
+	// how far to the sides of an entity, in tiles along the width of the
+	// screen, a wall hides it, a wall is two tiles wide on the screen
+	occlusionWidth = 1
+
+	// how the walls hiding an entity are drawn
+	occlusionEffect = d2enum.DrawEffectPctTransparency50
+)
+
+// SetFocus sets the entity, usually the hero, which is kept visible with the
+// selectable entities around it when walls stand in front of them.
+func (mr *MapRenderer) SetFocus(focus d2interface.MapEntity) {
+	mr.focus = focus
+}
+
+// SetWallTransparency sets whether the walls in front of the focused entity
+// and the selectable entities around it are drawn see-through.
+func (mr *MapRenderer) SetWallTransparency(wallTransparency bool) {
+	mr.wallTransparency = wallTransparency
+}
+
+// findOccluded returns the positions of the entities which are kept visible
+// behind the walls, there are none without wall transparency.
+func (mr *MapRenderer) findOccluded() [][2]float64 {
+	if !mr.wallTransparency || mr.focus == nil {
+		return nil
+	}
+
+	focusX, focusY := mr.focus.GetPositionF()
+	occluded := [][2]float64{{focusX, focusY}}
+
+	for _, mapEntity := range *mr.mapEngine.Entities() {
+		if mapEntity == mr.focus || !mapEntity.Selectable() {
+			continue
+		}
+
+		x, y := mapEntity.GetPositionF()
+		if math.Abs(x-focusX) > occlusionRadius || math.Abs(y-focusY) > occlusionRadius {
+			continue
+		}
+
+		occluded = append(occluded, [2]float64{x, y})
+	}
+
+	return occluded
+}
+
+// occludes tells whether the upper walls of a tile hide one of the entities
+// kept visible. The walls of a tile are drawn over what stands behind them on
+// the screen, i.e. above and close to their tile.
+func (mr *MapRenderer) occludes(tileX, tileY int) bool {
+	centerX, centerY := float64(tileX)+0.5, float64(tileY)+0.5
+
+	for _, position := range mr.occluded {
+		depth := (centerX + centerY) - (position[0] + position[1])
+		if depth <= 0 || depth > occlusionDepth {
+			continue
+		}
+
+		side := (centerX - centerY) - (position[0] - position[1])
+		if math.Abs(side) <= occlusionWidth {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pushOcclusion makes the upper walls of a tile see-through when they hide one
+// of the entities kept visible. It returns false when nothing was pushed.
+func (mr *MapRenderer) pushOcclusion(target d2interface.Surface, tileX, tileY int) bool {
+	if !mr.occludes(tileX, tileY) {
+		return false
+	}
+
+	target.PushEffect(occlusionEffect)
+
+	return true
+}
@@ -0,0 +1,68 @@
+package d2maprenderer
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+)
+
+// stub is a map entity standing on the map
+type stub struct {
+	d2interface.MapEntity
+	x, y       float64
+	selectable bool
+}
+
+func (s *stub) GetPositionF() (float64, float64) {
+	return s.x, s.y
+}
+
+func (s *stub) Selectable() bool {
+	return s.selectable
+}
+
+func TestOccludes(t *testing.T) {
+	hero := &stub{x: 10.5, y: 10.5}
+	npc := &stub{x: 14.5, y: 10.5, selectable: true}
+
+	mapEngine := d2mapengine.CreateMapEngine()
+	mapEngine.AddEntity(hero)
+	mapEngine.AddEntity(npc)
+	mapEngine.AddEntity(&stub{x: 30.5, y: 30.5, selectable: true}) // too far
+	mapEngine.AddEntity(&stub{x: 5.5, y: 10.5})                    // not selectable
+
+	mapRenderer := &MapRenderer{mapEngine: mapEngine, wallTransparency: true}
+	mapRenderer.SetFocus(hero)
+	mapRenderer.occluded = mapRenderer.findOccluded()
+
+	if len(mapRenderer.occluded) != 2 {
+		t.Fatalf("expected the hero and the npc kept visible, got %v", mapRenderer.occluded)
+	}
+
+	tests := []struct {
+		tileX, tileY int
+		occludes     bool
+	}{
+		{11, 11, true},  // right in front of the hero
+		{12, 11, true},  // in front of the hero, slightly to the right
+		{10, 10, false}, // the tile of the hero
+		{9, 9, false},   // behind the hero
+		{16, 16, false}, // too far in front of the hero
+		{13, 8, false},  // to the right of the hero
+		{15, 11, true},  // in front of the npc
+		{6, 11, false},  // in front of the entity which is not selectable
+	}
+
+	for _, test := range tests {
+		if occludes := mapRenderer.occludes(test.tileX, test.tileY); occludes != test.occludes {
+			t.Errorf("tile %d,%d: expected %v, got %v", test.tileX, test.tileY, test.occludes, occludes)
+		}
+	}
+
+	mapRenderer.SetWallTransparency(false)
+
+	if mapRenderer.findOccluded() != nil {
+		t.Error("expected no entity kept visible without wall transparency")
+	}
+}
@@ -11,19 +11,28 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2config"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2maplight"
 )
 
 // MapRenderer manages the game viewport and camera. It requests tile and entity data from MapEngine and renders it.
 type MapRenderer struct {
-	renderer      d2interface.Renderer   // Used for drawing operations
-	mapEngine     *d2mapengine.MapEngine // The map engine that is being rendered
-	palette       d2interface.Palette    // The palette used for this map
-	viewport      *Viewport              // Used for rendering offsets
-	camera        Camera                 // Used to determine where on the map we are rendering
-	debugVisLevel int                    // Debug visibility index (0=none, 1=tiles, 2=sub-tiles)
-	lastFrameTime float64                // The last time the map was rendered
-	currentFrame  int                    // Current render frame (for animations)
+	renderer         d2interface.Renderer   // Used for drawing operations
+	mapEngine        *d2mapengine.MapEngine // The map engine that is being rendered
+	palette          d2interface.Palette    // The palette used for this map
+	viewport         *Viewport              // Used for rendering offsets
+	camera           Camera                 // Used to determine where on the map we are rendering
+	debugVisLevel    int                    // Debug visibility index (0=none, 1=tiles, 2=sub-tiles)
+	lastFrameTime    float64                // The last time the map was rendered
+	currentFrame     int                    // Current render frame (for animations)
+	lighting         bool                   // Whether the map is lit by its levels and light sources
+	dayNightCycle    bool                   // Whether the light outdoors follows the time of the day
+	timeOfDay        float64                // From 0 at midnight to 1 at the next midnight
+	lightMap         *d2maplight.LightMap   // The light of the rendered sub-tiles, nil when fully lit
+	wallTransparency bool                   // Whether the walls hiding the focused entities are see-through
+	focus            d2interface.MapEntity  // The entity, usually the hero, kept visible behind the walls
+	occluded         [][2]float64           // The positions of the entities kept visible behind the walls
 }
 
 // CreateMapRenderer creates a new MapRenderer, sets the required fields and returns a pointer to it.
@@ -32,14 +41,30 @@ func CreateMapRenderer(renderer d2interface.Renderer, mapEngine *d2mapengine.Map
 		renderer:  renderer,
 		mapEngine: mapEngine,
 		viewport:  NewViewport(0, 0, 800, 600),
+		lighting:  true,
+		timeOfDay: noon,
 	}
 
+	result.wallTransparency = d2config.Config == nil || !d2config.Config.OpaqueWalls
+
 	result.viewport.SetCamera(&result.camera)
 
 	term.BindAction("mapdebugvis", "set map debug visualization level", func(level int) {
 		result.debugVisLevel = level
 	})
 
+	term.BindAction("maplighting", "toggle the lighting of the map", func(lighting bool) {
+		result.SetLighting(lighting)
+	})
+
+	term.BindAction("mapdaynight", "toggle the day and night cycle outdoors", func(dayNightCycle bool) {
+		result.SetDayNightCycle(dayNightCycle)
+	})
+
+	term.BindAction("mapwalls", "toggle the transparency of the walls hiding the player", func(wallTransparency bool) {
+		result.SetWallTransparency(wallTransparency)
+	})
+
 	if mapEngine.LevelType().ID != 0 {
 		result.generateTileCache()
 	}
@@ -80,6 +105,9 @@ func (mr *MapRenderer) Render(target d2interface.Surface) {
 	endX := int(math.Min(float64(mapSize.Width), math.Ceil(etxf)))
 	endY := int(math.Min(float64(mapSize.Height), math.Ceil(etyf)))
 
+	mr.lightMap = mr.computeLight(startX, startY, endX, endY)
+	mr.occluded = mr.findOccluded()
+
 	mr.renderPass1(target, startX, startY, endX, endY)
 	mr.renderPass2(target, startX, startY, endX, endY)
 
@@ -87,7 +115,7 @@ func (mr *MapRenderer) Render(target d2interface.Surface) {
 		mr.renderDebug(mr.debugVisLevel, target, startX, startY, endX, endY)
 	}
 
-	mr.renderPass3(target, startX, startY, endX, endY)
+	mr.renderPass3(target, startX, startY, endX, endY, true)
 	mr.renderPass4(target, startX, startY, endX, endY)
 }
 
@@ -123,7 +151,13 @@ func (mr *MapRenderer) renderPass1(target d2interface.Surface, startX, startY, e
 		for tileX := startX; tileX < endX; tileX++ {
 			tile := mr.mapEngine.TileAt(tileX, tileY)
 			mr.viewport.PushTranslationWorld(float64(tileX), float64(tileY))
+			lit := mr.pushTileLight(target, tileX, tileY)
 			mr.renderTilePass1(tile, target)
+
+			if lit {
+				target.Pop()
+			}
+
 			mr.viewport.PopTranslation()
 		}
 	}
@@ -134,66 +168,80 @@ func (mr *MapRenderer) renderPass2(target d2interface.Surface, startX, startY, e
 	for tileY := startY; tileY < endY; tileY++ {
 		for tileX := startX; tileX < endX; tileX++ {
 			mr.viewport.PushTranslationWorld(float64(tileX), float64(tileY))
-
-			// TODO: Do not loop over every entity every frame
-			for _, mapEntity := range *mr.mapEngine.Entities() {
-				entityX, entityY := mapEntity.GetPosition()
-
-				if mapEntity.GetLayer() != 1 {
-					continue
-				}
-
-				if (int(entityX) != tileX) || (int(entityY) != tileY) {
-					continue
-				}
-
-				target.PushTranslation(mr.viewport.GetTranslationScreen())
-				mapEntity.Render(target)
-				target.Pop()
-			}
-
+			mr.renderEntities(target, tileX, tileY, true)
 			mr.viewport.PopTranslation()
 		}
 	}
 }
 
-// Upper wall tiles and entities above walls.
-func (mr *MapRenderer) renderPass3(target d2interface.Surface, startX, startY, endX, endY int) {
+// Upper wall tiles and, unless they are hidden, entities above walls.
+func (mr *MapRenderer) renderPass3(target d2interface.Surface, startX, startY, endX, endY int, entities bool) {
 	for tileY := startY; tileY < endY; tileY++ {
 		for tileX := startX; tileX < endX; tileX++ {
 			tile := mr.mapEngine.TileAt(tileX, tileY)
 			mr.viewport.PushTranslationWorld(float64(tileX), float64(tileY))
+			lit := mr.pushTileLight(target, tileX, tileY)
+			seeThrough := mr.pushOcclusion(target, tileX, tileY)
 			mr.renderTilePass2(tile, target)
 
-			// TODO: Do not loop over every entity every frame
-			for _, mapEntity := range *mr.mapEngine.Entities() {
-				entityX, entityY := mapEntity.GetPosition()
-
-				if mapEntity.GetLayer() == 1 {
-					continue
-				}
-
-				if (int(entityX) != tileX) || (int(entityY) != tileY) {
-					continue
-				}
+			if seeThrough {
+				target.Pop()
+			}
 
-				target.PushTranslation(mr.viewport.GetTranslationScreen())
-				mapEntity.Render(target)
+			if lit {
 				target.Pop()
 			}
 
+			if entities {
+				mr.renderEntities(target, tileX, tileY, false)
+			}
+
 			mr.viewport.PopTranslation()
 		}
 	}
 }
 
+// renderEntities draws the entities on a tile which are either below or above the walls.
+func (mr *MapRenderer) renderEntities(target d2interface.Surface, tileX, tileY int, belowWalls bool) {
+	// TODO: Do not loop over every entity every frame
+	for _, mapEntity := range *mr.mapEngine.Entities() {
+		entityX, entityY := mapEntity.GetPosition()
+
+		if (mapEntity.GetLayer() == 1) != belowWalls {
+			continue
+		}
+
+		if (int(entityX) != tileX) || (int(entityY) != tileY) {
+			continue
+		}
+
+		target.PushTranslation(mr.viewport.GetTranslationScreen())
+
+		x, y := mapEntity.GetPositionF()
+		lit := mr.pushLight(target, int(x*subTilesPerTile), int(y*subTilesPerTile))
+		mapEntity.Render(target)
+
+		if lit {
+			target.Pop()
+		}
+
+		target.Pop()
+	}
+}
+
 // Roof tiles.
 func (mr *MapRenderer) renderPass4(target d2interface.Surface, startX, startY, endX, endY int) {
 	for tileY := startY; tileY < endY; tileY++ {
 		for tileX := startX; tileX < endX; tileX++ {
 			tile := mr.mapEngine.TileAt(tileX, tileY)
 			mr.viewport.PushTranslationWorld(float64(tileX), float64(tileY))
+			lit := mr.pushTileLight(target, tileX, tileY)
 			mr.renderTilePass3(tile, target)
+
+			if lit {
+				target.Pop()
+			}
+
 			mr.viewport.PopTranslation()
 		}
 	}
@@ -387,6 +435,8 @@ func (mr *MapRenderer) Advance(elapsed float64) {
 	if mr.currentFrame > 9 {
 		mr.currentFrame = 0
 	}
+
+	mr.advanceTimeOfDay(elapsed)
 }
 
 func loadPaletteForAct(levelType d2enum.RegionIdType) (d2interface.Palette, error) {
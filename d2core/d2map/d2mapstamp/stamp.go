@@ -63,23 +63,39 @@ func LoadStamp(levelType d2enum.RegionIdType, levelPreset int, fileIndex int) *S
 		panic("no level files to pick from")
 	}
 
-	stamp.regionPath = levelFilesToPick[levelIndex]
-	fileData, err := d2asset.LoadFile("/data/global/tiles/" + stamp.regionPath)
+	stamp.loadDS1(levelFilesToPick[levelIndex], levelType)
+
+	return stamp
+}
+
+// LoadStampFile loads a Stamp from a DS1 file which is not a level preset,
+// e.g. a tile substitution from LvlSub.txt.
+func LoadStampFile(levelType d2enum.RegionIdType, fileName string) *Stamp {
+	stamp := &Stamp{
+		levelType: d2datadict.LevelTypes[levelType],
+	}
+
+	stamp.loadDS1(fileName, levelType)
+
+	return stamp
+}
+
+func (mr *Stamp) loadDS1(fileName string, levelType d2enum.RegionIdType) {
+	mr.regionPath = fileName
+	fileData, err := d2asset.LoadFile("/data/global/tiles/" + mr.regionPath)
 
 	if err != nil {
 		panic(err)
 	}
 
-	stamp.ds1, _ = d2ds1.LoadDS1(fileData)
+	mr.ds1, _ = d2ds1.LoadDS1(fileData)
 
 	// Update the region info for the tiles
-	for rx := 0; rx < len(stamp.ds1.Tiles); rx++ {
-		for x := 0; x < len(stamp.ds1.Tiles[rx]); x++ {
-			stamp.ds1.Tiles[rx][x].RegionType = levelType
+	for rx := 0; rx < len(mr.ds1.Tiles); rx++ {
+		for x := 0; x < len(mr.ds1.Tiles[rx]); x++ {
+			mr.ds1.Tiles[rx][x].RegionType = levelType
 		}
 	}
-
-	return stamp
 }
 
 // Size returns the size of the stamp in tiles.
@@ -124,13 +140,27 @@ func (mr *Stamp) Entities(tileOffsetX, tileOffsetY int) []d2interface.MapEntity
 
 	for _, object := range mr.ds1.Objects {
 		if object.Type == int(d2enum.ObjectTypeCharacter) {
-			monstat := d2datadict.MonStats[d2datadict.MonPresets[mr.ds1.Act][object.Id]]
+			place := d2datadict.MonPresets[mr.ds1.Act][object.Id]
+			monstat := d2datadict.MonStats[place]
+
+			// SuperUniques are placed by their key, their minions are spawned
+			// when the level is populated.
+			superUnique := d2datadict.SuperUniques[place]
+			if monstat == nil && superUnique != nil {
+				monstat = d2datadict.MonStats[superUnique.Class]
+			}
+
 			// If monstat is nil here it is a place_ type object, idk how to handle those yet.
 			// (See monpreset and monplace txts for reference)
 			if monstat != nil {
 				// Temorary use of Lookup.
 				npc := d2mapentity.CreateNPC((tileOffsetX*5)+object.X, (tileOffsetY*5)+object.Y, monstat, 0)
 				npc.SetPaths(convertPaths(tileOffsetX, tileOffsetY, object.Paths))
+
+				if superUnique != nil && superUnique.Class == monstat.Key {
+					npc.SetSuperUnique(superUnique)
+				}
+
 				entities = append(entities, npc)
 			}
 		}
@@ -0,0 +1,55 @@
+package d2object
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+)
+
+// dropItemLevel is the highest base level of items dropped by objects.
+// TODO: drop from the treasure classes of the level once TreasureClassEx.txt is loaded
+const dropItemLevel = 10
+
+// dropOffsets are the positions around an object, in sub-tiles, where its
+// items are dropped.
+var dropOffsets = [][2]int{{2, 2}, {-2, 2}, {2, -2}, {0, 3}, {3, 0}, {-2, -2}} //nolint:gochecknoglobals // constant lookup table
+
+// dropItems drops between min and max items around an object. The items
+// are picked with random numbers derived from the map seed and the object's
+// position, so the server and all clients drop the same items.
+func dropItems(ob *Object, world World, min, max int) {
+	items := DroppableItems(dropItemLevel)
+	if len(items) == 0 {
+		return
+	}
+
+	x, y := int(ob.LocationX), int(ob.LocationY)
+	rng := rand.New(rand.NewSource(world.Seed() ^ (int64(x)<<32 | int64(y)))) //nolint:gosec // not used for security
+
+	count := min
+	if max > min {
+		count += rng.Intn(max - min + 1)
+	}
+
+	for idx := 0; idx < count && idx < len(dropOffsets); idx++ {
+		offset := dropOffsets[idx]
+		world.DropItem(x+offset[0], y+offset[1], items[rng.Intn(len(items))])
+	}
+}
+
+// DroppableItems returns the spawnable items up to the given level, ordered
+// by their code.
+func DroppableItems(maxLevel int) []*d2datadict.ItemCommonRecord {
+	items := make([]*d2datadict.ItemCommonRecord, 0)
+
+	for _, item := range d2datadict.CommonItems {
+		if item.Spawnable && item.Quest == 0 && item.FlippyFile != "" && item.Level <= maxLevel {
+			items = append(items, item)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Code < items[j].Code })
+
+	return items
+}
@@ -25,6 +25,8 @@ type Object struct {
 	objectRecord *d2datadict.ObjectRecord
 	drawLayer    int
 	name         string
+	mode         d2enum.ObjectAnimationMode
+	operated     bool
 }
 
 // CreateObject creates an instance of AnimatedComposite
@@ -64,6 +66,7 @@ func (ob *Object) setMode(animationMode d2enum.ObjectAnimationMode, direction in
 		return err
 	}
 
+	ob.mode = animationMode
 	ob.composite.SetDirection(direction)
 
 	// mode := d2enum.ObjectAnimationModeFromString(animationMode)
@@ -92,6 +95,98 @@ func (ob *Object) setMode(animationMode d2enum.ObjectAnimationMode, direction in
 	return err
 }
 
+// setOpenedMode switches the object to its opened mode, or to its operating
+// mode if it has no opened mode.
+func (ob *Object) setOpenedMode() {
+	if ob.objectRecord.HasAnimationMode[d2enum.ObjectAnimationModeOpened] {
+		ob.setMode(d2enum.ObjectAnimationModeOpened, 0, false)
+		return
+	}
+
+	if ob.objectRecord.HasAnimationMode[d2enum.ObjectAnimationModeOperating] {
+		ob.setMode(d2enum.ObjectAnimationModeOperating, 0, false)
+	}
+}
+
+// Operate runs the object's operate function, e.g. opens a chest or a door.
+// It returns false if the object can not be operated.
+func (ob *Object) Operate(world World) bool {
+	fun := operateFunction(ob)
+	if fun == nil {
+		return false
+	}
+
+	fun(ob, world)
+
+	return true
+}
+
+// IsOperated returns true if the object was operated, e.g. a chest was
+// opened or a door is open.
+func (ob *Object) IsOperated() bool {
+	return ob.operated
+}
+
+// IsWaypoint returns true if the object is a waypoint.
+func (ob *Object) IsWaypoint() bool {
+	return ob.objectRecord.OperateFn == operateWaypoint || ob.objectRecord.SubClass&subClassWaypoint != 0
+}
+
+// IsShrine returns true if the object is a shrine.
+func (ob *Object) IsShrine() bool {
+	return ob.objectRecord.OperateFn == operateShrine || ob.objectRecord.SubClass&subClassShrine != 0
+}
+
+// OperateRange returns the distance, in sub-tiles, from which the object
+// can be operated.
+func (ob *Object) OperateRange() int {
+	return ob.objectRecord.OperateRange
+}
+
+// HasCollision returns true if the object blocks the way in its current mode.
+func (ob *Object) HasCollision() bool {
+	return ob.objectRecord.HasCollision[ob.mode]
+}
+
+// Collision returns what the object blocks in its current mode, closed doors
+// block the way of units and, unless missiles fly through them, missiles.
+func (ob *Object) Collision() d2enum.Collision {
+	if !ob.HasCollision() {
+		return d2enum.CollisionNone
+	}
+
+	if ob.objectRecord.BlockMissile {
+		return d2enum.CollisionSolid
+	}
+
+	return d2enum.CollisionUnit
+}
+
+// LightRadius returns the radius, in sub-tiles, of the light the object
+// emits in its current mode, e.g. the fire of a torch.
+func (ob *Object) LightRadius() float64 {
+	return float64(ob.objectRecord.LightDiameter[ob.mode]) / 2
+}
+
+// Footprint returns the area covered by the object, in sub-tiles.
+func (ob *Object) Footprint() d2common.Rectangle {
+	width, height := ob.objectRecord.SizeX, ob.objectRecord.SizeY
+	if width < 1 {
+		width = 1
+	}
+
+	if height < 1 {
+		height = 1
+	}
+
+	return d2common.Rectangle{
+		Left:   int(ob.LocationX) - width/2,
+		Top:    int(ob.LocationY) - height/2,
+		Width:  width,
+		Height: height,
+	}
+}
+
 // Highlight sets the entity highlighted flag to true.
 func (ob *Object) Highlight() {
 	ob.highlight = true
@@ -0,0 +1,143 @@
+package d2object
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+)
+
+// OperateFn values of objects.txt
+const (
+	operateCasket          = 1
+	operateShrine          = 2
+	operateUrn             = 3
+	operateChest           = 4
+	operateBarrel          = 5
+	operateExplodingBarrel = 7
+	operateDoor            = 8
+	operateWell            = 22
+	operateWaypoint        = 23
+)
+
+// SubClass flags of objects.txt
+const (
+	subClassShrine    = 1
+	subClassContainer = 8
+	subClassWell      = 32
+	subClassWaypoint  = 64
+)
+
+// World is what an object can affect when it is operated by a player. The
+// server and the clients each provide their own, so that an object is
+// operated the same way on all of them.
+type World interface {
+	// Seed is the seed of the map, operating objects which involve chance
+	// derives its random numbers from it.
+	Seed() int64
+
+	// SetAreaCollision changes the collision of the walk mesh, in sub-tiles.
+	SetAreaCollision(area d2common.Rectangle, collision d2enum.Collision)
+
+	// DropItem drops an item on the ground at the given sub-tile.
+	DropItem(subTileX, subTileY int, item *d2datadict.ItemCommonRecord)
+
+	// GrantEffect grants the operating player a timed effect.
+	GrantEffect(effect d2hero.EffectType, duration float64)
+
+	// Restore refills the life and/or mana of the operating player.
+	Restore(life, mana bool)
+
+	// OpenWaypoints lets the operating player travel from the waypoint.
+	OpenWaypoints(waypoint *Object)
+}
+
+// Finds an operate function for the given object
+func operateFunction(ob *Object) func(*Object, World) {
+	funcs := map[int]func(*Object, World){
+		operateCasket:          operateContainer(1, 1),
+		operateShrine:          operateShrineFn,
+		operateUrn:             operateContainer(0, 1),
+		operateChest:           operateContainer(1, 3),
+		operateBarrel:          operateContainer(0, 1),
+		operateExplodingBarrel: operateContainer(0, 1),
+		operateDoor:            operateDoorFn,
+		operateWell:            operateWellFn,
+		operateWaypoint:        operateWaypointFn,
+	}
+
+	if fun, ok := funcs[ob.objectRecord.OperateFn]; ok {
+		return fun
+	}
+
+	switch {
+	case ob.objectRecord.IsDoor:
+		return operateDoorFn
+	case ob.objectRecord.SubClass&subClassWaypoint != 0:
+		return operateWaypointFn
+	case ob.objectRecord.SubClass&subClassShrine != 0:
+		return operateShrineFn
+	case ob.objectRecord.SubClass&subClassWell != 0:
+		return operateWellFn
+	case ob.objectRecord.SubClass&subClassContainer != 0:
+		return operateContainer(1, 1)
+	}
+
+	return nil
+}
+
+// operateContainer opens chests, caskets, urns and barrels, which drop
+// between min and max items.
+// TODO: exploding barrels should damage everything around them
+func operateContainer(min, max int) func(*Object, World) {
+	return func(ob *Object, world World) {
+		if ob.operated {
+			return
+		}
+
+		ob.operated = true
+		ob.setOpenedMode()
+		dropItems(ob, world, min, max)
+	}
+}
+
+// operateDoorFn opens closed doors and closes open doors, which changes
+// whether they block the way.
+func operateDoorFn(ob *Object, world World) {
+	if ob.mode == d2enum.ObjectAnimationModeNeutral {
+		ob.setOpenedMode()
+	} else {
+		ob.setMode(d2enum.ObjectAnimationModeNeutral, 0, false)
+	}
+
+	ob.operated = !ob.operated
+	world.SetAreaCollision(ob.Footprint(), ob.Collision())
+}
+
+// operateShrineFn grants the shrine's effect. Shrines can only be used once.
+func operateShrineFn(ob *Object, world World) {
+	if ob.operated {
+		return
+	}
+
+	ob.operated = true
+	ob.setOpenedMode()
+	pickShrine(world.Seed(), int(ob.LocationX), int(ob.LocationY)).apply(world)
+}
+
+// operateWellFn refills the life of the player.
+func operateWellFn(ob *Object, world World) {
+	if ob.operated {
+		return
+	}
+
+	ob.operated = true
+	ob.setOpenedMode()
+	world.Restore(true, false)
+}
+
+// operateWaypointFn activates the waypoint and opens the travel menu.
+func operateWaypointFn(ob *Object, world World) {
+	ob.operated = true
+	world.OpenWaypoints(ob)
+}
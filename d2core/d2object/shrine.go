@@ -0,0 +1,52 @@
+package d2object
+
+import (
+	"math/rand"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+)
+
+const (
+	// durations of the shrine effects, in seconds
+	shrineEffectDuration     = 96
+	experienceShrineDuration = 144
+)
+
+// shrine is what a shrine does when it is operated. The refilling shrines
+// restore life and mana, the others grant a timed effect.
+type shrine struct {
+	effect     d2hero.EffectType
+	duration   float64
+	life, mana bool
+}
+
+//nolint:gochecknoglobals // constant lookup table
+var shrines = []shrine{
+	{life: true, mana: true},
+	{life: true},
+	{mana: true},
+	{effect: d2hero.EffectArmor, duration: shrineEffectDuration},
+	{effect: d2hero.EffectCombat, duration: shrineEffectDuration},
+	{effect: d2hero.EffectResistFire, duration: shrineEffectDuration},
+	{effect: d2hero.EffectResistCold, duration: shrineEffectDuration},
+	{effect: d2hero.EffectResistLightning, duration: shrineEffectDuration},
+	{effect: d2hero.EffectResistPoison, duration: shrineEffectDuration},
+	{effect: d2hero.EffectExperience, duration: experienceShrineDuration},
+}
+
+// pickShrine picks the kind of a shrine from the map seed and its position,
+// so it is the same on the server and all clients.
+func pickShrine(seed int64, x, y int) *shrine {
+	rng := rand.New(rand.NewSource(seed ^ (int64(x)<<32 | int64(y)))) //nolint:gosec // not used for security
+
+	return &shrines[rng.Intn(len(shrines))]
+}
+
+func (s *shrine) apply(world World) {
+	if s.life || s.mana {
+		world.Restore(s.life, s.mana)
+		return
+	}
+
+	world.GrantEffect(s.effect, s.duration)
+}
@@ -0,0 +1,3 @@
+// Package software provides a renderer which draws on images in memory, so
+// the engine can render without a window or a GPU, e.g. in tests and tools.
+package software
@@ -0,0 +1,107 @@
+package software
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+)
+
+//nolint:gochecknoglobals // test flag
+var updateGolden = flag.Bool("update", false, "update the golden images in testdata")
+
+// expectGolden compares a surface with the image in testdata, or replaces
+// the image when the tests run with -update.
+func expectGolden(t *testing.T, surface d2interface.Surface, name string) {
+	t.Helper()
+
+	filePath := filepath.Join("testdata", name+".png")
+	actual := surface.Screenshot()
+
+	if *updateGolden {
+		file, err := os.Create(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer file.Close()
+
+		if err := png.Encode(file, actual); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	expected, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !expected.Bounds().Eq(actual.Bounds()) {
+		t.Fatalf("expected an image of %v, got %v", expected.Bounds(), actual.Bounds())
+	}
+
+	bounds := actual.Bounds()
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if color.RGBAModel.Convert(expected.At(x, y)) != actual.RGBAAt(x, y) {
+				t.Fatalf("the image differs from %s at %d,%d", filePath, x, y)
+			}
+		}
+	}
+}
+
+func TestGoldenScene(t *testing.T) {
+	// a square with a transparent hole
+	sprite := newSurface(t, 16, 16)
+	pixels := make([]byte, 16*16*4)
+
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			if x < 4 || y < 4 || x >= 12 || y >= 12 {
+				copy(pixels[(x+y*16)*4:], []byte{200, 120, 40, 255})
+			}
+		}
+	}
+
+	_ = sprite.ReplacePixels(pixels)
+
+	target := newSurface(t, 96, 64)
+	_ = target.Clear(color.RGBA{R: 20, G: 20, B: 40, A: 255})
+
+	target.PushTranslation(4, 4)
+	_ = target.Render(sprite)
+	target.PushTranslation(20, 0)
+	target.PushColor(color.RGBA{R: 255, A: 255})
+	_ = target.Render(sprite)
+	target.PushTranslation(20, 0)
+	target.PushEffect(d2enum.DrawEffectPctTransparency50)
+	_ = target.Render(sprite)
+	target.PopN(2)
+	target.PushTranslation(40, 0)
+	target.PushBrightness(0.5)
+	_ = target.RenderSection(sprite, image.Rect(0, 0, 8, 16))
+	target.PopN(4)
+
+	target.PushTranslation(4, 24)
+	target.DrawLine(60, 10, color.RGBA{G: 255, A: 255})
+	target.DrawText("OpenDiablo2")
+	target.Pop()
+
+	expectGolden(t, target, "scene")
+}
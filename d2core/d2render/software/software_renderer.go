@@ -0,0 +1,148 @@
+package software
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2config"
+)
+
+// Renderer draws on images in memory. It has no window, the frames it runs
+// are drawn but not shown.
+type Renderer struct {
+	vsyncEnabled   bool
+	ticksPerSecond int
+
+	// the frames drawn since the frame rate was last measured
+	frames      int
+	framesStart time.Time
+	fps         float64
+}
+
+// CreateRenderer creates a software Renderer. The frames are run at the
+// configured ticks per second, if the configuration is loaded.
+func CreateRenderer() (*Renderer, error) {
+	result := &Renderer{}
+
+	if config := d2config.Config; config != nil {
+		result.ticksPerSecond = config.TicksPerSecond
+		result.vsyncEnabled = config.VsyncEnabled
+	}
+
+	return result, nil
+}
+
+// GetRendererName returns the name of the renderer
+func (*Renderer) GetRendererName() string {
+	return "Software"
+}
+
+// SetWindowIcon does nothing, there is no window
+func (*Renderer) SetWindowIcon(fileName string) {
+}
+
+// IsDrawingSkipped returns false, the drawing is never skipped
+func (*Renderer) IsDrawingSkipped() bool {
+	return false
+}
+
+// Run calls f with a screen of the given size at each tick until it returns
+// an error. Without a limit of ticks per second, the frames are run as fast
+// as they can be drawn.
+func (r *Renderer) Run(f func(surface d2interface.Surface) error, width, height int, title string) error {
+	screen := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	var tickLength time.Duration
+	if r.ticksPerSecond > 0 {
+		tickLength = time.Second / time.Duration(r.ticksPerSecond)
+	}
+
+	r.framesStart = time.Now()
+
+	for {
+		tickStart := time.Now()
+
+		surface := createSoftwareSurface(screen)
+		if err := surface.Clear(color.Transparent); err != nil {
+			return err
+		}
+
+		if err := f(surface); err != nil {
+			return err
+		}
+
+		r.countFrame()
+
+		if elapsed := time.Since(tickStart); elapsed < tickLength {
+			time.Sleep(tickLength - elapsed)
+		}
+	}
+}
+
+// countFrame measures the frame rate every second
+func (r *Renderer) countFrame() {
+	r.frames++
+
+	if elapsed := time.Since(r.framesStart); elapsed >= time.Second {
+		r.fps = float64(r.frames) / elapsed.Seconds()
+		r.frames = 0
+		r.framesStart = time.Now()
+	}
+}
+
+// CreateSurface creates a surface which draws on the image of another surface
+func (*Renderer) CreateSurface(surface d2interface.Surface) (d2interface.Surface, error) {
+	result := createSoftwareSurface(
+		surface.(*softwareSurface).image,
+		surfaceState{
+			filter: d2enum.FilterNearest,
+			effect: d2enum.DrawEffectNone,
+		},
+	)
+
+	return result, nil
+}
+
+// NewSurface creates a transparent surface of the given size
+func (*Renderer) NewSurface(width, height int, filter d2enum.Filter) (d2interface.Surface, error) {
+	if width < 0 || height < 0 {
+		return nil, errors.New("invalid surface size")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	return createSoftwareSurface(img, surfaceState{filter: filter, effect: d2enum.DrawEffectNone}), nil
+}
+
+// IsFullScreen returns false, there is no window
+func (*Renderer) IsFullScreen() bool {
+	return false
+}
+
+// SetFullScreen does nothing, there is no window
+func (*Renderer) SetFullScreen(fullScreen bool) {
+}
+
+// SetVSyncEnabled sets whether vsync is enabled
+func (r *Renderer) SetVSyncEnabled(vsync bool) {
+	r.vsyncEnabled = vsync
+}
+
+// GetVSyncEnabled returns whether vsync is enabled
+func (r *Renderer) GetVSyncEnabled() bool {
+	return r.vsyncEnabled
+}
+
+// GetCursorPos returns the origin, there is no cursor
+func (*Renderer) GetCursorPos() (int, int) {
+	return 0, 0
+}
+
+// CurrentFPS returns the number of frames drawn per second
+func (r *Renderer) CurrentFPS() float64 {
+	return r.fps
+}
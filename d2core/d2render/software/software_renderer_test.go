@@ -0,0 +1,37 @@
+package software
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+)
+
+func TestRunUntilError(t *testing.T) {
+	renderer, _ := CreateRenderer()
+	stop := errors.New("stop")
+	frames := 0
+
+	err := renderer.Run(func(surface d2interface.Surface) error {
+		if width, height := surface.GetSize(); width != 800 || height != 600 {
+			t.Fatalf("expected an 800x600 screen, got %dx%d", width, height)
+		}
+
+		if surface.GetDepth() != 0 {
+			t.Fatal("expected a new screen at each frame")
+		}
+
+		surface.PushTranslation(1, 1)
+
+		frames++
+		if frames == 3 {
+			return stop
+		}
+
+		return nil
+	}, 800, 600, "test")
+
+	if err != stop || frames != 3 {
+		t.Errorf("expected Run to stop at the third frame with its error, got %v after %d frames", err, frames)
+	}
+}
@@ -0,0 +1,347 @@
+package software
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	maxChannel = 0xffff
+
+	// the lines of text are as far apart as the lines of the debug text of
+	// the ebiten renderer
+	textLineHeight = 16
+)
+
+// tint scales the premultiplied channels of the drawn pixels, 0xffff keeps
+// a channel unchanged
+type tint [4]uint32
+
+// compositeMode tells how the drawn pixels are combined with the pixels of
+// the surface.
+type compositeMode int
+
+const (
+	compositeSourceOver compositeMode = iota
+	compositeLighter
+)
+
+//nolint:gochecknoglobals // constant tint
+var noTint = tint{maxChannel, maxChannel, maxChannel, maxChannel}
+
+type softwareSurface struct {
+	stateStack   []surfaceState
+	stateCurrent surfaceState
+	image        *image.RGBA
+}
+
+func createSoftwareSurface(img *image.RGBA, currentState ...surfaceState) *softwareSurface {
+	state := surfaceState{effect: d2enum.DrawEffectNone}
+	if len(currentState) > 0 {
+		state = currentState[0]
+	}
+
+	return &softwareSurface{
+		image:        img,
+		stateCurrent: state,
+	}
+}
+
+func (s *softwareSurface) PushTranslation(x, y int) {
+	s.stateStack = append(s.stateStack, s.stateCurrent)
+	s.stateCurrent.x += x
+	s.stateCurrent.y += y
+}
+
+func (s *softwareSurface) PushEffect(effect d2enum.DrawEffect) {
+	s.stateStack = append(s.stateStack, s.stateCurrent)
+	s.stateCurrent.effect = effect
+}
+
+func (s *softwareSurface) PushFilter(filter d2enum.Filter) {
+	s.stateStack = append(s.stateStack, s.stateCurrent)
+	s.stateCurrent.filter = filter
+}
+
+func (s *softwareSurface) PushColor(color color.Color) {
+	s.stateStack = append(s.stateStack, s.stateCurrent)
+	s.stateCurrent.color = color
+}
+
+func (s *softwareSurface) PushBrightness(brightness float64) {
+	s.stateStack = append(s.stateStack, s.stateCurrent)
+	s.stateCurrent.brightness = brightness
+}
+
+func (s *softwareSurface) Pop() {
+	count := len(s.stateStack)
+	if count == 0 {
+		panic("empty stack")
+	}
+
+	s.stateCurrent = s.stateStack[count-1]
+	s.stateStack = s.stateStack[:count-1]
+}
+
+func (s *softwareSurface) PopN(n int) {
+	for i := 0; i < n; i++ {
+		s.Pop()
+	}
+}
+
+func (s *softwareSurface) Render(sfc d2interface.Surface) error {
+	img := sfc.(*softwareSurface).image
+	s.draw(img, img.Bounds())
+
+	return nil
+}
+
+// Renders the section of the animation frame enclosed by bounds
+func (s *softwareSurface) RenderSection(sfc d2interface.Surface, bound image.Rectangle) error {
+	img := sfc.(*softwareSurface).image
+	s.draw(img, bound.Intersect(img.Bounds()))
+
+	return nil
+}
+
+// DrawText draws white text with a fixed size font, like the debug text of
+// the ebiten renderer.
+func (s *softwareSurface) DrawText(format string, params ...interface{}) {
+	drawer := font.Drawer{
+		Dst:  s.image,
+		Src:  image.White,
+		Face: basicfont.Face7x13,
+	}
+
+	for i, line := range strings.Split(fmt.Sprintf(format, params...), "\n") {
+		drawer.Dot = fixed.P(s.stateCurrent.x, s.stateCurrent.y+i*textLineHeight+basicfont.Face7x13.Ascent)
+		drawer.DrawString(line)
+	}
+}
+
+// DrawLine draws a line from the current position to the position at the
+// given offset.
+func (s *softwareSurface) DrawLine(x, y int, color color.Color) {
+	x0, y0 := s.stateCurrent.x, s.stateCurrent.y
+	x1, y1 := x0+x, y0+y
+
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	stepX, stepY := sign(x1-x0), sign(y1-y0)
+	err := dx + dy
+
+	for {
+		s.blendPixel(x0, y0, color)
+
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+
+		e2 := 2 * err
+
+		if e2 >= dy {
+			err += dy
+			x0 += stepX
+		}
+
+		if e2 <= dx {
+			err += dx
+			y0 += stepY
+		}
+	}
+}
+
+func (s *softwareSurface) DrawRect(width, height int, color color.Color) {
+	bounds := image.Rect(s.stateCurrent.x, s.stateCurrent.y, s.stateCurrent.x+width, s.stateCurrent.y+height)
+	bounds = bounds.Intersect(s.image.Bounds())
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			s.blendPixel(x, y, color)
+		}
+	}
+}
+
+func (s *softwareSurface) Clear(fillColor color.Color) error {
+	c := color.RGBAModel.Convert(fillColor).(color.RGBA)
+
+	for i := 0; i < len(s.image.Pix); i += 4 {
+		s.image.Pix[i], s.image.Pix[i+1], s.image.Pix[i+2], s.image.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+
+	return nil
+}
+
+func (s *softwareSurface) GetSize() (int, int) {
+	size := s.image.Bounds().Size()
+	return size.X, size.Y
+}
+
+func (s *softwareSurface) GetDepth() int {
+	return len(s.stateStack)
+}
+
+// ReplacePixels replaces the pixels of the surface by premultiplied RGBA
+// pixels, row by row.
+func (s *softwareSurface) ReplacePixels(pixels []byte) error {
+	if len(pixels) != len(s.image.Pix) {
+		return errors.New("the pixels do not match the size of the surface")
+	}
+
+	copy(s.image.Pix, pixels)
+
+	return nil
+}
+
+func (s *softwareSurface) Screenshot() *image.RGBA {
+	rgba := image.NewRGBA(s.image.Bounds())
+	copy(rgba.Pix, s.image.Pix)
+
+	return rgba
+}
+
+// draw composites the given part of an image over the surface at the current
+// position.
+func (s *softwareSurface) draw(src *image.RGBA, bounds image.Rectangle) {
+	offset := image.Pt(s.stateCurrent.x, s.stateCurrent.y).Sub(bounds.Min)
+	target := bounds.Add(offset).Intersect(s.image.Bounds())
+	srcTint := s.tint()
+	mode := compositeSourceOver
+
+	if s.stateCurrent.effect == d2enum.DrawEffectModulate {
+		mode = compositeLighter
+	}
+
+	for y := target.Min.Y; y < target.Max.Y; y++ {
+		srcIndex := src.PixOffset(target.Min.X-offset.X, y-offset.Y)
+		dstIndex := s.image.PixOffset(target.Min.X, y)
+
+		for x := target.Min.X; x < target.Max.X; x++ {
+			blend(s.image.Pix[dstIndex:dstIndex+4], src.Pix[srcIndex:srcIndex+4], srcTint, mode)
+
+			srcIndex += 4
+			dstIndex += 4
+		}
+	}
+}
+
+// tint returns the tint of the current color, brightness and effect, which
+// changes the drawn pixels like the color matrices of the ebiten renderer.
+func (s *softwareSurface) tint() tint {
+	result := noTint
+
+	if s.stateCurrent.color != nil {
+		r, g, b, a := s.stateCurrent.color.RGBA()
+
+		// colors which are not premultiplied, e.g. a white with an alpha,
+		// tint the pixels as if they were
+		result = tint{minUint32(r, a), minUint32(g, a), minUint32(b, a), a}
+	}
+
+	if s.stateCurrent.brightness != 0 {
+		for i := 0; i < 3; i++ {
+			result[i] = uint32(float64(result[i]) * s.stateCurrent.brightness)
+		}
+	}
+
+	var opacity float64
+
+	switch s.stateCurrent.effect {
+	case d2enum.DrawEffectPctTransparency25:
+		opacity = 0.75
+	case d2enum.DrawEffectPctTransparency50:
+		opacity = 0.5
+	case d2enum.DrawEffectPctTransparency75:
+		opacity = 0.25
+	default:
+		return result
+	}
+
+	for i := range result {
+		result[i] = uint32(float64(result[i]) * opacity)
+	}
+
+	return result
+}
+
+func (s *softwareSurface) blendPixel(x, y int, pixelColor color.Color) {
+	if !(image.Point{X: x, Y: y}).In(s.image.Bounds()) {
+		return
+	}
+
+	c := color.RGBAModel.Convert(pixelColor).(color.RGBA)
+	index := s.image.PixOffset(x, y)
+	blend(s.image.Pix[index:index+4], []uint8{c.R, c.G, c.B, c.A}, noTint, compositeSourceOver)
+}
+
+// blend composites a premultiplied source pixel, scaled by a tint, with a
+// premultiplied destination pixel.
+func blend(dst, src []uint8, srcTint tint, mode compositeMode) {
+	var tinted [4]uint32
+
+	for i := range tinted {
+		tinted[i] = uint32(src[i]) * srcTint[i] / maxChannel
+	}
+
+	if tinted[3] > 0xff {
+		tinted[3] = 0xff
+	}
+
+	// brightened colors are at most as bright as they are opaque
+	for i := 0; i < 3; i++ {
+		tinted[i] = minUint32(tinted[i], tinted[3])
+	}
+
+	if tinted[3] == 0 {
+		return
+	}
+
+	if mode == compositeLighter {
+		for i := range tinted {
+			dst[i] = uint8(minUint32(tinted[i]+uint32(dst[i]), 0xff))
+		}
+
+		return
+	}
+
+	remaining := 0xff - tinted[3]
+
+	for i := range tinted {
+		dst[i] = uint8(tinted[i] + uint32(dst[i])*remaining/0xff)
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
+
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func minUint32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,192 @@
+package software
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+)
+
+func newSurface(t *testing.T, width, height int) d2interface.Surface {
+	renderer, _ := CreateRenderer()
+
+	surface, err := renderer.NewSurface(width, height, d2enum.FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return surface
+}
+
+func expectPixel(t *testing.T, surface d2interface.Surface, x, y int, expected color.RGBA) {
+	t.Helper()
+
+	if actual := surface.Screenshot().RGBAAt(x, y); actual != expected {
+		t.Errorf("pixel %d,%d: expected %v, got %v", x, y, expected, actual)
+	}
+}
+
+func TestRenderAtTranslation(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+
+	source := newSurface(t, 2, 2)
+	_ = source.Clear(red)
+
+	target := newSurface(t, 4, 4)
+	_ = target.Clear(blue)
+
+	target.PushTranslation(1, 1)
+	target.PushTranslation(2, 2)
+	_ = target.Render(source)
+	target.PopN(2)
+
+	if target.GetDepth() != 0 {
+		t.Errorf("expected an empty stack, got %d", target.GetDepth())
+	}
+
+	expectPixel(t, target, 2, 2, blue)
+	expectPixel(t, target, 3, 3, red)
+}
+
+func TestRenderSection(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+
+	source := newSurface(t, 4, 4)
+	source.PushTranslation(2, 0)
+	source.DrawRect(2, 4, red)
+	source.Pop()
+
+	target := newSurface(t, 4, 4)
+	_ = target.RenderSection(source, image.Rect(2, 0, 4, 2))
+
+	expectPixel(t, target, 0, 0, red)
+	expectPixel(t, target, 1, 1, red)
+	expectPixel(t, target, 2, 0, color.RGBA{})
+	expectPixel(t, target, 0, 2, color.RGBA{})
+}
+
+func TestRenderBlendsWithColor(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+	source := newSurface(t, 1, 1)
+	_ = source.Clear(white)
+
+	target := newSurface(t, 1, 1)
+	_ = target.Clear(color.RGBA{A: 255})
+
+	// a half transparent white, as drawn on the shadows of the map
+	target.PushColor(color.RGBA{R: 255, G: 255, B: 255, A: 128})
+	_ = target.Render(source)
+	target.Pop()
+
+	expectPixel(t, target, 0, 0, color.RGBA{R: 128, G: 128, B: 128, A: 255})
+}
+
+func TestDrawLine(t *testing.T) {
+	green := color.RGBA{G: 255, A: 255}
+
+	target := newSurface(t, 8, 8)
+	target.PushTranslation(1, 1)
+	target.DrawLine(4, 2, green)
+	target.Pop()
+
+	expectPixel(t, target, 1, 1, green)
+	expectPixel(t, target, 5, 3, green)
+	expectPixel(t, target, 0, 0, color.RGBA{})
+}
+
+func TestDrawOutOfBounds(t *testing.T) {
+	green := color.RGBA{G: 255, A: 255}
+
+	target := newSurface(t, 2, 2)
+	target.PushTranslation(-1, -1)
+	target.DrawRect(2, 10, green)
+	target.DrawLine(10, 0, green)
+	target.Pop()
+
+	expectPixel(t, target, 0, 0, green)
+	expectPixel(t, target, 0, 1, green)
+	expectPixel(t, target, 1, 0, color.RGBA{})
+}
+
+func TestReplacePixels(t *testing.T) {
+	target := newSurface(t, 1, 2)
+
+	if err := target.ReplacePixels([]byte{1, 2, 3, 255}); err == nil {
+		t.Error("expected an error for pixels of another size")
+	}
+
+	if err := target.ReplacePixels([]byte{1, 2, 3, 255, 4, 5, 6, 255}); err != nil {
+		t.Fatal(err)
+	}
+
+	expectPixel(t, target, 0, 1, color.RGBA{R: 4, G: 5, B: 6, A: 255})
+}
+
+func TestRenderEffects(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	gray := color.RGBA{R: 100, G: 100, B: 100, A: 255}
+
+	tests := []struct {
+		effect   d2enum.DrawEffect
+		expected color.RGBA
+	}{
+		{d2enum.DrawEffectNone, white},
+		{d2enum.DrawEffectPctTransparency25, color.RGBA{R: 174, G: 174, B: 174, A: 255}},
+		{d2enum.DrawEffectPctTransparency50, color.RGBA{R: 149, G: 149, B: 149, A: 255}},
+		{d2enum.DrawEffectPctTransparency75, color.RGBA{R: 124, G: 124, B: 124, A: 255}},
+		{d2enum.DrawEffectModulate, white},
+	}
+
+	source := newSurface(t, 1, 1)
+	_ = source.Clear(color.RGBA{R: 200, G: 200, B: 200, A: 255})
+
+	for _, test := range tests {
+		target := newSurface(t, 1, 1)
+		_ = target.Clear(gray)
+
+		if test.effect == d2enum.DrawEffectNone {
+			// the source is brightened to white
+			target.PushBrightness(2)
+		} else {
+			target.PushEffect(test.effect)
+		}
+
+		_ = target.Render(source)
+		target.Pop()
+
+		expectPixel(t, target, 0, 0, test.expected)
+	}
+}
+
+func TestDrawText(t *testing.T) {
+	target := newSurface(t, 40, 40)
+	target.PushTranslation(2, 2)
+	target.DrawText("%d\n%s", 1, "I")
+	target.Pop()
+
+	drawn := func(top, bottom int) bool {
+		screenshot := target.Screenshot()
+
+		for y := top; y < bottom; y++ {
+			for x := 0; x < 40; x++ {
+				if screenshot.RGBAAt(x, y).A != 0 {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	if !drawn(2, 2+textLineHeight) || !drawn(2+textLineHeight, 2+2*textLineHeight) {
+		t.Error("expected a line of text on each line")
+	}
+
+	if drawn(0, 2) {
+		t.Error("expected the text below the current position")
+	}
+}
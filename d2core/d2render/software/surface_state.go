@@ -0,0 +1,16 @@
+package software
+
+import (
+	"image/color"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+type surfaceState struct {
+	x          int
+	y          int
+	filter     d2enum.Filter
+	color      color.Color
+	brightness float64
+	effect     d2enum.DrawEffect
+}
@@ -0,0 +1,182 @@
+package d2save
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2config"
+)
+
+// BackupCount is the number of previous saves kept next to a save file
+const BackupCount = 3
+
+// Errors
+var (
+	ErrCorrupted = errors.New("save file corrupted")
+	ErrChecksum  = errors.New("save file checksum mismatch")
+	ErrNoBackup  = errors.New("no valid backup of the save file")
+)
+
+// saveFile is what a save file holds, the state with the version of its
+// schema and its checksum
+type saveFile struct {
+	Version  int             `json:"version"`
+	Checksum uint32          `json:"checksum"` // CRC-32 of the compact JSON of the state
+	State    json.RawMessage `json:"state"`
+}
+
+// Directory returns the directory the games are saved to, the
+// SaveDirectory of the configuration if it is set
+func Directory() (string, error) {
+	if d2config.Config != nil && d2config.Config.SaveDirectory != "" {
+		return d2config.Config.SaveDirectory, nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(configDir, "OpenDiablo2/Saves"), nil
+}
+
+// Save writes a state with the version of its schema to a file. The state is
+// written to a temporary file first, which then replaces the file, so that
+// the file is never left half written. The file which is replaced becomes
+// the newest of the backups.
+func Save(filePath string, version int, state interface{}) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	fileData, err := json.MarshalIndent(saveFile{
+		Version:  version,
+		Checksum: crc32.ChecksumIEEE(data),
+		State:    data,
+	}, "", "   ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(path.Dir(filePath), 0755); err != nil {
+		return err
+	}
+
+	tempPath, err := writeTemp(filePath, fileData)
+	if err != nil {
+		return err
+	}
+
+	if err := rotateBackups(filePath); err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+
+	return os.Rename(tempPath, filePath)
+}
+
+func writeTemp(filePath string, data []byte) (string, error) {
+	file, err := ioutil.TempFile(path.Dir(filePath), path.Base(filePath)+".tmp")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+// rotateBackups copies the file to the newest backup, after shifting the
+// backups by one and dropping the oldest
+func rotateBackups(filePath string) error {
+	data, err := ioutil.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for n := BackupCount - 1; n > 0; n-- {
+		err := os.Rename(BackupPath(filePath, n), BackupPath(filePath, n+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return ioutil.WriteFile(BackupPath(filePath, 1), data, 0644)
+}
+
+// Load reads the state saved to a file and the version of its schema. The
+// files saved before the versions hold the state alone, they have version 0.
+func Load(filePath string) (state []byte, version int, err error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	file := saveFile{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, 0, ErrCorrupted
+	}
+
+	if file.State == nil {
+		return data, 0, nil
+	}
+
+	compact := new(bytes.Buffer)
+	if err := json.Compact(compact, file.State); err != nil {
+		return nil, 0, ErrCorrupted
+	}
+
+	if crc32.ChecksumIEEE(compact.Bytes()) != file.Checksum {
+		return nil, 0, ErrChecksum
+	}
+
+	return compact.Bytes(), file.Version, nil
+}
+
+// LoadBackup reads the state saved to the newest valid backup of a file
+func LoadBackup(filePath string) (state []byte, version int, err error) {
+	for n := 1; n <= BackupCount; n++ {
+		if state, version, err := Load(BackupPath(filePath, n)); err == nil {
+			return state, version, nil
+		}
+	}
+
+	return nil, 0, ErrNoBackup
+}
+
+// Remove deletes a file and its backups
+func Remove(filePath string) error {
+	for n := 1; n <= BackupCount; n++ {
+		if err := os.Remove(BackupPath(filePath, n)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return os.Remove(filePath)
+}
+
+// BackupPath returns the path of the nth newest backup of a file
+func BackupPath(filePath string, n int) string {
+	return filePath + ".bak" + strconv.Itoa(n)
+}
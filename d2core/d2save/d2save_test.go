@@ -0,0 +1,114 @@
+package d2save
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2config"
+)
+
+type testState struct {
+	Name  string `json:"name"`
+	Level int    `json:"level"`
+}
+
+func createSaveDirectory(t *testing.T) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "d2save")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d2config.Config = &d2config.Configuration{SaveDirectory: dir}
+
+	return dir
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := createSaveDirectory(t)
+	defer os.RemoveAll(dir)
+
+	if saveDir, _ := Directory(); saveDir != dir {
+		t.Fatalf("expected the save directory of the configuration, got %s", saveDir)
+	}
+
+	filePath := path.Join(dir, "0.od2")
+
+	for level := 1; level <= BackupCount+2; level++ {
+		if err := Save(filePath, 2, testState{Name: "Tester", Level: level}); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	state, version, err := Load(filePath)
+	if err != nil || version != 2 || string(state) != `{"name":"Tester","level":5}` {
+		t.Errorf("expected the last state saved, got %s, version %d, %v", state, version, err)
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != BackupCount+1 {
+		t.Errorf("expected the file and %d backups, got %d files", BackupCount, len(files))
+	}
+
+	if state, _, _ := Load(BackupPath(filePath, BackupCount)); string(state) != `{"name":"Tester","level":2}` {
+		t.Errorf("expected the oldest backup to be the second save, got %s", state)
+	}
+
+	if err := Remove(filePath); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("expected the backups to be removed, got %d files", len(files))
+	}
+}
+
+func TestLoadCorrupted(t *testing.T) {
+	dir := createSaveDirectory(t)
+	defer os.RemoveAll(dir)
+
+	filePath := path.Join(dir, "0.od2")
+
+	_ = Save(filePath, 1, testState{Name: "Tester", Level: 1})
+	_ = Save(filePath, 1, testState{Name: "Tester", Level: 2})
+
+	data, _ := ioutil.ReadFile(filePath)
+
+	// the level is edited by hand
+	_ = ioutil.WriteFile(filePath, bytes.Replace(data, []byte(`"level": 2`), []byte(`"level": 99`), 1), 0644)
+
+	if _, _, err := Load(filePath); err != ErrChecksum {
+		t.Errorf("expected %v, got %v", ErrChecksum, err)
+	}
+
+	_ = ioutil.WriteFile(filePath, data[:len(data)/2], 0644)
+
+	if _, _, err := Load(filePath); err != ErrCorrupted {
+		t.Errorf("expected %v, got %v", ErrCorrupted, err)
+	}
+
+	if state, _, err := LoadBackup(filePath); err != nil || string(state) != `{"name":"Tester","level":1}` {
+		t.Errorf("expected the backup of the first save, got %s, %v", state, err)
+	}
+}
+
+func TestLoadUnversioned(t *testing.T) {
+	dir := createSaveDirectory(t)
+	defer os.RemoveAll(dir)
+
+	filePath := path.Join(dir, "0.od2")
+	_ = ioutil.WriteFile(filePath, []byte(`{"name": "Tester", "level": 3}`), 0644)
+
+	state, version, err := Load(filePath)
+	if err != nil || version != 0 || string(state) != `{"name": "Tester", "level": 3}` {
+		t.Errorf("expected the state alone with version 0, got %s, version %d, %v", state, version, err)
+	}
+
+	if _, _, err := LoadBackup(filePath); err != ErrNoBackup {
+		t.Errorf("expected %v, got %v", ErrNoBackup, err)
+	}
+}
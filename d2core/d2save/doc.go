@@ -0,0 +1,3 @@
+// Package d2save writes the saved games to versioned files, atomically and
+// with backups of the previous saves
+package d2save
@@ -7,7 +7,7 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2gui"
 	"image/color"
 	"math"
-	"os"
+	"strings"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2input"
 
@@ -22,6 +22,12 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client/d2clientconnectiontype"
 )
 
+//nolint:gochecknoglobals // constant colors of the names of the characters
+var (
+	characterNameColor = color.RGBA{R: 188, G: 168, B: 140, A: 255}
+	hardcoreNameColor  = color.RGBA{R: 255, G: 80, B: 80, A: 255}
+)
+
 // CharacterSelect represents the character select screen
 type CharacterSelect struct {
 	background             *d2ui.Sprite
@@ -32,10 +38,13 @@ type CharacterSelect struct {
 	okButton               d2ui.Button
 	deleteCharCancelButton d2ui.Button
 	deleteCharOkButton     d2ui.Button
+	difficultyButtons      [3]d2ui.Button
 	selectionBox           *d2ui.Sprite
 	okCancelBox            *d2ui.Sprite
 	d2HeroTitle            d2ui.Label
 	deleteCharConfirmLabel d2ui.Label
+	difficultyLabel        d2ui.Label
+	loadErrorLabel         d2ui.Label
 	charScrollbar          d2ui.Scrollbar
 	characterNameLabel     [8]d2ui.Label
 	characterStatsLabel    [8]d2ui.Label
@@ -44,6 +53,7 @@ type CharacterSelect struct {
 	gameStates             []*d2player.PlayerState
 	selectedCharacter      int
 	showDeleteConfirmation bool
+	showDifficultySelect   bool
 	connectionType         d2clientconnectiontype.ClientConnectionType
 	connectionHost         string
 	audioProvider          d2interface.AudioProvider
@@ -96,6 +106,16 @@ func (v *CharacterSelect) OnLoad(loading d2screen.LoadingState) {
 	v.deleteCharConfirmLabel.Alignment = d2gui.HorizontalAlignCenter
 	v.deleteCharConfirmLabel.SetPosition(400, 185)
 
+	v.difficultyLabel = d2ui.CreateLabel(d2resource.Font16, d2resource.PaletteUnits)
+	v.difficultyLabel.SetText("Select the difficulty\nof the new game")
+	v.difficultyLabel.Alignment = d2gui.HorizontalAlignCenter
+	v.difficultyLabel.SetPosition(400, 185)
+
+	v.loadErrorLabel = d2ui.CreateLabel(d2resource.Font16, d2resource.PaletteUnits)
+	v.loadErrorLabel.Color = color.RGBA{R: 255, G: 80, B: 80, A: 255}
+	v.loadErrorLabel.Alignment = d2gui.HorizontalAlignCenter
+	v.loadErrorLabel.SetPosition(320, 62)
+
 	animation, _ = d2asset.LoadAnimation(d2resource.CharacterSelectionSelectBox, d2resource.PaletteSky)
 	v.selectionBox, _ = d2ui.LoadSprite(animation)
 	v.selectionBox.SetPosition(37, 86)
@@ -116,7 +136,6 @@ func (v *CharacterSelect) OnLoad(loading d2screen.LoadingState) {
 		}
 
 		v.characterNameLabel[i] = d2ui.CreateLabel(d2resource.Font16, d2resource.PaletteUnits)
-		v.characterNameLabel[i].Color = color.RGBA{R: 188, G: 168, B: 140, A: 255}
 		v.characterNameLabel[i].SetPosition(xOffset, 100+((i/2)*95))
 		v.characterStatsLabel[i] = d2ui.CreateLabel(d2resource.Font16, d2resource.PaletteUnits)
 		v.characterStatsLabel[i].SetPosition(xOffset, 115+((i/2)*95))
@@ -125,6 +144,54 @@ func (v *CharacterSelect) OnLoad(loading d2screen.LoadingState) {
 		v.characterExpLabel[i].SetPosition(xOffset, 130+((i/2)*95))
 	}
 	v.refreshGameStates()
+	v.bindTerminalActions()
+}
+
+// OnUnload releases the terminal actions of the Character Select screen
+func (v *CharacterSelect) OnUnload() error {
+	if err := v.terminal.UnbindAction("importd2s"); err != nil {
+		return err
+	}
+
+	return v.terminal.UnbindAction("exportd2s")
+}
+
+func (v *CharacterSelect) bindTerminalActions() {
+	if err := v.terminal.BindAction("importd2s", "import a character saved by Diablo II", v.importD2S); err != nil {
+		fmt.Println("failed to bind the importd2s action")
+	}
+
+	if err := v.terminal.BindAction("exportd2s", "export the selected character for Diablo II", v.exportD2S); err != nil {
+		fmt.Println("failed to bind the exportd2s action")
+	}
+}
+
+func (v *CharacterSelect) importD2S(filePath string) {
+	gameState, err := d2player.ImportD2S(filePath)
+	if err != nil {
+		v.terminal.OutputErrorf("can not import %s: %v", filePath, err)
+		return
+	}
+
+	v.terminal.OutputInfof("imported %s, level %d %s", gameState.HeroName, gameState.HeroLevel, gameState.HeroType)
+	v.refreshGameStates()
+	v.deleteCharButton.SetEnabled(true)
+	v.okButton.SetEnabled(true)
+}
+
+func (v *CharacterSelect) exportD2S(filePath string) {
+	if v.selectedCharacter < 0 {
+		v.terminal.OutputErrorf("no character selected")
+		return
+	}
+
+	gameState := v.gameStates[v.selectedCharacter]
+	if err := gameState.ExportD2S(filePath); err != nil {
+		v.terminal.OutputErrorf("can not export %s: %v", gameState.HeroName, err)
+		return
+	}
+
+	v.terminal.OutputInfof("exported %s to %s", gameState.HeroName, filePath)
 }
 
 func (v *CharacterSelect) createButtons(loading d2screen.LoadingState) {
@@ -173,6 +240,16 @@ func (v *CharacterSelect) createButtons(loading d2screen.LoadingState) {
 	v.deleteCharOkButton.OnActivated(func() { v.onDeleteCharacterConfirmClicked() })
 	d2ui.AddWidget(&v.deleteCharOkButton)
 
+	difficulties := []d2enum.DifficultyType{d2enum.DifficultyNormal, d2enum.DifficultyNightmare, d2enum.DifficultyHell}
+	for i, difficulty := range difficulties {
+		difficulty := difficulty
+		v.difficultyButtons[i] = d2ui.CreateButton(v.renderer, d2ui.ButtonTypeMedium, strings.ToUpper(difficulty.String()))
+		v.difficultyButtons[i].SetPosition(335, 240+i*40)
+		v.difficultyButtons[i].SetVisible(false)
+		v.difficultyButtons[i].OnActivated(func() { v.startGame(difficulty) })
+		d2ui.AddWidget(&v.difficultyButtons[i])
+	}
+
 	v.okButton = d2ui.CreateButton(v.renderer, d2ui.ButtonTypeMedium, "OK")
 	v.okButton.SetPosition(625, 537)
 	v.okButton.OnActivated(func() { v.onOkButtonClicked() })
@@ -198,15 +275,39 @@ func (v *CharacterSelect) updateCharacterBoxes() {
 			continue
 		}
 
-		v.characterNameLabel[i].SetText(v.gameStates[idx].HeroName)
-		v.characterStatsLabel[i].SetText("Level 1 " + v.gameStates[idx].HeroType.String())
+		gameState := v.gameStates[idx]
+
+		v.characterNameLabel[i].SetText(gameState.HeroName)
+		v.characterStatsLabel[i].SetText(fmt.Sprintf("Level %d %s", gameState.Stats.Level, gameState.HeroType))
 		v.characterExpLabel[i].SetText(expText)
+
+		if gameState.Difficulty > d2enum.DifficultyNormal {
+			v.characterStatsLabel[i].SetText(fmt.Sprintf("Level %d %s - %s", gameState.Stats.Level,
+				gameState.HeroType, gameState.Difficulty))
+		}
+		v.characterNameLabel[i].Color = characterNameColor
+
+		// hardcore characters are named in red, the dead ones can only be
+		// watched as ghosts or deleted
+		switch {
+		case gameState.IsGhost():
+			v.characterNameLabel[i].Color = hardcoreNameColor
+			v.characterExpLabel[i].SetText("DEAD HARDCORE " + expText)
+		case gameState.Hardcore:
+			v.characterNameLabel[i].Color = hardcoreNameColor
+			v.characterExpLabel[i].SetText("HARDCORE " + expText)
+		}
+
 		// TODO: Generate or load the object from the actual player data...
 		v.characterImage[i] = d2mapentity.CreatePlayer("", "", 0, 0, 0,
-			v.gameStates[idx].HeroType,
-			*v.gameStates[idx].Stats,
-			d2inventory.HeroObjects[v.gameStates[idx].HeroType],
+			gameState.HeroType,
+			*gameState.Stats,
+			d2inventory.HeroObjects[gameState.HeroType],
 		)
+
+		if gameState.IsGhost() {
+			v.characterImage[i].Kill()
+		}
 	}
 }
 
@@ -227,6 +328,7 @@ func (v *CharacterSelect) Render(screen d2interface.Surface) error {
 	}
 
 	v.d2HeroTitle.Render(screen)
+	v.loadErrorLabel.Render(screen)
 	actualSelectionIndex := v.selectedCharacter - (v.charScrollbar.GetCurrentOffset() * 2)
 
 	if v.selectedCharacter > -1 && actualSelectionIndex >= 0 && actualSelectionIndex < 8 {
@@ -259,6 +361,11 @@ func (v *CharacterSelect) Render(screen d2interface.Surface) error {
 		v.deleteCharConfirmLabel.Render(screen)
 	}
 
+	if v.showDifficultySelect {
+		screen.DrawRect(800, 600, color.RGBA{A: 128})
+		v.difficultyLabel.Render(screen)
+	}
+
 	return nil
 }
 
@@ -273,11 +380,16 @@ func (v *CharacterSelect) moveSelectionBox() {
 	selectedIndex := v.selectedCharacter - (v.charScrollbar.GetCurrentOffset() * 2)
 	v.selectionBox.SetPosition(37+((selectedIndex&1)*bw), 86+(bh*(selectedIndex/2)))
 	v.d2HeroTitle.SetText(v.gameStates[v.selectedCharacter].HeroName)
+
+	// dead hardcore characters can only watch the game as ghosts
+	if v.gameStates[v.selectedCharacter].IsGhost() {
+		v.d2HeroTitle.SetText(v.gameStates[v.selectedCharacter].HeroName + " - Ghost")
+	}
 }
 
 // OnMouseButtonDown is called when a mouse button is clicked
 func (v *CharacterSelect) OnMouseButtonDown(event d2interface.MouseEvent) bool {
-	if !v.showDeleteConfirmation {
+	if !v.showDeleteConfirmation && !v.showDifficultySelect {
 		if event.Button() == d2enum.MouseButtonLeft {
 			mx, my := event.X(), event.Y()
 			bw := 272
@@ -322,7 +434,9 @@ func (v *CharacterSelect) onDeleteCharButtonClicked() {
 }
 
 func (v *CharacterSelect) onDeleteCharacterConfirmClicked() {
-	_ = os.Remove(v.gameStates[v.selectedCharacter].FilePath)
+	if err := v.gameStates[v.selectedCharacter].Delete(); err != nil {
+		v.terminal.OutputErrorf("can not delete %s: %v", v.gameStates[v.selectedCharacter].HeroName, err)
+	}
 	v.charScrollbar.SetCurrentOffset(0)
 	v.refreshGameStates()
 	v.toggleDeleteCharacterDialog(false)
@@ -345,9 +459,23 @@ func (v *CharacterSelect) toggleDeleteCharacterDialog(showDialog bool) {
 }
 
 func (v *CharacterSelect) refreshGameStates() {
-	v.gameStates = d2player.GetAllPlayerStates()
+	gameStates, errs := d2player.GetAllPlayerStates()
+	v.gameStates = gameStates
 	v.updateCharacterBoxes()
 
+	for _, err := range errs {
+		v.terminal.OutputErrorf("can not load the character %v", err)
+	}
+
+	switch len(errs) {
+	case 0:
+		v.loadErrorLabel.SetText("")
+	case 1:
+		v.loadErrorLabel.SetText(errs[0].Error())
+	default:
+		v.loadErrorLabel.SetText(fmt.Sprintf("%d characters could not be loaded, see the console", len(errs)))
+	}
+
 	if len(v.gameStates) > 0 {
 		v.selectedCharacter = 0
 		v.d2HeroTitle.SetText(v.gameStates[0].HeroName)
@@ -360,8 +488,33 @@ func (v *CharacterSelect) refreshGameStates() {
 	v.moveSelectionBox()
 }
 
+func (v *CharacterSelect) toggleDifficultyDialog(showDialog bool) {
+	v.showDifficultySelect = showDialog
+	v.okButton.SetEnabled(!showDialog)
+	v.deleteCharButton.SetEnabled(!showDialog)
+	v.exitButton.SetEnabled(!showDialog)
+	v.newCharButton.SetEnabled(!showDialog)
+
+	for i := range v.difficultyButtons {
+		v.difficultyButtons[i].SetVisible(showDialog)
+		v.difficultyButtons[i].SetEnabled(v.gameStates[v.selectedCharacter].CanPlay(d2enum.DifficultyType(i)))
+	}
+}
+
 func (v *CharacterSelect) onOkButtonClicked() {
-	gameClient, _ := d2client.Create(v.connectionType)
+	// the difficulty is chosen by the host of the game, characters which have
+	// not unlocked any other difficulty play on normal right away
+	gameState := v.gameStates[v.selectedCharacter]
+	if v.connectionType != d2clientconnectiontype.LANClient && gameState.Difficulty > d2enum.DifficultyNormal {
+		v.toggleDifficultyDialog(true)
+		return
+	}
+
+	v.startGame(d2enum.DifficultyNormal)
+}
+
+func (v *CharacterSelect) startGame(difficulty d2enum.DifficultyType) {
+	gameClient, _ := d2client.Create(v.connectionType, difficulty)
 
 	host := ""
 	if v.connectionType == d2clientconnectiontype.LANClient {
@@ -2,6 +2,7 @@ package d2gamescreen
 
 import (
 	"image/color"
+	"math"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
@@ -12,7 +13,9 @@ import (
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2input"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2maprenderer"
 	"github.com/OpenDiablo2/OpenDiablo2/d2game/d2player"
@@ -20,7 +23,19 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
 )
 
-const hideZoneTextAfterSeconds = 2.0
+const (
+	hideZoneTextAfterSeconds = 2.0
+
+	// objects can be operated from this many tiles away
+	operateDistance = 2.0
+
+	// the player walks next to objects it operates, at most this many sub-tiles from them
+	operateApproachDistance = 10
+
+	// the town NPCs walk around, the player talks to them from this many
+	// tiles away
+	talkDistance = 3.0
+)
 
 // Game represents the Gameplay screen
 type Game struct {
@@ -29,9 +44,25 @@ type Game struct {
 	gameControls         *d2player.GameControls // TODO: Hack
 	localPlayer          *d2mapentity.Player
 	lastRegionType       d2enum.RegionIdType
+	lastLevelID          int
 	ticksSinceLevelCheck float64
 	escapeMenu           *EscapeMenu
 
+	// the object the player walks to in order to operate it, in sub-tiles
+	operateTarget *d2common.Point
+
+	// the item on the ground the player walks to in order to pick it up, in
+	// sub-tiles
+	pickUpTarget *d2common.Point
+
+	// the exit of the warp the player walks to in order to use it, in
+	// sub-tiles, and the level the warp leads to
+	warpTarget  *d2common.Point
+	warpLevelID int
+
+	// the town NPC the player walks to in order to talk to it
+	npcTarget *d2mapentity.NPC
+
 	renderer      d2interface.Renderer
 	audioProvider d2interface.AudioProvider
 	terminal      d2interface.Terminal
@@ -68,10 +99,20 @@ func (v *Game) OnLoad(_ d2screen.LoadingState) {
 
 // OnUnload releases the resources of Gameplay screen
 func (v *Game) OnUnload() error {
+	if v.gameControls != nil {
+		v.gameControls.SaveAutoMap()
+	}
+
 	if err := d2input.UnbindHandler(v.gameControls); err != nil { // TODO: hack
 		return err
 	}
 
+	if v.gameControls != nil {
+		if err := d2input.UnbindHandler(v.gameControls.Inventory()); err != nil {
+			return err
+		}
+	}
+
 	if err := d2input.UnbindHandler(v.escapeMenu); err != nil { // TODO: hack
 		return err
 	}
@@ -88,6 +129,9 @@ func (v *Game) Render(screen d2interface.Surface) error {
 	if v.gameClient.RegenMap {
 		v.gameClient.RegenMap = false
 		v.mapRenderer.RegenerateTileCache()
+
+		// the targets were on the previous map
+		v.operateTarget, v.pickUpTarget, v.warpTarget, v.npcTarget = nil, nil, nil, nil
 	}
 
 	if err := screen.Clear(color.Black); err != nil {
@@ -96,6 +140,36 @@ func (v *Game) Render(screen d2interface.Surface) error {
 
 	v.mapRenderer.Render(screen)
 
+	if v.gameClient.OpenWaypointMenu && v.gameControls != nil {
+		v.gameClient.OpenWaypointMenu = false
+		v.gameControls.OpenWaypointMenu(v.gameClient.GameState)
+	}
+
+	if v.gameClient.UpdateInventory && v.gameControls != nil {
+		v.gameClient.UpdateInventory = false
+		v.gameControls.SetInventory(v.gameClient.GameState.Inventory)
+	}
+
+	if v.gameClient.UpdateVendor && v.gameControls != nil {
+		v.gameClient.UpdateVendor = false
+		v.gameControls.UpdateVendor(v.gameClient.Vendor)
+	}
+
+	if v.gameClient.TradeRequested && v.gameControls != nil {
+		v.gameClient.TradeRequested = false
+		v.showTradeRequest(v.gameClient.TradeRequestFrom)
+	}
+
+	if v.gameClient.UpdateTrade && v.gameControls != nil {
+		v.gameClient.UpdateTrade = false
+		v.gameControls.UpdateTrade(v.gameClient.Trade)
+	}
+
+	if v.gameClient.PlayerDied && v.gameControls != nil {
+		v.gameClient.PlayerDied = false
+		v.showDeath()
+	}
+
 	if v.gameControls != nil {
 		v.gameControls.Render(screen)
 	}
@@ -105,6 +179,8 @@ func (v *Game) Render(screen d2interface.Surface) error {
 
 // Advance runs the update logic on the Gameplay screen
 func (v *Game) Advance(tickTime float64) error {
+	v.gameClient.Advance()
+
 	if (v.escapeMenu != nil && !v.escapeMenu.isOpen) || len(v.gameClient.Players) != 1 {
 		v.gameClient.MapEngine.Advance(tickTime) // TODO: Hack
 	}
@@ -124,15 +200,19 @@ func (v *Game) Advance(tickTime float64) error {
 				musicInfo := d2common.GetMusicDef(tile.RegionType)
 				v.audioProvider.PlayBGM(musicInfo.MusicFile)
 
+				levelID := v.gameClient.MapEngine.LevelAt(v.localPlayer.TileX, v.localPlayer.TileY)
+				levelDetails := d2datadict.GetLevelDetails(levelID)
+
 				// skip showing zone change text the first time we enter the world
-				if v.lastRegionType != d2enum.RegionNone && v.lastRegionType != tile.RegionType {
-					//TODO: Should not be using RegionType as an index - this will return incorrect LevelDetails record for most of the zones.
-					v.gameControls.SetZoneChangeText(fmt.Sprintf("Entering The %s", d2datadict.LevelDetails[int(tile.RegionType)].LevelDisplayName))
+				if v.lastLevelID != 0 && v.lastLevelID != levelID && levelDetails != nil {
+					v.gameControls.SaveAutoMap()
+					v.gameControls.SetZoneChangeText(fmt.Sprintf("Entering The %s", levelDetails.LevelDisplayName))
 					v.gameControls.ShowZoneChangeText()
 					v.gameControls.HideZoneChangeTextAfter(hideZoneTextAfterSeconds)
 				}
 
 				v.lastRegionType = tile.RegionType
+				v.lastLevelID = levelID
 			}
 		}
 	}
@@ -142,6 +222,11 @@ func (v *Game) Advance(tickTime float64) error {
 		v.bindGameControls()
 	}
 
+	v.operateWhenInRange()
+	v.pickUpWhenInRange()
+	v.enterWarpWhenInRange()
+	v.talkWhenInRange()
+
 	// Update the camera to focus on the player
 	if v.localPlayer != nil && !v.gameControls.FreeCam {
 		rx, ry := v.mapRenderer.WorldToOrtho(v.localPlayer.LocationX/5, v.localPlayer.LocationY/5)
@@ -151,6 +236,36 @@ func (v *Game) Advance(tickTime float64) error {
 	return nil
 }
 
+// showSpectator tells the local player it can only look around, if it is a
+// dead hardcore player or has not unlocked the difficulty of the game
+func (v *Game) showSpectator() {
+	gameState := v.gameClient.GameState
+
+	switch {
+	case gameState.IsGhost():
+		v.gameControls.SetZoneChangeText("Your hardcore hero is dead, you are watching as a ghost")
+	case !gameState.CanPlay(v.gameClient.Difficulty):
+		v.gameControls.SetZoneChangeText(fmt.Sprintf("%s has not unlocked %s, you are watching",
+			gameState.HeroName, v.gameClient.Difficulty))
+	default:
+		return
+	}
+
+	v.gameControls.FreeCam = true
+	v.gameControls.ShowZoneChangeText()
+}
+
+// showDeath tells the local player it died, for good if it is hardcore
+func (v *Game) showDeath() {
+	if v.gameClient.GameState.Dead {
+		v.gameControls.SetZoneChangeText("You have died. Your hardcore hero is lost")
+	} else {
+		v.gameControls.SetZoneChangeText("You have died")
+	}
+
+	v.gameControls.ShowZoneChangeText()
+}
+
 func (v *Game) bindGameControls() {
 	for _, player := range v.gameClient.Players {
 		if player.Id != v.gameClient.PlayerId {
@@ -158,19 +273,36 @@ func (v *Game) bindGameControls() {
 		}
 
 		v.localPlayer = player
+		v.mapRenderer.SetFocus(player)
 		v.gameControls = d2player.NewGameControls(v.renderer, player, v.gameClient.MapEngine, v.mapRenderer, v, v.terminal)
 		v.gameControls.Load()
 
+		if v.gameClient.GameState != nil {
+			v.gameControls.LoadAutoMap(v.gameClient.GameState.AutoMapPath())
+			v.gameControls.SetInventory(v.gameClient.GameState.Inventory)
+
+			v.showSpectator()
+		}
+
 		if err := d2input.BindHandler(v.gameControls); err != nil {
 			fmt.Printf("failed to add gameControls as input handler for player: %s\n", player.Id)
 		}
 
+		// the clicks on the items are not passed on to the game controls
+		if err := d2input.BindHandlerWithPriority(v.gameControls.Inventory(), d2enum.PriorityHigh); err != nil {
+			fmt.Printf("failed to add the inventory as input handler for player: %s\n", player.Id)
+		}
+
 		break
 	}
 }
 
 // OnPlayerMove sends the player move action to the server
 func (v *Game) OnPlayerMove(x, y float64) {
+	// walking somewhere else cancels operating an object, picking up an item,
+	// using a warp or talking to an NPC
+	v.operateTarget, v.pickUpTarget, v.warpTarget, v.npcTarget = nil, nil, nil, nil
+
 	heroPosX := v.localPlayer.LocationX / 5.0
 	heroPosY := v.localPlayer.LocationY / 5.0
 
@@ -190,3 +322,315 @@ func (v *Game) OnPlayerCast(missileID int, targetX, targetY float64) {
 		)
 	}
 }
+
+// OnPlayerAllocateStat sends the request to spend a stat point to the server
+func (v *Game) OnPlayerAllocateStat(stat d2hero.StatType) {
+	err := v.gameClient.SendPacketToServer(d2netpacket.CreateAllocateStatPointPacket(v.gameClient.PlayerId, stat))
+	if err != nil {
+		fmt.Printf("failed to send AllocateStatPoint packet to the server, playerId: %s, stat: %d\n",
+			v.gameClient.PlayerId, stat)
+	}
+}
+
+// OnPlayerOperate walks the player to an object and operates it once the
+// player is close enough
+func (v *Game) OnPlayerOperate(objectX, objectY int) {
+	target := &d2common.Point{X: objectX, Y: objectY}
+
+	if !v.isInOperateRange(target) {
+		x, y, found := v.gameClient.MapEngine.ClosestWalkable(objectX, objectY, operateApproachDistance)
+		if !found {
+			return
+		}
+
+		v.OnPlayerMove(float64(x)/5.0, float64(y)/5.0)
+	}
+
+	v.operateTarget = target
+}
+
+// OnPlayerPickUpItem walks the player to an item on the ground and picks it
+// up once the player is close enough
+func (v *Game) OnPlayerPickUpItem(itemX, itemY int) {
+	target := &d2common.Point{X: itemX, Y: itemY}
+
+	if !v.isInOperateRange(target) {
+		x, y, found := v.gameClient.MapEngine.ClosestWalkable(itemX, itemY, operateApproachDistance)
+		if !found {
+			return
+		}
+
+		v.OnPlayerMove(float64(x)/5.0, float64(y)/5.0)
+	}
+
+	v.pickUpTarget = target
+}
+
+// OnPlayerMoveItem sends the request to move an item of the player to the
+// server
+func (v *Game) OnPlayerMoveItem(itemID int, to d2inventory.ItemLocation) {
+	err := v.gameClient.SendPacketToServer(d2netpacket.CreateMoveItemPacket(v.gameClient.PlayerId, itemID, to))
+	if err != nil {
+		fmt.Printf("failed to send MoveItem packet to the server, playerId: %s, item: %d\n",
+			v.gameClient.PlayerId, itemID)
+	}
+}
+
+// OnPlayerUseItem sends the request to use an item of the player to the
+// server
+func (v *Game) OnPlayerUseItem(itemID int) {
+	err := v.gameClient.SendPacketToServer(d2netpacket.CreateUseItemPacket(v.gameClient.PlayerId, itemID))
+	if err != nil {
+		fmt.Printf("failed to send UseItem packet to the server, playerId: %s, item: %d\n",
+			v.gameClient.PlayerId, itemID)
+	}
+}
+
+// OnPlayerDropItem sends the request to drop an item of the player on the
+// ground to the server
+func (v *Game) OnPlayerDropItem(itemID int) {
+	err := v.gameClient.SendPacketToServer(d2netpacket.CreateDropItemPacket(v.gameClient.PlayerId, itemID, nil, 0, 0))
+	if err != nil {
+		fmt.Printf("failed to send DropItem packet to the server, playerId: %s, item: %d\n",
+			v.gameClient.PlayerId, itemID)
+	}
+}
+
+// OnPlayerToggleHostile sends the request of the player to turn hostile, or
+// peaceful again, to the server. Only hardcore players can turn hostile.
+func (v *Game) OnPlayerToggleHostile() {
+	if v.gameClient.GameState == nil {
+		return
+	}
+
+	if !v.gameClient.GameState.Hardcore {
+		v.terminal.OutputErrorf("only hardcore players can turn hostile")
+		return
+	}
+
+	hostile := !v.gameClient.GameState.Hostile
+
+	err := v.gameClient.SendPacketToServer(d2netpacket.CreateSetHostilePacket(v.gameClient.PlayerId, hostile))
+	if err != nil {
+		fmt.Printf("failed to send SetHostile packet to the server, playerId: %s, hostile: %t\n",
+			v.gameClient.PlayerId, hostile)
+	}
+}
+
+// OnPlayerTravel sends the request to travel to a waypoint to the server
+func (v *Game) OnPlayerTravel(waypointX, waypointY int) {
+	err := v.gameClient.SendPacketToServer(
+		d2netpacket.CreateTravelWaypointPacket(v.gameClient.PlayerId, waypointX, waypointY, 0, 0))
+	if err != nil {
+		fmt.Printf("failed to send TravelWaypoint packet to the server, playerId: %s, x: %d, y: %d\n",
+			v.gameClient.PlayerId, waypointX, waypointY)
+	}
+}
+
+// OnPlayerEnterWarp walks the player to the exit of a warp and enters the
+// level it leads to once the player is close enough
+func (v *Game) OnPlayerEnterWarp(levelID, exitX, exitY int) {
+	target := &d2common.Point{X: exitX, Y: exitY}
+
+	if !v.isInOperateRange(target) {
+		x, y, found := v.gameClient.MapEngine.ClosestWalkable(exitX, exitY, operateApproachDistance)
+		if !found {
+			return
+		}
+
+		v.OnPlayerMove(float64(x)/5.0, float64(y)/5.0)
+	}
+
+	v.warpTarget, v.warpLevelID = target, levelID
+}
+
+// OnPlayerTalkTo walks the player to a town NPC and opens its menu once the
+// player is close enough
+func (v *Game) OnPlayerTalkTo(npc *d2mapentity.NPC) {
+	if !v.isInTalkRange(npc) {
+		x, y, found := v.gameClient.MapEngine.ClosestWalkable(
+			int(npc.LocationX), int(npc.LocationY), operateApproachDistance)
+		if !found {
+			return
+		}
+
+		v.OnPlayerMove(float64(x)/5.0, float64(y)/5.0)
+	}
+
+	v.npcTarget = npc
+}
+
+// OnPlayerInteractNPC sends the request of the player for a service of a
+// town NPC, e.g. to trade with it, to the server
+func (v *Game) OnPlayerInteractNPC(npc string, service d2enum.NPCService) {
+	err := v.gameClient.SendPacketToServer(
+		d2netpacket.CreateInteractNPCPacket(v.gameClient.PlayerId, npc, service, 0))
+	if err != nil {
+		fmt.Printf("failed to send InteractNPC packet to the server, playerId: %s, npc: %s, service: %d\n",
+			v.gameClient.PlayerId, npc, service)
+	}
+}
+
+// OnPlayerBuyItem sends the request to buy an item of a town NPC to the
+// server
+func (v *Game) OnPlayerBuyItem(npc string, itemID int) {
+	err := v.gameClient.SendPacketToServer(d2netpacket.CreateBuyItemPacket(v.gameClient.PlayerId, npc, itemID))
+	if err != nil {
+		fmt.Printf("failed to send BuyItem packet to the server, playerId: %s, npc: %s, item: %d\n",
+			v.gameClient.PlayerId, npc, itemID)
+	}
+}
+
+// OnPlayerSellItem sends the request to sell an item of the player to a town
+// NPC to the server
+func (v *Game) OnPlayerSellItem(npc string, itemID int) {
+	err := v.gameClient.SendPacketToServer(d2netpacket.CreateSellItemPacket(v.gameClient.PlayerId, npc, itemID))
+	if err != nil {
+		fmt.Printf("failed to send SellItem packet to the server, playerId: %s, npc: %s, item: %d\n",
+			v.gameClient.PlayerId, npc, itemID)
+	}
+}
+
+// OnPlayerStashGold sends the request to move gold to the stash, or to
+// withdraw it when the amount is negative, to the server
+func (v *Game) OnPlayerStashGold(amount int) {
+	err := v.gameClient.SendPacketToServer(d2netpacket.CreateStashGoldPacket(v.gameClient.PlayerId, amount))
+	if err != nil {
+		fmt.Printf("failed to send StashGold packet to the server, playerId: %s, amount: %d\n",
+			v.gameClient.PlayerId, amount)
+	}
+}
+
+// OnPlayerRequestTrade sends the request of the player to trade with the
+// other player to the server. Asking the player which asked to trade first
+// agrees to trade with it.
+func (v *Game) OnPlayerRequestTrade(playerID string) {
+	accept := playerID == v.gameClient.TradeRequestFrom
+	if accept {
+		v.gameClient.TradeRequestFrom = ""
+	}
+
+	err := v.gameClient.SendPacketToServer(d2netpacket.CreateTradeRequestPacket(v.gameClient.PlayerId, playerID, accept))
+	if err != nil {
+		fmt.Printf("failed to send TradeRequest packet to the server, playerId: %s, other: %s\n",
+			v.gameClient.PlayerId, playerID)
+	}
+}
+
+// OnPlayerTrade sends what the player does in the trade window, for the
+// given revision of the offers, to the server
+func (v *Game) OnPlayerTrade(action d2enum.TradeAction, itemID, gold, revision int) {
+	err := v.gameClient.SendPacketToServer(
+		d2netpacket.CreateTradeActionPacket(v.gameClient.PlayerId, action, itemID, gold, revision))
+	if err != nil {
+		fmt.Printf("failed to send TradeAction packet to the server, playerId: %s, action: %s\n",
+			v.gameClient.PlayerId, action)
+	}
+}
+
+// OnPlayerTransmute sends the request to transmute the items in the
+// Horadric Cube to the server
+func (v *Game) OnPlayerTransmute() {
+	err := v.gameClient.SendPacketToServer(d2netpacket.CreateTransmuteCubePacket(v.gameClient.PlayerId))
+	if err != nil {
+		fmt.Printf("failed to send TransmuteCube packet to the server, playerId: %s\n", v.gameClient.PlayerId)
+	}
+}
+
+// OnPlayerSocketItem sends the request to put the gem, rune or jewel into a
+// socket of the item to the server
+func (v *Game) OnPlayerSocketItem(itemID, socketableID int) {
+	err := v.gameClient.SendPacketToServer(
+		d2netpacket.CreateSocketItemPacket(v.gameClient.PlayerId, itemID, socketableID))
+	if err != nil {
+		fmt.Printf("failed to send SocketItem packet to the server, playerId: %s, item: %d, socketable: %d\n",
+			v.gameClient.PlayerId, itemID, socketableID)
+	}
+}
+
+// showTradeRequest tells the player which player asks to trade with it.
+func (v *Game) showTradeRequest(playerID string) {
+	player, ok := v.gameClient.Players[playerID]
+	if !ok {
+		return
+	}
+
+	v.terminal.OutputInfof("%s wants to trade, click on %s to trade", player.Name(), player.Name())
+}
+
+// operateWhenInRange sends the request to operate the object the player
+// walks to once it is close enough.
+func (v *Game) operateWhenInRange() {
+	if v.operateTarget == nil || v.localPlayer == nil || !v.isInOperateRange(v.operateTarget) {
+		return
+	}
+
+	target := *v.operateTarget
+	v.operateTarget = nil
+
+	err := v.gameClient.SendPacketToServer(d2netpacket.CreateOperateObjectPacket(v.gameClient.PlayerId, target.X, target.Y, 0))
+	if err != nil {
+		fmt.Printf("failed to send OperateObject packet to the server, playerId: %s, x: %d, y: %d\n",
+			v.gameClient.PlayerId, target.X, target.Y)
+	}
+}
+
+// pickUpWhenInRange sends the request to pick up the item the player walks
+// to once it is close enough.
+func (v *Game) pickUpWhenInRange() {
+	if v.pickUpTarget == nil || v.localPlayer == nil || !v.isInOperateRange(v.pickUpTarget) {
+		return
+	}
+
+	target := *v.pickUpTarget
+	v.pickUpTarget = nil
+
+	err := v.gameClient.SendPacketToServer(d2netpacket.CreatePickUpItemPacket(v.gameClient.PlayerId, target.X, target.Y))
+	if err != nil {
+		fmt.Printf("failed to send PickUpItem packet to the server, playerId: %s, x: %d, y: %d\n",
+			v.gameClient.PlayerId, target.X, target.Y)
+	}
+}
+
+func (v *Game) isInOperateRange(target *d2common.Point) bool {
+	dx := v.localPlayer.LocationX - float64(target.X)
+	dy := v.localPlayer.LocationY - float64(target.Y)
+
+	return math.Hypot(dx, dy)/5.0 <= operateDistance
+}
+
+// talkWhenInRange opens the menu of the town NPC the player walks to once it
+// is close enough.
+func (v *Game) talkWhenInRange() {
+	if v.npcTarget == nil || v.localPlayer == nil || v.gameControls == nil || !v.isInTalkRange(v.npcTarget) {
+		return
+	}
+
+	npc := v.npcTarget
+	v.npcTarget = nil
+
+	v.gameControls.OpenNPCMenu(npc)
+}
+
+func (v *Game) isInTalkRange(npc *d2mapentity.NPC) bool {
+	dx := v.localPlayer.LocationX - npc.LocationX
+	dy := v.localPlayer.LocationY - npc.LocationY
+
+	return math.Hypot(dx, dy)/5.0 <= talkDistance
+}
+
+// enterWarpWhenInRange sends the request to enter the level of the warp the
+// player walks to once it is close enough.
+func (v *Game) enterWarpWhenInRange() {
+	if v.warpTarget == nil || v.localPlayer == nil || !v.isInOperateRange(v.warpTarget) {
+		return
+	}
+
+	v.warpTarget = nil
+
+	err := v.gameClient.SendPacketToServer(d2netpacket.CreateChangeLevelPacket(v.gameClient.PlayerId, v.warpLevelID, 0, 0))
+	if err != nil {
+		fmt.Printf("failed to send ChangeLevel packet to the server, playerId: %s, level: %d\n",
+			v.gameClient.PlayerId, v.warpLevelID)
+	}
+}
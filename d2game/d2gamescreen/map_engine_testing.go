@@ -147,6 +147,7 @@ func (met *MapEngineTest) loadRegionByIndex(n, levelPreset, fileIndex int) {
 	if n == 0 {
 		met.mapEngine.SetSeed(time.Now().UnixNano())
 		d2mapgen.GenerateAct1Overworld(met.mapEngine)
+		d2mapgen.PopulateMonsters(met.mapEngine, d2enum.DifficultyNormal)
 	} else {
 		met.mapEngine = d2mapengine.CreateMapEngine() // necessary for map name update
 		met.mapEngine.SetSeed(time.Now().UnixNano())
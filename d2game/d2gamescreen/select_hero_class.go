@@ -345,13 +345,18 @@ func (v *SelectHeroClass) onExitButtonClicked() {
 }
 
 func (v *SelectHeroClass) onOkButtonClicked() {
-	gameState := d2player.CreatePlayerState(
+	gameState, err := d2player.CreatePlayerState(
 		v.heroNameTextbox.GetText(),
 		v.selectedHero,
 		d2datadict.CharStats[v.selectedHero],
 		v.hardcoreCheckbox.GetCheckState(),
 	)
-	gameClient, _ := d2client.Create(d2clientconnectiontype.Local)
+	if err != nil {
+		fmt.Printf("can not save the new character: %v\n", err)
+		return
+	}
+
+	gameClient, _ := d2client.Create(d2clientconnectiontype.Local, d2enum.DifficultyNormal)
 
 	if err := gameClient.Open(v.connectionHost, gameState.FilePath); err != nil {
 		fmt.Printf("can not connect to the host: %s\n", v.connectionHost)
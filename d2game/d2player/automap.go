@@ -0,0 +1,294 @@
+package d2player
+
+import (
+	"image/color"
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2automap"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+)
+
+const (
+	// the corner automap is drawn in this area at the top right of the screen
+	cornerAutoMapWidth  = 200
+	cornerAutoMapHeight = 150
+	cornerAutoMapMargin = 10
+
+	// the cells of the corner automap are this many times smaller
+	cornerAutoMapScale = 4
+
+	// the full screen automap is centered above the control panel
+	autoMapOffsetY = -50
+
+	autoMapMarkerSize = 5
+)
+
+// autoMapMode tells how the automap is shown.
+type autoMapMode int
+
+// Automap modes, in the order Tab switches between them
+const (
+	autoMapClosed autoMapMode = iota
+	autoMapFullScreen
+	autoMapCorner
+	autoMapModes
+)
+
+//nolint:gochecknoglobals // constant colors
+var (
+	autoMapWallColor   = color.RGBA{R: 200, G: 200, B: 200, A: 255}
+	autoMapFloorColor  = color.RGBA{R: 80, G: 80, B: 80, A: 160}
+	autoMapHeroColor   = color.RGBA{R: 255, G: 40, B: 40, A: 255}
+	autoMapPartyColor  = color.RGBA{R: 40, G: 220, B: 40, A: 255}
+	autoMapWarpColor   = color.RGBA{R: 200, G: 80, B: 255, A: 255}
+	autoMapCornerColor = color.RGBA{A: 120}
+)
+
+// AutoMap reveals the tiles around the hero as it walks and shows the
+// revealed parts of the map, with markers for the hero, the other players
+// and the warps, either over the whole screen or in the top right corner.
+type AutoMap struct {
+	mapEngine   *d2mapengine.MapEngine
+	hero        *d2mapentity.Player
+	exploration *d2automap.Exploration
+	filePath    string
+	cellFinders map[d2enum.RegionIdType]*d2automap.CellFinder
+	cells       d2interface.Animation
+	mode        autoMapMode
+
+	// the last tile the tiles were revealed around, on the map of the seed
+	lastTileX int
+	lastTileY int
+	lastSeed  int64
+}
+
+// NewAutoMap creates the automap of the map the hero is on.
+func NewAutoMap(mapEngine *d2mapengine.MapEngine, hero *d2mapentity.Player) *AutoMap {
+	return &AutoMap{
+		mapEngine:   mapEngine,
+		hero:        hero,
+		exploration: d2automap.CreateExploration(),
+		cellFinders: make(map[d2enum.RegionIdType]*d2automap.CellFinder),
+		lastTileX:   -1,
+		lastTileY:   -1,
+	}
+}
+
+// Load the resources of the automap
+func (a *AutoMap) Load() {
+	a.cells, _ = d2asset.LoadAnimation(d2resource.AutoMapCells, d2resource.PaletteSky)
+}
+
+// LoadExploration loads the tiles the character has seen from a file, the
+// automap is saved to that file afterwards.
+func (a *AutoMap) LoadExploration(filePath string) {
+	exploration, err := d2automap.LoadExploration(filePath)
+	if err != nil {
+		log.Printf("failed to load the automap from %s: %s", filePath, err)
+		return
+	}
+
+	a.exploration = exploration
+	a.filePath = filePath
+	a.lastTileX, a.lastTileY = -1, -1
+}
+
+// SaveExploration saves the tiles the character has seen.
+func (a *AutoMap) SaveExploration() {
+	if a.filePath == "" {
+		return
+	}
+
+	if err := a.exploration.Save(a.filePath); err != nil {
+		log.Printf("failed to save the automap to %s: %s", a.filePath, err)
+	}
+}
+
+// IsOpen returns true if the automap is shown
+func (a *AutoMap) IsOpen() bool {
+	return a.mode != autoMapClosed
+}
+
+// Toggle switches from the full screen automap to the corner automap and
+// then closes it
+func (a *AutoMap) Toggle() {
+	a.mode = (a.mode + 1) % autoMapModes
+}
+
+// Advance reveals the tiles around the hero when it moved to another tile
+// or the map changed.
+func (a *AutoMap) Advance() {
+	seed := a.mapEngine.Seed()
+	if a.hero.TileX == a.lastTileX && a.hero.TileY == a.lastTileY && seed == a.lastSeed {
+		return
+	}
+
+	a.lastTileX, a.lastTileY, a.lastSeed = a.hero.TileX, a.hero.TileY, seed
+
+	size := a.mapEngine.Size()
+	a.exploration.RevealAround(seed, size.Width, size.Height, a.hero.TileX, a.hero.TileY, a.mapEngine.LevelAt)
+}
+
+// Render draws the automap
+func (a *AutoMap) Render(target d2interface.Surface) {
+	width, height := target.GetSize()
+
+	switch a.mode {
+	case autoMapFullScreen:
+		a.render(target, width/2, height/2+autoMapOffsetY, 1, 0, 0, width, height)
+	case autoMapCorner:
+		left := width - cornerAutoMapWidth - cornerAutoMapMargin
+
+		target.PushTranslation(left, cornerAutoMapMargin)
+		target.DrawRect(cornerAutoMapWidth, cornerAutoMapHeight, autoMapCornerColor)
+		target.Pop()
+
+		a.render(target, left+cornerAutoMapWidth/2, cornerAutoMapMargin+cornerAutoMapHeight/2, cornerAutoMapScale,
+			left, cornerAutoMapMargin, cornerAutoMapWidth, cornerAutoMapHeight)
+	}
+}
+
+// render draws the revealed tiles and the markers centered on the hero at
+// the given position, the cells are scaled down by the given factor and
+// only the ones within the given area of the screen are drawn.
+func (a *AutoMap) render(target d2interface.Surface, centerX, centerY, scale, left, top, width, height int) {
+	originX, originY := a.hero.LocationX/5, a.hero.LocationY/5
+	size := a.mapEngine.Size()
+	seed := a.mapEngine.Seed()
+	levels := make(map[int]*d2automap.LevelExploration)
+
+	inArea := func(x, y int) bool {
+		return x >= left && y >= top && x < left+width && y < top+height
+	}
+
+	for tileY := 0; tileY < size.Height; tileY++ {
+		for tileX := 0; tileX < size.Width; tileX++ {
+			x, y := d2automap.CellPosition(float64(tileX), float64(tileY), originX, originY)
+			x, y = centerX+x/scale, centerY+y/scale
+
+			if !inArea(x, y) {
+				continue
+			}
+
+			levelID := a.mapEngine.LevelAt(tileX, tileY)
+			if levelID == 0 {
+				continue
+			}
+
+			level, found := levels[levelID]
+			if !found {
+				level = a.exploration.Level(levelID, seed, size.Width, size.Height)
+				levels[levelID] = level
+			}
+
+			if level.IsRevealed(tileX, tileY) {
+				a.renderTile(target, tileX, tileY, x, y, scale)
+			}
+		}
+	}
+
+	for _, warp := range a.mapEngine.Warps() {
+		level := a.exploration.Level(warp.LevelID, seed, size.Width, size.Height)
+		if !level.IsRevealed(warp.TileX, warp.TileY) {
+			continue
+		}
+
+		area := warp.SelectArea()
+		x, y := d2automap.CellPosition(float64(area.Left+area.Width/2)/5, float64(area.Top+area.Height/2)/5,
+			originX, originY)
+		a.renderMarker(target, centerX+x/scale, centerY+y/scale, autoMapWarpColor, inArea)
+	}
+
+	for _, entity := range *a.mapEngine.Entities() {
+		player, ok := entity.(*d2mapentity.Player)
+		if !ok || player == a.hero {
+			continue
+		}
+
+		x, y := d2automap.CellPosition(player.LocationX/5, player.LocationY/5, originX, originY)
+		a.renderMarker(target, centerX+x/scale, centerY+y/scale, autoMapPartyColor, inArea)
+	}
+
+	a.renderMarker(target, centerX, centerY, autoMapHeroColor, inArea)
+}
+
+// renderTile draws the cells of the floor and the walls of a tile at the
+// given position. Scaled down cells are drawn as dots.
+func (a *AutoMap) renderTile(target d2interface.Surface, tileX, tileY, x, y, scale int) {
+	tile := a.mapEngine.Tile(tileX, tileY)
+
+	if scale > 1 || a.cells == nil {
+		dotColor := autoMapFloorColor
+		if len(tile.Walls) > 0 {
+			dotColor = autoMapWallColor
+		} else if len(tile.Floors) == 0 {
+			return
+		}
+
+		target.PushTranslation(x, y)
+		target.DrawRect(d2automap.CellWidth/scale/2, d2automap.CellHeight/scale/2, dotColor)
+		target.Pop()
+
+		return
+	}
+
+	cellFinder := a.cellFinder(tile.RegionType)
+
+	for _, floor := range tile.Floors {
+		a.renderCell(target, cellFinder, d2enum.TileFloor, int(floor.Style), int(floor.Sequence), tileX+tileY, x, y)
+	}
+
+	for _, wall := range tile.Walls {
+		a.renderCell(target, cellFinder, wall.Type, int(wall.Style), int(wall.Sequence), tileX+tileY, x, y)
+	}
+}
+
+func (a *AutoMap) renderCell(target d2interface.Surface, cellFinder *d2automap.CellFinder, tileType d2enum.TileType,
+	style, sequence, variant, x, y int) {
+	frame, found := cellFinder.Frame(tileType, style, sequence, variant)
+	if !found || frame >= a.cells.GetFrameCount() {
+		return
+	}
+
+	if err := a.cells.SetCurrentFrame(frame); err != nil {
+		return
+	}
+
+	target.PushTranslation(x, y)
+	_ = a.cells.RenderFromOrigin(target)
+	target.Pop()
+}
+
+func (a *AutoMap) renderMarker(target d2interface.Surface, x, y int, markerColor color.Color, inArea func(x, y int) bool) {
+	if !inArea(x, y) {
+		return
+	}
+
+	target.PushTranslation(x-autoMapMarkerSize/2, y-autoMapMarkerSize/2)
+	target.DrawRect(autoMapMarkerSize, autoMapMarkerSize, markerColor)
+	target.Pop()
+}
+
+// cellFinder returns the cells of the level type of a tile.
+func (a *AutoMap) cellFinder(regionType d2enum.RegionIdType) *d2automap.CellFinder {
+	cellFinder, found := a.cellFinders[regionType]
+	if found {
+		return cellFinder
+	}
+
+	levelTypeName := ""
+	if int(regionType) < len(d2datadict.LevelTypes) {
+		levelTypeName = d2datadict.LevelTypes[regionType].Name
+	}
+
+	cellFinder = d2automap.CreateCellFinder(levelTypeName, d2datadict.AutoMaps)
+	a.cellFinders[regionType] = cellFinder
+
+	return cellFinder
+}
@@ -0,0 +1,171 @@
+package d2player
+
+import (
+	"image/color"
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2gui"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2ui"
+)
+
+// the top left corner of the items in the cube, and the transmute button
+// under them, in the left panel
+const (
+	cubeGridX      = 117
+	cubeGridY      = 120
+	cubeButtonY    = 260
+	cubeButtonSize = 24
+)
+
+// CubePanel shows the items in the Horadric Cube. Putting an item held with
+// the cursor in the cube puts it there, a left click on an item in the cube
+// picks it up, and the transmute button transmutes the items.
+type CubePanel struct {
+	grid          *ItemGrid
+	inventory     *Inventory
+	inputListener InputCallbackListener
+	titleLabel    d2ui.Label
+	itemLabel     d2ui.Label
+	buttonLabel   d2ui.Label
+	mouseX        int
+	mouseY        int
+	isOpen        bool
+}
+
+// NewCubePanel creates the panel of the Horadric Cube. The item held with
+// the cursor in the inventory panel is put in the cube.
+func NewCubePanel(inventory *Inventory, inputListener InputCallbackListener) *CubePanel {
+	center := leftMenuRect.Width / 2
+	grid := NewItemGrid(d2inventory.CubeWidth, d2inventory.CubeHeight, cubeGridX, cubeGridY)
+
+	titleLabel := d2ui.CreateLabel(d2resource.Font30, d2resource.PaletteUnits)
+	titleLabel.Alignment = d2gui.HorizontalAlignCenter
+	titleLabel.SetPosition(center, 40)
+	titleLabel.SetText("Horadric Cube")
+
+	itemLabel := d2ui.CreateLabel(d2resource.Font16, d2resource.PaletteStatic)
+	itemLabel.Alignment = d2gui.HorizontalAlignCenter
+	itemLabel.SetPosition(center, cubeButtonY+40)
+
+	buttonLabel := d2ui.CreateLabel(d2resource.Font16, d2resource.PaletteStatic)
+	buttonLabel.Alignment = d2gui.HorizontalAlignCenter
+	buttonLabel.SetPosition(center, cubeButtonY)
+	buttonLabel.SetText("Transmute")
+
+	return &CubePanel{
+		grid:          grid,
+		inventory:     inventory,
+		inputListener: inputListener,
+		titleLabel:    titleLabel,
+		itemLabel:     itemLabel,
+		buttonLabel:   buttonLabel,
+	}
+}
+
+// IsOpen returns true if the cube panel is open
+func (p *CubePanel) IsOpen() bool {
+	return p.isOpen
+}
+
+// Open the cube panel
+func (p *CubePanel) Open() {
+	p.isOpen = true
+}
+
+// Close the cube panel
+func (p *CubePanel) Close() {
+	p.isOpen = false
+}
+
+// Update shows the items in the cube of the given inventory
+func (p *CubePanel) Update(inventory *d2inventory.PlayerInventory) {
+	p.grid.Clear()
+
+	if inventory == nil {
+		return
+	}
+
+	for _, item := range inventory.Cube {
+		if err := p.grid.Set(item.SlotX, item.SlotY, item); err != nil {
+			log.Print(err)
+		}
+	}
+
+	p.grid.Load()
+}
+
+// OnMouseMove keeps track of the cursor, to describe the item under it.
+func (p *CubePanel) OnMouseMove(mx, my int) {
+	p.mouseX, p.mouseY = mx, my
+}
+
+// OnMouseButtonDown puts the item held with the cursor in the cube, picks up
+// the item in the cube left clicked, and transmutes the items when the
+// button is clicked. It returns true if the panel was clicked.
+func (p *CubePanel) OnMouseButtonDown(mx, my int, button d2enum.MouseButton) bool {
+	if !p.isOpen || !leftMenuRect.IsInRect(mx, my) {
+		return false
+	}
+
+	if button != d2enum.MouseButtonLeft {
+		return true
+	}
+
+	switch {
+	case p.grid.IsInGrid(mx, my):
+		if held := p.inventory.HeldItem(); held != nil {
+			x, y := p.grid.PlacementSlot(held, mx, my)
+			p.inputListener.OnPlayerMoveItem(held.ID, d2inventory.ItemLocation{Store: d2inventory.StoreCube, X: x, Y: y})
+
+			return true
+		}
+
+		if item, ok := p.grid.GetSlot(p.grid.ScreenToSlot(mx, my)).(*d2inventory.Item); ok {
+			p.inputListener.OnPlayerMoveItem(item.ID, d2inventory.ItemLocation{Store: d2inventory.StoreCursor})
+		}
+	case p.isOnButton(mx, my):
+		p.inputListener.OnPlayerTransmute()
+	}
+
+	return true
+}
+
+// isOnButton returns true if the cursor is on the transmute button.
+func (p *CubePanel) isOnButton(mx, my int) bool {
+	button := d2common.Rectangle{
+		Left:   leftMenuRect.Width / 4,
+		Top:    cubeButtonY - cubeButtonSize/4,
+		Width:  leftMenuRect.Width / 2,
+		Height: cubeButtonSize,
+	}
+
+	return button.IsInRect(mx, my)
+}
+
+// Render the cube panel
+func (p *CubePanel) Render(target d2interface.Surface) {
+	if !p.isOpen {
+		return
+	}
+
+	target.DrawRect(leftMenuRect.Width, leftMenuRect.Height-bottomMenuRect.Height, color.RGBA{R: 16, G: 12, B: 8, A: 224})
+
+	p.titleLabel.Render(target)
+	p.grid.Render(target)
+	p.buttonLabel.Render(target)
+
+	p.itemLabel.SetText("")
+
+	if p.grid.IsInGrid(p.mouseX, p.mouseY) {
+		if item, ok := p.grid.GetSlot(p.grid.ScreenToSlot(p.mouseX, p.mouseY)).(*d2inventory.Item); ok {
+			p.itemLabel.SetText(itemTooltip(item))
+		}
+	}
+
+	p.itemLabel.Render(target)
+}
@@ -11,12 +11,15 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2maprenderer"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2object"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2ui"
 )
 
@@ -45,7 +48,15 @@ type GameControls struct {
 	mapRenderer    *d2maprenderer.MapRenderer
 	inventory      *Inventory
 	heroStatsPanel *HeroStatsPanel
+	waypointMenu   *WaypointMenu
+	npcMenu        *NPCMenu
+	vendorPanel    *VendorPanel
+	tradePanel     *TradePanel
+	cubePanel      *CubePanel
+	autoMap        *AutoMap
 	inputListener  InputCallbackListener
+	hoveredEntity  d2interface.MapEntity
+	hoveredWarp    *d2mapengine.Warp
 	FreeCam        bool
 	lastMouseX     int
 	lastMouseY     int
@@ -96,14 +107,22 @@ func NewGameControls(renderer d2interface.Renderer, hero *d2mapentity.Player, ma
 	nameLabel.SetText("")
 	nameLabel.Color = color.White
 
+	inventory := NewInventory(inputListener)
+
 	gc := &GameControls{
 		renderer:       renderer,
 		hero:           hero,
 		mapEngine:      mapEngine,
 		inputListener:  inputListener,
 		mapRenderer:    mapRenderer,
-		inventory:      NewInventory(),
-		heroStatsPanel: NewHeroStatsPanel(renderer, hero.Name(), hero.Class, hero.Stats),
+		inventory:      inventory,
+		heroStatsPanel: NewHeroStatsPanel(renderer, hero.Name(), hero.Class, &hero.Stats, inputListener),
+		waypointMenu:   NewWaypointMenu(mapEngine, inputListener),
+		npcMenu:        NewNPCMenu(inputListener),
+		vendorPanel:    NewVendorPanel(inventory, inputListener),
+		tradePanel:     NewTradePanel(hero.Id, inventory, inputListener),
+		cubePanel:      NewCubePanel(inventory, inputListener),
+		autoMap:        NewAutoMap(mapEngine, hero),
 		nameLabel:      &nameLabel,
 		zoneChangeText: &zoneLabel,
 		actionableRegions: []ActionableRegion{
@@ -122,6 +141,28 @@ func NewGameControls(renderer d2interface.Renderer, hero *d2mapentity.Player, ma
 		gc.FreeCam = !gc.FreeCam
 	})
 
+	term.BindAction("hostile", "toggle attacking the other hardcore players", func() {
+		inputListener.OnPlayerToggleHostile()
+	})
+
+	term.BindAction("stashgold", "move gold to the stash", func(amount int) {
+		inputListener.OnPlayerStashGold(amount)
+	})
+
+	term.BindAction("withdrawgold", "take gold out of the stash", func(amount int) {
+		inputListener.OnPlayerStashGold(-amount)
+	})
+
+	term.BindAction("tradegold", "offer gold to the player you trade with", func(amount int) {
+		gc.tradePanel.OfferGold(amount)
+	})
+
+	// TODO: open the cube with a right click on the Horadric Cube item, once
+	// the quest which gives it exists
+	term.BindAction("cube", "open the horadric cube", func() {
+		gc.OpenCube()
+	})
+
 	return gc
 }
 
@@ -159,9 +200,15 @@ func (g *GameControls) OnKeyRepeat(event d2interface.KeyEvent) bool {
 func (g *GameControls) OnKeyDown(event d2interface.KeyEvent) bool {
 	switch event.Key() {
 	case d2enum.KeyEscape:
-		if g.inventory.IsOpen() || g.heroStatsPanel.IsOpen() {
+		if g.inventory.IsOpen() || g.heroStatsPanel.IsOpen() || g.waypointMenu.IsOpen() ||
+			g.npcMenu.IsOpen() || g.vendorPanel.IsOpen() || g.tradePanel.IsOpen() || g.cubePanel.IsOpen() {
 			g.inventory.Close()
 			g.heroStatsPanel.Close()
+			g.waypointMenu.Close()
+			g.npcMenu.Close()
+			g.vendorPanel.Close()
+			g.tradePanel.Cancel()
+			g.cubePanel.Close()
 			g.updateLayout()
 			break
 		}
@@ -169,10 +216,20 @@ func (g *GameControls) OnKeyDown(event d2interface.KeyEvent) bool {
 		g.inventory.Toggle()
 		g.updateLayout()
 	case d2enum.KeyC:
+		// the trade window takes the place of the character panel
+		if g.tradePanel.IsOpen() {
+			break
+		}
+
+		g.waypointMenu.Close()
+		g.vendorPanel.Close()
+		g.cubePanel.Close()
 		g.heroStatsPanel.Toggle()
 		g.updateLayout()
 	case d2enum.KeyR:
 		g.onToggleRunButton()
+	case d2enum.KeyTab:
+		g.autoMap.Toggle()
 	default:
 		return false
 	}
@@ -198,6 +255,16 @@ func (g *GameControls) OnMouseButtonRepeat(event d2interface.MouseEvent) bool {
 	shouldDoLeft  := lastLeft >= mouseBtnActionsTreshhold
 	shouldDoRight  := lastRight >= mouseBtnActionsTreshhold
 
+	// keep walking to the object, item or warp clicked on
+	_, isObject := g.hoveredEntity.(*d2object.Object)
+	_, isItem := g.hoveredEntity.(*d2mapentity.Item)
+	npc, isNPC := g.hoveredEntity.(*d2mapentity.NPC)
+	isNPC = isNPC && len(npc.Services()) > 0
+
+	if (isObject || isItem || isNPC || g.hoveredWarp != nil) && isLeft {
+		return true
+	}
+
 	if isLeft && shouldDoLeft && inRect {
 		lastLeftBtnActionTime = now
 		g.inputListener.OnPlayerMove(px, py)
@@ -217,6 +284,9 @@ func (g *GameControls) OnMouseMove(event d2interface.MouseMoveEvent) bool {
 	mx, my := event.X(), event.Y()
 	g.lastMouseX = mx
 	g.lastMouseY = my
+	g.vendorPanel.OnMouseMove(mx, my)
+	g.tradePanel.OnMouseMove(mx, my)
+	g.cubePanel.OnMouseMove(mx, my)
 
 	for i := range g.actionableRegions {
 		// Mouse over a game control element
@@ -238,12 +308,73 @@ func (g *GameControls) OnMouseButtonDown(event d2interface.MouseEvent) bool {
 		}
 	}
 
+	if event.Button() == d2enum.MouseButtonLeft && g.waypointMenu.OnMouseButtonDown(mx, my) {
+		g.updateLayout()
+		return true
+	}
+
+	if event.Button() == d2enum.MouseButtonLeft && g.npcMenu.OnMouseButtonDown(mx, my) {
+		return true
+	}
+
+	if g.vendorPanel.OnMouseButtonDown(mx, my, event.Button()) {
+		return true
+	}
+
+	if g.tradePanel.OnMouseButtonDown(mx, my, event.Button()) {
+		return true
+	}
+
+	if g.cubePanel.OnMouseButtonDown(mx, my, event.Button()) {
+		return true
+	}
+
 	px, py := g.mapRenderer.ScreenToWorld(mx, my)
 	px = float64(int(px*10)) / 10.0
 	py = float64(int(py*10)) / 10.0
 
 	if event.Button() == d2enum.MouseButtonLeft && !g.isInActiveMenusRect(mx, my) {
 		lastLeftBtnActionTime = d2common.Now()
+
+		if held := g.inventory.HeldItem(); held != nil {
+			g.inputListener.OnPlayerDropItem(held.ID)
+			return true
+		}
+
+		if object, ok := g.hoveredEntity.(*d2object.Object); ok {
+			g.inputListener.OnPlayerOperate(int(object.LocationX), int(object.LocationY))
+			return true
+		}
+
+		if item, ok := g.hoveredEntity.(*d2mapentity.Item); ok {
+			g.inputListener.OnPlayerPickUpItem(int(item.LocationX), int(item.LocationY))
+			return true
+		}
+
+		if npc, ok := g.hoveredEntity.(*d2mapentity.NPC); ok && len(npc.Services()) > 0 {
+			g.inputListener.OnPlayerTalkTo(npc)
+			return true
+		}
+
+		if player, ok := g.hoveredEntity.(*d2mapentity.Player); ok && player != g.hero {
+			g.inputListener.OnPlayerRequestTrade(player.Id)
+			return true
+		}
+
+		if g.hoveredWarp != nil {
+			exitX, exitY := g.hoveredWarp.ExitWalk()
+			g.inputListener.OnPlayerEnterWarp(g.hoveredWarp.DestinationID, exitX, exitY)
+
+			return true
+		}
+
+		// walking away from an NPC ends the trade
+		if g.npcMenu.IsOpen() || g.vendorPanel.IsOpen() {
+			g.npcMenu.Close()
+			g.vendorPanel.Close()
+			g.updateLayout()
+		}
+
 		g.inputListener.OnPlayerMove(px, py)
 		return true
 	}
@@ -277,6 +408,102 @@ func (g *GameControls) Load() {
 
 	g.inventory.Load()
 	g.heroStatsPanel.Load()
+	g.waypointMenu.Load()
+	g.autoMap.Load()
+}
+
+// LoadAutoMap loads the parts of the levels the player has explored from a
+// file, and saves them to it when SaveAutoMap is called.
+func (g *GameControls) LoadAutoMap(filePath string) {
+	g.autoMap.LoadExploration(filePath)
+}
+
+// SaveAutoMap saves the parts of the levels the player has explored.
+func (g *GameControls) SaveAutoMap() {
+	g.autoMap.SaveExploration()
+}
+
+// Inventory returns the inventory panel, which handles the input before the
+// game controls.
+func (g *GameControls) Inventory() *Inventory {
+	return g.inventory
+}
+
+// SetInventory shows the given items of the player in the inventory panel.
+func (g *GameControls) SetInventory(inventory *d2inventory.PlayerInventory) {
+	g.inventory.Update(inventory)
+	g.cubePanel.Update(inventory)
+}
+
+// OpenCube opens the Horadric Cube next to the inventory panel.
+func (g *GameControls) OpenCube() {
+	if g.tradePanel.IsOpen() {
+		return
+	}
+
+	g.heroStatsPanel.Close()
+	g.waypointMenu.Close()
+	g.npcMenu.Close()
+	g.vendorPanel.Close()
+	g.cubePanel.Open()
+	g.inventory.Open()
+	g.updateLayout()
+}
+
+// OpenWaypointMenu opens the waypoint menu after the player operated a
+// waypoint, listing the waypoints the player activated.
+func (g *GameControls) OpenWaypointMenu(playerState *PlayerState) {
+	g.heroStatsPanel.Close()
+	g.cubePanel.Close()
+	g.waypointMenu.OpenFor(playerState)
+	g.updateLayout()
+}
+
+// OpenNPCMenu opens the menu of the town NPC after the player walked up to
+// it.
+func (g *GameControls) OpenNPCMenu(npc *d2mapentity.NPC) {
+	g.vendorPanel.Close()
+	g.npcMenu.Open(npc)
+	g.updateLayout()
+}
+
+// UpdateVendor shows the items the NPC the player trades with sells, next to
+// the inventory panel.
+func (g *GameControls) UpdateVendor(vendor *d2inventory.Vendor) {
+	if g.vendorPanel.NPC() == vendor.NPC {
+		g.vendorPanel.Update(vendor)
+		return
+	}
+
+	name := vendor.NPC
+	if record := d2datadict.MonStats[vendor.NPC]; record != nil {
+		name = d2common.TranslateString(record.NameStringTableKey)
+	}
+
+	g.heroStatsPanel.Close()
+	g.waypointMenu.Close()
+	g.cubePanel.Close()
+	g.vendorPanel.Open(vendor, name)
+	g.inventory.Open()
+	g.updateLayout()
+}
+
+// UpdateTrade shows the trade window the player shares with another player,
+// next to the inventory panel, or closes it when the trade is nil.
+func (g *GameControls) UpdateTrade(trade *Trade) {
+	wasOpen := g.tradePanel.IsOpen()
+	g.tradePanel.Update(trade)
+
+	if g.tradePanel.IsOpen() && !wasOpen {
+		g.heroStatsPanel.Close()
+		g.waypointMenu.Close()
+		g.npcMenu.Close()
+		g.vendorPanel.Close()
+		g.cubePanel.Close()
+		g.inventory.Open()
+	}
+
+	g.updateLayout()
 }
 
 func (g *GameControls) loadUIButtons() {
@@ -299,6 +526,8 @@ func (g *GameControls) onToggleRunButton() {
 
 // ScreenAdvanceHandler
 func (g *GameControls) Advance(elapsed float64) error {
+	g.autoMap.Advance()
+
 	return nil
 }
 
@@ -317,7 +546,8 @@ func (g *GameControls) updateLayout() {
 
 func (g *GameControls) isLeftPanelOpen() bool {
 	// TODO: add quest log panel
-	return g.heroStatsPanel.IsOpen()
+	return g.heroStatsPanel.IsOpen() || g.waypointMenu.IsOpen() || g.vendorPanel.IsOpen() || g.tradePanel.IsOpen() ||
+		g.cubePanel.IsOpen()
 }
 
 func (g *GameControls) isRightPanelOpen() bool {
@@ -343,6 +573,8 @@ func (g *GameControls) isInActiveMenusRect(px int, py int) bool {
 
 // TODO: consider caching the panels to single image that is reused.
 func (g *GameControls) Render(target d2interface.Surface) {
+	g.hoveredEntity = nil
+
 	for entityIdx := range *g.mapEngine.Entities() {
 		entity := (*g.mapEngine.Entities())[entityIdx]
 		if !entity.Selectable() {
@@ -359,12 +591,21 @@ func (g *GameControls) Render(target d2interface.Surface) {
 			g.nameLabel.SetPosition(entScreenX, entScreenY-100)
 			g.nameLabel.Render(target)
 			entity.Highlight()
+			g.hoveredEntity = entity
 			break
 		}
 	}
 
+	g.renderHoveredWarp(target)
+	g.autoMap.Render(target)
+
 	g.inventory.Render(target)
 	g.heroStatsPanel.Render(target)
+	g.waypointMenu.Render(target)
+	g.vendorPanel.Render(target)
+	g.tradePanel.Render(target)
+	g.cubePanel.Render(target)
+	g.npcMenu.Render(target)
 
 	width, height := target.GetSize()
 	offset := 0
@@ -469,11 +710,15 @@ func (g *GameControls) Render(target d2interface.Surface) {
 	g.globeSprite.Render(target)
 	g.globeSprite.Render(target)
 
+	g.inventory.RenderBelt(target)
+
 	if g.isZoneTextShown {
 		g.zoneChangeText.SetPosition(width/2, height/4)
 		g.zoneChangeText.Render(target)
 	}
 
+	g.inventory.RenderHeldItem(target)
+
 }
 
 func (g *GameControls) SetZoneChangeText(text string) {
@@ -537,3 +782,30 @@ func (g *GameControls) onClickActionable(item ActionableType) {
 		log.Printf("Unrecognized ActionableType(%d) being clicked\n", item)
 	}
 }
+
+// renderHoveredWarp names the level the warp under the mouse leads to, if
+// no entity is hovered.
+func (g *GameControls) renderHoveredWarp(target d2interface.Surface) {
+	g.hoveredWarp = nil
+
+	if g.hoveredEntity != nil {
+		return
+	}
+
+	px, py := g.mapRenderer.ScreenToWorld(g.lastMouseX, g.lastMouseY)
+
+	warp := g.mapEngine.WarpAt(int(px*5), int(py*5))
+	if warp == nil {
+		return
+	}
+
+	levelDetails := d2datadict.GetLevelDetails(warp.DestinationID)
+	if levelDetails == nil {
+		return
+	}
+
+	g.hoveredWarp = warp
+	g.nameLabel.SetText(levelDetails.LevelWarpName)
+	g.nameLabel.SetPosition(g.lastMouseX, g.lastMouseY-20)
+	g.nameLabel.Render(target)
+}
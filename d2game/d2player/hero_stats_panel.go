@@ -36,6 +36,9 @@ type StatsPanelLabels struct {
 	MaxMana      d2ui.Label
 	MaxStamina   d2ui.Label
 	Stamina      d2ui.Label
+	StatPoints   d2ui.Label
+	// shown only while there are stat points to spend
+	StatPointsText d2ui.Label
 }
 
 var StaticTextLabels = []PanelText{
@@ -77,6 +80,8 @@ type HeroStatsPanel struct {
 	renderer             d2interface.Renderer
 	staticMenuImageCache *d2interface.Surface
 	labels               *StatsPanelLabels
+	statButtons          map[d2hero.StatType]*d2ui.Button
+	inputListener        InputCallbackListener
 
 	originX int
 	originY int
@@ -84,18 +89,20 @@ type HeroStatsPanel struct {
 }
 
 func NewHeroStatsPanel(renderer d2interface.Renderer, heroName string, heroClass d2enum.Hero,
-	heroState d2hero.HeroStatsState) *HeroStatsPanel {
+	heroState *d2hero.HeroStatsState, inputListener InputCallbackListener) *HeroStatsPanel {
 	originX := 0
 	originY := 0
 
 	return &HeroStatsPanel{
-		renderer:  renderer,
-		originX:   originX,
-		originY:   originY,
-		heroState: &heroState,
-		heroName:  heroName,
-		heroClass: heroClass,
-		labels:    &StatsPanelLabels{},
+		renderer:      renderer,
+		originX:       originX,
+		originY:       originY,
+		heroState:     heroState,
+		heroName:      heroName,
+		heroClass:     heroClass,
+		labels:        &StatsPanelLabels{},
+		statButtons:   make(map[d2hero.StatType]*d2ui.Button),
+		inputListener: inputListener,
 	}
 }
 
@@ -105,6 +112,7 @@ func (s *HeroStatsPanel) Load() {
 	animation, _ = d2asset.LoadAnimation(d2resource.InventoryCharacterPanel, d2resource.PaletteSky)
 	s.panel, _ = d2ui.LoadSprite(animation)
 	s.initStatValueLabels()
+	s.initStatButtons()
 }
 
 func (s *HeroStatsPanel) IsOpen() bool {
@@ -113,17 +121,22 @@ func (s *HeroStatsPanel) IsOpen() bool {
 
 func (s *HeroStatsPanel) Toggle() {
 	s.isOpen = !s.isOpen
+	s.updateStatButtons()
 }
 
 func (s *HeroStatsPanel) Open() {
 	s.isOpen = true
+	s.updateStatButtons()
 }
 
 func (s *HeroStatsPanel) Close() {
 	s.isOpen = false
+	s.updateStatButtons()
 }
 
 func (s *HeroStatsPanel) Render(target d2interface.Surface) {
+	s.updateStatButtons()
+
 	if !s.isOpen {
 		return
 	}
@@ -244,6 +257,40 @@ func (s *HeroStatsPanel) initStatValueLabels() {
 
 	s.labels.MaxMana = s.createStatValueLabel(s.heroState.MaxMana, 330, 355)
 	s.labels.Mana = s.createStatValueLabel(s.heroState.Mana, 370, 355)
+
+	s.labels.StatPoints = s.createStatValueLabel(s.heroState.StatPoints, 175, 413)
+	s.labels.StatPointsText = s.createTextLabel(PanelText{X: 100, Y: 400, Text: "Stat Points", Font: d2resource.Font6})
+}
+
+// initStatButtons creates the buttons used to spend stat points, next to the
+// value of each stat.
+func (s *HeroStatsPanel) initStatButtons() {
+	positions := map[d2hero.StatType]int{
+		d2hero.StatStrength:  140,
+		d2hero.StatDexterity: 200,
+		d2hero.StatVitality:  288,
+		d2hero.StatEnergy:    348,
+	}
+
+	for stat, y := range positions {
+		stat := stat
+		button := d2ui.CreateButton(s.renderer, d2ui.ButtonTypeAddStat, "")
+		button.SetPosition(206, y)
+		button.SetVisible(false)
+		button.OnActivated(func() { s.inputListener.OnPlayerAllocateStat(stat) })
+		d2ui.AddWidget(&button)
+
+		s.statButtons[stat] = &button
+	}
+}
+
+// updateStatButtons shows the stat buttons while the panel is open and the
+// hero has stat points to spend.
+func (s *HeroStatsPanel) updateStatButtons() {
+	visible := s.isOpen && s.heroState.StatPoints > 0
+	for _, button := range s.statButtons {
+		button.SetVisible(visible)
+	}
 }
 
 func (s *HeroStatsPanel) renderStatValues(target d2interface.Surface) {
@@ -264,6 +311,11 @@ func (s *HeroStatsPanel) renderStatValues(target d2interface.Surface) {
 
 	s.renderStatValueNum(s.labels.MaxMana, s.heroState.MaxMana, target)
 	s.renderStatValueNum(s.labels.Mana, s.heroState.Mana, target)
+
+	if s.heroState.StatPoints > 0 {
+		s.labels.StatPointsText.Render(target)
+		s.renderStatValueNum(s.labels.StatPoints, s.heroState.StatPoints, target)
+	}
 }
 
 func (s *HeroStatsPanel) renderStatValueNum(label d2ui.Label, value int, target d2interface.Surface) {
@@ -1,6 +1,31 @@
 package d2player
 
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+)
+
 type InputCallbackListener interface {
 	OnPlayerMove(x, y float64)
 	OnPlayerCast(skillID int, x, y float64)
+	OnPlayerAllocateStat(stat d2hero.StatType)
+	OnPlayerOperate(objectX, objectY int)
+	OnPlayerTravel(waypointX, waypointY int)
+	OnPlayerEnterWarp(levelID, exitX, exitY int)
+	OnPlayerMoveItem(itemID int, to d2inventory.ItemLocation)
+	OnPlayerUseItem(itemID int)
+	OnPlayerDropItem(itemID int)
+	OnPlayerPickUpItem(itemX, itemY int)
+	OnPlayerToggleHostile()
+	OnPlayerTalkTo(npc *d2mapentity.NPC)
+	OnPlayerInteractNPC(npc string, service d2enum.NPCService)
+	OnPlayerBuyItem(npc string, itemID int)
+	OnPlayerSellItem(npc string, itemID int)
+	OnPlayerStashGold(amount int)
+	OnPlayerRequestTrade(playerID string)
+	OnPlayerTrade(action d2enum.TradeAction, itemID, gold, revision int)
+	OnPlayerTransmute()
+	OnPlayerSocketItem(itemID, socketableID int)
 }
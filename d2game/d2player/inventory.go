@@ -1,30 +1,60 @@
 package d2player
 
 import (
+	"fmt"
+	"log"
+	"strings"
+
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2gui"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2ui"
 )
 
+// the top left corner of the potions in the belt, in the bottom panel
+const (
+	beltOriginX = 422
+	beltOriginY = 563
+)
+
 type Inventory struct {
-	frame   *d2ui.Sprite
-	panel   *d2ui.Sprite
-	grid    *ItemGrid
-	originX int
-	originY int
-	isOpen  bool
+	frame         *d2ui.Sprite
+	panel         *d2ui.Sprite
+	grid          *ItemGrid
+	belt          *ItemGrid
+	goldLabel     d2ui.Label
+	itemLabel     d2ui.Label
+	items         *d2inventory.PlayerInventory
+	inputListener InputCallbackListener
+	originX       int
+	originY       int
+	mouseX        int
+	mouseY        int
+	isOpen        bool
 }
 
-func NewInventory() *Inventory {
+func NewInventory(inputListener InputCallbackListener) *Inventory {
 	originX := 400
 	originY := 0
+
+	goldLabel := d2ui.CreateLabel(d2resource.Font16, d2resource.PaletteStatic)
+	goldLabel.Alignment = d2gui.HorizontalAlignCenter
+	goldLabel.SetPosition(originX+200, originY+445)
+
+	itemLabel := d2ui.CreateLabel(d2resource.Font16, d2resource.PaletteStatic)
+	itemLabel.Alignment = d2gui.HorizontalAlignCenter
+
 	return &Inventory{
-		grid:    NewItemGrid(10, 4, originX+19, originY+320),
-		originX: originX,
-		originY: originY,
+		goldLabel:     goldLabel,
+		itemLabel:     itemLabel,
+		grid:          NewItemGrid(d2inventory.InventoryWidth, d2inventory.InventoryHeight, originX+19, originY+320),
+		belt:          NewItemGrid(d2inventory.BeltColumns, 1, beltOriginX, beltOriginY),
+		inputListener: inputListener,
+		originX:       originX,
+		originY:       originY,
 	}
 }
 
@@ -50,26 +80,162 @@ func (g *Inventory) Load() {
 
 	animation, _ = d2asset.LoadAnimation(d2resource.InventoryCharacterPanel, d2resource.PaletteSky)
 	g.panel, _ = d2ui.LoadSprite(animation)
-	items := []InventoryItem{
-		d2inventory.GetWeaponItemByCode("wnd"),
-		d2inventory.GetWeaponItemByCode("sst"),
-		d2inventory.GetWeaponItemByCode("jav"),
-		d2inventory.GetArmorItemByCode("buc"),
-		d2inventory.GetWeaponItemByCode("clb"),
-		// TODO: Load the player's actual items
-	}
-	g.grid.ChangeEquippedSlot(d2enum.EquippedSlotLeftArm, d2inventory.GetWeaponItemByCode("wnd"))
-	g.grid.ChangeEquippedSlot(d2enum.EquippedSlotRightArm, d2inventory.GetArmorItemByCode("buc"))
-	g.grid.ChangeEquippedSlot(d2enum.EquippedSlotHead, d2inventory.GetArmorItemByCode("crn"))
-	g.grid.ChangeEquippedSlot(d2enum.EquippedSlotTorso, d2inventory.GetArmorItemByCode("plt"))
-	g.grid.ChangeEquippedSlot(d2enum.EquippedSlotLegs, d2inventory.GetArmorItemByCode("vbt"))
-	g.grid.ChangeEquippedSlot(d2enum.EquippedSlotBelt, d2inventory.GetArmorItemByCode("vbl"))
-	g.grid.ChangeEquippedSlot(d2enum.EquippedSlotGloves, d2inventory.GetArmorItemByCode("lgl"))
-	g.grid.ChangeEquippedSlot(d2enum.EquippedSlotLeftHand, d2inventory.GetMiscItemByCode("rin"))
-	g.grid.ChangeEquippedSlot(d2enum.EquippedSlotRightHand, d2inventory.GetMiscItemByCode("rin"))
-	g.grid.ChangeEquippedSlot(d2enum.EquippedSlotNeck, d2inventory.GetMiscItemByCode("amu"))
-	// TODO: Load the player's actual items
-	g.grid.Add(items...)
+}
+
+// Update shows the given items of the player in the inventory panel and in
+// the belt.
+func (g *Inventory) Update(inventory *d2inventory.PlayerInventory) {
+	g.items = inventory
+	g.grid.Clear()
+	g.belt.Clear()
+
+	if inventory == nil {
+		return
+	}
+
+	for _, item := range inventory.Inventory {
+		if err := g.grid.Set(item.SlotX, item.SlotY, item); err != nil {
+			log.Print(err)
+		}
+	}
+
+	for slot, item := range inventory.Equipped {
+		if item != nil {
+			g.grid.ChangeEquippedSlot(slot, item)
+		}
+	}
+
+	// only the bottom row of the belt is shown in the bottom panel
+	for _, item := range inventory.Belt {
+		if item.SlotY == 0 {
+			_ = g.belt.Set(item.SlotX, item.SlotY, item)
+		}
+	}
+
+	// the item held with the cursor is drawn with the sprites of the grid
+	if inventory.Cursor != nil {
+		g.grid.Load(inventory.Cursor)
+	} else {
+		g.grid.Load()
+	}
+}
+
+// HeldItem returns the item held with the cursor, nil if there is none.
+func (g *Inventory) HeldItem() *d2inventory.Item {
+	if g.items == nil {
+		return nil
+	}
+
+	return g.items.Cursor
+}
+
+// OnMouseMove keeps track of the cursor, to draw the item held with it.
+func (g *Inventory) OnMouseMove(event d2interface.MouseMoveEvent) bool {
+	g.mouseX, g.mouseY = event.X(), event.Y()
+	return false
+}
+
+// OnMouseButtonDown picks up, puts down and uses the items clicked in the
+// inventory panel and the belt. It returns true if an item was clicked.
+func (g *Inventory) OnMouseButtonDown(event d2interface.MouseEvent) bool {
+	if g.items == nil {
+		return false
+	}
+
+	mx, my := event.X(), event.Y()
+	held := g.items.Cursor
+
+	if g.isOpen {
+		if slot, ok := g.grid.EquipmentSlotAt(mx, my); ok && event.Button() == d2enum.MouseButtonLeft {
+			if item, ok := g.grid.EquippedItem(slot).(*d2inventory.Item); ok && g.socket(held, item) {
+				return true
+			}
+
+			if held != nil {
+				g.inputListener.OnPlayerMoveItem(held.ID, d2inventory.ItemLocation{Store: d2inventory.StoreEquipped, Slot: slot})
+			} else if item, ok := g.grid.EquippedItem(slot).(*d2inventory.Item); ok {
+				g.inputListener.OnPlayerMoveItem(item.ID, d2inventory.ItemLocation{Store: d2inventory.StoreCursor})
+			}
+
+			return true
+		}
+
+		if g.grid.IsInGrid(mx, my) {
+			g.onGridClicked(g.grid, d2inventory.StoreInventory, event)
+			return true
+		}
+	}
+
+	if g.belt.IsInGrid(mx, my) {
+		g.onGridClicked(g.belt, d2inventory.StoreBelt, event)
+		return true
+	}
+
+	return false
+}
+
+// onGridClicked puts the item held with the cursor in the clicked grid, or
+// handles a click on the item under the cursor: a left click picks it up, or
+// moves it to the belt while shift is held, a right click drinks a potion.
+func (g *Inventory) onGridClicked(grid *ItemGrid, store d2inventory.ItemStore, event d2interface.MouseEvent) {
+	mx, my := event.X(), event.Y()
+
+	if held := g.items.Cursor; held != nil {
+		if event.Button() == d2enum.MouseButtonLeft {
+			if item, ok := grid.GetSlot(grid.ScreenToSlot(mx, my)).(*d2inventory.Item); ok && g.socket(held, item) {
+				return
+			}
+
+			x, y := grid.PlacementSlot(held, mx, my)
+			g.inputListener.OnPlayerMoveItem(held.ID, d2inventory.ItemLocation{Store: store, X: x, Y: y})
+		}
+
+		return
+	}
+
+	item, ok := grid.GetSlot(grid.ScreenToSlot(mx, my)).(*d2inventory.Item)
+	if !ok {
+		return
+	}
+
+	switch event.Button() {
+	case d2enum.MouseButtonLeft:
+		if location, ok := g.items.FreeBeltSlot(item); ok && store != d2inventory.StoreBelt &&
+			event.KeyMod()&d2enum.KeyModShift != 0 {
+			g.inputListener.OnPlayerMoveItem(item.ID, location)
+			return
+		}
+
+		g.inputListener.OnPlayerMoveItem(item.ID, d2inventory.ItemLocation{Store: d2inventory.StoreCursor})
+	case d2enum.MouseButtonRight:
+		if _, ok := item.Potion(); ok {
+			g.inputListener.OnPlayerUseItem(item.ID)
+		}
+	}
+}
+
+// socket puts the held gem, rune or jewel into a free socket of the item,
+// and returns true if it goes in.
+func (g *Inventory) socket(held, item *d2inventory.Item) bool {
+	if held == nil || !held.IsSocketable() || item.FreeSockets() <= 0 {
+		return false
+	}
+
+	g.inputListener.OnPlayerSocketItem(item.ID, held.ID)
+
+	return true
+}
+
+// RenderBelt draws the potions in the belt.
+func (g *Inventory) RenderBelt(target d2interface.Surface) {
+	g.belt.Render(target)
+}
+
+// RenderHeldItem draws the item held with the cursor.
+func (g *Inventory) RenderHeldItem(target d2interface.Surface) {
+	if held := g.HeldItem(); held != nil {
+		g.grid.RenderItemAt(target, held, g.mouseX, g.mouseY)
+	}
 }
 
 func (g *Inventory) Render(target d2interface.Surface) {
@@ -146,4 +312,43 @@ func (g *Inventory) Render(target d2interface.Surface) {
 	g.panel.Render(target)
 
 	g.grid.Render(target)
+
+	if g.items != nil {
+		g.goldLabel.SetText(fmt.Sprintf("Gold: %d  Stash: %d", g.items.Gold, g.items.StashedGold))
+		g.goldLabel.Render(target)
+	}
+
+	g.renderItemTooltip(target)
+}
+
+// renderItemTooltip describes the item under the cursor, unless an item is
+// held with it.
+func (g *Inventory) renderItemTooltip(target d2interface.Surface) {
+	if g.HeldItem() != nil {
+		return
+	}
+
+	var hovered InventoryItem
+
+	if slot, ok := g.grid.EquipmentSlotAt(g.mouseX, g.mouseY); ok {
+		hovered = g.grid.EquippedItem(slot)
+	} else if g.grid.IsInGrid(g.mouseX, g.mouseY) {
+		hovered = g.grid.GetSlot(g.grid.ScreenToSlot(g.mouseX, g.mouseY))
+	}
+
+	item, ok := hovered.(*d2inventory.Item)
+	if !ok {
+		return
+	}
+
+	g.itemLabel.SetText(itemTooltip(item))
+	_, height := g.itemLabel.GetSize()
+	g.itemLabel.SetPosition(g.mouseX, g.mouseY-height-10)
+	g.itemLabel.Render(target)
+}
+
+// itemTooltip returns the description of the item, with what is in its
+// sockets and its properties.
+func itemTooltip(item *d2inventory.Item) string {
+	return strings.Join(item.Description(), "\n")
 }
@@ -60,6 +60,36 @@ func (g *ItemGrid) ScreenToSlot(screenX int, screenY int) (slotX int, slotY int)
 	return slotX, slotY
 }
 
+// IsInGrid returns true if the screen position is on a slot of the grid.
+func (g *ItemGrid) IsInGrid(screenX, screenY int) bool {
+	return screenX >= g.originX && screenX < g.originX+g.width*g.slotSize &&
+		screenY >= g.originY && screenY < g.originY+g.height*g.slotSize
+}
+
+// PlacementSlot returns the slot of the grid the item is put in when it is
+// held centered on the given screen position.
+func (g *ItemGrid) PlacementSlot(item InventoryItem, screenX, screenY int) (slotX, slotY int) {
+	width, height := item.InventoryGridSize()
+	return g.ScreenToSlot(screenX-(width-1)*g.slotSize/2, screenY-(height-1)*g.slotSize/2)
+}
+
+// EquipmentSlotAt returns the equipment slot at the screen position.
+func (g *ItemGrid) EquipmentSlotAt(screenX, screenY int) (d2enum.EquippedSlot, bool) {
+	for slot, eq := range g.equipmentSlots {
+		// the slots are positioned by their bottom left corner
+		if screenX >= eq.x && screenX < eq.x+eq.width && screenY > eq.y-eq.height && screenY <= eq.y {
+			return slot, true
+		}
+	}
+
+	return 0, false
+}
+
+// EquippedItem returns the item in the equipment slot.
+func (g *ItemGrid) EquippedItem(slot d2enum.EquippedSlot) InventoryItem {
+	return g.equipmentSlots[slot].item
+}
+
 func (g *ItemGrid) GetSlot(x int, y int) InventoryItem {
 	for _, item := range g.items {
 		slotX, slotY := item.InventoryGridSlot()
@@ -160,9 +190,9 @@ func (g *ItemGrid) canFit(x int, y int, item InventoryItem) bool {
 		slotX, slotY := compItem.InventoryGridSlot()
 		compWidth, compHeight := compItem.InventoryGridSize()
 
-		if x+insertWidth >= slotX &&
+		if x+insertWidth > slotX &&
 			x < slotX+compWidth &&
-			y+insertHeight >= slotY &&
+			y+insertHeight > slotY &&
 			y < slotY+compHeight {
 			return false
 		}
@@ -200,6 +230,15 @@ func (g *ItemGrid) Remove(item InventoryItem) {
 	g.items = g.items[:n]
 }
 
+// Clear removes all items from the grid and the equipment slots.
+func (g *ItemGrid) Clear() {
+	g.items = nil
+
+	for slot := range g.equipmentSlots {
+		g.ChangeEquippedSlot(slot, nil)
+	}
+}
+
 func (g *ItemGrid) renderItem(item InventoryItem, target d2interface.Surface, x int, y int) {
 	itemSprite := g.sprites[item.GetItemCode()]
 	if itemSprite != nil {
@@ -209,6 +248,18 @@ func (g *ItemGrid) renderItem(item InventoryItem, target d2interface.Surface, x
 	}
 }
 
+// RenderItemAt draws the item centered on the given screen position, e.g.
+// the item held with the cursor. The sprite of the item has to be loaded.
+func (g *ItemGrid) RenderItemAt(target d2interface.Surface, item InventoryItem, screenX, screenY int) {
+	itemSprite := g.sprites[item.GetItemCode()]
+	if itemSprite == nil {
+		return
+	}
+
+	width, height := itemSprite.GetCurrentFrameSize()
+	g.renderItem(item, target, screenX-width/2, screenY+height/2)
+}
+
 func (g *ItemGrid) Render(target d2interface.Surface) {
 	g.renderInventoryItems(target)
 	g.renderEquippedItems(target)
@@ -217,6 +268,10 @@ func (g *ItemGrid) Render(target d2interface.Surface) {
 func (g *ItemGrid) renderInventoryItems(target d2interface.Surface) {
 	for _, item := range g.items {
 		itemSprite := g.sprites[item.GetItemCode()]
+		if itemSprite == nil {
+			continue
+		}
+
 		slotX, slotY := g.SlotToScreen(item.InventoryGridSlot())
 		_, h := itemSprite.GetCurrentFrameSize()
 		slotY = slotY + h
@@ -226,7 +281,7 @@ func (g *ItemGrid) renderInventoryItems(target d2interface.Surface) {
 
 func (g *ItemGrid) renderEquippedItems(target d2interface.Surface) {
 	for _, eq := range g.equipmentSlots {
-		if eq.item != nil {
+		if eq.item != nil && g.sprites[eq.item.GetItemCode()] != nil {
 			itemSprite := g.sprites[eq.item.GetItemCode()]
 			itemWidth, itemHeight := itemSprite.GetCurrentFrameSize()
 			var x = eq.x + ((eq.width - itemWidth) / 2)
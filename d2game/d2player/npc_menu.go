@@ -0,0 +1,129 @@
+package d2player
+
+import (
+	"image/color"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2gui"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2ui"
+)
+
+const (
+	npcMenuX          = 300
+	npcMenuY          = 150
+	npcMenuWidth      = 200
+	npcMenuLineHeight = 24
+)
+
+// NPCMenu lists what a town NPC does for the player after the player walked
+// up to it, and asks the NPC to do the one the player clicks.
+type NPCMenu struct {
+	inputListener InputCallbackListener
+	npc           *d2mapentity.NPC
+	services      []d2enum.NPCService
+	nameLabel     d2ui.Label
+	labels        []d2ui.Label
+	isOpen        bool
+}
+
+// NewNPCMenu creates the menu of the town NPCs.
+func NewNPCMenu(inputListener InputCallbackListener) *NPCMenu {
+	nameLabel := d2ui.CreateLabel(d2resource.Font16, d2resource.PaletteStatic)
+	nameLabel.Alignment = d2gui.HorizontalAlignCenter
+	nameLabel.Color = color.RGBA{R: 199, G: 179, B: 119, A: 255}
+	nameLabel.SetPosition(npcMenuX+npcMenuWidth/2, npcMenuY+npcMenuLineHeight/4)
+
+	return &NPCMenu{
+		inputListener: inputListener,
+		nameLabel:     nameLabel,
+	}
+}
+
+// IsOpen returns true if the NPC menu is open
+func (m *NPCMenu) IsOpen() bool {
+	return m.isOpen
+}
+
+// Open the menu of the NPC and list what it does
+func (m *NPCMenu) Open(npc *d2mapentity.NPC) {
+	m.npc = npc
+	m.services = npc.Services()
+	m.labels = m.labels[:0]
+	m.nameLabel.SetText(npc.Name())
+
+	for idx, service := range m.services {
+		label := d2ui.CreateLabel(d2resource.Font16, d2resource.PaletteStatic)
+		label.Alignment = d2gui.HorizontalAlignCenter
+		label.SetText(service.String())
+		label.SetPosition(npcMenuX+npcMenuWidth/2, npcMenuY+(idx+1)*npcMenuLineHeight+npcMenuLineHeight/4)
+
+		m.labels = append(m.labels, label)
+	}
+
+	m.isOpen = len(m.services) > 0
+}
+
+// Close the NPC menu
+func (m *NPCMenu) Close() {
+	m.isOpen = false
+}
+
+// OnMouseButtonDown asks the NPC to do the clicked service. It returns true
+// if the menu was clicked.
+func (m *NPCMenu) OnMouseButtonDown(mx, my int) bool {
+	if !m.isOpen {
+		return false
+	}
+
+	for idx, service := range m.services {
+		entry := d2common.Rectangle{
+			Left:   npcMenuX,
+			Top:    npcMenuY + (idx+1)*npcMenuLineHeight,
+			Width:  npcMenuWidth,
+			Height: npcMenuLineHeight,
+		}
+
+		if !entry.IsInRect(mx, my) {
+			continue
+		}
+
+		// TODO: play the dialogs of the NPCs when the player talks to them
+		if service != d2enum.NPCServiceTalk {
+			m.inputListener.OnPlayerInteractNPC(m.npc.MonStatsRecord().Key, service)
+		}
+
+		m.Close()
+
+		return true
+	}
+
+	menu := d2common.Rectangle{
+		Left:   npcMenuX,
+		Top:    npcMenuY,
+		Width:  npcMenuWidth,
+		Height: (len(m.services) + 1) * npcMenuLineHeight,
+	}
+
+	return menu.IsInRect(mx, my)
+}
+
+// Render the NPC menu
+func (m *NPCMenu) Render(target d2interface.Surface) {
+	if !m.isOpen {
+		return
+	}
+
+	target.PushTranslation(npcMenuX, npcMenuY)
+	target.DrawRect(npcMenuWidth, (len(m.services)+1)*npcMenuLineHeight, color.RGBA{A: 192})
+	target.Pop()
+
+	m.nameLabel.Render(target)
+
+	for idx := range m.labels {
+		m.labels[idx].Render(target)
+	}
+}
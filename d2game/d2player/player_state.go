@@ -2,17 +2,23 @@ package d2player
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"os"
 	"path"
 	"strconv"
 	"strings"
 
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2combat"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2save"
 )
 
 type PlayerState struct {
@@ -22,38 +28,130 @@ type PlayerState struct {
 	Act       int                            `json:"act"`
 	FilePath  string                         `json:"-"`
 	Equipment d2inventory.CharacterEquipment `json:"equipment"`
+	Inventory *d2inventory.PlayerInventory   `json:"inventory"`
 	Stats     *d2hero.HeroStatsState          `json:"stats"`
 	X         float64                        `json:"x"`
 	Y         float64                        `json:"y"`
+	LevelID   int                            `json:"levelId"`
+	Hardcore  bool                           `json:"hardcore"`
+	Dead      bool                           `json:"dead"` // a hardcore player which died, it can not play anymore
+	Hostile   bool                           `json:"-"`    // the player may attack the other hostile hardcore players
+
+	// the hardest difficulty the player has unlocked
+	Difficulty d2enum.DifficultyType `json:"difficulty"`
+
+	// the Levels.txt IDs of the levels whose waypoint the player activated
+	Waypoints []int `json:"waypoints,omitempty"`
+}
+
+// the version of the schema of the saved player states
+const saveVersion = 2
+
+//nolint:gochecknoglobals // constant list of the migrations of the player states saved with each version to the next
+var saveMigrations = [saveVersion]func(*PlayerState){
+	// the players saved before their stats were saved start with the stats
+	// of their class
+	func(v *PlayerState) {
+		if v.Stats == nil {
+			v.Stats = d2hero.CreateHeroStatsState(v.HeroType, d2datadict.CharStats[v.HeroType])
+		}
+	},
+	// the players saved before their inventory was saved start with the items
+	// they have equipped
+	func(v *PlayerState) {
+		if v.Inventory == nil {
+			v.Inventory = d2inventory.CreateEquippedInventory(v.Equipment)
+		}
+	},
+}
+
+// Errors
+var (
+	ErrNewerSave  = errors.New("saved by a newer version of the game")
+	ErrNoHero     = errors.New("saved without a hero")
+	ErrNoVendor   = errors.New("the NPC does not trade")
+	ErrNotForSale = errors.New("equipped items can not be sold")
+)
+
+// SaveError tells which save file could not be loaded
+type SaveError struct {
+	FilePath string
+	Restored bool // the player was restored from the newest valid backup
+	Err      error
+}
+
+func (e *SaveError) Error() string {
+	if e.Restored {
+		return fmt.Sprintf("%s: %v, restored from a backup", path.Base(e.FilePath), e.Err)
+	}
+
+	return fmt.Sprintf("%s: %v", path.Base(e.FilePath), e.Err)
 }
 
 func HasGameStates() bool {
-	basePath, _ := getGameBaseSavePath()
+	basePath, _ := d2save.Directory()
 	files, _ := ioutil.ReadDir(basePath)
-	return len(files) > 0
+	for _, file := range files {
+		if isSaveFile(file) {
+			return true
+		}
+	}
+	return false
 }
 
-func GetAllPlayerStates() []*PlayerState {
-	basePath, _ := getGameBaseSavePath()
+func isSaveFile(file os.FileInfo) bool {
+	return !file.IsDir() && strings.ToLower(path.Ext(file.Name())) == ".od2"
+}
+
+// GetAllPlayerStates loads the saved players. The players whose save file
+// is damaged are restored from their newest valid backup if they can be,
+// the errors tell which ones.
+func GetAllPlayerStates() ([]*PlayerState, []error) {
+	basePath, _ := d2save.Directory()
 	files, _ := ioutil.ReadDir(basePath)
 	result := make([]*PlayerState, 0)
+	var errs []error
 	for _, file := range files {
-		fileName := file.Name()
-		if file.IsDir() || len(fileName) < 5 || strings.ToLower(fileName[len(fileName)-4:]) != ".od2" {
+		if !isSaveFile(file) {
 			continue
 		}
-		gameState := LoadPlayerState(path.Join(basePath, file.Name()))
-		if gameState == nil || gameState.HeroType == d2enum.HeroNone {
-			continue
-		// temporarily loading default class stats if the character was created before saving stats was introduced
-		// to be removed in the future
-		} else if gameState.Stats == nil {
-			gameState.Stats = d2hero.CreateHeroStatsState(gameState.HeroType, d2datadict.CharStats[gameState.HeroType])
-			gameState.Save()
+
+		filePath := path.Join(basePath, file.Name())
+		gameState, err := LoadPlayerState(filePath)
+		if err != nil {
+			// only the corrupted saves are restored, the saves of newer
+			// versions and the ones which could not be read are left untouched
+			if err == d2save.ErrCorrupted || err == d2save.ErrChecksum {
+				gameState = restorePlayerState(filePath)
+			}
+
+			errs = append(errs, &SaveError{FilePath: filePath, Restored: gameState != nil, Err: err})
+		}
+
+		if gameState != nil {
+			result = append(result, gameState)
 		}
+	}
+	return result, errs
+}
+
+// restorePlayerState loads the newest valid backup of a save file, and saves
+// it back to the file
+func restorePlayerState(filePath string) *PlayerState {
+	data, version, err := d2save.LoadBackup(filePath)
+	if err != nil {
+		return nil
+	}
+
+	result, err := decodePlayerState(filePath, data, version)
+	if err != nil {
+		return nil
+	}
 
-		result = append(result, gameState)
+	if err := result.Save(); err != nil {
+		log.Printf("failed to restore %s: %v", filePath, err)
 	}
+
 	return result
 }
 
@@ -63,64 +161,501 @@ func CreateTestGameState() *PlayerState {
 	return result
 }
 
-func LoadPlayerState(path string) *PlayerState {
-	strData, err := ioutil.ReadFile(path)
+// LoadPlayerState loads a saved player. The players saved with an older
+// version are migrated to the current one, and saved back.
+func LoadPlayerState(filePath string) (*PlayerState, error) {
+	data, version, err := d2save.Load(filePath)
 	if err != nil {
-		return nil
+		return nil, err
+	}
+
+	result, err := decodePlayerState(filePath, data, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if version < saveVersion {
+		if err := result.Save(); err != nil {
+			log.Printf("failed to save %s migrated to version %d: %v", filePath, saveVersion, err)
+		}
+	}
+
+	return result, nil
+}
+
+func decodePlayerState(filePath string, data []byte, version int) (*PlayerState, error) {
+	if version > saveVersion {
+		return nil, ErrNewerSave
 	}
 
 	result := &PlayerState{
-		FilePath: path,
+		FilePath: filePath,
 	}
-	err = json.Unmarshal(strData, result)
-	if err != nil {
-		return nil
+
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, d2save.ErrCorrupted
 	}
-	return result
+
+	if result.HeroType == d2enum.HeroNone {
+		return nil, ErrNoHero
+	}
+
+	for ; version < saveVersion; version++ {
+		saveMigrations[version](result)
+	}
+
+	return result, nil
 }
 
-func CreatePlayerState(heroName string, hero d2enum.Hero, classStats *d2datadict.CharStatsRecord, hardcore bool) *PlayerState {
+func CreatePlayerState(heroName string, hero d2enum.Hero, classStats *d2datadict.CharStatsRecord, hardcore bool) (*PlayerState, error) {
 	result := &PlayerState{
 		HeroName:  heroName,
 		HeroType:  hero,
 		Act:       1,
 		Stats: d2hero.CreateHeroStatsState(hero, classStats),
 		Equipment: d2inventory.HeroObjects[hero],
+		Inventory: d2inventory.CreateEquippedInventory(d2inventory.HeroObjects[hero]),
 		FilePath:  "",
+		Hardcore:  hardcore,
 	}
 
-	result.Save()
-	return result
-}
-
-func getGameBaseSavePath() (string, error) {
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		return "", err
+	if err := result.Save(); err != nil {
+		return nil, err
 	}
 
-	return path.Join(configDir, "OpenDiablo2/Saves"), nil
+	return result, nil
 }
 
-func getFirstFreeFileName() string {
+func getFirstFreeFileName() (string, error) {
 	i := 0
-	basePath, _ := getGameBaseSavePath()
+	basePath, err := d2save.Directory()
+	if err != nil {
+		return "", err
+	}
 	for {
 		filePath := path.Join(basePath, strconv.Itoa(i)+".od2")
 		if _, err := os.Stat(filePath); os.IsNotExist(err) {
-			return filePath
+			return filePath, nil
 		}
 		i++
 	}
 }
 
-func (v *PlayerState) Save() {
+// IsGhost returns true if the player is a hardcore player which died. Ghosts
+// may look around the game, but are not put in the world.
+func (v *PlayerState) IsGhost() bool {
+	return v.Hardcore && v.Dead
+}
+
+// Die takes the life of the player. A hardcore player dies for good.
+func (v *PlayerState) Die() {
+	if v.Stats != nil {
+		v.Stats.Health = 0
+	}
+
+	if v.Hardcore {
+		v.Dead = true
+		v.Hostile = false
+	}
+}
+
+// Respawn brings a softcore player which died back to life, with its full
+// life and mana. It returns false if the player died in hardcore mode.
+func (v *PlayerState) Respawn() bool {
+	if v.Dead || v.Stats == nil {
+		return false
+	}
+
+	v.Stats.Health = v.Stats.MaxHealth
+	v.Stats.Mana = v.Stats.MaxMana
+
+	return true
+}
+
+// CanPlay returns true if the player has unlocked the difficulty.
+func (v *PlayerState) CanPlay(difficulty d2enum.DifficultyType) bool {
+	return difficulty <= v.Difficulty
+}
+
+// CompleteDifficulty unlocks the difficulty after the given one, once the
+// player has defeated its final boss. It returns true if a difficulty was
+// unlocked.
+func (v *PlayerState) CompleteDifficulty(difficulty d2enum.DifficultyType) bool {
+	if difficulty != v.Difficulty || difficulty >= d2enum.DifficultyHell {
+		return false
+	}
+
+	v.Difficulty++
+
+	return true
+}
+
+// ActivateWaypoint lets the player travel to the waypoint of the level. It
+// returns false if the waypoint was activated before.
+func (v *PlayerState) ActivateWaypoint(levelID int) bool {
+	if v.HasWaypoint(levelID) {
+		return false
+	}
+
+	v.Waypoints = append(v.Waypoints, levelID)
+
+	return true
+}
+
+// HasWaypoint returns true if the player activated the waypoint of the level.
+func (v *PlayerState) HasWaypoint(levelID int) bool {
+	for _, waypoint := range v.Waypoints {
+		if waypoint == levelID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AutoMapPath returns the path of the file the parts of the levels the
+// player has explored are saved to, next to the save file of the player.
+func (v *PlayerState) AutoMapPath() string {
+	return strings.TrimSuffix(v.FilePath, path.Ext(v.FilePath)) + ".map"
+}
+
+// MoveItem moves an item of the player to another location, and updates the
+// equipment the player is seen with.
+func (v *PlayerState) MoveItem(itemID int, to d2inventory.ItemLocation) error {
+	if v.Inventory == nil {
+		return d2inventory.ErrItemNotFound
+	}
+
+	item, _, found := v.Inventory.Find(itemID)
+	if !found {
+		return d2inventory.ErrItemNotFound
+	}
+
+	if to.Store == d2inventory.StoreEquipped && !item.MeetsRequirements(v.HeroType, v.Stats) {
+		return d2inventory.ErrRequirementsNotMet
+	}
+
+	if err := v.Inventory.Move(itemID, to); err != nil {
+		return err
+	}
+
+	v.Equipment = v.Inventory.CharacterEquipment()
+
+	return nil
+}
+
+// UseItem drinks a potion of the player.
+func (v *PlayerState) UseItem(itemID int) error {
+	if v.Inventory == nil || v.Stats == nil {
+		return d2inventory.ErrItemNotFound
+	}
+
+	item, _, found := v.Inventory.Find(itemID)
+	if !found {
+		return d2inventory.ErrItemNotFound
+	}
+
+	potion, ok := item.Potion()
+	if !ok {
+		return d2inventory.ErrNotUsable
+	}
+
+	life, mana := potion.Life, potion.Mana
+	if potion.Percent {
+		life, mana = v.Stats.MaxHealth*life/100, v.Stats.MaxMana*mana/100
+	}
+
+	v.Stats.Health = d2common.MinInt(v.Stats.Health+life, v.Stats.MaxHealth)
+	v.Stats.Mana = d2common.MinInt(v.Stats.Mana+mana, v.Stats.MaxMana)
+
+	_, err := v.Inventory.Remove(itemID)
+
+	return err
+}
+
+// DropItem takes an item out of the inventory of the player, to drop it on
+// the ground.
+func (v *PlayerState) DropItem(itemID int) (*d2inventory.Item, error) {
+	if v.Inventory == nil {
+		return nil, d2inventory.ErrItemNotFound
+	}
+
+	item, err := v.Inventory.Remove(itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	v.Equipment = v.Inventory.CharacterEquipment()
+
+	return item, nil
+}
+
+// PickUpItem puts an item picked up from the ground in the belt if it is a
+// potion which fits there, or else in the inventory panel.
+func (v *PlayerState) PickUpItem(item *d2inventory.Item) error {
+	if v.Inventory == nil {
+		return d2inventory.ErrInventoryFull
+	}
+
+	return pickUp(v.Inventory, item)
+}
+
+// pickUp puts an item the player gets in the belt if it is a potion which
+// fits there, or else in the inventory panel. The item gets a new ID.
+func pickUp(inventory *d2inventory.PlayerInventory, item *d2inventory.Item) error {
+	item.ID = 0
+
+	if record := item.Record(); record != nil && record.AutoBelt {
+		if location, ok := inventory.FreeBeltSlot(item); ok {
+			return inventory.Place(item, location)
+		}
+	}
+
+	return inventory.Add(item)
+}
+
+// BuyItem buys an item of the vendor. It is put in the belt if it is a
+// potion which fits there, or else in the inventory panel.
+func (v *PlayerState) BuyItem(vendor *d2inventory.Vendor, itemID int) error {
+	npc := d2datadict.NPCs[vendor.NPC]
+	if npc == nil {
+		return ErrNoVendor
+	}
+
+	if v.Inventory == nil {
+		return d2inventory.ErrInventoryFull
+	}
+
+	item, found := vendor.Find(itemID)
+	if !found {
+		return d2inventory.ErrItemNotFound
+	}
+
+	price := d2inventory.BuyPrice(item, npc)
+	if v.Inventory.Gold < price {
+		return d2inventory.ErrNotEnoughGold
+	}
+
+	if err := v.PickUpItem(item.Clone()); err != nil {
+		return err
+	}
+
+	v.Inventory.Gold -= price
+	_, err := vendor.Remove(itemID)
+
+	return err
+}
+
+// SellItem sells an item of the player to the vendor, which sells it on.
+// The equipped items can not be sold.
+func (v *PlayerState) SellItem(vendor *d2inventory.Vendor, itemID int, difficulty d2enum.DifficultyType) error {
+	npc := d2datadict.NPCs[vendor.NPC]
+	if npc == nil {
+		return ErrNoVendor
+	}
+
+	if v.Inventory == nil || v.Stats == nil {
+		return d2inventory.ErrItemNotFound
+	}
+
+	item, location, found := v.Inventory.Find(itemID)
+	if !found {
+		return d2inventory.ErrItemNotFound
+	}
+
+	if location.Store == d2inventory.StoreEquipped {
+		return ErrNotForSale
+	}
+
+	if err := v.Inventory.AddGold(d2inventory.SellPrice(item, npc, difficulty), v.Stats.Level); err != nil {
+		return err
+	}
+
+	if _, err := v.Inventory.Remove(itemID); err != nil {
+		return err
+	}
+
+	// the vendor keeps the item only if it has room for it
+	_ = vendor.Add(item)
+
+	return nil
+}
+
+// StashGold moves gold of the player to its stash, or withdraws it from the
+// stash when the amount is negative.
+func (v *PlayerState) StashGold(amount int) error {
+	if v.Inventory == nil || v.Stats == nil {
+		return d2inventory.ErrNotEnoughGold
+	}
+
+	return v.Inventory.StashGold(amount, v.Stats.Level)
+}
+
+// SocketItem puts the gem, rune or jewel with the socketable ID into a free
+// socket of the item with the item ID.
+func (v *PlayerState) SocketItem(itemID, socketableID int, rng *rand.Rand) error {
+	if v.Inventory == nil {
+		return d2inventory.ErrItemNotFound
+	}
+
+	return v.Inventory.Socket(itemID, socketableID, rng)
+}
+
+// CombatStats returns the combat stats of the player on the difficulty, with
+// the defense and the block of its equipped items, and the properties of
+// those and of what is in their sockets.
+// TODO: roll the defense of the items, they defend with the minimum of armor.txt
+func (v *PlayerState) CombatStats(difficulty d2enum.DifficultyType) *d2combat.Stats {
+	properties := make([]d2combat.Property, 0)
+
+	if v.Inventory != nil {
+		for _, item := range v.Inventory.Equipped {
+			if item == nil || item.IsBroken() {
+				continue
+			}
+
+			if record := item.Record(); record != nil {
+				properties = append(properties, v.baseProperties(record)...)
+			}
+
+			for _, property := range item.AllProperties() {
+				properties = append(properties, d2combat.Property{Code: property.Code, Value: property.Value})
+			}
+		}
+	}
+
+	return d2combat.HeroStats(v.Stats, difficulty, properties...)
+}
+
+// baseProperties returns the defense of armor.txt the item adds, and the
+// block of the shield with the block factor of the class of the player.
+func (v *PlayerState) baseProperties(record *d2datadict.ItemCommonRecord) []d2combat.Property {
+	properties := make([]d2combat.Property, 0)
+
+	if record.MinAC > 0 {
+		properties = append(properties, d2combat.Property{Code: "ac", Value: record.MinAC})
+	}
+
+	if record.Block > 0 {
+		block := record.Block
+		if classStats := d2datadict.CharStats[v.HeroType]; classStats != nil {
+			block += classStats.BlockFactor
+		}
+
+		properties = append(properties, d2combat.Property{Code: "block", Value: block})
+	}
+
+	return properties
+}
+
+// TransmuteCube transmutes the items in the Horadric Cube of the player with
+// the recipes it can use in the difficulty.
+func (v *PlayerState) TransmuteCube(difficulty d2enum.DifficultyType, rng *rand.Rand) error {
+	if v.Inventory == nil || v.Stats == nil {
+		return d2inventory.ErrNoRecipe
+	}
+
+	// there are no ladder games yet, so the ladder recipes are never offered
+	rules := d2inventory.TransmuteRules{Difficulty: difficulty, Hero: v.HeroType, Level: v.Stats.Level, Stats: v.Stats}
+
+	return v.Inventory.Transmute(rules, rng)
+}
+
+// RepairItems repairs all items of the player at the smith, and returns the
+// gold it cost. Nothing is repaired if the player can not afford it all.
+func (v *PlayerState) RepairItems(npc *d2datadict.NPCRecord) (int, error) {
+	if v.Inventory == nil || npc == nil {
+		return 0, nil
+	}
+
+	items := append(append([]*d2inventory.Item{}, v.Inventory.Inventory...), v.Inventory.Stash...)
+	for _, item := range v.Inventory.Equipped {
+		if item != nil {
+			items = append(items, item)
+		}
+	}
+
+	cost := 0
+	for _, item := range items {
+		cost += d2inventory.RepairCost(item, npc)
+	}
+
+	if v.Inventory.Gold < cost {
+		return 0, d2inventory.ErrNotEnoughGold
+	}
+
+	v.Inventory.Gold -= cost
+
+	for _, item := range items {
+		item.Repair()
+	}
+
+	return cost, nil
+}
+
+// IdentifyItems identifies all items of the player, and returns how many
+// were unidentified.
+func (v *PlayerState) IdentifyItems() int {
+	if v.Inventory == nil {
+		return 0
+	}
+
+	count := 0
+	identify := func(items []*d2inventory.Item) {
+		for _, item := range items {
+			if item != nil && item.Unidentified {
+				item.Unidentified = false
+				count++
+			}
+		}
+	}
+
+	identify(v.Inventory.Inventory)
+	identify(v.Inventory.Stash)
+	identify([]*d2inventory.Item{v.Inventory.Cursor})
+
+	for _, item := range v.Inventory.Equipped {
+		identify([]*d2inventory.Item{item})
+	}
+
+	return count
+}
+
+// Copy returns a copy of the player which shares nothing with it.
+func (v *PlayerState) Copy() (*PlayerState, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PlayerState{FilePath: v.FilePath, Hostile: v.Hostile}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Save saves the player with the current version of the schema, keeping
+// backups of the previous saves.
+func (v *PlayerState) Save() error {
 	if v.FilePath == "" {
-		v.FilePath = getFirstFreeFileName()
+		filePath, err := getFirstFreeFileName()
+		if err != nil {
+			return err
+		}
+		v.FilePath = filePath
 	}
-	if err := os.MkdirAll(path.Dir(v.FilePath), 0755); err != nil {
-		log.Panic(err.Error())
+
+	return d2save.Save(v.FilePath, saveVersion, v)
+}
+
+// Delete deletes the save file of the player and its backups, and the
+// automap of the player
+func (v *PlayerState) Delete() error {
+	if err := d2save.Remove(v.AutoMapPath()); err != nil && !os.IsNotExist(err) {
+		return err
 	}
-	fileJson, _ := json.MarshalIndent(v, "", "   ")
-	ioutil.WriteFile(v.FilePath, fileJson, 0644)
+
+	return d2save.Remove(v.FilePath)
 }
@@ -0,0 +1,374 @@
+package d2player
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"math/rand"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2d2s"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+)
+
+// ErrUnknownClass is returned when a character of an unknown class is
+// imported or exported
+var ErrUnknownClass = errors.New("unknown character class")
+
+// d2sItemVersion is the version of the items of Diablo II 1.10 to 1.14
+const d2sItemVersion = 101
+
+// the progression of a character counts the acts it completed, over all the
+// difficulties
+const (
+	d2sClassicActs   = 4
+	d2sExpansionActs = 5
+)
+
+//nolint:gochecknoglobals // constant lookup table of the equipped slots of the body parts of Diablo II
+var d2sBodyParts = map[byte]d2enum.EquippedSlot{
+	d2d2s.BodyHead:      d2enum.EquippedSlotHead,
+	d2d2s.BodyNeck:      d2enum.EquippedSlotNeck,
+	d2d2s.BodyTorso:     d2enum.EquippedSlotTorso,
+	d2d2s.BodyRightHand: d2enum.EquippedSlotLeftArm,
+	d2d2s.BodyLeftHand:  d2enum.EquippedSlotRightArm,
+	d2d2s.BodyRightRing: d2enum.EquippedSlotRightHand,
+	d2d2s.BodyLeftRing:  d2enum.EquippedSlotLeftHand,
+	d2d2s.BodyBelt:      d2enum.EquippedSlotBelt,
+	d2d2s.BodyFeet:      d2enum.EquippedSlotLegs,
+	d2d2s.BodyGloves:    d2enum.EquippedSlotGloves,
+}
+
+// ImportD2S converts a character saved by Diablo II (.d2s file) to a player
+// state, and saves it with the other characters. The quests, the skills and
+// the mercenary are left out, as are the items without a place in the
+// inventory.
+func ImportD2S(filePath string) (*PlayerState, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	character, err := d2d2s.Load(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := playerStateFromD2S(character)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := result.Save(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ExportD2S saves the player as a character of Diablo II (.d2s file). The
+// game only loads the characters saved to a file named after them.
+func (v *PlayerState) ExportD2S(filePath string) error {
+	character, err := v.d2s()
+	if err != nil {
+		return err
+	}
+
+	data, err := character.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filePath, data, 0644)
+}
+
+func playerStateFromD2S(character *d2d2s.D2S) (*PlayerState, error) {
+	hero := character.Class.Hero()
+
+	classStats := d2datadict.CharStats[hero]
+	if classStats == nil {
+		return nil, ErrUnknownClass
+	}
+
+	stat := func(id d2d2s.StatID) int {
+		return int(character.Stats[id])
+	}
+
+	stats := d2hero.CreateHeroStatsState(hero, classStats)
+	stats.Level = int(character.Level)
+	stats.Experience = stat(d2d2s.StatExperience)
+	stats.NextLevelExp = d2datadict.GetExperienceBreakpoint(hero, stats.Level)
+	stats.Strength = stat(d2d2s.StatStrength)
+	stats.Dexterity = stat(d2d2s.StatDexterity)
+	stats.Vitality = stat(d2d2s.StatVitality)
+	stats.Energy = stat(d2d2s.StatEnergy)
+	stats.StatPoints = stat(d2d2s.StatStatPoints)
+	stats.SkillPoints = stat(d2d2s.StatSkillPoints)
+	stats.Health = stat(d2d2s.StatLife) >> d2d2s.StatFractionBits
+	stats.MaxHealth = stat(d2d2s.StatMaxLife) >> d2d2s.StatFractionBits
+	stats.Mana = stat(d2d2s.StatMana) >> d2d2s.StatFractionBits
+	stats.MaxMana = stat(d2d2s.StatMaxMana) >> d2d2s.StatFractionBits
+	stats.MaxStamina = stat(d2d2s.StatMaxStamina) >> d2d2s.StatFractionBits
+	stats.Stamina = stats.MaxStamina
+	stats.UpdateRatings(classStats)
+
+	_, act := character.Act()
+
+	result := &PlayerState{
+		HeroName:  character.Name,
+		HeroType:  hero,
+		HeroLevel: stats.Level,
+		Act:       act + 1,
+		Stats:     stats,
+		Inventory: d2inventory.CreatePlayerInventory(),
+		Hardcore:  character.Status.Has(d2d2s.StatusHardcore),
+		Dead:      character.Status.Has(d2d2s.StatusHardcore | d2d2s.StatusDied),
+	}
+
+	acts := d2sClassicActs
+	if character.Status.Has(d2d2s.StatusExpansion) {
+		acts = d2sExpansionActs
+	}
+
+	result.Difficulty = d2enum.DifficultyType(d2common.MinInt(int(character.Progression)/acts, int(d2enum.DifficultyHell)))
+
+	// the equipped items go first, the belt holds potions
+	var equipped, others []*d2d2s.Item
+
+	for _, item := range character.Items {
+		if item.Location == d2d2s.LocationEquipped {
+			equipped = append(equipped, item)
+		} else {
+			others = append(others, item)
+		}
+	}
+
+	leftOut := 0
+
+	for _, item := range append(equipped, others...) {
+		if !importD2SItem(result.Inventory, item) {
+			leftOut++
+		}
+	}
+
+	if leftOut > 0 {
+		log.Printf("%d items of %s left out of the inventory", leftOut, character.Name)
+	}
+
+	result.Equipment = result.Inventory.CharacterEquipment()
+
+	return result, nil
+}
+
+// importD2SItem puts an item where it was, or else in the inventory panel.
+func importD2SItem(inventory *d2inventory.PlayerInventory, item *d2d2s.Item) bool {
+	if item.Ear != nil || d2datadict.CommonItems[item.Code] == nil {
+		return false
+	}
+
+	converted := d2inventory.CreateItem(item.Code)
+
+	if !item.Flags.Has(d2d2s.ItemSimple) && converted.Quantity > 0 {
+		converted.Quantity = item.Quantity
+	}
+
+	if item.Flags.Has(d2d2s.ItemSocketed) {
+		converted.Sockets = int(item.Sockets)
+
+		// the properties of the runewords are rolled again
+		rng := rand.New(rand.NewSource(int64(item.ID))) //nolint:gosec // not used for security
+
+		for _, socketed := range item.SocketedItems {
+			if d2datadict.CommonItems[socketed.Code] == nil {
+				continue
+			}
+
+			if err := converted.Socket(d2inventory.CreateItem(socketed.Code), rng); err != nil {
+				log.Printf("%s left out of the sockets of %s: %s", socketed.Code, item.Code, err)
+			}
+		}
+	}
+
+	if location, ok := d2sItemLocation(item); ok && inventory.Place(converted, location) == nil {
+		return true
+	}
+
+	return inventory.Add(converted) == nil
+}
+
+func d2sItemLocation(item *d2d2s.Item) (d2inventory.ItemLocation, bool) {
+	switch item.Location {
+	case d2d2s.LocationStored:
+		switch item.Storage {
+		case d2d2s.StorageInventory:
+			return d2inventory.ItemLocation{Store: d2inventory.StoreInventory, X: int(item.X), Y: int(item.Y)}, true
+		case d2d2s.StorageStash:
+			return d2inventory.ItemLocation{Store: d2inventory.StoreStash, X: int(item.X), Y: int(item.Y)}, true
+		case d2d2s.StorageCube:
+			return d2inventory.ItemLocation{Store: d2inventory.StoreCube, X: int(item.X), Y: int(item.Y)}, true
+		}
+	case d2d2s.LocationEquipped:
+		if slot, ok := d2sBodyParts[item.BodyPart]; ok {
+			return d2inventory.ItemLocation{Store: d2inventory.StoreEquipped, Slot: slot}, true
+		}
+	case d2d2s.LocationBelt:
+		column, row := int(item.X)%d2inventory.BeltColumns, int(item.X)/d2inventory.BeltColumns
+		return d2inventory.ItemLocation{Store: d2inventory.StoreBelt, X: column, Y: row}, true
+	}
+
+	return d2inventory.ItemLocation{}, false
+}
+
+func (v *PlayerState) d2s() (*d2d2s.D2S, error) {
+	class, ok := d2d2s.ClassOf(v.HeroType)
+	if !ok || v.Stats == nil {
+		return nil, ErrUnknownClass
+	}
+
+	result := d2d2s.CreateD2S(v.HeroName, class)
+	result.Level = byte(v.Stats.Level)
+
+	if v.Hardcore {
+		result.Status |= d2d2s.StatusHardcore
+	}
+
+	result.Progression = byte(int(v.Difficulty) * d2sExpansionActs)
+
+	if v.Dead {
+		result.Status |= d2d2s.StatusDied
+	}
+
+	if v.Act > 0 {
+		result.SetAct(0, v.Act-1)
+	}
+
+	stats := map[d2d2s.StatID]int{
+		d2d2s.StatStrength:    v.Stats.Strength,
+		d2d2s.StatEnergy:      v.Stats.Energy,
+		d2d2s.StatDexterity:   v.Stats.Dexterity,
+		d2d2s.StatVitality:    v.Stats.Vitality,
+		d2d2s.StatStatPoints:  v.Stats.StatPoints,
+		d2d2s.StatSkillPoints: v.Stats.SkillPoints,
+		d2d2s.StatLife:        v.Stats.Health << d2d2s.StatFractionBits,
+		d2d2s.StatMaxLife:     v.Stats.MaxHealth << d2d2s.StatFractionBits,
+		d2d2s.StatMana:        v.Stats.Mana << d2d2s.StatFractionBits,
+		d2d2s.StatMaxMana:     v.Stats.MaxMana << d2d2s.StatFractionBits,
+		d2d2s.StatStamina:     v.Stats.MaxStamina << d2d2s.StatFractionBits,
+		d2d2s.StatMaxStamina:  v.Stats.MaxStamina << d2d2s.StatFractionBits,
+		d2d2s.StatLevel:       v.Stats.Level,
+		d2d2s.StatExperience:  v.Stats.Experience,
+	}
+
+	// the game leaves out the stats which are zero
+	for id, value := range stats {
+		if value > 0 {
+			result.Stats[id] = uint32(value)
+		}
+	}
+
+	if v.Inventory != nil {
+		result.Items = exportD2SItems(v.Inventory)
+	}
+
+	return result, nil
+}
+
+func exportD2SItems(inventory *d2inventory.PlayerInventory) []*d2d2s.Item {
+	result := make([]*d2d2s.Item, 0)
+
+	add := func(item *d2inventory.Item, location d2d2s.ItemLocation, storage d2d2s.ItemStorage) *d2d2s.Item {
+		exported := exportD2SItem(item)
+		if exported == nil {
+			return nil
+		}
+
+		exported.Location, exported.Storage = location, storage
+		exported.X, exported.Y = byte(item.SlotX), byte(item.SlotY)
+		result = append(result, exported)
+
+		return exported
+	}
+
+	for _, item := range inventory.Inventory {
+		add(item, d2d2s.LocationStored, d2d2s.StorageInventory)
+	}
+
+	for _, item := range inventory.Stash {
+		add(item, d2d2s.LocationStored, d2d2s.StorageStash)
+	}
+
+	for _, item := range inventory.Cube {
+		add(item, d2d2s.LocationStored, d2d2s.StorageCube)
+	}
+
+	for _, item := range inventory.Belt {
+		if exported := add(item, d2d2s.LocationBelt, d2d2s.StorageNone); exported != nil {
+			exported.X, exported.Y = byte(item.SlotY*d2inventory.BeltColumns+item.SlotX), 0
+		}
+	}
+
+	for bodyPart, slot := range d2sBodyParts {
+		if item := inventory.Equipped[slot]; item != nil {
+			if exported := add(item, d2d2s.LocationEquipped, d2d2s.StorageNone); exported != nil {
+				exported.BodyPart = bodyPart
+			}
+		}
+	}
+
+	if inventory.Cursor != nil {
+		add(inventory.Cursor, d2d2s.LocationCursor, d2d2s.StorageNone)
+	}
+
+	return result
+}
+
+// exportD2SItem converts an item to an identified item of normal quality,
+// with the base defense and durability of its record, and what is in its
+// sockets. The runewords are exported as the runes in their sockets.
+func exportD2SItem(item *d2inventory.Item) *d2d2s.Item {
+	record := item.Record()
+	if record == nil {
+		return nil
+	}
+
+	result := &d2d2s.Item{
+		Flags:   d2d2s.ItemIdentified | d2d2s.ItemAlwaysSet,
+		Version: d2sItemVersion,
+		Code:    item.Code,
+	}
+
+	if record.CompactSave {
+		result.Flags |= d2d2s.ItemSimple
+		return result
+	}
+
+	result.ID = uint32(item.ID)
+	result.Level = byte(record.Level)
+	result.Quality = d2d2s.QualityNormal
+	result.Quantity = item.Quantity
+
+	if record.Source == d2enum.InventoryItemTypeArmor {
+		result.Defense = record.MinAC
+	}
+
+	if !record.NoDurability {
+		result.MaxDurability, result.Durability = record.Durability, record.Durability
+	}
+
+	if item.Sockets > 0 {
+		result.Flags |= d2d2s.ItemSocketed
+		result.Sockets = byte(item.Sockets)
+	}
+
+	for idx, socketed := range item.Socketed {
+		if exported := exportD2SItem(socketed); exported != nil {
+			exported.Location, exported.X = d2d2s.LocationSocket, byte(idx)
+			result.SocketedItems = append(result.SocketedItems, exported)
+		}
+	}
+
+	return result
+}
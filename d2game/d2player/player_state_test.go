@@ -0,0 +1,115 @@
+package d2player
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2config"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2save"
+)
+
+func TestCombatStatsEquipment(t *testing.T) {
+	d2datadict.DifficultyLevels = nil
+	d2datadict.CharStats = map[d2enum.Hero]*d2datadict.CharStatsRecord{
+		d2enum.HeroBarbarian: {Class: d2enum.HeroBarbarian, BlockFactor: 20},
+	}
+	d2datadict.CommonItems = map[string]*d2datadict.ItemCommonRecord{
+		"cap": {Code: "cap", Source: d2enum.InventoryItemTypeArmor, Type: "helm", MinAC: 3, MaxAC: 5},
+		"buc": {Code: "buc", Source: d2enum.InventoryItemTypeArmor, Type: "shie", MinAC: 4, MaxAC: 6, Block: 20},
+	}
+
+	state := &PlayerState{
+		HeroType:  d2enum.HeroBarbarian,
+		Inventory: d2inventory.CreatePlayerInventory(),
+		Stats:     &d2hero.HeroStatsState{Level: 10, Dexterity: 35},
+	}
+
+	if stats := state.CombatStats(d2enum.DifficultyNormal); stats.Defense != 0 || stats.BlockChance != 0 {
+		t.Fatalf("expected no defense and no block without items, got %d and %d", stats.Defense, stats.BlockChance)
+	}
+
+	state.Inventory.Equipped[d2enum.EquippedSlotHead] = d2inventory.CreateItem("cap")
+	state.Inventory.Equipped[d2enum.EquippedSlotRightArm] = d2inventory.CreateItem("buc")
+
+	// the block of the buckler and of the class, 40 * (35 - 15) / (10 * 2)
+	stats := state.CombatStats(d2enum.DifficultyNormal)
+	if stats.Defense != 7 || stats.BlockChance != 40 {
+		t.Errorf("expected a defense of 7 and a block chance of 40, got %d and %d", stats.Defense, stats.BlockChance)
+	}
+}
+
+func TestGetAllPlayerStatesBackups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "saves")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	d2config.Config = &d2config.Configuration{SaveDirectory: dir}
+
+	defer func() { d2config.Config = nil }()
+
+	// saved twice, the first save is the backup of the corrupted one
+	corrupted := &PlayerState{HeroName: "corrupted", HeroType: d2enum.HeroBarbarian, FilePath: path.Join(dir, "0.od2")}
+	for i := 0; i < 2; i++ {
+		if err := corrupted.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ioutil.WriteFile(corrupted.FilePath, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// a newer version saved over a valid backup
+	newer := &PlayerState{HeroName: "newer", HeroType: d2enum.HeroSorceress, FilePath: path.Join(dir, "1.od2")}
+	if err := newer.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d2save.Save(newer.FilePath, saveVersion+1, newer); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := ioutil.ReadFile(newer.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	states, errs := GetAllPlayerStates()
+	if len(states) != 1 || states[0].HeroName != "corrupted" {
+		t.Fatalf("expected the corrupted player to be restored alone, got %v", states)
+	}
+
+	if len(errs) != 2 || !errs[0].(*SaveError).Restored || errs[1].(*SaveError).Restored || errs[1].(*SaveError).Err != ErrNewerSave {
+		t.Errorf("unexpected errors %v", errs)
+	}
+
+	if data, err := ioutil.ReadFile(newer.FilePath); err != nil || !bytes.Equal(data, saved) {
+		t.Errorf("the save of the newer version should be left untouched")
+	}
+}
+
+func TestRespawn(t *testing.T) {
+	softcore := &PlayerState{Stats: &d2hero.HeroStatsState{Health: 30, MaxHealth: 50, MaxMana: 20}}
+	softcore.Die()
+
+	if !softcore.Respawn() || softcore.IsGhost() || softcore.Stats.Health != 50 || softcore.Stats.Mana != 20 {
+		t.Errorf("expected the softcore player to respawn with full life and mana, got %+v", softcore.Stats)
+	}
+
+	hardcore := &PlayerState{Hardcore: true, Stats: &d2hero.HeroStatsState{Health: 30, MaxHealth: 50}}
+	hardcore.Die()
+
+	if hardcore.Respawn() || !hardcore.IsGhost() || hardcore.Stats.Health != 0 {
+		t.Errorf("expected the hardcore player to stay dead, got %+v", hardcore.Stats)
+	}
+}
@@ -0,0 +1,250 @@
+package d2player
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+)
+
+// Errors of the trades between players
+var (
+	ErrNotTrading   = errors.New("the player is not part of the trade")
+	ErrNotTradable  = errors.New("equipped items can not be traded")
+	ErrTradeChanged = errors.New("the trade changed")
+	ErrNotAccepted  = errors.New("the trade is not accepted by both players")
+)
+
+// Trade is the trade window two players share: the items and the gold each
+// of them offers the other. A trade completes in two phases, both players
+// accept the offers, then both confirm them. Any change of the offers takes
+// back the accepts and the confirmations. Players accept and confirm the
+// revision of the offers they have seen, so that nobody agrees to offers
+// which changed in the meantime.
+type Trade struct {
+	PlayerIDs [2]string                  `json:"playerIds"`
+	Names     [2]string                  `json:"names"`
+	Offers    [2]*d2inventory.TradeOffer `json:"offers"`
+	Accepted  [2]bool                    `json:"accepted"`
+	Confirmed [2]bool                    `json:"confirmed"`
+	Revision  int                        `json:"revision"`
+}
+
+// CreateTrade opens a trade between two players, which offer nothing yet.
+func CreateTrade(firstID, firstName, secondID, secondName string) *Trade {
+	return &Trade{
+		PlayerIDs: [2]string{firstID, secondID},
+		Names:     [2]string{firstName, secondName},
+		Offers:    [2]*d2inventory.TradeOffer{d2inventory.CreateTradeOffer(), d2inventory.CreateTradeOffer()},
+	}
+}
+
+// Side returns 0 for the player which asked for the trade, 1 for the other
+// one.
+func (t *Trade) Side(playerID string) (int, bool) {
+	for side, id := range t.PlayerIDs {
+		if id == playerID {
+			return side, true
+		}
+	}
+
+	return 0, false
+}
+
+// OtherID returns the ID of the player the given player trades with.
+func (t *Trade) OtherID(playerID string) string {
+	if t.PlayerIDs[0] == playerID {
+		return t.PlayerIDs[1]
+	}
+
+	return t.PlayerIDs[0]
+}
+
+// OfferItem adds an item of the player to its offer. The equipped items can
+// not be traded.
+func (t *Trade) OfferItem(playerID string, state *PlayerState, itemID int) error {
+	side, ok := t.Side(playerID)
+	if !ok {
+		return ErrNotTrading
+	}
+
+	if state.Inventory == nil {
+		return d2inventory.ErrItemNotFound
+	}
+
+	item, location, found := state.Inventory.Find(itemID)
+	if !found {
+		return d2inventory.ErrItemNotFound
+	}
+
+	if location.Store == d2inventory.StoreEquipped {
+		return ErrNotTradable
+	}
+
+	if err := t.Offers[side].Add(item); err != nil {
+		return err
+	}
+
+	t.Revise()
+
+	return nil
+}
+
+// WithdrawItem takes an item out of the offer of the player.
+func (t *Trade) WithdrawItem(playerID string, itemID int) error {
+	side, ok := t.Side(playerID)
+	if !ok {
+		return ErrNotTrading
+	}
+
+	if _, err := t.Offers[side].Remove(itemID); err != nil {
+		return err
+	}
+
+	t.Revise()
+
+	return nil
+}
+
+// OfferGold sets the gold the player offers, at most the gold it carries.
+func (t *Trade) OfferGold(playerID string, state *PlayerState, gold int) error {
+	side, ok := t.Side(playerID)
+	if !ok {
+		return ErrNotTrading
+	}
+
+	if gold < 0 || state.Inventory == nil || gold > state.Inventory.Gold {
+		return d2inventory.ErrNotEnoughGold
+	}
+
+	t.Offers[side].Gold = gold
+	t.Revise()
+
+	return nil
+}
+
+// Accept agrees to the given revision of the offers for the player.
+func (t *Trade) Accept(playerID string, revision int) error {
+	side, ok := t.Side(playerID)
+	if !ok {
+		return ErrNotTrading
+	}
+
+	if revision != t.Revision {
+		return ErrTradeChanged
+	}
+
+	t.Accepted[side] = true
+
+	return nil
+}
+
+// Confirm agrees for the player to swap the items of the given revision of
+// the offers, once both players accepted them.
+func (t *Trade) Confirm(playerID string, revision int) error {
+	side, ok := t.Side(playerID)
+	if !ok {
+		return ErrNotTrading
+	}
+
+	if revision != t.Revision {
+		return ErrTradeChanged
+	}
+
+	if !t.Accepted[0] || !t.Accepted[1] {
+		return ErrNotAccepted
+	}
+
+	t.Confirmed[side] = true
+
+	return nil
+}
+
+// IsConfirmed returns true if both players confirmed the offers.
+func (t *Trade) IsConfirmed() bool {
+	return t.Confirmed[0] && t.Confirmed[1]
+}
+
+// Revise takes back the accepts and the confirmations of both players, after
+// the offers changed or the trade failed to complete.
+func (t *Trade) Revise() {
+	t.Revision++
+	t.Accepted = [2]bool{}
+	t.Confirmed = [2]bool{}
+}
+
+// Complete swaps the offered items and gold between the players, in the
+// order of their IDs in the trade. Either all of them change hands or none
+// does: the offered items must still be with their players as they were
+// offered, and the players must have room for the items and the gold they
+// get.
+func (t *Trade) Complete(states [2]*PlayerState) error {
+	if !t.IsConfirmed() {
+		return ErrNotAccepted
+	}
+
+	var (
+		inventories [2]*d2inventory.PlayerInventory
+		received    [2][]*d2inventory.Item
+	)
+
+	for side, state := range states {
+		if state.Inventory == nil || state.Stats == nil {
+			return d2inventory.ErrItemNotFound
+		}
+
+		inventories[side] = state.Inventory.Clone()
+
+		for _, offered := range t.Offers[side].Items {
+			item, location, found := inventories[side].Find(offered.ID)
+			if !found || location.Store == d2inventory.StoreEquipped || !sameItem(item, offered) {
+				return ErrTradeChanged
+			}
+
+			if _, err := inventories[side].Remove(offered.ID); err != nil {
+				return err
+			}
+
+			received[1-side] = append(received[1-side], item)
+		}
+
+		if err := inventories[side].AddGold(-t.Offers[side].Gold, state.Stats.Level); err != nil {
+			return err
+		}
+	}
+
+	for side, state := range states {
+		for _, item := range received[side] {
+			if err := pickUp(inventories[side], item); err != nil {
+				return err
+			}
+		}
+
+		if err := inventories[side].AddGold(t.Offers[1-side].Gold, state.Stats.Level); err != nil {
+			return err
+		}
+	}
+
+	for side, state := range states {
+		state.Inventory = inventories[side]
+		state.Equipment = state.Inventory.CharacterEquipment()
+	}
+
+	return nil
+}
+
+// Clone returns a copy of the trade which holds copies of the offers.
+func (t *Trade) Clone() *Trade {
+	clone := *t
+	clone.Offers = [2]*d2inventory.TradeOffer{t.Offers[0].Clone(), t.Offers[1].Clone()}
+
+	return &clone
+}
+
+// sameItem returns true if the items are the same but for where they are.
+func sameItem(item, other *d2inventory.Item) bool {
+	a, b := item.Clone(), other.Clone()
+	a.SlotX, a.SlotY, b.SlotX, b.SlotY = 0, 0, 0, 0
+
+	return reflect.DeepEqual(a, b)
+}
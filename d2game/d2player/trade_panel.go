@@ -0,0 +1,274 @@
+package d2player
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2gui"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2ui"
+)
+
+// the top left corners of the offers of the other player and of the player,
+// and the line of the buttons, in the left panel
+const (
+	tradeGridX      = 55
+	tradeOtherGridY = 90
+	tradeOwnGridY   = 270
+	tradeButtonsY   = 450
+	tradeActionX    = 100
+	tradeCancelX    = 300
+	tradeButtonSize = 24
+)
+
+// TradePanel shows the trade window the player shares with another player:
+// the items and the gold each of them offers. Putting an item held with the
+// cursor on the offer of the player offers it, a right click on an offered
+// item takes it back.
+type TradePanel struct {
+	playerID      string
+	trade         *Trade
+	inventory     *Inventory
+	inputListener InputCallbackListener
+	otherGrid     *ItemGrid
+	ownGrid       *ItemGrid
+	titleLabel    d2ui.Label
+	otherLabel    d2ui.Label
+	ownLabel      d2ui.Label
+	statusLabel   d2ui.Label
+	itemLabel     d2ui.Label
+	actionLabel   d2ui.Label
+	cancelLabel   d2ui.Label
+	mouseX        int
+	mouseY        int
+}
+
+// NewTradePanel creates the trade window of the player with the given ID.
+// The item held with the cursor in the inventory panel is offered when put
+// on the offer of the player.
+func NewTradePanel(playerID string, inventory *Inventory, inputListener InputCallbackListener) *TradePanel {
+	newLabel := func(font string, x, y int) d2ui.Label {
+		label := d2ui.CreateLabel(font, d2resource.PaletteStatic)
+		label.Alignment = d2gui.HorizontalAlignCenter
+		label.SetPosition(x, y)
+
+		return label
+	}
+
+	center := leftMenuRect.Width / 2
+	otherGrid := NewItemGrid(d2inventory.TradeWidth, d2inventory.TradeHeight, tradeGridX, tradeOtherGridY)
+	ownGrid := NewItemGrid(d2inventory.TradeWidth, d2inventory.TradeHeight, tradeGridX, tradeOwnGridY)
+	otherGridBottom := tradeOtherGridY + d2inventory.TradeHeight*otherGrid.slotSize
+	ownGridBottom := tradeOwnGridY + d2inventory.TradeHeight*ownGrid.slotSize
+
+	titleLabel := d2ui.CreateLabel(d2resource.Font30, d2resource.PaletteUnits)
+	titleLabel.Alignment = d2gui.HorizontalAlignCenter
+	titleLabel.SetPosition(center, 40)
+
+	cancelLabel := newLabel(d2resource.Font16, tradeCancelX, tradeButtonsY)
+	cancelLabel.SetText("Cancel")
+
+	return &TradePanel{
+		playerID:      playerID,
+		inventory:     inventory,
+		inputListener: inputListener,
+		otherGrid:     otherGrid,
+		ownGrid:       ownGrid,
+		titleLabel:    titleLabel,
+		otherLabel:    newLabel(d2resource.Font16, center, otherGridBottom+6),
+		ownLabel:      newLabel(d2resource.Font16, center, ownGridBottom+6),
+		statusLabel:   newLabel(d2resource.Font16, center, tradeButtonsY-30),
+		itemLabel:     newLabel(d2resource.Font16, center, otherGridBottom+40),
+		actionLabel:   newLabel(d2resource.Font16, tradeActionX, tradeButtonsY),
+		cancelLabel:   cancelLabel,
+	}
+}
+
+// IsOpen returns true if the player trades with another player
+func (p *TradePanel) IsOpen() bool {
+	return p.trade != nil
+}
+
+// Update shows the given trade, and closes the panel when it is nil
+func (p *TradePanel) Update(trade *Trade) {
+	p.trade = trade
+	p.otherGrid.Clear()
+	p.ownGrid.Clear()
+
+	side, ok := p.side()
+	if !ok {
+		p.trade = nil
+		return
+	}
+
+	other := 1 - side
+
+	for _, grid := range []struct {
+		grid  *ItemGrid
+		offer *d2inventory.TradeOffer
+	}{{p.otherGrid, trade.Offers[other]}, {p.ownGrid, trade.Offers[side]}} {
+		for _, item := range grid.offer.Items {
+			if err := grid.grid.Set(item.SlotX, item.SlotY, item); err != nil {
+				log.Print(err)
+			}
+		}
+
+		grid.grid.Load()
+	}
+
+	p.titleLabel.SetText(trade.Names[other])
+	p.otherLabel.SetText(fmt.Sprintf("%s offers %d gold", trade.Names[other], trade.Offers[other].Gold))
+	p.ownLabel.SetText(fmt.Sprintf("You offer %d gold", trade.Offers[side].Gold))
+
+	switch {
+	case trade.Confirmed[side]:
+		p.statusLabel.SetText(fmt.Sprintf("Waiting for %s to confirm", trade.Names[other]))
+	case trade.Accepted[side] && trade.Accepted[other]:
+		p.statusLabel.SetText("Both accepted, confirm to trade")
+	case trade.Accepted[side]:
+		p.statusLabel.SetText(fmt.Sprintf("Waiting for %s to accept", trade.Names[other]))
+	case trade.Accepted[other]:
+		p.statusLabel.SetText(fmt.Sprintf("%s accepted", trade.Names[other]))
+	default:
+		p.statusLabel.SetText("")
+	}
+
+	p.actionLabel.SetText("")
+
+	if action, ok := p.action(); ok {
+		p.actionLabel.SetText(action.String())
+	}
+}
+
+// side returns the side of the player in the trade
+func (p *TradePanel) side() (int, bool) {
+	if p.trade == nil {
+		return 0, false
+	}
+
+	return p.trade.Side(p.playerID)
+}
+
+// action returns what the button of the panel does: accept the offers, or
+// confirm them once both players accepted them.
+func (p *TradePanel) action() (d2enum.TradeAction, bool) {
+	side, ok := p.side()
+
+	switch {
+	case !ok || p.trade.Confirmed[side]:
+		return 0, false
+	case !p.trade.Accepted[side]:
+		return d2enum.TradeActionAccept, true
+	case p.trade.Accepted[1-side]:
+		return d2enum.TradeActionConfirm, true
+	}
+
+	return 0, false
+}
+
+// OnMouseMove keeps track of the cursor, to show the name of the offered
+// item under it.
+func (p *TradePanel) OnMouseMove(mx, my int) {
+	p.mouseX, p.mouseY = mx, my
+}
+
+// OnMouseButtonDown offers the item held with the cursor when it is put on
+// the offer of the player, takes back the offered item right clicked, and
+// accepts, confirms or cancels the trade when the buttons are clicked. It
+// returns true if the panel was clicked.
+func (p *TradePanel) OnMouseButtonDown(mx, my int, button d2enum.MouseButton) bool {
+	if !p.IsOpen() || !leftMenuRect.IsInRect(mx, my) {
+		return false
+	}
+
+	revision := p.trade.Revision
+
+	switch {
+	case p.ownGrid.IsInGrid(mx, my):
+		if held := p.inventory.HeldItem(); held != nil {
+			if button == d2enum.MouseButtonLeft {
+				p.inputListener.OnPlayerTrade(d2enum.TradeActionOfferItem, held.ID, 0, revision)
+			}
+
+			return true
+		}
+
+		if item, ok := p.ownGrid.GetSlot(p.ownGrid.ScreenToSlot(mx, my)).(*d2inventory.Item); ok &&
+			button == d2enum.MouseButtonRight {
+			p.inputListener.OnPlayerTrade(d2enum.TradeActionWithdrawItem, item.ID, 0, revision)
+		}
+	case button != d2enum.MouseButtonLeft:
+	case isOnTradeButton(tradeActionX, mx, my):
+		if action, ok := p.action(); ok {
+			p.inputListener.OnPlayerTrade(action, 0, 0, revision)
+		}
+	case isOnTradeButton(tradeCancelX, mx, my):
+		p.Cancel()
+	}
+
+	return true
+}
+
+// Cancel the trade
+func (p *TradePanel) Cancel() {
+	if p.IsOpen() {
+		p.inputListener.OnPlayerTrade(d2enum.TradeActionCancel, 0, 0, p.trade.Revision)
+	}
+}
+
+// OfferGold sets the gold the player offers
+func (p *TradePanel) OfferGold(gold int) {
+	if p.IsOpen() {
+		p.inputListener.OnPlayerTrade(d2enum.TradeActionOfferGold, 0, gold, p.trade.Revision)
+	}
+}
+
+// isOnTradeButton returns true if the cursor is on the button centered at
+// the given position on the line of the buttons.
+func isOnTradeButton(x, mx, my int) bool {
+	button := d2common.Rectangle{
+		Left:   x - leftMenuRect.Width/8,
+		Top:    tradeButtonsY - tradeButtonSize/4,
+		Width:  leftMenuRect.Width / 4,
+		Height: tradeButtonSize,
+	}
+
+	return button.IsInRect(mx, my)
+}
+
+// Render the trade panel
+func (p *TradePanel) Render(target d2interface.Surface) {
+	if !p.IsOpen() {
+		return
+	}
+
+	target.DrawRect(leftMenuRect.Width, leftMenuRect.Height-bottomMenuRect.Height, color.RGBA{R: 16, G: 12, B: 8, A: 224})
+
+	p.titleLabel.Render(target)
+	p.otherGrid.Render(target)
+	p.ownGrid.Render(target)
+	p.otherLabel.Render(target)
+	p.ownLabel.Render(target)
+	p.statusLabel.Render(target)
+	p.actionLabel.Render(target)
+	p.cancelLabel.Render(target)
+
+	p.itemLabel.SetText("")
+
+	for _, grid := range []*ItemGrid{p.otherGrid, p.ownGrid} {
+		if !grid.IsInGrid(p.mouseX, p.mouseY) {
+			continue
+		}
+
+		if item, ok := grid.GetSlot(grid.ScreenToSlot(p.mouseX, p.mouseY)).(*d2inventory.Item); ok {
+			p.itemLabel.SetText(itemTooltip(item))
+		}
+	}
+
+	p.itemLabel.Render(target)
+}
@@ -0,0 +1,175 @@
+package d2player
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+)
+
+func testTraders(t *testing.T) (*Trade, [2]*PlayerState) {
+	d2datadict.CommonItems = map[string]*d2datadict.ItemCommonRecord{
+		"cap": {Code: "cap", Source: d2enum.InventoryItemTypeArmor, Type: "helm", InventoryWidth: 2, InventoryHeight: 2},
+	}
+
+	var states [2]*PlayerState
+
+	for side := range states {
+		states[side] = &PlayerState{
+			Inventory: d2inventory.CreatePlayerInventory(),
+			Stats:     &d2hero.HeroStatsState{Level: 10},
+		}
+
+		if err := states[side].Inventory.Add(d2inventory.CreateItem("cap")); err != nil {
+			t.Fatal(err)
+		}
+
+		states[side].Inventory.Gold = 1000
+	}
+
+	return CreateTrade("first", "First", "second", "Second"), states
+}
+
+// confirm has both players accept and confirm the current offers.
+func confirm(t *testing.T, trade *Trade) {
+	for _, id := range trade.PlayerIDs {
+		if err := trade.Accept(id, trade.Revision); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, id := range trade.PlayerIDs {
+		if err := trade.Confirm(id, trade.Revision); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTradeComplete(t *testing.T) {
+	trade, states := testTraders(t)
+	capID := states[0].Inventory.Inventory[0].ID
+
+	if err := trade.OfferItem("first", states[0], capID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := trade.OfferGold("second", states[1], 300); err != nil {
+		t.Fatal(err)
+	}
+
+	confirm(t, trade)
+
+	if err := trade.Complete(states); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(states[0].Inventory.Inventory) != 0 || len(states[1].Inventory.Inventory) != 2 {
+		t.Errorf("want the cap to change hands, got %d and %d items",
+			len(states[0].Inventory.Inventory), len(states[1].Inventory.Inventory))
+	}
+
+	if states[0].Inventory.Gold != 1300 || states[1].Inventory.Gold != 700 {
+		t.Errorf("want 1300 and 700 gold, got %d and %d", states[0].Inventory.Gold, states[1].Inventory.Gold)
+	}
+}
+
+func TestTradeCompleteWithoutRoom(t *testing.T) {
+	trade, states := testTraders(t)
+
+	// fill the inventory panel of the second player with caps
+	for count := 1; count < (d2inventory.InventoryWidth/2)*(d2inventory.InventoryHeight/2); count++ {
+		if err := states[1].Inventory.Add(d2inventory.CreateItem("cap")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inventories := [2]*d2inventory.PlayerInventory{states[0].Inventory, states[1].Inventory}
+	counts := [2]int{len(inventories[0].Inventory), len(inventories[1].Inventory)}
+
+	if err := trade.OfferItem("first", states[0], inventories[0].Inventory[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := trade.OfferGold("second", states[1], 300); err != nil {
+		t.Fatal(err)
+	}
+
+	confirm(t, trade)
+
+	if err := trade.Complete(states); err != d2inventory.ErrInventoryFull {
+		t.Fatalf("want the trade to fail for a full inventory, got %v", err)
+	}
+
+	for side, state := range states {
+		if state.Inventory != inventories[side] || len(state.Inventory.Inventory) != counts[side] ||
+			state.Inventory.Gold != 1000 {
+			t.Errorf("the inventory of player %d changed although the trade failed", side)
+		}
+	}
+}
+
+func TestTradeChangedAfterAccept(t *testing.T) {
+	trade, states := testTraders(t)
+	item := states[0].Inventory.Inventory[0]
+
+	if err := trade.OfferItem("first", states[0], item.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	revision := trade.Revision
+	if err := trade.Accept("second", revision); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := trade.OfferGold("first", states[0], 100); err != nil {
+		t.Fatal(err)
+	}
+
+	if trade.Revision == revision || trade.Accepted[1] {
+		t.Error("changing the offers did not take back the accept")
+	}
+
+	if err := trade.Accept("second", revision); err != ErrTradeChanged {
+		t.Errorf("want accepting an old revision to fail, got %v", err)
+	}
+
+	confirm(t, trade)
+
+	// the offered item changes in the inventory after it was confirmed
+	item.Durability++
+
+	if err := trade.Complete(states); err != ErrTradeChanged {
+		t.Fatalf("want the trade to fail for a changed item, got %v", err)
+	}
+
+	if len(states[0].Inventory.Inventory) != 1 || len(states[1].Inventory.Inventory) != 1 {
+		t.Error("items changed hands although the trade failed")
+	}
+}
+
+func TestTradeSameItemTwice(t *testing.T) {
+	trade, states := testTraders(t)
+	item := states[0].Inventory.Inventory[0]
+
+	if err := trade.OfferItem("first", states[0], item.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := trade.OfferItem("first", states[0], item.ID); err == nil {
+		t.Error("the same item was offered twice")
+	}
+
+	// an offer holding the item twice can not complete
+	trade.Offers[0].Items = append(trade.Offers[0].Items, trade.Offers[0].Items[0])
+	confirm(t, trade)
+
+	if err := trade.Complete(states); err != ErrTradeChanged {
+		t.Fatalf("want the trade to fail for an item offered twice, got %v", err)
+	}
+
+	if len(states[0].Inventory.Inventory) != 1 || len(states[1].Inventory.Inventory) != 1 {
+		t.Error("items changed hands although the trade failed")
+	}
+}
@@ -0,0 +1,157 @@
+package d2player
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2gui"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2ui"
+)
+
+// the top left corner of the items of the vendor, in the left panel
+const (
+	vendorGridX = 55
+	vendorGridY = 90
+)
+
+// VendorPanel shows the items an NPC sells while the player trades with it.
+// A right click on an item buys it, putting an item held with the cursor on
+// the items sells it.
+type VendorPanel struct {
+	grid          *ItemGrid
+	vendor        *d2inventory.Vendor
+	inventory     *Inventory
+	inputListener InputCallbackListener
+	nameLabel     d2ui.Label
+	priceLabel    d2ui.Label
+	mouseX        int
+	mouseY        int
+	isOpen        bool
+}
+
+// NewVendorPanel creates the panel of the vendors. The item held with the
+// cursor in the inventory panel is sold when put on it.
+func NewVendorPanel(inventory *Inventory, inputListener InputCallbackListener) *VendorPanel {
+	nameLabel := d2ui.CreateLabel(d2resource.Font30, d2resource.PaletteUnits)
+	nameLabel.Alignment = d2gui.HorizontalAlignCenter
+	nameLabel.SetPosition(leftMenuRect.Width/2, 40)
+
+	grid := NewItemGrid(d2inventory.VendorWidth, d2inventory.VendorHeight, vendorGridX, vendorGridY)
+
+	priceLabel := d2ui.CreateLabel(d2resource.Font16, d2resource.PaletteStatic)
+	priceLabel.Alignment = d2gui.HorizontalAlignCenter
+	priceLabel.SetPosition(leftMenuRect.Width/2, vendorGridY+d2inventory.VendorHeight*grid.slotSize+10)
+
+	return &VendorPanel{
+		grid:          grid,
+		inventory:     inventory,
+		inputListener: inputListener,
+		nameLabel:     nameLabel,
+		priceLabel:    priceLabel,
+	}
+}
+
+// IsOpen returns true if the vendor panel is open
+func (p *VendorPanel) IsOpen() bool {
+	return p.isOpen
+}
+
+// Open the vendor panel and show the items the vendor sells
+func (p *VendorPanel) Open(vendor *d2inventory.Vendor, name string) {
+	p.isOpen = true
+	p.nameLabel.SetText(name)
+	p.Update(vendor)
+}
+
+// Close the vendor panel
+func (p *VendorPanel) Close() {
+	p.isOpen = false
+	p.vendor = nil
+}
+
+// Update shows the given items of the vendor
+func (p *VendorPanel) Update(vendor *d2inventory.Vendor) {
+	p.vendor = vendor
+	p.grid.Clear()
+
+	if vendor == nil {
+		return
+	}
+
+	for _, item := range vendor.Items {
+		if err := p.grid.Set(item.SlotX, item.SlotY, item); err != nil {
+			log.Print(err)
+		}
+	}
+
+	p.grid.Load()
+}
+
+// NPC returns the Id in monstats.txt of the NPC the player trades with, an
+// empty string if the panel is closed.
+func (p *VendorPanel) NPC() string {
+	if !p.isOpen || p.vendor == nil {
+		return ""
+	}
+
+	return p.vendor.NPC
+}
+
+// OnMouseMove keeps track of the cursor, to show the price of the item under
+// it.
+func (p *VendorPanel) OnMouseMove(mx, my int) {
+	p.mouseX, p.mouseY = mx, my
+}
+
+// OnMouseButtonDown sells the item held with the cursor when it is put on
+// the items of the vendor, and buys the item of the vendor right clicked. It
+// returns true if the items of the vendor were clicked.
+func (p *VendorPanel) OnMouseButtonDown(mx, my int, button d2enum.MouseButton) bool {
+	if !p.isOpen || p.vendor == nil || !p.grid.IsInGrid(mx, my) {
+		return false
+	}
+
+	if held := p.inventory.HeldItem(); held != nil {
+		if button == d2enum.MouseButtonLeft {
+			p.inputListener.OnPlayerSellItem(p.vendor.NPC, held.ID)
+		}
+
+		return true
+	}
+
+	if item, ok := p.grid.GetSlot(p.grid.ScreenToSlot(mx, my)).(*d2inventory.Item); ok &&
+		button == d2enum.MouseButtonRight {
+		p.inputListener.OnPlayerBuyItem(p.vendor.NPC, item.ID)
+	}
+
+	return true
+}
+
+// Render the vendor panel
+func (p *VendorPanel) Render(target d2interface.Surface) {
+	if !p.isOpen {
+		return
+	}
+
+	target.DrawRect(leftMenuRect.Width, leftMenuRect.Height-bottomMenuRect.Height, color.RGBA{R: 16, G: 12, B: 8, A: 224})
+
+	p.nameLabel.Render(target)
+	p.grid.Render(target)
+
+	p.priceLabel.SetText("")
+
+	npc := d2datadict.NPCs[p.NPC()]
+	if npc != nil && p.grid.IsInGrid(p.mouseX, p.mouseY) {
+		if item, ok := p.grid.GetSlot(p.grid.ScreenToSlot(p.mouseX, p.mouseY)).(*d2inventory.Item); ok {
+			p.priceLabel.SetText(fmt.Sprintf("%s\nCost: %d", itemTooltip(item), d2inventory.BuyPrice(item, npc)))
+		}
+	}
+
+	p.priceLabel.Render(target)
+}
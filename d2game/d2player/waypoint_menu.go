@@ -0,0 +1,172 @@
+package d2player
+
+import (
+	"fmt"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2object"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2ui"
+)
+
+const (
+	waypointListX      = 150
+	waypointListY      = 100
+	waypointListHeight = 30
+)
+
+// WaypointMenu lists the waypoints of the map after the player operated a
+// waypoint, and lets the player travel to one of them by clicking it.
+type WaypointMenu struct {
+	panel         *d2ui.Sprite
+	mapEngine     *d2mapengine.MapEngine
+	inputListener InputCallbackListener
+	waypoints     []*d2object.Object
+	playerState   *PlayerState // lists the waypoints it activated, all of them if nil
+	labels        []d2ui.Label
+
+	originX int
+	originY int
+	isOpen  bool
+}
+
+// NewWaypointMenu creates the waypoint menu for the waypoints of the map.
+func NewWaypointMenu(mapEngine *d2mapengine.MapEngine, inputListener InputCallbackListener) *WaypointMenu {
+	return &WaypointMenu{
+		mapEngine:     mapEngine,
+		inputListener: inputListener,
+	}
+}
+
+// Load the resources of the waypoint menu
+func (m *WaypointMenu) Load() {
+	animation, _ := d2asset.LoadAnimation(d2resource.WaypointPanel, d2resource.PaletteSky)
+	m.panel, _ = d2ui.LoadSprite(animation)
+}
+
+// IsOpen returns true if the waypoint menu is open
+func (m *WaypointMenu) IsOpen() bool {
+	return m.isOpen
+}
+
+// Toggle opens the waypoint menu if it is closed and closes it if it is open
+func (m *WaypointMenu) Toggle() {
+	if m.isOpen {
+		m.Close()
+	} else {
+		m.Open()
+	}
+}
+
+// OpenFor opens the waypoint menu listing the waypoints on the map the
+// player activated.
+func (m *WaypointMenu) OpenFor(playerState *PlayerState) {
+	m.playerState = playerState
+	m.Open()
+}
+
+// Open the waypoint menu and list the waypoints currently on the map
+func (m *WaypointMenu) Open() {
+	m.isOpen = true
+	m.waypoints = m.waypoints[:0]
+	m.labels = m.labels[:0]
+
+	for _, entity := range *m.mapEngine.Entities() {
+		waypoint, ok := entity.(*d2object.Object)
+		if !ok || !waypoint.IsWaypoint() {
+			continue
+		}
+
+		if m.playerState != nil && !m.playerState.HasWaypoint(m.mapEngine.LevelAt(waypoint.TileX, waypoint.TileY)) {
+			continue
+		}
+
+		label := d2ui.CreateLabel(d2resource.Font16, d2resource.PaletteStatic)
+		label.SetText(m.waypointName(waypoint, len(m.waypoints)))
+		label.SetPosition(m.originX+waypointListX, m.originY+waypointListY+len(m.waypoints)*waypointListHeight)
+
+		m.waypoints = append(m.waypoints, waypoint)
+		m.labels = append(m.labels, label)
+	}
+}
+
+// Close the waypoint menu
+func (m *WaypointMenu) Close() {
+	m.isOpen = false
+}
+
+// waypointName returns the name of the level the waypoint is in.
+func (m *WaypointMenu) waypointName(waypoint *d2object.Object, index int) string {
+	levelDetails := d2datadict.GetLevelDetails(m.mapEngine.LevelAt(waypoint.TileX, waypoint.TileY))
+	if levelDetails == nil {
+		return fmt.Sprintf("Waypoint %d", index+1)
+	}
+
+	return levelDetails.LevelDisplayName
+}
+
+// OnMouseButtonDown travels to the clicked waypoint. It returns true if a
+// waypoint was clicked.
+func (m *WaypointMenu) OnMouseButtonDown(mx, my int) bool {
+	if !m.isOpen {
+		return false
+	}
+
+	for idx, waypoint := range m.waypoints {
+		entry := d2common.Rectangle{
+			Left:   m.originX + waypointListX,
+			Top:    m.originY + waypointListY + idx*waypointListHeight,
+			Width:  leftMenuRect.Width - waypointListX,
+			Height: waypointListHeight,
+		}
+
+		if entry.IsInRect(mx, my) {
+			m.inputListener.OnPlayerTravel(int(waypoint.LocationX), int(waypoint.LocationY))
+			m.Close()
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// Render the waypoint menu
+func (m *WaypointMenu) Render(target d2interface.Surface) {
+	if !m.isOpen {
+		return
+	}
+
+	if m.panel != nil {
+		m.renderPanel(target)
+	}
+
+	for idx := range m.labels {
+		m.labels[idx].Render(target)
+	}
+}
+
+// renderPanel draws the background, which is made of four frames.
+func (m *WaypointMenu) renderPanel(target d2interface.Surface) {
+	x, y := m.originX, m.originY
+
+	for frame := 0; frame < m.panel.GetFrameCount(); frame++ {
+		if err := m.panel.SetCurrentFrame(frame); err != nil {
+			return
+		}
+
+		w, h := m.panel.GetCurrentFrameSize()
+		m.panel.SetPosition(x, y+h)
+		m.panel.Render(target)
+
+		if frame%2 == 0 {
+			x += w
+		} else {
+			x, y = m.originX, y+h
+		}
+	}
+}
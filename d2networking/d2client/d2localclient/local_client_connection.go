@@ -1,6 +1,7 @@
 package d2localclient
 
 import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2game/d2player"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client/d2clientconnectiontype"
@@ -15,6 +16,7 @@ type LocalClientConnection struct {
 	clientListener    d2networking.ClientListener // The game client
 	uniqueId          string                      // Unique ID generated on construction
 	openNetworkServer bool                        // True if this is a server
+	difficulty        d2enum.DifficultyType       // Difficulty of the game the server creates
 	playerState       *d2player.PlayerState       // Local player state
 }
 
@@ -29,26 +31,35 @@ func (l LocalClientConnection) GetConnectionType() d2clientconnectiontype.Client
 	return d2clientconnectiontype.Local
 }
 
-// SendPacketToClient passes a packet to the game client for processing.
+// SendPacketToClient passes a packet to the game client, which queues it to
+// process it on the main thread.
 func (l *LocalClientConnection) SendPacketToClient(packet d2netpacket.NetPacket) error {
 	return l.clientListener.OnPacketReceived(packet)
 }
 
-// Create constructs a new LocalClientConnection and returns
-// a pointer to it.
-func Create(openNetworkServer bool) *LocalClientConnection {
+// Create constructs a new LocalClientConnection, which creates a game on the
+// given difficulty, and returns a pointer to it.
+func Create(openNetworkServer bool, difficulty d2enum.DifficultyType) *LocalClientConnection {
 	result := &LocalClientConnection{
 		uniqueId:          uuid.NewV4().String(),
 		openNetworkServer: openNetworkServer,
+		difficulty:        difficulty,
 	}
 
 	return result
 }
 
-// Open creates a new GameServer, runs the server and connects this client to it.
-func (l *LocalClientConnection) Open(_ string, saveFilePath string) error {
-	l.SetPlayerState(d2player.LoadPlayerState(saveFilePath))
-	d2server.Create(l.openNetworkServer)
+// Open creates a new GameServer, runs the server and connects this client to
+// it. The server changes the player on its own goroutine, so it is given a
+// copy of the player the game client shows.
+func (l *LocalClientConnection) Open(_ string, playerState *d2player.PlayerState) error {
+	serverPlayerState, err := playerState.Copy()
+	if err != nil {
+		return err
+	}
+
+	l.SetPlayerState(serverPlayerState)
+	d2server.Create(l.openNetworkServer, l.difficulty)
 
 	go d2server.Run()
 	d2server.OnClientConnected(l)
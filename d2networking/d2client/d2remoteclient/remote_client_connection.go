@@ -41,7 +41,7 @@ func Create() *RemoteClientConnection {
 
 // Open runs serverListener() in a goroutine to continuously read UDP packets.
 // It also sends a PlayerConnectionRequestPacket packet to the server (see d2netpacket).
-func (r *RemoteClientConnection) Open(connectionString, saveFilePath string) error {
+func (r *RemoteClientConnection) Open(connectionString string, playerState *d2player.PlayerState) error {
 	if !strings.Contains(connectionString, ":") {
 		connectionString += ":6669"
 	}
@@ -65,8 +65,7 @@ func (r *RemoteClientConnection) Open(connectionString, saveFilePath string) err
 
 	log.Printf("Connected to server at %s", r.udpConnection.RemoteAddr().String())
 
-	gameState := d2player.LoadPlayerState(saveFilePath)
-	err = r.SendPacketToServer(d2netpacket.CreatePlayerConnectionRequestPacket(r.GetUniqueID(), gameState))
+	err = r.SendPacketToServer(d2netpacket.CreatePlayerConnectionRequestPacket(r.GetUniqueID(), playerState))
 
 	if err != nil {
 		log.Print("RemoteClientConnection: error sending PlayerConnectionRequestPacket to server.")
@@ -130,6 +129,10 @@ func (r *RemoteClientConnection) SendPacketToServer(packet d2netpacket.NetPacket
 		return err
 	}
 
+	if buff.Len() > d2netpacket.MaxPacketSize {
+		return fmt.Errorf("remoteClientConnection: %v packet of %d bytes is too large to send", packet.PacketType, buff.Len())
+	}
+
 	if _, err = r.udpConnection.Write(buff.Bytes()); err != nil {
 		return err
 	}
@@ -140,7 +143,7 @@ func (r *RemoteClientConnection) SendPacketToServer(packet d2netpacket.NetPacket
 // serverListener runs a while loop, reading from the GameServer's UDP
 // connection.
 func (r *RemoteClientConnection) serverListener() {
-	buffer := make([]byte, 4096)
+	buffer := make([]byte, d2netpacket.MaxPacketSize)
 
 	for r.active {
 		n, _, err := r.udpConnection.ReadFromUDP(buffer)
@@ -153,14 +156,26 @@ func (r *RemoteClientConnection) serverListener() {
 			continue
 		}
 
-		data, packetType, err := r.bytesToJSON(buffer)
+		data, packetType, err := r.bytesToJSON(buffer[:n])
 		if err != nil {
 			log.Println(packetType, err)
+			continue
 		}
 
 		packet, err := r.decodeToPacket(packetType, data)
 		if err != nil {
 			log.Println(packetType, err)
+			continue
+		}
+
+		// the server drops the clients which do not answer its pings, they are
+		// answered right away instead of when the game client gets to them
+		if packet.PacketType == d2netpackettype.Ping {
+			if err := r.SendPacketToServer(d2netpacket.CreatePongPacket(r.uniqueID)); err != nil {
+				log.Printf("RemoteClientConnection: error responding to server ping: %s", err)
+			}
+
+			continue
 		}
 
 		err = r.clientListener.OnPacketReceived(packet)
@@ -262,6 +277,122 @@ func (r *RemoteClientConnection) decodeToPacket(t d2netpackettype.NetPacketType,
 
 		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
 
+	case d2netpackettype.UpdatePlayerStats:
+		var p d2netpacket.UpdatePlayerStatsPacket
+		if err = json.Unmarshal([]byte(data), &p); err != nil {
+			break
+		}
+
+		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
+
+	case d2netpackettype.OperateObject:
+		var p d2netpacket.OperateObjectPacket
+		if err = json.Unmarshal([]byte(data), &p); err != nil {
+			break
+		}
+
+		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
+
+	case d2netpackettype.TravelWaypoint:
+		var p d2netpacket.TravelWaypointPacket
+		if err = json.Unmarshal([]byte(data), &p); err != nil {
+			break
+		}
+
+		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
+
+	case d2netpackettype.ChangeLevel:
+		var p d2netpacket.ChangeLevelPacket
+		if err = json.Unmarshal([]byte(data), &p); err != nil {
+			break
+		}
+
+		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
+	case d2netpackettype.UpdateInventory:
+		var p d2netpacket.UpdateInventoryPacket
+		if err = json.Unmarshal([]byte(data), &p); err != nil {
+			break
+		}
+
+		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
+	case d2netpackettype.DropItem:
+		var p d2netpacket.DropItemPacket
+		if err = json.Unmarshal([]byte(data), &p); err != nil {
+			break
+		}
+
+		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
+	case d2netpackettype.PickUpItem:
+		var p d2netpacket.PickUpItemPacket
+		if err = json.Unmarshal([]byte(data), &p); err != nil {
+			break
+		}
+
+		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
+	case d2netpackettype.SetHostile:
+		var p d2netpacket.SetHostilePacket
+		if err = json.Unmarshal([]byte(data), &p); err != nil {
+			break
+		}
+
+		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
+	case d2netpackettype.InteractNPC:
+		var p d2netpacket.InteractNPCPacket
+		if err = json.Unmarshal([]byte(data), &p); err != nil {
+			break
+		}
+
+		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
+	case d2netpackettype.UpdateVendor:
+		var p d2netpacket.UpdateVendorPacket
+		if err = json.Unmarshal([]byte(data), &p); err != nil {
+			break
+		}
+
+		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
+	case d2netpackettype.TradeRequest:
+		var p d2netpacket.TradeRequestPacket
+		if err = json.Unmarshal([]byte(data), &p); err != nil {
+			break
+		}
+
+		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
+	case d2netpackettype.UpdateTrade:
+		var p d2netpacket.UpdateTradePacket
+		if err = json.Unmarshal([]byte(data), &p); err != nil {
+			break
+		}
+
+		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
+	case d2netpackettype.SpawnNPC:
+		var p d2netpacket.SpawnNPCPacket
+		if err = json.Unmarshal([]byte(data), &p); err != nil {
+			break
+		}
+
+		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
+	case d2netpackettype.MoveNPC:
+		var p d2netpacket.MoveNPCPacket
+		if err = json.Unmarshal([]byte(data), &p); err != nil {
+			break
+		}
+
+		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
+	case d2netpackettype.AttackNPC:
+		var p d2netpacket.AttackNPCPacket
+		if err = json.Unmarshal([]byte(data), &p); err != nil {
+			break
+		}
+
+		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
+	case d2netpackettype.KillNPC:
+		var p d2netpacket.KillNPCPacket
+		if err = json.Unmarshal([]byte(data), &p); err != nil {
+			break
+		}
+
+		np = d2netpacket.NetPacket{PacketType: t, PacketData: p}
+
 	default:
 		err = fmt.Errorf("RemoteClientConnection: unrecognized packet type: %v", t)
 	}
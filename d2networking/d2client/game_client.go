@@ -4,12 +4,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
 
-	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapgen"
-
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
 
@@ -31,15 +31,35 @@ type GameClient struct {
 	MapEngine        *d2mapengine.MapEngine                      // Map and entities
 	PlayerId         string                                      // ID of the local player
 	Players          map[string]*d2mapentity.Player              // IDs of the other players
+	NPCs             map[int]*d2mapentity.NPC                    // Monsters and NPCs on the map, by the IDs the server gave them
+	LevelID          int                                         // Levels.txt ID of the level the local player is in
+	playerLevels     map[string]int                              // Levels.txt IDs of the levels the players entered
 	Seed             int64                                       // Map seed
+	Difficulty       d2enum.DifficultyType                       // Difficulty of the game
 	RegenMap         bool                                        // Regenerate tile cache on render (map has changed)
+	OpenWaypointMenu bool                                        // Open the waypoint menu (local player operated a waypoint)
+	UpdateInventory  bool                                        // Reload the inventory panel (items of the local player have changed)
+	PlayerDied       bool                                        // Tell the local player it died
+	Vendor           *d2inventory.Vendor                         // Items of the NPC the local player trades with
+	UpdateVendor     bool                                        // Show the items of the vendor (they have changed)
+	TradeRequestFrom string                                      // ID of the player which asked the local player to trade
+	TradeRequested   bool                                        // Tell the local player another player asks to trade
+	Trade            *d2player.Trade                             // Trade window of the local player, nil if it does not trade
+	UpdateTrade      bool                                        // Show the trade window (it has changed, or was closed)
+	packets          []d2netpacket.NetPacket                     // Packets received from the server, not handled yet
+	packetsMutex     sync.Mutex                                  // Guards packets, the server sends them from its own goroutines
 }
 
-// Create constructs a new GameClient and returns a pointer to it.
-func Create(connectionType d2clientconnectiontype.ClientConnectionType) (*GameClient, error) {
+// Create constructs a new GameClient and returns a pointer to it. Local
+// clients create a game on the given difficulty, remote clients join a game
+// on the difficulty of the server.
+func Create(connectionType d2clientconnectiontype.ClientConnectionType,
+	difficulty d2enum.DifficultyType) (*GameClient, error) {
 	result := &GameClient{
 		MapEngine:      d2mapengine.CreateMapEngine(), // TODO: Mapgen - Needs levels.txt stuff
 		Players:        make(map[string]*d2mapentity.Player),
+		NPCs:           make(map[int]*d2mapentity.NPC),
+		playerLevels:   make(map[string]int),
 		connectionType: connectionType,
 	}
 
@@ -47,9 +67,9 @@ func Create(connectionType d2clientconnectiontype.ClientConnectionType) (*GameCl
 	case d2clientconnectiontype.LANClient:
 		result.clientConnection = d2remoteclient.Create()
 	case d2clientconnectiontype.LANServer:
-		result.clientConnection = d2localclient.Create(true)
+		result.clientConnection = d2localclient.Create(true, difficulty)
 	case d2clientconnectiontype.Local:
-		result.clientConnection = d2localclient.Create(false)
+		result.clientConnection = d2localclient.Create(false, difficulty)
 	default:
 		return nil, fmt.Errorf("unknown client connection type specified: %d", connectionType)
 	}
@@ -57,11 +77,18 @@ func Create(connectionType d2clientconnectiontype.ClientConnectionType) (*GameCl
 	return result, nil
 }
 
-// Open creates the server and connects to it if the client is local.
-// If the client is remote it sends a PlayerConnectionRequestPacket to the
-// server (see d2netpacket).
+// Open loads the saved player, creates the server and connects to it if the
+// client is local. If the client is remote it sends a
+// PlayerConnectionRequestPacket to the server (see d2netpacket).
 func (g *GameClient) Open(connectionString string, saveFilePath string) error {
-	return g.clientConnection.Open(connectionString, saveFilePath)
+	gameState, err := d2player.LoadPlayerState(saveFilePath)
+	if err != nil {
+		return err
+	}
+
+	g.GameState = gameState
+
+	return g.clientConnection.Open(connectionString, gameState)
 }
 
 // Close destroys the server if the client is local. For remote clients
@@ -75,31 +102,66 @@ func (g *GameClient) Destroy() error {
 	return g.clientConnection.Close()
 }
 
-// OnPacketReceived is called by the ClientConection and processes incoming
-// packets.
+// OnPacketReceived is called by the ClientConection and queues incoming
+// packets. The server and the UDP listener send them from their own
+// goroutines, the packets change the map and the players which are rendered,
+// so they are processed in Advance on the main thread.
 func (g *GameClient) OnPacketReceived(packet d2netpacket.NetPacket) error {
+	g.packetsMutex.Lock()
+	g.packets = append(g.packets, packet)
+	g.packetsMutex.Unlock()
+
+	return nil
+}
+
+// Advance processes the packets received since the last call.
+func (g *GameClient) Advance() {
+	g.packetsMutex.Lock()
+	packets := g.packets
+	g.packets = nil
+	g.packetsMutex.Unlock()
+
+	for _, packet := range packets {
+		if err := g.handlePacket(packet); err != nil {
+			log.Printf("GameClient: error handling %v packet: %s", packet.PacketType, err)
+		}
+	}
+}
+
+// handlePacket processes a packet received from the server.
+func (g *GameClient) handlePacket(packet d2netpacket.NetPacket) error {
 	switch packet.PacketType {
 	case d2netpackettype.GenerateMap:
 		mapData := packet.PacketData.(d2netpacket.GenerateMapPacket)
-		switch mapData.RegionType {
-		case d2enum.RegionAct1Town:
-			d2mapgen.GenerateAct1Overworld(g.MapEngine)
+		if err := g.generateLevel(mapData.LevelID); err != nil {
+			return err
 		}
-		g.RegenMap = true
 	case d2netpackettype.UpdateServerInfo:
 		serverInfo := packet.PacketData.(d2netpacket.UpdateServerInfoPacket)
 		g.MapEngine.SetSeed(serverInfo.Seed)
 		g.PlayerId = serverInfo.PlayerId
 		g.Seed = serverInfo.Seed
+		g.Difficulty = serverInfo.Difficulty
 		log.Printf("Player id set to %s", serverInfo.PlayerId)
 	case d2netpackettype.AddPlayer:
 		player := packet.PacketData.(d2netpacket.AddPlayerPacket)
 		newPlayer := d2mapentity.CreatePlayer(player.Id, player.Name, player.X, player.Y, 0, player.HeroType, player.Stats, player.Equipment)
 		g.Players[newPlayer.Id] = newPlayer
-		g.MapEngine.AddEntity(newPlayer)
+		// a softcore player which died lies dead until it respawns, when its
+		// life is restored
+		if player.Stats.Health <= 0 {
+			newPlayer.Kill()
+		}
+		g.playerLevels[newPlayer.Id] = player.LevelID
+		if g.isOnMap(player.LevelID) {
+			g.MapEngine.AddEntity(newPlayer)
+		}
 	case d2netpackettype.MovePlayer:
 		movePlayer := packet.PacketData.(d2netpacket.MovePlayerPacket)
 		player := g.Players[movePlayer.PlayerId]
+		if player == nil || !g.isOnMap(g.playerLevels[movePlayer.PlayerId]) {
+			break
+		}
 		path, _, _ := g.MapEngine.PathFind(movePlayer.StartX, movePlayer.StartY, movePlayer.DestX, movePlayer.DestY)
 		if len(path) > 0 {
 			player.SetPath(path, func() {
@@ -123,6 +185,9 @@ func (g *GameClient) OnPacketReceived(packet d2netpacket.NetPacket) error {
 	case d2netpackettype.CastSkill:
 		playerCast := packet.PacketData.(d2netpacket.CastPacket)
 		player := g.Players[playerCast.SourceEntityID]
+		if player == nil || !g.isOnMap(g.playerLevels[playerCast.SourceEntityID]) {
+			break
+		}
 		player.SetCasting()
 		player.ClearPath()
 		// currently hardcoded to missile skill
@@ -147,11 +212,164 @@ func (g *GameClient) OnPacketReceived(packet d2netpacket.NetPacket) error {
 		})
 
 		g.MapEngine.AddEntity(missile)
-	case d2netpackettype.Ping:
-		err := g.clientConnection.SendPacketToServer(d2netpacket.CreatePongPacket(g.PlayerId))
+	case d2netpackettype.UpdatePlayerStats:
+		playerStats := packet.PacketData.(d2netpacket.UpdatePlayerStatsPacket)
+		player, ok := g.Players[playerStats.PlayerID]
+		if !ok {
+			break
+		}
+		player.Stats = playerStats.Stats
+		if playerStats.LevelsGained > 0 {
+			log.Printf("%s has reached level %d", player.Name(), player.Stats.Level)
+		}
+
+		if playerStats.PlayerID == g.PlayerId && g.GameState != nil {
+			stats := playerStats.Stats
+			g.GameState.Stats = &stats
+
+			if playerStats.Difficulty > g.GameState.Difficulty {
+				g.GameState.Difficulty = playerStats.Difficulty
+				log.Printf("%s has unlocked %s", player.Name(), playerStats.Difficulty)
+			}
+		}
+
+		if player.Stats.Health <= 0 && !player.IsDead() {
+			player.Kill()
+
+			if playerStats.PlayerID == g.PlayerId && g.GameState != nil {
+				g.GameState.Die()
+				g.PlayerDied = true
+			}
+		} else if player.Stats.Health > 0 && player.IsDead() {
+			// softcore players respawn in town
+			player.Revive()
+		}
+
+		if playerStats.PlayerID == g.PlayerId {
+			g.savePlayer()
+		}
+	case d2netpackettype.OperateObject:
+		operateObject := packet.PacketData.(d2netpacket.OperateObjectPacket)
+		if operateObject.PlayerID == g.PlayerId && operateObject.Waypoint != 0 && g.GameState != nil &&
+			g.GameState.ActivateWaypoint(operateObject.Waypoint) {
+			g.savePlayer()
+		}
+
+		object := g.MapEngine.ObjectAt(operateObject.X, operateObject.Y)
+		if object == nil {
+			break
+		}
+		object.Operate(objectWorld{GameClient: g, playerID: operateObject.PlayerID})
+	case d2netpackettype.TravelWaypoint:
+		travelWaypoint := packet.PacketData.(d2netpacket.TravelWaypointPacket)
+		player, ok := g.Players[travelWaypoint.PlayerID]
+		if !ok {
+			break
+		}
+		player.SetPosition(travelWaypoint.DestX*5, travelWaypoint.DestY*5)
+	case d2netpackettype.ChangeLevel:
+		if err := g.onChangeLevel(packet.PacketData.(d2netpacket.ChangeLevelPacket)); err != nil {
+			return err
+		}
+	case d2netpackettype.SpawnNPC:
+		g.onSpawnNPC(packet.PacketData.(d2netpacket.SpawnNPCPacket))
+	case d2netpackettype.MoveNPC:
+		g.onMoveNPC(packet.PacketData.(d2netpacket.MoveNPCPacket))
+	case d2netpackettype.AttackNPC:
+		g.onAttackNPC(packet.PacketData.(d2netpacket.AttackNPCPacket))
+	case d2netpackettype.KillNPC:
+		g.onKillNPC(packet.PacketData.(d2netpacket.KillNPCPacket))
+	case d2netpackettype.UpdateInventory:
+		playerInventory := packet.PacketData.(d2netpacket.UpdateInventoryPacket)
+		if player, ok := g.Players[playerInventory.PlayerID]; ok {
+			player.Equip(playerInventory.Equipment)
+		}
+
+		if playerInventory.PlayerID == g.PlayerId && g.GameState != nil {
+			inventory := playerInventory.Inventory
+			g.GameState.Inventory = &inventory
+			g.GameState.Equipment = playerInventory.Equipment
+			g.UpdateInventory = true
+			g.savePlayer()
+		}
+	case d2netpackettype.DropItem:
+		dropItem := packet.PacketData.(d2netpacket.DropItemPacket)
+		if dropItem.Item == nil || !g.isOnMap(g.playerLevels[dropItem.PlayerID]) {
+			break
+		}
+
+		item, err := d2mapentity.CreateDroppedItem(dropItem.X, dropItem.Y, dropItem.Item)
 		if err != nil {
-			log.Printf("GameClient: error responding to server ping: %s", err)
+			return err
+		}
+
+		g.MapEngine.AddEntity(item)
+	case d2netpackettype.PickUpItem:
+		pickUpItem := packet.PacketData.(d2netpacket.PickUpItemPacket)
+		if !g.isOnMap(g.playerLevels[pickUpItem.PlayerID]) {
+			break
+		}
+
+		if item := g.MapEngine.ItemAt(pickUpItem.X, pickUpItem.Y); item != nil {
+			g.MapEngine.RemoveEntity(item)
+		}
+	case d2netpackettype.SetHostile:
+		setHostile := packet.PacketData.(d2netpacket.SetHostilePacket)
+		player, ok := g.Players[setHostile.PlayerID]
+		if !ok {
+			break
+		}
+
+		if setHostile.PlayerID == g.PlayerId && g.GameState != nil {
+			g.GameState.Hostile = setHostile.Hostile
+		}
+
+		if setHostile.Hostile {
+			log.Printf("%s is now hostile", player.Name())
+		} else {
+			log.Printf("%s is now peaceful", player.Name())
 		}
+	case d2netpackettype.InteractNPC:
+		interaction := packet.PacketData.(d2netpacket.InteractNPCPacket)
+		if interaction.PlayerID != g.PlayerId || g.GameState == nil {
+			break
+		}
+
+		switch interaction.Service {
+		case d2enum.NPCServiceRepair:
+			log.Printf("Repaired the items for %d gold", interaction.Cost)
+		case d2enum.NPCServiceIdentify:
+			log.Print("Identified the items")
+		}
+	case d2netpackettype.UpdateVendor:
+		updateVendor := packet.PacketData.(d2netpacket.UpdateVendorPacket)
+		if updateVendor.PlayerID != g.PlayerId {
+			break
+		}
+
+		vendor := updateVendor.Vendor
+		g.Vendor = &vendor
+		g.UpdateVendor = true
+	case d2netpackettype.TradeRequest:
+		tradeRequest := packet.PacketData.(d2netpacket.TradeRequestPacket)
+		if tradeRequest.OtherID != g.PlayerId {
+			break
+		}
+
+		g.TradeRequestFrom = tradeRequest.PlayerID
+		g.TradeRequested = true
+	case d2netpackettype.UpdateTrade:
+		updateTrade := packet.PacketData.(d2netpacket.UpdateTradePacket)
+		if updateTrade.PlayerID != g.PlayerId {
+			break
+		}
+
+		if updateTrade.Completed {
+			log.Print("Trade completed")
+		}
+
+		g.Trade = updateTrade.Trade
+		g.UpdateTrade = true
 	case d2netpackettype.PlayerDisconnectionNotification:
 		// Not implemented
 		log.Printf("RemoteClientConnection: received disconnect: %s", packet.PacketData)
@@ -165,6 +383,18 @@ func (g *GameClient) OnPacketReceived(packet d2netpacket.NetPacket) error {
 	return nil
 }
 
+// savePlayer saves the local player when the client is remote, the server
+// saves the players of local clients.
+func (g *GameClient) savePlayer() {
+	if g.connectionType != d2clientconnectiontype.LANClient || g.GameState == nil {
+		return
+	}
+
+	if err := g.GameState.Save(); err != nil {
+		log.Printf("GameClient: error saving the player: %s", err)
+	}
+}
+
 // SendPacketToServer calls server.OnPacketReceived if the client is local.
 // If it is remote the NetPacket sent over a UDP connection to the server.
 func (g *GameClient) SendPacketToServer(packet d2netpacket.NetPacket) error {
@@ -0,0 +1,68 @@
+package d2client
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapgen"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// isOnMap returns true if the level is on the client's copy of the map.
+func (g *GameClient) isOnMap(levelID int) bool {
+	_, ok := g.MapEngine.LevelArea(levelID)
+	return ok
+}
+
+// generateLevel replaces the client's copy of the map with the map of the
+// level the local player is in, along with the players in it. The server
+// sends the NPCs of the map.
+func (g *GameClient) generateLevel(levelID int) error {
+	g.LevelID = levelID
+	g.MapEngine.SetSeed(d2mapgen.LevelSeed(g.Seed, levelID))
+
+	if err := d2mapgen.GenerateLevel(g.MapEngine, levelID, g.Difficulty); err != nil {
+		return err
+	}
+
+	g.removeNPCs()
+
+	for id, player := range g.Players {
+		if g.isOnMap(g.playerLevels[id]) {
+			g.MapEngine.AddEntity(player)
+		}
+	}
+
+	g.RegenMap = true
+
+	return nil
+}
+
+// onChangeLevel moves a player which used a warp to the level it leads to.
+// When the local player changes the level, the map of the level is
+// generated, otherwise the player is taken off or put on the map.
+func (g *GameClient) onChangeLevel(changeLevel d2netpacket.ChangeLevelPacket) error {
+	player, ok := g.Players[changeLevel.PlayerID]
+	if !ok {
+		return nil
+	}
+
+	wasOnMap := g.isOnMap(g.playerLevels[changeLevel.PlayerID])
+	g.playerLevels[changeLevel.PlayerID] = changeLevel.LevelID
+	player.SetPosition(changeLevel.X*5, changeLevel.Y*5)
+
+	if changeLevel.PlayerID == g.PlayerId {
+		if g.isOnMap(changeLevel.LevelID) {
+			g.LevelID = changeLevel.LevelID
+			return nil
+		}
+
+		return g.generateLevel(changeLevel.LevelID)
+	}
+
+	switch onMap := g.isOnMap(changeLevel.LevelID); {
+	case wasOnMap && !onMap:
+		g.MapEngine.RemoveEntity(player)
+	case !wasOnMap && onMap:
+		g.MapEngine.AddEntity(player)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package d2client
+
+import (
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// removeNPCs takes the NPCs the presets of a generated map put on it off
+// the map, the server sends its own NPCs instead.
+func (g *GameClient) removeNPCs() {
+	entities := *g.MapEngine.Entities()
+
+	for _, entity := range entities {
+		if _, ok := entity.(*d2mapentity.NPC); ok {
+			g.MapEngine.RemoveEntity(entity)
+		}
+	}
+
+	g.NPCs = make(map[int]*d2mapentity.NPC)
+}
+
+// onSpawnNPC puts an NPC sent by the server on the map.
+func (g *GameClient) onSpawnNPC(spawn d2netpacket.SpawnNPCPacket) {
+	record, ok := d2datadict.MonStats[spawn.MonStats]
+	if !ok {
+		log.Printf("GameClient: unknown NPC %s spawned", spawn.MonStats)
+		return
+	}
+
+	npc := d2mapentity.CreateNPC(spawn.X, spawn.Y, record, spawn.Direction)
+	npc.SetPaths(spawn.Paths)
+
+	if superUnique, ok := d2datadict.SuperUniques[spawn.SuperUnique]; ok {
+		npc.SetSuperUnique(superUnique)
+	}
+
+	if spawn.Dead {
+		npc.Kill()
+	}
+
+	if old, ok := g.NPCs[spawn.ID]; ok {
+		g.MapEngine.RemoveEntity(old)
+	}
+
+	g.NPCs[spawn.ID] = npc
+	g.MapEngine.AddEntity(npc)
+}
+
+// onMoveNPC paths an NPC from where it is on the server to where the server
+// moves it, the way the monster AI of the server paths it.
+func (g *GameClient) onMoveNPC(move d2netpacket.MoveNPCPacket) {
+	npc, ok := g.NPCs[move.ID]
+	if !ok || npc.IsDead() {
+		return
+	}
+
+	npc.SetPosition(move.StartX*5, move.StartY*5)
+
+	collision := d2enum.CollisionWalk
+	if npc.MonStatsRecord().IsFlying {
+		collision = d2enum.CollisionFly
+	}
+
+	path, _, found := g.MapEngine.PathFindAs(collision, move.StartX, move.StartY, move.DestX, move.DestY)
+	if found && len(path) > 0 {
+		npc.SetPath(path, nil)
+	}
+}
+
+// onAttackNPC stops an NPC and plays its attack.
+func (g *GameClient) onAttackNPC(attack d2netpacket.AttackNPCPacket) {
+	npc, ok := g.NPCs[attack.ID]
+	if !ok || npc.IsDead() {
+		return
+	}
+
+	npc.ClearPath()
+
+	if err := npc.SetAnimationMode(attack.Mode); err != nil {
+		log.Printf("GameClient: error playing the attack of NPC %d: %s", attack.ID, err)
+	}
+}
+
+// onKillNPC plays the death of an NPC, which stays on the map as a corpse.
+func (g *GameClient) onKillNPC(kill d2netpacket.KillNPCPacket) {
+	if npc, ok := g.NPCs[kill.ID]; ok {
+		npc.Kill()
+	}
+}
@@ -0,0 +1,55 @@
+package d2client
+
+import (
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2object"
+)
+
+// objectWorld is what an object operated by a player affects on the
+// client. Changes to the stats of players are left to the server, which
+// sends them separately.
+type objectWorld struct {
+	*GameClient
+	playerID string
+}
+
+// Seed returns the map seed.
+func (w objectWorld) Seed() int64 {
+	return w.MapEngine.Seed()
+}
+
+// SetAreaCollision changes the collision of the client's walk mesh.
+func (w objectWorld) SetAreaCollision(area d2common.Rectangle, collision d2enum.Collision) {
+	w.MapEngine.SetAreaCollision(area, collision)
+}
+
+// DropItem puts the item on the client's copy of the map.
+func (w objectWorld) DropItem(subTileX, subTileY int, item *d2datadict.ItemCommonRecord) {
+	entity, err := d2mapentity.CreateItem(subTileX, subTileY, item)
+	if err != nil {
+		log.Printf("GameClient: error dropping item %s: %s", item.Code, err)
+		return
+	}
+
+	w.MapEngine.AddEntity(entity)
+}
+
+// GrantEffect does nothing, the server sends the new stats of the player.
+func (w objectWorld) GrantEffect(d2hero.EffectType, float64) {}
+
+// Restore does nothing, the server sends the new stats of the player.
+func (w objectWorld) Restore(bool, bool) {}
+
+// OpenWaypoints opens the waypoint menu if the local player operated the
+// waypoint.
+func (w objectWorld) OpenWaypoints(waypoint *d2object.Object) {
+	if w.playerID == w.PlayerId {
+		w.OpenWaypointMenu = true
+	}
+}
@@ -1,6 +1,7 @@
 package d2client
 
 import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2game/d2player"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
 )
@@ -8,7 +9,7 @@ import (
 // ServerConnection is an interface for abstracting local and
 // remote server connections.
 type ServerConnection interface {
-	Open(connectionString string, saveFilePath string) error
+	Open(connectionString string, playerState *d2player.PlayerState) error
 	Close() error
 	SendPacketToServer(packet d2netpacket.NetPacket) error
 	SetClientListener(listener d2networking.ClientListener)
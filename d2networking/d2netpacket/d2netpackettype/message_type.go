@@ -25,6 +25,31 @@ const (
 	Pong                                                 // Responds to a Ping packet
 	ServerClosed                                         // Sent by the local host when it has closed the server
 	CastSkill                                            // Sent by client or server, indicates entity casting skill
+	UpdatePlayerStats                                    // Sent by the server, client updates the stats of a player
+	AllocateStatPoint                                    // Sent by the client, spends a stat point of the player
+	OperateObject                                        // Sent by client or server, operates an object on the map
+	TravelWaypoint                                       // Sent by client or server, moves a player to a waypoint
+	ChangeLevel                                          // Sent by client or server, moves a player through a warp to another level
+	MoveItem                                             // Sent by the client, moves an item of the player
+	UpdateInventory                                      // Sent by the server, client updates the items of a player
+	UseItem                                              // Sent by the client, uses an item of the player
+	DropItem                                             // Sent by client or server, drops an item of a player on the ground
+	PickUpItem                                           // Sent by client or server, picks an item up from the ground
+	SetHostile                                           // Sent by client or server, makes a hardcore player hostile or peaceful
+	InteractNPC                                          // Sent by client or server, a player repairs, identifies, hires or trades at an NPC
+	UpdateVendor                                         // Sent by the server, client shows the items an NPC sells
+	BuyItem                                              // Sent by the client, buys an item of an NPC
+	SellItem                                             // Sent by the client, sells an item of the player to an NPC
+	StashGold                                            // Sent by the client, moves gold of the player to or from its stash
+	TradeRequest                                         // Sent by client or server, a player asks another to trade, or agrees to
+	TradeAction                                          // Sent by the client, offers items or gold, accepts, confirms or cancels a trade
+	UpdateTrade                                          // Sent by the server, client shows the trade window it shares with another player
+	TransmuteCube                                        // Sent by the client, transmutes the items in the Horadric Cube of the player
+	SocketItem                                           // Sent by the client, puts a gem, rune or jewel into a socket of an item
+	SpawnNPC                                             // Sent by the server, client adds a monster or an NPC to its map
+	MoveNPC                                              // Sent by the server, client moves a monster or an NPC
+	AttackNPC                                            // Sent by the server, client shows a monster attacking
+	KillNPC                                              // Sent by the server, client shows a monster dying
 )
 
 func (n NetPacketType) String() string {
@@ -39,6 +64,31 @@ func (n NetPacketType) String() string {
 		Pong:                            "Pong",
 		ServerClosed:                    "ServerClosed",
 		CastSkill:                       "CastSkill",
+		UpdatePlayerStats:               "UpdatePlayerStats",
+		AllocateStatPoint:               "AllocateStatPoint",
+		OperateObject:                   "OperateObject",
+		TravelWaypoint:                  "TravelWaypoint",
+		ChangeLevel:                     "ChangeLevel",
+		MoveItem:                        "MoveItem",
+		UpdateInventory:                 "UpdateInventory",
+		UseItem:                         "UseItem",
+		DropItem:                        "DropItem",
+		PickUpItem:                      "PickUpItem",
+		SetHostile:                      "SetHostile",
+		InteractNPC:                     "InteractNPC",
+		UpdateVendor:                    "UpdateVendor",
+		BuyItem:                         "BuyItem",
+		SellItem:                        "SellItem",
+		StashGold:                       "StashGold",
+		TradeRequest:                    "TradeRequest",
+		TradeAction:                     "TradeAction",
+		UpdateTrade:                     "UpdateTrade",
+		TransmuteCube:                   "TransmuteCube",
+		SocketItem:                      "SocketItem",
+		SpawnNPC:                        "SpawnNPC",
+		MoveNPC:                         "MoveNPC",
+		AttackNPC:                       "AttackNPC",
+		KillNPC:                         "KillNPC",
 	}
 
 	return strings[n]
@@ -2,6 +2,10 @@ package d2netpacket
 
 import "github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 
+// MaxPacketSize is the largest compressed packet that fits in a UDP datagram.
+// Larger packets are not sent, rather than being truncated on their way.
+const MaxPacketSize = 65507
+
 // NetPacket is used to wrap and send all packet types under d2netpacket.
 // When decoding a packet: First the PacketType byte is read, then the
 // PacketData is unmarshalled to a struct of the type associated with
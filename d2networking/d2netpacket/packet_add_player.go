@@ -15,6 +15,7 @@ type AddPlayerPacket struct {
 	Name      string                         `json:"name"`
 	X         int                            `json:"x"`
 	Y         int                            `json:"y"`
+	LevelID   int                            `json:"levelId"`
 	HeroType  d2enum.Hero                    `json:"hero"`
 	Equipment d2inventory.CharacterEquipment `json:"equipment"`
 	Stats     d2hero.HeroStatsState          `json:"heroStats"`
@@ -22,7 +23,7 @@ type AddPlayerPacket struct {
 
 // CreateAddPlayerPacket returns a NetPacket which declares an
 // AddPlayerPacket with the data in given parameters.
-func CreateAddPlayerPacket(id, name string, x, y int, heroType d2enum.Hero, stats d2hero.HeroStatsState, equipment d2inventory.CharacterEquipment) NetPacket {
+func CreateAddPlayerPacket(id, name string, x, y, levelID int, heroType d2enum.Hero, stats d2hero.HeroStatsState, equipment d2inventory.CharacterEquipment) NetPacket {
 	return NetPacket{
 		PacketType: d2netpackettype.AddPlayer,
 		PacketData: AddPlayerPacket{
@@ -30,6 +31,7 @@ func CreateAddPlayerPacket(id, name string, x, y int, heroType d2enum.Hero, stat
 			Name:      name,
 			X:         x,
 			Y:         y,
+			LevelID:   levelID,
 			HeroType:  heroType,
 			Equipment: equipment,
 			Stats:     stats,
@@ -0,0 +1,28 @@
+package d2netpacket
+
+import "github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+
+// ChangeLevelPacket contains the Levels.txt ID of the level a player enters
+// through a warp. It is sent by the client to request entering the level
+// and by the server to all clients, with the position the player arrives
+// at, once it did.
+type ChangeLevelPacket struct {
+	PlayerID string  `json:"playerId"`
+	LevelID  int     `json:"levelId"`
+	X        float64 `json:"x"` // in tiles, set by the server
+	Y        float64 `json:"y"`
+}
+
+// CreateChangeLevelPacket returns a NetPacket which declares a
+// ChangeLevelPacket to the given level.
+func CreateChangeLevelPacket(playerID string, levelID int, x, y float64) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.ChangeLevel,
+		PacketData: ChangeLevelPacket{
+			PlayerID: playerID,
+			LevelID:  levelID,
+			X:        x,
+			Y:        y,
+		},
+	}
+}
@@ -1,24 +1,23 @@
 package d2netpacket
 
 import (
-	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 )
 
-// GenerateMapPacket contains an enumerable representing a region. It
-// is sent by the server to generate the map for the given region on
-// a client.
+// GenerateMapPacket contains the Levels.txt ID of a level. It is sent by
+// the server to generate the map of the level the player is in on a
+// client.
 type GenerateMapPacket struct {
-	RegionType d2enum.RegionIdType `json:"regionType"`
+	LevelID int `json:"levelId"`
 }
 
 // CreateGenerateMapPacket returns a NetPacket which declares a
-// GenerateMapPacket with the given regionType.
-func CreateGenerateMapPacket(regionType d2enum.RegionIdType) NetPacket {
+// GenerateMapPacket with the given levelID.
+func CreateGenerateMapPacket(levelID int) NetPacket {
 	return NetPacket{
 		PacketType: d2netpackettype.GenerateMap,
 		PacketData: GenerateMapPacket{
-			RegionType: regionType,
+			LevelID: levelID,
 		},
 	}
 
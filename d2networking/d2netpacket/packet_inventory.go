@@ -0,0 +1,136 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// MoveItemPacket contains a request of a player to move one of its items to
+// another location, e.g. to equip it. It is sent by the client.
+type MoveItemPacket struct {
+	PlayerID string                   `json:"playerId"`
+	ItemID   int                      `json:"itemId"`
+	To       d2inventory.ItemLocation `json:"to"`
+}
+
+// CreateMoveItemPacket returns a NetPacket which declares a MoveItemPacket
+// for the given item.
+func CreateMoveItemPacket(playerID string, itemID int, to d2inventory.ItemLocation) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.MoveItem,
+		PacketData: MoveItemPacket{
+			PlayerID: playerID,
+			ItemID:   itemID,
+			To:       to,
+		},
+	}
+}
+
+// UpdateInventoryPacket contains the authoritative items of a player. It is
+// sent by the server whenever the player moved an item, or tried to.
+type UpdateInventoryPacket struct {
+	PlayerID  string                         `json:"playerId"`
+	Inventory d2inventory.PlayerInventory    `json:"inventory"`
+	Equipment d2inventory.CharacterEquipment `json:"equipment"`
+}
+
+// CreateUpdateInventoryPacket returns a NetPacket which declares an
+// UpdateInventoryPacket with the given items.
+func CreateUpdateInventoryPacket(playerID string, inventory d2inventory.PlayerInventory,
+	equipment d2inventory.CharacterEquipment) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.UpdateInventory,
+		PacketData: UpdateInventoryPacket{
+			PlayerID:  playerID,
+			Inventory: inventory,
+			Equipment: equipment,
+		},
+	}
+}
+
+// UseItemPacket contains a request of a player to use one of its items,
+// e.g. to drink a potion. It is sent by the client.
+type UseItemPacket struct {
+	PlayerID string `json:"playerId"`
+	ItemID   int    `json:"itemId"`
+}
+
+// CreateUseItemPacket returns a NetPacket which declares a UseItemPacket for
+// the given item.
+func CreateUseItemPacket(playerID string, itemID int) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.UseItem,
+		PacketData: UseItemPacket{
+			PlayerID: playerID,
+			ItemID:   itemID,
+		},
+	}
+}
+
+// DropItemPacket contains an item a player drops on the ground. The client
+// sends the ID of the item, the server sends the dropped item and where it
+// lies.
+type DropItemPacket struct {
+	PlayerID string            `json:"playerId"`
+	ItemID   int               `json:"itemId"`
+	Item     *d2inventory.Item `json:"item,omitempty"`
+	X        int               `json:"x"` // in sub-tiles
+	Y        int               `json:"y"`
+}
+
+// CreateDropItemPacket returns a NetPacket which declares a DropItemPacket
+// for the given item.
+func CreateDropItemPacket(playerID string, itemID int, item *d2inventory.Item, x, y int) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.DropItem,
+		PacketData: DropItemPacket{
+			PlayerID: playerID,
+			ItemID:   itemID,
+			Item:     item,
+			X:        x,
+			Y:        y,
+		},
+	}
+}
+
+// PickUpItemPacket contains the item on the ground a player picks up. It is
+// sent by the client, and by the server once the item was picked up.
+type PickUpItemPacket struct {
+	PlayerID string `json:"playerId"`
+	X        int    `json:"x"` // in sub-tiles
+	Y        int    `json:"y"`
+}
+
+// CreatePickUpItemPacket returns a NetPacket which declares a
+// PickUpItemPacket for the item at the given sub-tile position.
+func CreatePickUpItemPacket(playerID string, x, y int) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.PickUpItem,
+		PacketData: PickUpItemPacket{
+			PlayerID: playerID,
+			X:        x,
+			Y:        y,
+		},
+	}
+}
+
+// SocketItemPacket contains a request of a player to put a gem, rune or
+// jewel into a socket of one of its items. It is sent by the client.
+type SocketItemPacket struct {
+	PlayerID     string `json:"playerId"`
+	ItemID       int    `json:"itemId"`
+	SocketableID int    `json:"socketableId"`
+}
+
+// CreateSocketItemPacket returns a NetPacket which declares a
+// SocketItemPacket to put the socketable item into the item.
+func CreateSocketItemPacket(playerID string, itemID, socketableID int) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.SocketItem,
+		PacketData: SocketItemPacket{
+			PlayerID:     playerID,
+			ItemID:       itemID,
+			SocketableID: socketableID,
+		},
+	}
+}
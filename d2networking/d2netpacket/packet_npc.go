@@ -0,0 +1,103 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// InteractNPCPacket contains a service a player asks of a town NPC, e.g. to
+// repair its items. It is sent by the client, and by the server to the
+// player once the NPC did it, with the gold it cost.
+type InteractNPCPacket struct {
+	PlayerID string            `json:"playerId"`
+	NPC      string            `json:"npc"` // the Id of the NPC in monstats.txt
+	Service  d2enum.NPCService `json:"service"`
+	Cost     int               `json:"cost"` // set by the server
+}
+
+// CreateInteractNPCPacket returns a NetPacket which declares an
+// InteractNPCPacket for the given NPC and service.
+func CreateInteractNPCPacket(playerID, npc string, service d2enum.NPCService, cost int) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.InteractNPC,
+		PacketData: InteractNPCPacket{
+			PlayerID: playerID,
+			NPC:      npc,
+			Service:  service,
+			Cost:     cost,
+		},
+	}
+}
+
+// UpdateVendorPacket contains the items an NPC sells. It is sent by the
+// server to the player trading with the NPC, whenever its items changed.
+type UpdateVendorPacket struct {
+	PlayerID string             `json:"playerId"`
+	Vendor   d2inventory.Vendor `json:"vendor"`
+}
+
+// CreateUpdateVendorPacket returns a NetPacket which declares an
+// UpdateVendorPacket with the given items.
+func CreateUpdateVendorPacket(playerID string, vendor d2inventory.Vendor) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.UpdateVendor,
+		PacketData: UpdateVendorPacket{
+			PlayerID: playerID,
+			Vendor:   vendor,
+		},
+	}
+}
+
+// TradeItemPacket contains an item a player buys from an NPC, or sells to
+// it. It is sent by the client, as a BuyItem or a SellItem packet.
+type TradeItemPacket struct {
+	PlayerID string `json:"playerId"`
+	NPC      string `json:"npc"`    // the Id of the NPC in monstats.txt
+	ItemID   int    `json:"itemId"` // of the NPC when buying, of the player when selling
+}
+
+// CreateBuyItemPacket returns a NetPacket which declares a TradeItemPacket
+// to buy the given item of the NPC.
+func CreateBuyItemPacket(playerID, npc string, itemID int) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.BuyItem,
+		PacketData: TradeItemPacket{
+			PlayerID: playerID,
+			NPC:      npc,
+			ItemID:   itemID,
+		},
+	}
+}
+
+// CreateSellItemPacket returns a NetPacket which declares a TradeItemPacket
+// to sell the given item of the player to the NPC.
+func CreateSellItemPacket(playerID, npc string, itemID int) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.SellItem,
+		PacketData: TradeItemPacket{
+			PlayerID: playerID,
+			NPC:      npc,
+			ItemID:   itemID,
+		},
+	}
+}
+
+// StashGoldPacket contains the gold a player moves to its stash, or
+// withdraws from it when the amount is negative. It is sent by the client.
+type StashGoldPacket struct {
+	PlayerID string `json:"playerId"`
+	Amount   int    `json:"amount"`
+}
+
+// CreateStashGoldPacket returns a NetPacket which declares a StashGoldPacket
+// for the given amount of gold.
+func CreateStashGoldPacket(playerID string, amount int) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.StashGold,
+		PacketData: StashGoldPacket{
+			PlayerID: playerID,
+			Amount:   amount,
+		},
+	}
+}
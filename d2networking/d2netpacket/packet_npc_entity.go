@@ -0,0 +1,99 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// SpawnNPCPacket contains a monster or an NPC of the map of a player. It is
+// sent by the server, the clients do not populate their maps themselves.
+type SpawnNPCPacket struct {
+	ID          int             `json:"id"`          // given by the server, unique in the game world
+	MonStats    string          `json:"monStats"`    // the Id of the NPC in monstats.txt
+	SuperUnique string          `json:"superUnique"` // the Key in superuniques.txt, if it is one
+	X           int             `json:"x"`           // in sub-tiles
+	Y           int             `json:"y"`
+	Direction   int             `json:"direction"`
+	Paths       []d2common.Path `json:"paths"` // the paths a town NPC walks
+	Dead        bool            `json:"dead"`
+}
+
+// CreateSpawnNPCPacket returns a NetPacket which declares a SpawnNPCPacket
+// for the given NPC.
+func CreateSpawnNPCPacket(id int, monStats, superUnique string, x, y, direction int, paths []d2common.Path,
+	dead bool) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.SpawnNPC,
+		PacketData: SpawnNPCPacket{
+			ID:          id,
+			MonStats:    monStats,
+			SuperUnique: superUnique,
+			X:           x,
+			Y:           y,
+			Direction:   direction,
+			Paths:       paths,
+			Dead:        dead,
+		},
+	}
+}
+
+// MoveNPCPacket contains the position a monster starts moving to. It is sent
+// by the server, the clients path the monster there themselves.
+type MoveNPCPacket struct {
+	ID     int     `json:"id"`
+	StartX float64 `json:"startX"` // in tiles
+	StartY float64 `json:"startY"`
+	DestX  float64 `json:"destX"` // in tiles
+	DestY  float64 `json:"destY"`
+}
+
+// CreateMoveNPCPacket returns a NetPacket which declares a MoveNPCPacket for
+// the given NPC.
+func CreateMoveNPCPacket(id int, startX, startY, destX, destY float64) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.MoveNPC,
+		PacketData: MoveNPCPacket{
+			ID:     id,
+			StartX: startX,
+			StartY: startY,
+			DestX:  destX,
+			DestY:  destY,
+		},
+	}
+}
+
+// AttackNPCPacket contains the animation mode of an attack of a monster. It
+// is sent by the server when the monster attacks.
+type AttackNPCPacket struct {
+	ID   int                         `json:"id"`
+	Mode d2enum.MonsterAnimationMode `json:"mode"`
+}
+
+// CreateAttackNPCPacket returns a NetPacket which declares an AttackNPCPacket
+// for the given NPC.
+func CreateAttackNPCPacket(id int, mode d2enum.MonsterAnimationMode) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.AttackNPC,
+		PacketData: AttackNPCPacket{
+			ID:   id,
+			Mode: mode,
+		},
+	}
+}
+
+// KillNPCPacket contains a monster which died. It is sent by the server.
+type KillNPCPacket struct {
+	ID int `json:"id"`
+}
+
+// CreateKillNPCPacket returns a NetPacket which declares a KillNPCPacket for
+// the given NPC.
+func CreateKillNPCPacket(id int) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.KillNPC,
+		PacketData: KillNPCPacket{
+			ID: id,
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package d2netpacket
+
+import "github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+
+// OperateObjectPacket contains the position of an object operated by a
+// player, e.g. a chest it opens. It is sent by the client to request
+// operating the object and by the server to all clients once it did.
+type OperateObjectPacket struct {
+	PlayerID string `json:"playerId"`
+	X        int    `json:"x"` // in sub-tiles
+	Y        int    `json:"y"`
+	Waypoint int    `json:"waypoint"` // the Levels.txt ID of the level of an operated waypoint, set by the server
+}
+
+// CreateOperateObjectPacket returns a NetPacket which declares an
+// OperateObjectPacket for the object at the given sub-tile position.
+func CreateOperateObjectPacket(playerID string, x, y, waypoint int) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.OperateObject,
+		PacketData: OperateObjectPacket{
+			PlayerID: playerID,
+			X:        x,
+			Y:        y,
+			Waypoint: waypoint,
+		},
+	}
+}
+
+// TravelWaypointPacket contains the waypoint a player travels to. It is
+// sent by the client to request travelling and by the server to all
+// clients, with the position the player arrives at, once it did.
+type TravelWaypointPacket struct {
+	PlayerID  string  `json:"playerId"`
+	WaypointX int     `json:"waypointX"` // in sub-tiles
+	WaypointY int     `json:"waypointY"`
+	DestX     float64 `json:"destX"` // in tiles, set by the server
+	DestY     float64 `json:"destY"`
+}
+
+// CreateTravelWaypointPacket returns a NetPacket which declares a
+// TravelWaypointPacket to the waypoint at the given sub-tile position.
+func CreateTravelWaypointPacket(playerID string, waypointX, waypointY int, destX, destY float64) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.TravelWaypoint,
+		PacketData: TravelWaypointPacket{
+			PlayerID:  playerID,
+			WaypointX: waypointX,
+			WaypointY: waypointY,
+			DestX:     destX,
+			DestY:     destY,
+		},
+	}
+}
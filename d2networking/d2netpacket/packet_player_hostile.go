@@ -0,0 +1,25 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// SetHostilePacket contains whether a player may attack the other hostile
+// players. It is sent by the client, and by the server once the hardcore
+// player became hostile or peaceful.
+type SetHostilePacket struct {
+	PlayerID string `json:"playerId"`
+	Hostile  bool   `json:"hostile"`
+}
+
+// CreateSetHostilePacket returns a NetPacket which declares a
+// SetHostilePacket for the given player.
+func CreateSetHostilePacket(playerID string, hostile bool) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.SetHostile,
+		PacketData: SetHostilePacket{
+			PlayerID: playerID,
+			Hostile:  hostile,
+		},
+	}
+}
@@ -0,0 +1,52 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// UpdatePlayerStatsPacket contains the authoritative stats of a player. It
+// is sent by the server whenever the stats change, e.g. when the player
+// gains experience, levels up or spends stat points, along with the hardest
+// difficulty the player has unlocked.
+type UpdatePlayerStatsPacket struct {
+	PlayerID     string                `json:"playerId"`
+	Stats        d2hero.HeroStatsState `json:"heroStats"`
+	LevelsGained int                   `json:"levelsGained"`
+	Difficulty   d2enum.DifficultyType `json:"difficulty"`
+}
+
+// CreateUpdatePlayerStatsPacket returns a NetPacket which declares an
+// UpdatePlayerStatsPacket with the given stats.
+func CreateUpdatePlayerStatsPacket(playerID string, stats d2hero.HeroStatsState, levelsGained int,
+	difficulty d2enum.DifficultyType) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.UpdatePlayerStats,
+		PacketData: UpdatePlayerStatsPacket{
+			PlayerID:     playerID,
+			Stats:        stats,
+			LevelsGained: levelsGained,
+			Difficulty:   difficulty,
+		},
+	}
+}
+
+// AllocateStatPointPacket contains a request of a player to spend one of
+// its stat points. It is sent by the client.
+type AllocateStatPointPacket struct {
+	PlayerID string          `json:"playerId"`
+	Stat     d2hero.StatType `json:"stat"`
+}
+
+// CreateAllocateStatPointPacket returns a NetPacket which declares an
+// AllocateStatPointPacket for the given stat.
+func CreateAllocateStatPointPacket(playerID string, stat d2hero.StatType) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.AllocateStatPoint,
+		PacketData: AllocateStatPointPacket{
+			PlayerID: playerID,
+			Stat:     stat,
+		},
+	}
+}
@@ -0,0 +1,77 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2game/d2player"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// TradeRequestPacket contains the player another player asks to trade with,
+// or agrees to trade with. It is sent by the client, and by the server to
+// the player asked, with the ID of the player which asks.
+type TradeRequestPacket struct {
+	PlayerID string `json:"playerId"`
+	OtherID  string `json:"otherId"` // the player asked, or the player which asked when Accept is set
+	Accept   bool   `json:"accept"`
+}
+
+// CreateTradeRequestPacket returns a NetPacket which declares a
+// TradeRequestPacket to trade with the other player.
+func CreateTradeRequestPacket(playerID, otherID string, accept bool) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.TradeRequest,
+		PacketData: TradeRequestPacket{
+			PlayerID: playerID,
+			OtherID:  otherID,
+			Accept:   accept,
+		},
+	}
+}
+
+// TradeActionPacket contains what a player does in the trade window: offer
+// or withdraw an item, offer gold, accept or confirm a revision of the
+// offers, or cancel the trade. It is sent by the client.
+type TradeActionPacket struct {
+	PlayerID string             `json:"playerId"`
+	Action   d2enum.TradeAction `json:"action"`
+	ItemID   int                `json:"itemId"`
+	Gold     int                `json:"gold"`
+	Revision int                `json:"revision"` // of the offers the player accepts or confirms
+}
+
+// CreateTradeActionPacket returns a NetPacket which declares a
+// TradeActionPacket for the given action.
+func CreateTradeActionPacket(playerID string, action d2enum.TradeAction, itemID, gold, revision int) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.TradeAction,
+		PacketData: TradeActionPacket{
+			PlayerID: playerID,
+			Action:   action,
+			ItemID:   itemID,
+			Gold:     gold,
+			Revision: revision,
+		},
+	}
+}
+
+// UpdateTradePacket contains the trade window a player shares with another
+// player. It is sent by the server to both players whenever the trade
+// changed, without a trade once it completed or was cancelled.
+type UpdateTradePacket struct {
+	PlayerID  string          `json:"playerId"`
+	Trade     *d2player.Trade `json:"trade,omitempty"`
+	Completed bool            `json:"completed"`
+}
+
+// CreateUpdateTradePacket returns a NetPacket which declares an
+// UpdateTradePacket with the given trade, nil if it is closed.
+func CreateUpdateTradePacket(playerID string, trade *d2player.Trade, completed bool) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.UpdateTrade,
+		PacketData: UpdateTradePacket{
+			PlayerID:  playerID,
+			Trade:     trade,
+			Completed: completed,
+		},
+	}
+}
@@ -0,0 +1,20 @@
+package d2netpacket
+
+import "github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+
+// TransmuteCubePacket contains the player which transmutes the items in its
+// Horadric Cube. It is sent by the client.
+type TransmuteCubePacket struct {
+	PlayerID string `json:"playerId"`
+}
+
+// CreateTransmuteCubePacket returns a NetPacket which declares a
+// TransmuteCubePacket for the player.
+func CreateTransmuteCubePacket(playerID string) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.TransmuteCube,
+		PacketData: TransmuteCubePacket{
+			PlayerID: playerID,
+		},
+	}
+}
@@ -48,26 +48,39 @@ func (c *ConnectionManager) Run() {
 
 // checkPeers manages connection validation and cleanup for all peers.
 func (c *ConnectionManager) checkPeers() {
+	dropped := make([]string, 0)
+
+	c.gameServer.RLock()
+	c.Lock()
+
 	for id, connection := range c.gameServer.clientConnections {
 		if connection.GetConnectionType() != d2clientconnectiontype.Local {
 			if err := connection.SendPacketToClient(d2netpacket.CreatePingPacket()); err != nil {
 				log.Printf("Cannot ping client id: %s", id)
 			}
-			c.RWMutex.Lock()
 			c.status[id] += 1
 
 			if c.status[id] >= c.retries {
 				delete(c.status, id)
-				c.Drop(id)
+				dropped = append(dropped, id)
 			}
-
-			c.RWMutex.Unlock()
 		}
 	}
+
+	c.Unlock()
+	c.gameServer.RUnlock()
+
+	// the connections are dropped once the game server is not locked for
+	// reading anymore
+	for _, id := range dropped {
+		c.Drop(id)
+	}
 }
 
 // Recv simply resets the counter, acknowledging we have received a pong from the client.
 func (c *ConnectionManager) Recv(id string) {
+	c.Lock()
+	defer c.Unlock()
 	c.status[id] = 0
 }
 
@@ -84,11 +97,13 @@ func (c *ConnectionManager) Shutdown() {
 	// TODO: Currently this will never actually get called as the go routines are never signaled about the application termination.
 	// Things can be done more cleanly once we have graceful exits however we still need to account for other OS Signals
 	log.Print("Notifying clients server is shutting down...")
+	c.gameServer.RLock()
 	for _, connection := range c.gameServer.clientConnections {
 		err := connection.SendPacketToClient(d2netpacket.CreateServerClosedPacket())
 		if err != nil {
 			log.Printf("ConnectionManager: error sending ServerClosedPacket to client ID %s: %s", connection.GetUniqueId(), err)
 		}
 	}
+	c.gameServer.RUnlock()
 	Stop()
 }
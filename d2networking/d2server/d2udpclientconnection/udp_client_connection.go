@@ -43,6 +43,11 @@ func (u UDPClientConnection) GetUniqueId() string {
 	return u.id
 }
 
+// GetAddress returns UDPClientConnection.address
+func (u UDPClientConnection) GetAddress() *net.UDPAddr {
+	return u.address
+}
+
 // GetConnectionType returns an enum representing the connection type.
 // See: d2clientconnectiontype.
 func (u UDPClientConnection) GetConnectionType() d2clientconnectiontype.ClientConnectionType {
@@ -68,6 +73,9 @@ func (u *UDPClientConnection) SendPacketToClient(packet d2netpacket.NetPacket) e
 	if err = writer.Close(); err != nil {
 		return err
 	}
+	if buff.Len() > d2netpacket.MaxPacketSize {
+		return fmt.Errorf("UDPClientConnection: %v packet of %d bytes is too large to send", packet.PacketType, buff.Len())
+	}
 	if _, err = u.udpConnection.WriteToUDP(buff.Bytes(), u.address); err != nil {
 		return err
 	}
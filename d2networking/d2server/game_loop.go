@@ -0,0 +1,454 @@
+package d2server
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2ai"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2combat"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapgen"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2object"
+	"github.com/OpenDiablo2/OpenDiablo2/d2game/d2player"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+const (
+	serverTicksPerSecond  = 25
+	missileHitRadius      = 1.0  // in tiles
+	partyExperienceRadius = 40.0 // in tiles
+
+	// additional experience of players with the experience shrine effect, in percent
+	experienceShrineBonus = 50
+
+	// chance of a monster to drop an item when it dies, in percent
+	monsterDropChance = 30
+
+	// monsters drop their items at most this many sub-tiles from where they die
+	monsterDropDistance = 10
+
+	// chance of an armor piece of a player to wear when a monster hits the player, in percent
+	armorWearChance = 10
+
+	// the seconds a softcore player which died lies dead before respawning in town
+	respawnDelay = 5.0
+)
+
+// finalBosses are the MonStats.txt IDs of the monsters which complete a
+// difficulty when they are killed
+var finalBosses = map[string]bool{"diablo": true, "baalcrab": true} //nolint:gochecknoglobals // constant lookup table
+
+// gameWorld is the server side state of a single map: the map engine, the
+// monster AI and the combat stats of the monsters on it.
+type gameWorld struct {
+	*d2mapengine.MapEngine
+	ai         *d2ai.Controller
+	monsters   map[*d2mapentity.NPC]*d2combat.Stats
+	npcIDs     map[*d2mapentity.NPC]int       // the Ids the clients know the NPCs by
+	vendors    map[string]*d2inventory.Vendor // the items each NPC sells, by MonStats.txt ID
+	difficulty d2enum.DifficultyType
+	rng        *rand.Rand
+}
+
+// createGameWorld puts every monster on the map with a known AI under the
+// control of the monster AI, with its stats on the given difficulty, and
+// gives every NPC the Id the clients know it by.
+func createGameWorld(mapEngine *d2mapengine.MapEngine, difficulty d2enum.DifficultyType) *gameWorld {
+	world := &gameWorld{
+		MapEngine:  mapEngine,
+		monsters:   make(map[*d2mapentity.NPC]*d2combat.Stats),
+		npcIDs:     make(map[*d2mapentity.NPC]int),
+		vendors:    make(map[string]*d2inventory.Vendor),
+		difficulty: difficulty,
+		rng:        rand.New(rand.NewSource(mapEngine.Seed())), //nolint:gosec // not used for security
+	}
+
+	world.ai = d2ai.CreateController(world, mapEngine.Seed())
+	world.ai.OnAttack = world.onMonsterAttack
+	world.ai.OnMove = world.onMonsterMove
+	world.addNPCs()
+
+	for _, entity := range *mapEngine.Entities() {
+		npc, ok := entity.(*d2mapentity.NPC)
+		if !ok {
+			continue
+		}
+
+		if _, ok := world.ai.AddAgent(npc, npc.MonStatsRecord(), world.difficulty); ok {
+			stats := d2combat.MonsterStats(npc.MonStatsRecord(), world.difficulty, world.rng)
+
+			x, y := npc.GetPositionF()
+			levelDetails := d2datadict.GetLevelDetails(mapEngine.LevelAt(int(x), int(y)))
+			stats.Level = d2combat.MonsterLevel(npc.MonStatsRecord(), levelDetails, world.difficulty)
+
+			world.monsters[npc] = stats
+		}
+	}
+
+	return world
+}
+
+// Targets returns the living players the monsters can attack.
+func (w *gameWorld) Targets() []d2ai.Target {
+	targets := make([]d2ai.Target, 0, len(singletonServer.clientConnections))
+
+	for _, connection := range singletonServer.clientConnections {
+		playerState := connection.GetPlayerState()
+		if playerState == nil || playerState.Stats == nil || playerState.Stats.Health <= 0 ||
+			isSpectator(playerState) || !w.hasLevel(playerState.LevelID) {
+			continue
+		}
+
+		targets = append(targets, playerTarget{playerState, connection})
+	}
+
+	return targets
+}
+
+// Advance runs the monster AI and moves the entities by one tick.
+func (w *gameWorld) Advance(tickTime float64) {
+	w.ai.Advance(tickTime)
+	w.MapEngine.Advance(tickTime)
+}
+
+func (w *gameWorld) onMonsterAttack(agent *d2ai.Agent, target d2ai.Target, mode d2enum.MonsterAnimationMode) {
+	w.showMonsterAttack(agent, mode)
+
+	player, ok := target.(playerTarget)
+	if !ok {
+		return
+	}
+
+	attacker := w.monsters[agent.Actor.(*d2mapentity.NPC)]
+	attacker.AttackRating = d2combat.MonsterAttackRating(agent.Record, w.difficulty, mode)
+	damage := d2combat.MonsterDamage(agent.Record, w.difficulty, mode, w.rng)
+
+	defender := player.CombatStats(w.difficulty)
+	result := d2combat.Attack(attacker, defender, damage, w.rng)
+	player.Stats.Health = defender.Life
+
+	if result.Killed {
+		killPlayer(player.client, agent.Record.Key)
+		return
+	}
+
+	if result.Hit && player.Inventory != nil && w.rng.Intn(100) < armorWearChance &&
+		player.Inventory.WearArmor(w.rng) {
+		updateInventory(player.client)
+	}
+}
+
+// onPlayerCast resolves a missile cast by a player against the living
+// monster closest to the target position.
+// TODO: resolve hits when the missile collides with a monster on its way
+func (w *gameWorld) onPlayerCast(playerState *d2player.PlayerState, missileID int, targetX, targetY float64) {
+	record := d2datadict.Missiles[missileID]
+	if record == nil || playerState.Stats == nil {
+		return
+	}
+
+	var target *d2mapentity.NPC
+
+	closest := missileHitRadius
+
+	for npc := range w.monsters {
+		x, y := npc.GetPositionF()
+		if distance := math.Hypot(x-targetX, y-targetY); distance <= closest {
+			target, closest = npc, distance
+		}
+	}
+
+	if target == nil {
+		if playerState.Hostile {
+			w.hitPlayer(playerState, record, targetX, targetY)
+		}
+
+		return
+	}
+
+	attacker := playerState.CombatStats(w.difficulty)
+	result := d2combat.ApplyDamage(attacker, w.monsters[target], d2combat.MissileDamage(record), w.rng)
+	playerState.Stats.Health, playerState.Stats.Mana = attacker.Life, attacker.Mana
+
+	if result.Killed {
+		w.killMonster(target, playerState)
+	}
+}
+
+// hitPlayer resolves a missile cast by a hostile player against the player
+// closest to the target position it may attack.
+func (w *gameWorld) hitPlayer(caster *d2player.PlayerState, record *d2datadict.MissileRecord, targetX, targetY float64) {
+	var target ClientConnection
+
+	closest := missileHitRadius
+
+	for _, client := range singletonServer.clientConnections {
+		playerState := client.GetPlayerState()
+		if !canAttack(caster, playerState) || !w.hasLevel(playerState.LevelID) {
+			continue
+		}
+
+		if distance := math.Hypot(playerState.X-targetX, playerState.Y-targetY); distance <= closest {
+			target, closest = client, distance
+		}
+	}
+
+	if target == nil {
+		return
+	}
+
+	defender := target.GetPlayerState()
+	attackerStats := caster.CombatStats(w.difficulty)
+	defenderStats := defender.CombatStats(w.difficulty)
+	result := d2combat.ApplyDamage(attackerStats, defenderStats, d2combat.MissileDamage(record), w.rng)
+	caster.Stats.Health, caster.Stats.Mana = attackerStats.Life, attackerStats.Mana
+	defender.Stats.Health = defenderStats.Life
+
+	if result.Killed {
+		killPlayer(target, caster.HeroName)
+	} else {
+		updatePlayerStats(target, 0)
+	}
+}
+
+// canAttack returns true if a player may attack another one: hostility is
+// limited to hardcore players, and the attacker must have turned hostile.
+func canAttack(attacker, defender *d2player.PlayerState) bool {
+	if attacker == defender || defender == nil || attacker.Stats == nil || defender.Stats == nil {
+		return false
+	}
+
+	return attacker.Hostile && attacker.Hardcore && defender.Hardcore &&
+		defender.Stats.Health > 0 && !isSpectator(defender)
+}
+
+// killPlayer kills the client's player. A hardcore player is saved dead and
+// is not put in the world anymore, the others lose some experience depending
+// on the difficulty and respawn in town after a while.
+func killPlayer(client ClientConnection, killer string) {
+	playerState := client.GetPlayerState()
+	playerState.Die()
+
+	log.Printf("%s was slain by %s", playerState.HeroName, killer)
+
+	if playerState.Dead {
+		log.Printf("%s died in hardcore mode and can not play anymore", playerState.HeroName)
+	} else {
+		if record := d2datadict.GetDifficultyLevel(singletonServer.difficulty); record != nil && playerState.Stats != nil {
+			lost := playerState.Stats.LoseExperience(record.DeathExperiencePenalty, d2datadict.CharStats[playerState.HeroType])
+			log.Printf("%s lost %d experience", playerState.HeroName, lost)
+		}
+
+		singletonServer.respawns[client.GetUniqueId()] = respawnDelay
+	}
+
+	updatePlayerStats(client, 0)
+}
+
+// respawnPlayer brings the client's player, a softcore player which died,
+// back to life in town.
+func respawnPlayer(client ClientConnection) {
+	playerState := client.GetPlayerState()
+	if !playerState.Respawn() {
+		return
+	}
+
+	town, err := singletonServer.worldOf(d2mapgen.LevelRogueEncampment)
+	if err != nil {
+		log.Printf("GameServer: error generating the town for client %s: %s", client.GetUniqueId(), err)
+		return
+	}
+
+	from := playerWorld(client)
+	x, y := town.GetStartPosition()
+	playerState.X, playerState.Y, playerState.LevelID = x, y, d2mapgen.LevelRogueEncampment
+
+	log.Printf("%s respawned in town", playerState.HeroName)
+
+	updatePlayerStats(client, 0)
+	broadcast(d2netpacket.CreateChangeLevelPacket(client.GetUniqueId(), playerState.LevelID, x, y))
+
+	// the client generates the map of the town, which has no NPCs yet
+	if from != town {
+		town.sendNPCs(client)
+	}
+}
+
+// killMonster removes a monster from the AI, shares its experience between
+// the killer and the players close to it and leaves a corpse on the map of
+// the server and of the clients.
+func (w *gameWorld) killMonster(npc *d2mapentity.NPC, killer *d2player.PlayerState) {
+	stats := w.monsters[npc]
+
+	w.ai.RemoveAgent(npc)
+	delete(w.monsters, npc)
+	npc.Kill()
+	w.broadcast(d2netpacket.CreateKillNPCPacket(w.npcIDs[npc]))
+
+	x, y := npc.GetPositionF()
+	party := make([]ClientConnection, 0)
+	levels := make([]int, 0)
+
+	// Parties are not in scope: the players in the game share the experience
+	// as a single party, without the players hostile to the killer and those
+	// the killer is hostile to
+	for _, client := range singletonServer.clientConnections {
+		playerState := client.GetPlayerState()
+		if playerState == nil || playerState.Stats == nil || playerState.Stats.Health <= 0 ||
+			isSpectator(playerState) || !w.hasLevel(playerState.LevelID) {
+			continue
+		}
+
+		if playerState != killer && (math.Hypot(playerState.X-x, playerState.Y-y) > partyExperienceRadius ||
+			canAttack(killer, playerState) || canAttack(playerState, killer)) {
+			continue
+		}
+
+		party = append(party, client)
+		levels = append(levels, playerState.Stats.Level)
+	}
+
+	experience := d2combat.MonsterExperience(npc.MonStatsRecord(), w.difficulty)
+	shares := d2hero.PartyExperience(experience, stats.Level, levels)
+
+	for idx, client := range party {
+		playerState := client.GetPlayerState()
+
+		share := shares[idx]
+		if playerState.Stats.HasEffect(d2hero.EffectExperience) {
+			share += share * experienceShrineBonus / 100
+		}
+
+		levelsGained := playerState.Stats.AddExperience(share, d2datadict.CharStats[playerState.HeroType])
+
+		if levelsGained > 0 {
+			log.Printf("Player %s reached level %d", playerState.HeroName, playerState.Stats.Level)
+		}
+
+		if finalBosses[npc.MonStatsRecord().Key] && playerState.CompleteDifficulty(w.difficulty) {
+			log.Printf("Player %s unlocked the %s difficulty", playerState.HeroName, playerState.Difficulty)
+		}
+
+		updatePlayerStats(client, levelsGained)
+	}
+
+	w.dropMonsterItem(npc, stats.Level, killer)
+}
+
+// dropMonsterItem may drop an item where a monster died, if the monster has a
+// treasure class on the difficulty. Monsters drop the items up to their
+// level, which depends on the difficulty too.
+// TODO: drop from the treasure class of the monster once TreasureClassEx.txt is loaded
+func (w *gameWorld) dropMonsterItem(npc *d2mapentity.NPC, level int, killer *d2player.PlayerState) {
+	if d2combat.MonsterTreasureClass(npc.MonStatsRecord(), w.difficulty) == "" || w.rng.Intn(100) >= monsterDropChance {
+		return
+	}
+
+	items := d2object.DroppableItems(level)
+	if len(items) == 0 {
+		return
+	}
+
+	x, y := npc.GetPositionF()
+
+	subTileX, subTileY, found := w.ClosestWalkable(int(x*5), int(y*5), monsterDropDistance)
+	if !found {
+		return
+	}
+
+	item := d2inventory.CreateItem(items[w.rng.Intn(len(items))].Code)
+	item.Level = level
+
+	item.RollSocketsByChance(w.rng)
+
+	entity, err := d2mapentity.CreateDroppedItem(subTileX, subTileY, item)
+	if err != nil {
+		log.Printf("GameServer: error dropping item %s: %s", item.Code, err)
+		return
+	}
+
+	w.AddEntity(entity)
+
+	for id, client := range singletonServer.clientConnections {
+		if client.GetPlayerState() == killer {
+			broadcast(d2netpacket.CreateDropItemPacket(id, 0, item.Clone(), subTileX, subTileY))
+			break
+		}
+	}
+}
+
+// playerTarget makes the server side state of a player a target for the AI.
+type playerTarget struct {
+	*d2player.PlayerState
+	client ClientConnection
+}
+
+// GetPositionF returns the position of the player in tiles.
+func (p playerTarget) GetPositionF() (float64, float64) {
+	return p.X, p.Y
+}
+
+// advanceEffects counts down the timed effects of the players and updates
+// the stats of those whose effects wore off.
+func advanceEffects(tickTime float64) {
+	for _, client := range singletonServer.clientConnections {
+		playerState := client.GetPlayerState()
+		if playerState == nil || playerState.Stats == nil {
+			continue
+		}
+
+		if playerState.Stats.AdvanceEffects(tickTime) {
+			updatePlayerStats(client, 0)
+		}
+	}
+}
+
+// advanceRespawns counts down the time the dead softcore players wait, and
+// respawns those whose time is up.
+func advanceRespawns(tickTime float64) {
+	for id, left := range singletonServer.respawns {
+		if left -= tickTime; left > 0 {
+			singletonServer.respawns[id] = left
+			continue
+		}
+
+		delete(singletonServer.respawns, id)
+
+		if client, ok := singletonServer.clientConnections[id]; ok {
+			respawnPlayer(client)
+		}
+	}
+}
+
+// runGameLoop advances the server's copy of the worlds at a fixed rate for
+// as long as the server is running.
+func runGameLoop() {
+	tickTime := 1.0 / serverTicksPerSecond
+	ticker := time.NewTicker(time.Second / serverTicksPerSecond)
+
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !singletonServer.running {
+			return
+		}
+
+		singletonServer.Lock()
+
+		for _, world := range singletonServer.worlds {
+			world.Advance(tickTime)
+		}
+
+		advanceEffects(tickTime)
+		advanceRespawns(tickTime)
+
+		singletonServer.Unlock()
+	}
+}
@@ -16,7 +16,10 @@ import (
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
 
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2game/d2player"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client/d2clientconnectiontype"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2server/d2udpclientconnection"
@@ -32,20 +35,28 @@ type GameServer struct {
 	clientConnections map[string]ClientConnection
 	manager           *ConnectionManager
 	mapEngines        []*d2mapengine.MapEngine
+	worlds            []*gameWorld
+	levels            map[int]*gameWorld               // the world of each generated level, by Levels.txt ID
+	trades            map[string]*d2player.Trade       // the trade of each trading player, by player ID
+	tradeRequests     map[string]string                // the player each player asked to trade with, by player ID
+	respawns          map[string]float64               // the seconds each dead softcore player waits to respawn, by player ID
+	remoteHeroes      map[string]*d2player.PlayerState // the last state of the heroes of remote clients, by hero name
 	scriptEngine      *d2script.ScriptEngine
 	udpConnection     *net.UDPConn
 	seed              int64
+	difficulty        d2enum.DifficultyType
 	running           bool
 }
 
 var singletonServer *GameServer
 
 // Create constructs a new GameServer and assigns it as a singleton. It
-// also generates the initial map and entities for the server.
+// also generates the initial map and entities for the server, on the
+// difficulty of the game.
 //
 // If openNetworkServer is true, the GameServer starts listening for UDP
 // packets.
-func Create(openNetworkServer bool) {
+func Create(openNetworkServer bool, difficulty d2enum.DifficultyType) {
 	log.Print("Creating GameServer")
 	if singletonServer != nil {
 		return
@@ -54,17 +65,21 @@ func Create(openNetworkServer bool) {
 	singletonServer = &GameServer{
 		clientConnections: make(map[string]ClientConnection),
 		mapEngines:        make([]*d2mapengine.MapEngine, 0),
+		levels:            make(map[int]*gameWorld),
+		trades:            make(map[string]*d2player.Trade),
+		tradeRequests:     make(map[string]string),
+		respawns:          make(map[string]float64),
+		remoteHeroes:      make(map[string]*d2player.PlayerState),
 		scriptEngine:      d2script.CreateScriptEngine(),
 		seed:              time.Now().UnixNano(),
+		difficulty:        difficulty,
 	}
 
 	singletonServer.manager = CreateConnectionManager(singletonServer)
 
-	mapEngine := d2mapengine.CreateMapEngine()
-	mapEngine.SetSeed(singletonServer.seed)
-	mapEngine.ResetMap(d2enum.RegionAct1Town, 100, 100) // TODO: Mapgen - Needs levels.txt stuff
-	d2mapgen.GenerateAct1Overworld(mapEngine)
-	singletonServer.mapEngines = append(singletonServer.mapEngines, mapEngine)
+	if _, err := singletonServer.worldOf(d2mapgen.LevelRogueEncampment); err != nil {
+		log.Printf("GameServer: error generating the town: %s", err)
+	}
 
 	singletonServer.scriptEngine.AddFunction("getMapEngines", func(call otto.FunctionCall) otto.Value {
 		val, err := singletonServer.scriptEngine.ToValue(singletonServer.mapEngines)
@@ -98,17 +113,25 @@ func createNetworkServer() {
 // runNetworkServer runs a while loop, reading from the GameServer's UDP
 // connection.
 func runNetworkServer() {
-	buffer := make([]byte, 4096)
+	buffer := make([]byte, d2netpacket.MaxPacketSize)
 	for singletonServer.running {
-		_, addr, err := singletonServer.udpConnection.ReadFromUDP(buffer)
+		n, addr, err := singletonServer.udpConnection.ReadFromUDP(buffer)
 		if err != nil {
 			fmt.Printf("Socket error: %s\n", err)
 			continue
 		}
-		buff := bytes.NewBuffer(buffer)
+		buff := bytes.NewBuffer(buffer[:n])
 		packetTypeId, err := buff.ReadByte()
+		if err != nil {
+			log.Printf("GameServer: error reading packet type: %s", err)
+			continue
+		}
 		packetType := d2netpackettype.NetPacketType(packetTypeId)
 		reader, err := gzip.NewReader(buff)
+		if err != nil {
+			log.Printf("GameServer: error creating reader for %v packet: %s", packetType, err)
+			continue
+		}
 		sb := new(strings.Builder)
 
 		// This will throw errors where packets are not compressed. This doesn't
@@ -117,7 +140,7 @@ func runNetworkServer() {
 		written, err := io.Copy(sb, reader)
 		if err != nil && err != gzip.ErrHeader {
 			log.Printf("GameServer: error copying bytes from %v packet: %s", packetType, err)
-
+			continue
 		}
 		if written == 0 {
 			log.Printf("GameServer: empty packet %v packet received", packetType)
@@ -133,27 +156,18 @@ func runNetworkServer() {
 				log.Printf("GameServer: error unmarshalling packet of type %T: %s", packetData, err)
 				continue
 			}
-			clientConnection := d2udpclientconnection.CreateUDPClientConnection(singletonServer.udpConnection, packetData.Id, addr)
-			clientConnection.SetPlayerState(packetData.PlayerState)
-			OnClientConnected(clientConnection)
-		case d2netpackettype.MovePlayer:
-			packetData := d2netpacket.MovePlayerPacket{}
-			err := json.Unmarshal([]byte(stringData), &packetData)
-			if err != nil {
-				log.Printf("GameServer: error unmarshalling %T: %s", packetData, err)
+			singletonServer.RLock()
+			_, connected := singletonServer.clientConnections[packetData.Id]
+			singletonServer.RUnlock()
+
+			if connected {
+				log.Printf("GameServer: refusing connection from %s, client %s is already connected", addr, packetData.Id)
 				continue
 			}
-			netPacket := d2netpacket.NetPacket{
-				PacketType: packetType,
-				PacketData: packetData,
-			}
 
-			for _, player := range singletonServer.clientConnections {
-				err = player.SendPacketToClient(netPacket)
-				if err != nil {
-					log.Printf("GameServer: error sending %T to client %s: %s", packetData, player.GetUniqueId(), err)
-				}
-			}
+			clientConnection := d2udpclientconnection.CreateUDPClientConnection(singletonServer.udpConnection, packetData.Id, addr)
+			clientConnection.SetPlayerState(packetData.PlayerState)
+			OnClientConnected(clientConnection)
 		case d2netpackettype.Pong:
 			packetData := d2netpacket.PlayerConnectionRequestPacket{}
 			err := json.Unmarshal([]byte(stringData), &packetData)
@@ -172,6 +186,8 @@ func runNetworkServer() {
 				continue
 			}
 			log.Printf("Received disconnect: %s", packet.Id)
+		default:
+			onPlayerPacket(packetType, []byte(stringData), addr)
 		}
 	}
 }
@@ -189,6 +205,8 @@ func Run() {
 		go runNetworkServer()
 	}
 	log.Print("Network server has been started")
+
+	go runGameLoop()
 }
 
 // Stop sets GameServer.running to false and closes the
@@ -215,46 +233,81 @@ func Destroy() {
 
 // OnClientConnected initializes the given ClientConnection. It sends the
 // following packets to the newly connected client: UpdateServerInfoPacket,
-// GenerateMapPacket, AddPlayerPacket.
+// GenerateMapPacket, a SpawnNPCPacket for each NPC in town, AddPlayerPacket.
 //
 // It also sends AddPlayerPackets for each other player entity to the new
 // player and vice versa, so all player entities exist on all clients.
 //
 // For more information, see d2networking.d2netpacket.
 func OnClientConnected(client ClientConnection) {
-	// Temporary position hack --------------------------------------------
-	sx, sy := singletonServer.mapEngines[0].GetStartPosition() // TODO: Another temporary hack
+	singletonServer.Lock()
+	defer singletonServer.Unlock()
+
+	// Players enter the game in town
+	town, err := singletonServer.worldOf(d2mapgen.LevelRogueEncampment)
+	if err != nil {
+		log.Printf("GameServer: error generating the town for client %s: %s", client.GetUniqueId(), err)
+		return
+	}
+
+	sx, sy := town.GetStartPosition()
 	clientPlayerState := client.GetPlayerState()
 	clientPlayerState.X = sx
 	clientPlayerState.Y = sy
-	// --------------------------------------------------------------------
+	clientPlayerState.LevelID = d2mapgen.LevelRogueEncampment
+
+	if client.GetConnectionType() != d2clientconnectiontype.Local {
+		singletonServer.checkRemoteHero(clientPlayerState)
+	}
+
+	// softcore players which left the game dead respawn right away
+	if clientPlayerState.Stats != nil && clientPlayerState.Stats.Health <= 0 {
+		clientPlayerState.Respawn()
+	}
 
 	log.Printf("Client connected with an id of %s", client.GetUniqueId())
 	singletonServer.clientConnections[client.GetUniqueId()] = client
-	err := client.SendPacketToClient(d2netpacket.CreateUpdateServerInfoPacket(singletonServer.seed, client.GetUniqueId()))
+	err = client.SendPacketToClient(d2netpacket.CreateUpdateServerInfoPacket(singletonServer.seed, client.GetUniqueId(),
+		singletonServer.difficulty))
 	if err != nil {
 		log.Printf("GameServer: error sending UpdateServerInfoPacket to client %s: %s", client.GetUniqueId(), err)
 	}
-	err = client.SendPacketToClient(d2netpacket.CreateGenerateMapPacket(d2enum.RegionAct1Town))
+	err = client.SendPacketToClient(d2netpacket.CreateGenerateMapPacket(clientPlayerState.LevelID))
 	if err != nil {
 		log.Printf("GameServer: error sending GenerateMapPacket to client %s: %s", client.GetUniqueId(), err)
 	}
 
+	town.sendNPCs(client)
+
 	playerState := client.GetPlayerState()
 	createPlayerPacket := d2netpacket.CreateAddPlayerPacket(client.GetUniqueId(), playerState.HeroName, int(sx*5)+3, int(sy*5)+3,
-		playerState.HeroType, *playerState.Stats, playerState.Equipment)
+		playerState.LevelID, playerState.HeroType, *playerState.Stats, playerState.Equipment)
+
+	// a dead hardcore player, or one which has not unlocked the difficulty of
+	// the game, is not put in the world, only its own client sees it
+	spectator := isSpectator(playerState)
+	if spectator {
+		log.Printf("Client %s joined with %s as a spectator", client.GetUniqueId(), playerState.HeroName)
+	}
+
 	for _, connection := range singletonServer.clientConnections {
-		err := connection.SendPacketToClient(createPlayerPacket)
-		if err != nil {
-			log.Printf("GameServer: error sending %T to client %s: %s", createPlayerPacket, connection.GetUniqueId(), err)
+		if !spectator || connection.GetUniqueId() == client.GetUniqueId() {
+			err := connection.SendPacketToClient(createPlayerPacket)
+			if err != nil {
+				log.Printf("GameServer: error sending %T to client %s: %s", createPlayerPacket, connection.GetUniqueId(), err)
+			}
 		}
 		if connection.GetUniqueId() == client.GetUniqueId() {
 			continue
 		}
 
 		conPlayerState := connection.GetPlayerState()
+		if isSpectator(conPlayerState) {
+			continue
+		}
+
 		err = client.SendPacketToClient(d2netpacket.CreateAddPlayerPacket(connection.GetUniqueId(), conPlayerState.HeroName,
-			int(conPlayerState.X*5)+3, int(conPlayerState.Y*5)+3, conPlayerState.HeroType, *conPlayerState.Stats, conPlayerState.Equipment))
+			int(conPlayerState.X*5)+3, int(conPlayerState.Y*5)+3, conPlayerState.LevelID, conPlayerState.HeroType, *conPlayerState.Stats, conPlayerState.Equipment))
 		if err != nil {
 			log.Printf("GameServer: error sending CreateAddPlayerPacket to client %s: %s", connection.GetUniqueId(), err)
 		}
@@ -262,37 +315,280 @@ func OnClientConnected(client ClientConnection) {
 
 }
 
+// checkRemoteHero keeps remote clients from changing the hardcore mode, the
+// death and the difficulty of their heroes: the heroes which joined the game
+// before keep those the server knows of, and the others may not have
+// unlocked a difficulty above the one of the game.
+// TODO: heroes are only told apart by their names, and only for as long as
+// the server runs
+func (s *GameServer) checkRemoteHero(playerState *d2player.PlayerState) {
+	if known, ok := s.remoteHeroes[playerState.HeroName]; ok {
+		playerState.Hardcore = known.Hardcore
+		playerState.Dead = known.Dead
+		playerState.Difficulty = known.Difficulty
+	} else if playerState.Difficulty > s.difficulty {
+		playerState.Difficulty = s.difficulty
+	}
+
+	s.remoteHeroes[playerState.HeroName] = playerState
+}
+
 // OnClientDisconnected removes the given client from the list
 // of client connections.
 func OnClientDisconnected(client ClientConnection) {
 	log.Printf("Client disconnected with an id of %s", client.GetUniqueId())
+	singletonServer.Lock()
+	singletonServer.cancelTrades(client.GetUniqueId())
+	delete(singletonServer.respawns, client.GetUniqueId())
 	delete(singletonServer.clientConnections, client.GetUniqueId())
+	singletonServer.Unlock()
 }
 
 // OnPacketReceived is called by the local client to 'send' a packet to the server.
 func OnPacketReceived(client ClientConnection, packet d2netpacket.NetPacket) error {
+	singletonServer.Lock()
+	defer singletonServer.Unlock()
+
+	// spectators may only look around, and dead players wait to respawn
+	if playerState := client.GetPlayerState(); isSpectator(playerState) || isDead(playerState) {
+		return nil
+	}
+
 	switch packet.PacketType {
 	case d2netpackettype.MovePlayer:
 		// TODO: This needs to be verified on the server (here) before sending to other clients....
 		// TODO: Hacky, this should be updated in realtime ----------------
 		// TODO: Verify player id
-		playerState := singletonServer.clientConnections[client.GetUniqueId()].GetPlayerState()
+		playerState := client.GetPlayerState()
 		playerState.X = packet.PacketData.(d2netpacket.MovePlayerPacket).DestX
 		playerState.Y = packet.PacketData.(d2netpacket.MovePlayerPacket).DestY
 		// ----------------------------------------------------------------
-		for _, player := range singletonServer.clientConnections {
-			err := player.SendPacketToClient(packet)
-			if err != nil {
-				log.Printf("GameServer: error sending %T to client %s: %s", packet, player.GetUniqueId(), err)
-			}
+		if world := playerWorld(client); world != nil {
+			world.onPlayerMove(playerState)
 		}
+
+		broadcast(packet)
 	case d2netpackettype.CastSkill:
-		for _, player := range singletonServer.clientConnections {
-			err := player.SendPacketToClient(packet)
-			if err != nil {
-				log.Printf("GameServer: error sending %T to client %s: %s", packet, player.GetUniqueId(), err)
+		castPacket := packet.PacketData.(d2netpacket.CastPacket)
+
+		if world := playerWorld(client); world != nil {
+			world.onPlayerCast(client.GetPlayerState(), castPacket.SkillID, castPacket.TargetX, castPacket.TargetY)
+		}
+
+		broadcast(packet)
+	case d2netpackettype.AllocateStatPoint:
+		allocatePacket := packet.PacketData.(d2netpacket.AllocateStatPointPacket)
+		playerState := client.GetPlayerState()
+
+		if playerState.Stats != nil &&
+			playerState.Stats.AllocateStatPoint(allocatePacket.Stat, d2datadict.CharStats[playerState.HeroType]) {
+			updatePlayerStats(client, 0)
+		}
+	case d2netpackettype.OperateObject:
+		operatePacket := packet.PacketData.(d2netpacket.OperateObjectPacket)
+
+		if world := playerWorld(client); world != nil && world.onPlayerOperate(client, operatePacket.X, operatePacket.Y) {
+			broadcast(d2netpacket.CreateOperateObjectPacket(client.GetUniqueId(), operatePacket.X, operatePacket.Y,
+				world.waypointLevel(operatePacket.X, operatePacket.Y)))
+		}
+	case d2netpackettype.TravelWaypoint:
+		travelPacket := packet.PacketData.(d2netpacket.TravelWaypointPacket)
+
+		if world := playerWorld(client); world != nil {
+			if x, y, ok := world.onPlayerTravel(client, travelPacket.WaypointX, travelPacket.WaypointY); ok {
+				broadcast(d2netpacket.CreateTravelWaypointPacket(client.GetUniqueId(),
+					travelPacket.WaypointX, travelPacket.WaypointY, x, y))
+			}
+		}
+	case d2netpackettype.ChangeLevel:
+		changePacket := packet.PacketData.(d2netpacket.ChangeLevelPacket)
+
+		from := playerWorld(client)
+		if x, y, ok := singletonServer.onPlayerChangeLevel(client.GetPlayerState(), changePacket.LevelID); ok {
+			broadcast(d2netpacket.CreateChangeLevelPacket(client.GetUniqueId(), changePacket.LevelID, x, y))
+
+			// the client generated the map of the level, which has no NPCs yet
+			if to := playerWorld(client); to != from {
+				to.sendNPCs(client)
+			}
+		}
+	case d2netpackettype.MoveItem:
+		movePacket := packet.PacketData.(d2netpacket.MoveItemPacket)
+		playerState := client.GetPlayerState()
+
+		if err := playerState.MoveItem(movePacket.ItemID, movePacket.To); err != nil {
+			log.Printf("GameServer: player %s can not move item %d: %s", client.GetUniqueId(), movePacket.ItemID, err)
+		}
+
+		// the client is told where its items are even if the move failed, so
+		// that it puts back the item it tried to move
+		updateInventory(client)
+	case d2netpackettype.UseItem:
+		usePacket := packet.PacketData.(d2netpacket.UseItemPacket)
+		playerState := client.GetPlayerState()
+
+		if err := playerState.UseItem(usePacket.ItemID); err != nil {
+			log.Printf("GameServer: player %s can not use item %d: %s", client.GetUniqueId(), usePacket.ItemID, err)
+		} else {
+			updatePlayerStats(client, 0)
+		}
+
+		updateInventory(client)
+	case d2netpackettype.DropItem:
+		dropPacket := packet.PacketData.(d2netpacket.DropItemPacket)
+
+		if world := playerWorld(client); world != nil {
+			if item, x, y, ok := world.onPlayerDropItem(client, dropPacket.ItemID); ok {
+				broadcast(d2netpacket.CreateDropItemPacket(client.GetUniqueId(), dropPacket.ItemID, item.Clone(), x, y))
 			}
 		}
+
+		updateInventory(client)
+	case d2netpackettype.PickUpItem:
+		pickUpPacket := packet.PacketData.(d2netpacket.PickUpItemPacket)
+
+		if world := playerWorld(client); world != nil && world.onPlayerPickUpItem(client, pickUpPacket.X, pickUpPacket.Y) {
+			broadcast(d2netpacket.CreatePickUpItemPacket(client.GetUniqueId(), pickUpPacket.X, pickUpPacket.Y))
+			updateInventory(client)
+		}
+	case d2netpackettype.InteractNPC:
+		interactPacket := packet.PacketData.(d2netpacket.InteractNPCPacket)
+
+		if world := playerWorld(client); world != nil {
+			world.onPlayerInteractNPC(client, interactPacket.NPC, interactPacket.Service)
+		}
+	case d2netpackettype.BuyItem, d2netpackettype.SellItem:
+		tradePacket := packet.PacketData.(d2netpacket.TradeItemPacket)
+
+		if world := playerWorld(client); world != nil {
+			world.onPlayerTrade(client, tradePacket.NPC, tradePacket.ItemID, packet.PacketType == d2netpackettype.BuyItem)
+		}
+
+		updateInventory(client)
+	case d2netpackettype.StashGold:
+		stashPacket := packet.PacketData.(d2netpacket.StashGoldPacket)
+		playerState := client.GetPlayerState()
+
+		if err := playerState.StashGold(stashPacket.Amount); err != nil {
+			log.Printf("GameServer: player %s can not stash %d gold: %s", client.GetUniqueId(), stashPacket.Amount, err)
+		}
+
+		updateInventory(client)
+	case d2netpackettype.TradeRequest:
+		requestPacket := packet.PacketData.(d2netpacket.TradeRequestPacket)
+
+		singletonServer.onPlayerRequestTrade(client, requestPacket.OtherID, requestPacket.Accept)
+	case d2netpackettype.TradeAction:
+		actionPacket := packet.PacketData.(d2netpacket.TradeActionPacket)
+
+		singletonServer.onPlayerTradeAction(client, actionPacket)
+	case d2netpackettype.SocketItem:
+		socketPacket := packet.PacketData.(d2netpacket.SocketItemPacket)
+		playerState := client.GetPlayerState()
+
+		if world := playerWorld(client); world != nil {
+			if err := playerState.SocketItem(socketPacket.ItemID, socketPacket.SocketableID, world.rng); err != nil {
+				log.Printf("GameServer: player %s can not socket item %d into item %d: %s", client.GetUniqueId(),
+					socketPacket.SocketableID, socketPacket.ItemID, err)
+			}
+		}
+
+		updateInventory(client)
+	case d2netpackettype.TransmuteCube:
+		playerState := client.GetPlayerState()
+
+		if world := playerWorld(client); world != nil {
+			if err := playerState.TransmuteCube(singletonServer.difficulty, world.rng); err != nil {
+				log.Printf("GameServer: player %s can not transmute the items in the cube: %s", client.GetUniqueId(), err)
+			}
+		}
+
+		updateInventory(client)
+	case d2netpackettype.SetHostile:
+		hostilePacket := packet.PacketData.(d2netpacket.SetHostilePacket)
+		playerState := client.GetPlayerState()
+
+		if !playerState.Hardcore {
+			log.Printf("GameServer: player %s can not turn hostile, only hardcore players can", client.GetUniqueId())
+		} else if playerState.Hostile != hostilePacket.Hostile {
+			playerState.Hostile = hostilePacket.Hostile
+			broadcast(d2netpacket.CreateSetHostilePacket(client.GetUniqueId(), playerState.Hostile))
+		}
 	}
 	return nil
 }
+
+// isSpectator returns true if the player only looks around the game: a dead
+// hardcore player, or one which has not unlocked the difficulty of the game.
+func isSpectator(playerState *d2player.PlayerState) bool {
+	return playerState.IsGhost() || !playerState.CanPlay(singletonServer.difficulty)
+}
+
+// isDead returns true if the player has no life left.
+func isDead(playerState *d2player.PlayerState) bool {
+	return playerState.Stats != nil && playerState.Stats.Health <= 0
+}
+
+// playerWorld returns the world of the level the client's player is in, or
+// nil if the level could not be generated.
+func playerWorld(client ClientConnection) *gameWorld {
+	world, err := singletonServer.worldOf(client.GetPlayerState().LevelID)
+	if err != nil {
+		log.Printf("GameServer: error generating level %d: %s", client.GetPlayerState().LevelID, err)
+		return nil
+	}
+
+	return world
+}
+
+// broadcast sends a packet to all clients.
+func broadcast(packet d2netpacket.NetPacket) {
+	for _, connection := range singletonServer.clientConnections {
+		err := connection.SendPacketToClient(packet)
+		if err != nil {
+			log.Printf("GameServer: error sending %T to client %s: %s", packet.PacketData, connection.GetUniqueId(), err)
+		}
+	}
+}
+
+func savePlayer(playerState *d2player.PlayerState) {
+	if err := playerState.Save(); err != nil {
+		log.Printf("GameServer: error saving %s: %s", playerState.HeroName, err)
+	}
+}
+
+// updatePlayerStats sends the stats of the client's player to all clients.
+// The stats of local players are saved right away, remote clients save
+// their own player when they receive the update.
+func updatePlayerStats(client ClientConnection, levelsGained int) {
+	playerState := client.GetPlayerState()
+	if client.GetConnectionType() == d2clientconnectiontype.Local {
+		savePlayer(playerState)
+	}
+
+	packet := d2netpacket.CreateUpdatePlayerStatsPacket(client.GetUniqueId(), *playerState.Stats, levelsGained,
+		playerState.Difficulty)
+	for _, connection := range singletonServer.clientConnections {
+		err := connection.SendPacketToClient(packet)
+		if err != nil {
+			log.Printf("GameServer: error sending %T to client %s: %s", packet, connection.GetUniqueId(), err)
+		}
+	}
+}
+
+// updateInventory sends the items of the client's player to all clients.
+// The items of local players are saved right away, remote clients save their
+// own player when they receive the update.
+func updateInventory(client ClientConnection) {
+	playerState := client.GetPlayerState()
+	if playerState.Inventory == nil {
+		return
+	}
+
+	if client.GetConnectionType() == d2clientconnectiontype.Local {
+		savePlayer(playerState)
+	}
+
+	inventory := playerState.Inventory.Clone()
+	broadcast(d2netpacket.CreateUpdateInventoryPacket(client.GetUniqueId(), *inventory, playerState.Equipment))
+}
@@ -0,0 +1,67 @@
+package d2server
+
+import (
+	"log"
+	"math"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+)
+
+// onPlayerDropItem drops an item of the player on the ground next to it. It
+// returns the dropped item and the sub-tile position it lies at.
+func (w *gameWorld) onPlayerDropItem(client ClientConnection, itemID int) (item *d2inventory.Item, x, y int, ok bool) {
+	playerState := client.GetPlayerState()
+	if playerState == nil || playerState.Inventory == nil {
+		return nil, 0, 0, false
+	}
+
+	item, _, found := playerState.Inventory.Find(itemID)
+	if !found {
+		return nil, 0, 0, false
+	}
+
+	x, y, found = w.ClosestWalkable(int(playerState.X*5), int(playerState.Y*5), arrivalDistance)
+	if !found {
+		return nil, 0, 0, false
+	}
+
+	entity, err := d2mapentity.CreateDroppedItem(x, y, item)
+	if err != nil {
+		log.Printf("GameServer: error dropping item %s: %s", item.Code, err)
+		return nil, 0, 0, false
+	}
+
+	if _, err := playerState.DropItem(itemID); err != nil {
+		return nil, 0, 0, false
+	}
+
+	w.AddEntity(entity)
+
+	return item, x, y, true
+}
+
+// onPlayerPickUpItem puts the item lying at the given sub-tile position in
+// the inventory of the player if the player is close enough to it and has
+// room for it. It returns true if the item was picked up.
+func (w *gameWorld) onPlayerPickUpItem(client ClientConnection, x, y int) bool {
+	entity := w.ItemAt(x, y)
+	playerState := client.GetPlayerState()
+
+	if entity == nil || playerState == nil || playerState.Stats == nil || playerState.Stats.Health <= 0 {
+		return false
+	}
+
+	itemX, itemY := entity.GetPositionF()
+	if math.Hypot(playerState.X-itemX, playerState.Y-itemY) > operateDistance {
+		return false
+	}
+
+	if err := playerState.PickUpItem(entity.Instance()); err != nil {
+		return false
+	}
+
+	w.RemoveEntity(entity)
+
+	return true
+}
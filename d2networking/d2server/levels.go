@@ -0,0 +1,117 @@
+package d2server
+
+import (
+	"math"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapgen"
+	"github.com/OpenDiablo2/OpenDiablo2/d2game/d2player"
+)
+
+const (
+	// players can use warps from this many tiles away from their exit
+	warpDistance = 3.0
+
+	// players arrive at most this many sub-tiles from the exit of a warp
+	arrivalWarpDistance = 15
+)
+
+// worldOf returns the world of a level, the level's map is generated and
+// populated the first time a player enters it.
+func (s *GameServer) worldOf(levelID int) (*gameWorld, error) {
+	if world, ok := s.levels[levelID]; ok {
+		return world, nil
+	}
+
+	mapEngine := d2mapengine.CreateMapEngine()
+	mapEngine.SetSeed(d2mapgen.LevelSeed(s.seed, levelID))
+
+	if err := d2mapgen.GenerateLevel(mapEngine, levelID, s.difficulty); err != nil {
+		return nil, err
+	}
+
+	d2mapgen.PopulateMonsters(mapEngine, s.difficulty)
+
+	world := createGameWorld(mapEngine, s.difficulty)
+	s.mapEngines = append(s.mapEngines, mapEngine)
+	s.worlds = append(s.worlds, world)
+
+	for _, id := range mapEngine.Levels() {
+		s.levels[id] = world
+	}
+
+	return world, nil
+}
+
+// hasLevel returns true if the level is on the map of the world.
+func (w *gameWorld) hasLevel(levelID int) bool {
+	_, ok := w.LevelArea(levelID)
+	return ok
+}
+
+// onPlayerMove keeps track of the level a player walks in, as a map can
+// hold more than one level.
+func (w *gameWorld) onPlayerMove(playerState *d2player.PlayerState) {
+	if levelID := w.LevelAt(int(playerState.X), int(playerState.Y)); levelID != 0 {
+		playerState.LevelID = levelID
+	}
+}
+
+// onPlayerChangeLevel moves a player through a warp close to it to the
+// level the warp leads to. It returns the position the player arrives at,
+// in tiles, and false if there is no such warp.
+func (s *GameServer) onPlayerChangeLevel(playerState *d2player.PlayerState, levelID int) (x, y float64, ok bool) {
+	from, err := s.worldOf(playerState.LevelID)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	warp := from.closestWarpTo(levelID, playerState.X, playerState.Y)
+	if warp == nil {
+		return 0, 0, false
+	}
+
+	to, err := s.worldOf(levelID)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	x, y = to.arrival(levelID, warp.LevelID)
+	playerState.X, playerState.Y, playerState.LevelID = x, y, levelID
+
+	return x, y, true
+}
+
+// closestWarpTo returns the warp to the given level which is in reach of
+// the given position, in tiles, or nil if there is none.
+func (w *gameWorld) closestWarpTo(levelID int, x, y float64) *d2mapengine.Warp {
+	for _, warp := range w.Warps() {
+		if warp.DestinationID != levelID {
+			continue
+		}
+
+		exitX, exitY := warp.ExitWalk()
+		if math.Hypot(float64(exitX)/5-x, float64(exitY)/5-y) <= warpDistance {
+			return warp
+		}
+	}
+
+	return nil
+}
+
+// arrival returns the position, in tiles, at which players coming from
+// another level arrive in a level: next to the warp back, or at the start
+// of the map if there is none.
+func (w *gameWorld) arrival(levelID, fromLevelID int) (x, y float64) {
+	warp := w.WarpTo(levelID, fromLevelID)
+	if warp == nil {
+		return w.GetStartPosition()
+	}
+
+	exitX, exitY := warp.ExitWalk()
+	if subX, subY, found := w.ClosestWalkable(exitX, exitY, arrivalWarpDistance); found {
+		exitX, exitY = subX, subY
+	}
+
+	return float64(exitX) / 5, float64(exitY) / 5
+}
@@ -0,0 +1,69 @@
+package d2server
+
+import (
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2ai"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// The monsters and NPCs only exist on the server, the clients are sent
+// copies of them when their player enters a map and are told whenever they
+// move or attack. The clients know them by the Ids the server gives them.
+
+// addNPCs gives Ids to the NPCs on the map of the world.
+func (w *gameWorld) addNPCs() {
+	for _, entity := range *w.Entities() {
+		if npc, ok := entity.(*d2mapentity.NPC); ok {
+			w.npcIDs[npc] = len(w.npcIDs) + 1
+		}
+	}
+}
+
+// sendNPCs sends the NPCs of the world to a client whose player entered
+// the map of the world.
+func (w *gameWorld) sendNPCs(client ClientConnection) {
+	for npc, id := range w.npcIDs {
+		superUnique := ""
+		if record := npc.SuperUnique(); record != nil {
+			superUnique = record.Key
+		}
+
+		packet := d2netpacket.CreateSpawnNPCPacket(id, npc.MonStatsRecord().Key, superUnique,
+			int(npc.LocationX), int(npc.LocationY), npc.Direction(), npc.Paths, npc.IsDead())
+
+		if err := client.SendPacketToClient(packet); err != nil {
+			log.Printf("GameServer: error sending %T to client %s: %s", packet.PacketData, client.GetUniqueId(), err)
+		}
+	}
+}
+
+// broadcast sends a packet to the clients whose player is on the map of the
+// world.
+func (w *gameWorld) broadcast(packet d2netpacket.NetPacket) {
+	for _, connection := range singletonServer.clientConnections {
+		playerState := connection.GetPlayerState()
+		if playerState == nil || !w.hasLevel(playerState.LevelID) {
+			continue
+		}
+
+		if err := connection.SendPacketToClient(packet); err != nil {
+			log.Printf("GameServer: error sending %T to client %s: %s", packet.PacketData, connection.GetUniqueId(), err)
+		}
+	}
+}
+
+// onMonsterMove tells the clients on the map where a monster moves to.
+func (w *gameWorld) onMonsterMove(agent *d2ai.Agent, x, y float64) {
+	npc := agent.Actor.(*d2mapentity.NPC)
+	startX, startY := npc.GetPositionF()
+
+	w.broadcast(d2netpacket.CreateMoveNPCPacket(w.npcIDs[npc], startX, startY, x, y))
+}
+
+// showMonsterAttack tells the clients on the map that a monster attacks.
+func (w *gameWorld) showMonsterAttack(agent *d2ai.Agent, mode d2enum.MonsterAnimationMode) {
+	w.broadcast(d2netpacket.CreateAttackNPCPacket(w.npcIDs[agent.Actor.(*d2mapentity.NPC)], mode))
+}
@@ -0,0 +1,128 @@
+package d2server
+
+import (
+	"log"
+	"math"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2game/d2player"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// the town NPCs walk around, players can interact with them from this many
+// tiles away
+const npcInteractDistance = 5.0
+
+// onPlayerInteractNPC has the NPC with the given Id in monstats.txt do the
+// service for the player if the player is close enough to it: the player is
+// sent the items the NPC sells, or its items are repaired or identified. The
+// player is told the gold it cost.
+func (w *gameWorld) onPlayerInteractNPC(client ClientConnection, key string, service d2enum.NPCService) {
+	playerState := client.GetPlayerState()
+
+	npc := w.npcNear(playerState, key)
+	if npc == nil || !npc.HasService(service) {
+		return
+	}
+
+	var (
+		cost int
+		err  error
+	)
+
+	switch service {
+	case d2enum.NPCServiceTrade:
+		sendVendor(client, w.vendor(key))
+		return
+	case d2enum.NPCServiceRepair:
+		cost, err = playerState.RepairItems(d2datadict.NPCs[key])
+	case d2enum.NPCServiceIdentify:
+		playerState.IdentifyItems()
+	default:
+		return
+	}
+
+	if err != nil {
+		log.Printf("GameServer: player %s can not %s at %s: %s", client.GetUniqueId(), service, key, err)
+		return
+	}
+
+	updateInventory(client)
+
+	packet := d2netpacket.CreateInteractNPCPacket(client.GetUniqueId(), key, service, cost)
+	if err := client.SendPacketToClient(packet); err != nil {
+		log.Printf("GameServer: error sending %T to client %s: %s", packet.PacketData, client.GetUniqueId(), err)
+	}
+}
+
+// onPlayerTrade buys an item of the NPC with the given Id in monstats.txt,
+// or sells an item of the player to it, if the player is close enough to it.
+// The player is sent the items the NPC sells afterwards.
+func (w *gameWorld) onPlayerTrade(client ClientConnection, key string, itemID int, buy bool) {
+	playerState := client.GetPlayerState()
+
+	npc := w.npcNear(playerState, key)
+	if npc == nil || !npc.HasService(d2enum.NPCServiceTrade) {
+		return
+	}
+
+	vendor := w.vendor(key)
+
+	var err error
+	if buy {
+		err = playerState.BuyItem(vendor, itemID)
+	} else {
+		err = playerState.SellItem(vendor, itemID, w.difficulty)
+	}
+
+	if err != nil {
+		log.Printf("GameServer: player %s can not trade item %d with %s: %s", client.GetUniqueId(), itemID, key, err)
+	}
+
+	sendVendor(client, vendor)
+}
+
+// npcNear returns the NPC with the given Id in monstats.txt close enough to
+// the player to interact with it, nil if there is none.
+func (w *gameWorld) npcNear(playerState *d2player.PlayerState, key string) *d2mapentity.NPC {
+	if playerState == nil || playerState.Stats == nil || playerState.Stats.Health <= 0 {
+		return nil
+	}
+
+	for _, entity := range *w.Entities() {
+		npc, ok := entity.(*d2mapentity.NPC)
+		if !ok || npc.MonStatsRecord() == nil || npc.MonStatsRecord().Key != key {
+			continue
+		}
+
+		x, y := npc.GetPositionF()
+		if math.Hypot(playerState.X-x, playerState.Y-y) <= npcInteractDistance {
+			return npc
+		}
+	}
+
+	return nil
+}
+
+// vendor returns the items the NPC with the given Id in monstats.txt sells.
+// The NPC is stocked when a player first trades with it.
+func (w *gameWorld) vendor(key string) *d2inventory.Vendor {
+	vendor, ok := w.vendors[key]
+	if !ok {
+		vendor = d2inventory.CreateVendor(key, w.difficulty, w.rng)
+		w.vendors[key] = vendor
+	}
+
+	return vendor
+}
+
+// sendVendor sends the items the vendor sells to the client.
+func sendVendor(client ClientConnection, vendor *d2inventory.Vendor) {
+	packet := d2netpacket.CreateUpdateVendorPacket(client.GetUniqueId(), *vendor.Clone())
+	if err := client.SendPacketToClient(packet); err != nil {
+		log.Printf("GameServer: error sending %T to client %s: %s", packet.PacketData, client.GetUniqueId(), err)
+	}
+}
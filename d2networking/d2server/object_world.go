@@ -0,0 +1,118 @@
+package d2server
+
+import (
+	"log"
+	"math"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2datadict"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2object"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client/d2clientconnectiontype"
+)
+
+const (
+	operateDistance = 3.0 // in tiles
+	arrivalDistance = 10  // in sub-tiles
+)
+
+// objectWorld is what an object operated by a player affects on the server.
+type objectWorld struct {
+	*gameWorld
+	client ClientConnection
+}
+
+// onPlayerOperate operates the object at the given sub-tile position if the
+// player is close enough to it. It returns true if the object was operated.
+func (w *gameWorld) onPlayerOperate(client ClientConnection, x, y int) bool {
+	object := w.ObjectAt(x, y)
+	playerState := client.GetPlayerState()
+
+	if object == nil || playerState == nil || playerState.Stats == nil || playerState.Stats.Health <= 0 {
+		return false
+	}
+
+	objectX, objectY := object.GetPositionF()
+	if math.Hypot(playerState.X-objectX, playerState.Y-objectY) > operateDistance {
+		return false
+	}
+
+	return object.Operate(objectWorld{gameWorld: w, client: client})
+}
+
+// onPlayerTravel moves the player next to the waypoint at the given sub-tile
+// position and returns where it arrived, in tiles. Players can only travel
+// to the waypoints they activated before.
+func (w *gameWorld) onPlayerTravel(client ClientConnection, x, y int) (destX, destY float64, ok bool) {
+	levelID := w.waypointLevel(x, y)
+	playerState := client.GetPlayerState()
+
+	if levelID == 0 || playerState == nil || !playerState.HasWaypoint(levelID) {
+		return 0, 0, false
+	}
+
+	subTileX, subTileY, ok := w.ClosestWalkable(x, y, arrivalDistance)
+	if !ok {
+		return 0, 0, false
+	}
+
+	playerState.X, playerState.Y = float64(subTileX)/5, float64(subTileY)/5
+
+	return playerState.X, playerState.Y, true
+}
+
+// waypointLevel returns the Levels.txt ID of the level of the waypoint at
+// the given sub-tile position, or 0 if there is no waypoint.
+func (w *gameWorld) waypointLevel(x, y int) int {
+	waypoint := w.ObjectAt(x, y)
+	if waypoint == nil || !waypoint.IsWaypoint() {
+		return 0
+	}
+
+	return w.LevelAt(waypoint.TileX, waypoint.TileY)
+}
+
+// DropItem puts the item on the server's copy of the map.
+func (w objectWorld) DropItem(subTileX, subTileY int, item *d2datadict.ItemCommonRecord) {
+	entity, err := d2mapentity.CreateItem(subTileX, subTileY, item)
+	if err != nil {
+		log.Printf("GameServer: error dropping item %s: %s", item.Code, err)
+		return
+	}
+
+	w.AddEntity(entity)
+}
+
+// GrantEffect grants the effect to the operating player.
+func (w objectWorld) GrantEffect(effect d2hero.EffectType, duration float64) {
+	w.client.GetPlayerState().Stats.AddEffect(effect, duration)
+	updatePlayerStats(w.client, 0)
+}
+
+// Restore refills the life and/or mana of the operating player.
+func (w objectWorld) Restore(life, mana bool) {
+	stats := w.client.GetPlayerState().Stats
+
+	if life {
+		stats.Health = stats.MaxHealth
+	}
+
+	if mana {
+		stats.Mana = stats.MaxMana
+	}
+
+	updatePlayerStats(w.client, 0)
+}
+
+// OpenWaypoints activates the waypoint for the operating player, the client
+// of the player opens its waypoint menu. The waypoints of local players are
+// saved right away, remote clients save their own player when they are told
+// the waypoint was operated.
+func (w objectWorld) OpenWaypoints(waypoint *d2object.Object) {
+	playerState := w.client.GetPlayerState()
+
+	if playerState.ActivateWaypoint(w.LevelAt(waypoint.TileX, waypoint.TileY)) &&
+		w.client.GetConnectionType() == d2clientconnectiontype.Local {
+		savePlayer(playerState)
+	}
+}
@@ -0,0 +1,161 @@
+package d2server
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2server/d2udpclientconnection"
+)
+
+// playerPacketDecoder unmarshals a packet a remote client sent about its
+// player, it returns the packet data and the ID of the player.
+type playerPacketDecoder func(data []byte) (packetData interface{}, playerID string, err error)
+
+// playerPacketDecoders are the decoders of the packets remote clients send
+// about their player, by packet type. The decoded packets are handled as
+// the packets of the local client.
+//
+//nolint:gochecknoglobals // constant lookup table
+var playerPacketDecoders = map[d2netpackettype.NetPacketType]playerPacketDecoder{
+	d2netpackettype.MovePlayer: func(data []byte) (interface{}, string, error) {
+		var packet d2netpacket.MovePlayerPacket
+		err := json.Unmarshal(data, &packet)
+		return packet, packet.PlayerId, err
+	},
+	d2netpackettype.AllocateStatPoint: func(data []byte) (interface{}, string, error) {
+		var packet d2netpacket.AllocateStatPointPacket
+		err := json.Unmarshal(data, &packet)
+		return packet, packet.PlayerID, err
+	},
+	d2netpackettype.OperateObject: func(data []byte) (interface{}, string, error) {
+		var packet d2netpacket.OperateObjectPacket
+		err := json.Unmarshal(data, &packet)
+		return packet, packet.PlayerID, err
+	},
+	d2netpackettype.TravelWaypoint: func(data []byte) (interface{}, string, error) {
+		var packet d2netpacket.TravelWaypointPacket
+		err := json.Unmarshal(data, &packet)
+		return packet, packet.PlayerID, err
+	},
+	d2netpackettype.ChangeLevel: func(data []byte) (interface{}, string, error) {
+		var packet d2netpacket.ChangeLevelPacket
+		err := json.Unmarshal(data, &packet)
+		return packet, packet.PlayerID, err
+	},
+	d2netpackettype.MoveItem: func(data []byte) (interface{}, string, error) {
+		var packet d2netpacket.MoveItemPacket
+		err := json.Unmarshal(data, &packet)
+		return packet, packet.PlayerID, err
+	},
+	d2netpackettype.UseItem: func(data []byte) (interface{}, string, error) {
+		var packet d2netpacket.UseItemPacket
+		err := json.Unmarshal(data, &packet)
+		return packet, packet.PlayerID, err
+	},
+	d2netpackettype.DropItem: func(data []byte) (interface{}, string, error) {
+		var packet d2netpacket.DropItemPacket
+		err := json.Unmarshal(data, &packet)
+		return packet, packet.PlayerID, err
+	},
+	d2netpackettype.PickUpItem: func(data []byte) (interface{}, string, error) {
+		var packet d2netpacket.PickUpItemPacket
+		err := json.Unmarshal(data, &packet)
+		return packet, packet.PlayerID, err
+	},
+	d2netpackettype.SetHostile: func(data []byte) (interface{}, string, error) {
+		var packet d2netpacket.SetHostilePacket
+		err := json.Unmarshal(data, &packet)
+		return packet, packet.PlayerID, err
+	},
+	d2netpackettype.InteractNPC: func(data []byte) (interface{}, string, error) {
+		var packet d2netpacket.InteractNPCPacket
+		err := json.Unmarshal(data, &packet)
+		return packet, packet.PlayerID, err
+	},
+	d2netpackettype.BuyItem:  decodeTradeItem,
+	d2netpackettype.SellItem: decodeTradeItem,
+	d2netpackettype.StashGold: func(data []byte) (interface{}, string, error) {
+		var packet d2netpacket.StashGoldPacket
+		err := json.Unmarshal(data, &packet)
+		return packet, packet.PlayerID, err
+	},
+	d2netpackettype.TradeRequest: func(data []byte) (interface{}, string, error) {
+		var packet d2netpacket.TradeRequestPacket
+		err := json.Unmarshal(data, &packet)
+		return packet, packet.PlayerID, err
+	},
+	d2netpackettype.TradeAction: func(data []byte) (interface{}, string, error) {
+		var packet d2netpacket.TradeActionPacket
+		err := json.Unmarshal(data, &packet)
+		return packet, packet.PlayerID, err
+	},
+	d2netpackettype.TransmuteCube: func(data []byte) (interface{}, string, error) {
+		var packet d2netpacket.TransmuteCubePacket
+		err := json.Unmarshal(data, &packet)
+		return packet, packet.PlayerID, err
+	},
+	d2netpackettype.SocketItem: func(data []byte) (interface{}, string, error) {
+		var packet d2netpacket.SocketItemPacket
+		err := json.Unmarshal(data, &packet)
+		return packet, packet.PlayerID, err
+	},
+}
+
+// decodeTradeItem decodes the BuyItem and SellItem packets.
+func decodeTradeItem(data []byte) (interface{}, string, error) {
+	var packet d2netpacket.TradeItemPacket
+	err := json.Unmarshal(data, &packet)
+
+	return packet, packet.PlayerID, err
+}
+
+// onPlayerPacket decodes a packet a remote client sent about its player and
+// hands it to OnPacketReceived, on behalf of the client of the player. Packets
+// that do not come from the address the player connected from are dropped.
+func onPlayerPacket(packetType d2netpackettype.NetPacketType, data []byte, addr *net.UDPAddr) {
+	decode, ok := playerPacketDecoders[packetType]
+	if !ok {
+		log.Printf("GameServer: unexpected %v packet received", packetType)
+		return
+	}
+
+	packetData, playerID, err := decode(data)
+	if err != nil {
+		log.Printf("GameServer: error unmarshalling packet of type %T: %s", packetData, err)
+		return
+	}
+
+	singletonServer.RLock()
+	client, ok := singletonServer.clientConnections[playerID]
+	singletonServer.RUnlock()
+
+	if !ok {
+		return
+	}
+
+	if !sentBy(client, addr) {
+		log.Printf("GameServer: dropping %v packet for player %s sent from %s", packetType, playerID, addr)
+		return
+	}
+
+	err = OnPacketReceived(client, d2netpacket.NetPacket{PacketType: packetType, PacketData: packetData})
+	if err != nil {
+		log.Printf("GameServer: error handling %T: %s", packetData, err)
+	}
+}
+
+// sentBy tells whether a packet received from addr comes from the remote
+// client of the connection.
+func sentBy(client ClientConnection, addr *net.UDPAddr) bool {
+	udpClient, ok := client.(*d2udpclientconnection.UDPClientConnection)
+	if !ok {
+		return false
+	}
+
+	address := udpClient.GetAddress()
+
+	return address.IP.Equal(addr.IP) && address.Port == addr.Port
+}
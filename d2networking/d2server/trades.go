@@ -0,0 +1,176 @@
+package d2server
+
+import (
+	"log"
+	"math"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2game/d2player"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// players trade with the players of their level at most this many tiles away
+const tradeDistance = 10.0
+
+// onPlayerRequestTrade asks the other player to trade with the client's
+// player, or opens the trade if the other player asked the client's player
+// to trade before.
+func (s *GameServer) onPlayerRequestTrade(client ClientConnection, otherID string, accept bool) {
+	playerID := client.GetUniqueId()
+
+	other, ok := s.clientConnections[otherID]
+	if !ok || otherID == playerID || !canTrade(client.GetPlayerState(), other.GetPlayerState()) {
+		return
+	}
+
+	if s.trades[playerID] != nil || s.trades[otherID] != nil {
+		log.Printf("GameServer: player %s can not trade with %s, one of them trades already", playerID, otherID)
+		return
+	}
+
+	if !accept {
+		s.tradeRequests[playerID] = otherID
+		sendPacket(other, d2netpacket.CreateTradeRequestPacket(playerID, otherID, false))
+
+		return
+	}
+
+	if s.tradeRequests[otherID] != playerID {
+		return
+	}
+
+	delete(s.tradeRequests, otherID)
+	delete(s.tradeRequests, playerID)
+
+	trade := d2player.CreateTrade(otherID, other.GetPlayerState().HeroName, playerID, client.GetPlayerState().HeroName)
+	s.trades[otherID], s.trades[playerID] = trade, trade
+
+	s.sendTrade(trade)
+}
+
+// onPlayerTradeAction changes the trade of the client's player. The items
+// and the gold are swapped once both players confirmed the offers.
+func (s *GameServer) onPlayerTradeAction(client ClientConnection, packet d2netpacket.TradeActionPacket) {
+	playerID := client.GetUniqueId()
+	playerState := client.GetPlayerState()
+
+	trade := s.trades[playerID]
+	if trade == nil {
+		return
+	}
+
+	var err error
+
+	switch packet.Action {
+	case d2enum.TradeActionOfferItem:
+		err = trade.OfferItem(playerID, playerState, packet.ItemID)
+	case d2enum.TradeActionWithdrawItem:
+		err = trade.WithdrawItem(playerID, packet.ItemID)
+	case d2enum.TradeActionOfferGold:
+		err = trade.OfferGold(playerID, playerState, packet.Gold)
+	case d2enum.TradeActionAccept:
+		err = trade.Accept(playerID, packet.Revision)
+	case d2enum.TradeActionConfirm:
+		err = trade.Confirm(playerID, packet.Revision)
+	case d2enum.TradeActionCancel:
+		s.closeTrade(trade, false)
+		return
+	}
+
+	if err != nil {
+		log.Printf("GameServer: player %s can not %s in the trade: %s", playerID, packet.Action, err)
+	}
+
+	if trade.IsConfirmed() {
+		s.completeTrade(trade)
+		return
+	}
+
+	s.sendTrade(trade)
+}
+
+// completeTrade swaps the items and the gold of the confirmed trade and
+// closes it. If the players are not together anymore the trade is
+// cancelled, if the swap failed the players have to agree to it again.
+func (s *GameServer) completeTrade(trade *d2player.Trade) {
+	var clients [2]ClientConnection
+
+	for side, id := range trade.PlayerIDs {
+		client, ok := s.clientConnections[id]
+		if !ok {
+			s.closeTrade(trade, false)
+			return
+		}
+
+		clients[side] = client
+	}
+
+	states := [2]*d2player.PlayerState{clients[0].GetPlayerState(), clients[1].GetPlayerState()}
+	if !canTrade(states[0], states[1]) {
+		s.closeTrade(trade, false)
+		return
+	}
+
+	if err := trade.Complete(states); err != nil {
+		log.Printf("GameServer: error completing the trade of %s and %s: %s", trade.PlayerIDs[0], trade.PlayerIDs[1], err)
+		trade.Revise()
+		s.sendTrade(trade)
+
+		return
+	}
+
+	for _, client := range clients {
+		updateInventory(client)
+	}
+
+	s.closeTrade(trade, true)
+}
+
+// cancelTrades cancels the trade of the player, and the trade it asked for.
+func (s *GameServer) cancelTrades(playerID string) {
+	delete(s.tradeRequests, playerID)
+
+	if trade := s.trades[playerID]; trade != nil {
+		s.closeTrade(trade, false)
+	}
+}
+
+// closeTrade ends the trade and tells both players it is over.
+func (s *GameServer) closeTrade(trade *d2player.Trade, completed bool) {
+	for _, id := range trade.PlayerIDs {
+		delete(s.trades, id)
+
+		if client, ok := s.clientConnections[id]; ok {
+			sendPacket(client, d2netpacket.CreateUpdateTradePacket(id, nil, completed))
+		}
+	}
+}
+
+// sendTrade sends the trade to both of its players.
+func (s *GameServer) sendTrade(trade *d2player.Trade) {
+	for _, id := range trade.PlayerIDs {
+		if client, ok := s.clientConnections[id]; ok {
+			sendPacket(client, d2netpacket.CreateUpdateTradePacket(id, trade.Clone(), false))
+		}
+	}
+}
+
+// canTrade returns true if the players are alive, in the same level and
+// close enough to each other to trade.
+func canTrade(playerState, other *d2player.PlayerState) bool {
+	for _, state := range []*d2player.PlayerState{playerState, other} {
+		if state == nil || state.Stats == nil || state.Stats.Health <= 0 || isSpectator(state) {
+			return false
+		}
+	}
+
+	return playerState.LevelID == other.LevelID &&
+		math.Hypot(playerState.X-other.X, playerState.Y-other.Y) <= tradeDistance
+}
+
+// sendPacket sends a packet to the client.
+func sendPacket(client ClientConnection, packet d2netpacket.NetPacket) {
+	if err := client.SendPacketToClient(packet); err != nil {
+		log.Printf("GameServer: error sending %T to client %s: %s", packet.PacketData, client.GetUniqueId(), err)
+	}
+}
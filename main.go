@@ -1,13 +1,16 @@
 package main
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2app"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
 	ebiten2 "github.com/OpenDiablo2/OpenDiablo2/d2core/d2audio/ebiten"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2config"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2input"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2render/ebiten"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2render/software"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2term"
 )
 
@@ -28,7 +31,7 @@ func main() {
 	}
 
 	// Initialize our providers
-	renderer, err := ebiten.CreateRenderer()
+	renderer, err := createRenderer(d2config.Config.Backend)
 	if err != nil {
 		panic(err)
 	}
@@ -50,3 +53,15 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// createRenderer creates the renderer of the configured backend
+func createRenderer(backend string) (d2interface.Renderer, error) {
+	switch backend {
+	case d2config.BackendEbiten:
+		return ebiten.CreateRenderer()
+	case d2config.BackendSoftware:
+		return software.CreateRenderer()
+	default:
+		return nil, fmt.Errorf("unknown backend %s", backend)
+	}
+}